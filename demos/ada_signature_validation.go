@@ -20,9 +20,8 @@ func main() {
 		return
 	}
 	fmt.Printf("✅ 生成密钥对成功\n")
-	fmt.Printf("   私钥 (hex): %s\n", privateKey)
-	fmt.Printf("   私钥长度: %d 字节\n", len(privateKey)/2) // hex编码是2倍长度
-	fmt.Printf("   公钥 (hex): %s\n", publicKey)
+	fmt.Printf("   账户私钥 (acct_xsk): %s\n", privateKey)
+	fmt.Printf("   账户公钥 (acct_xvk): %s\n", publicKey)
 	fmt.Printf("   地址: %s\n", address)
 
 	// 2. 创建一个测试交易
@@ -54,7 +53,7 @@ func main() {
 
 	// 3. 使用生成的私钥对交易进行签名
 	signer := &crypto.AdaTransactionSigner{}
-	signedTx, txHash, err := signer.SignTransaction(rawTx, privateKey)
+	signedTx, txHash, err := signWithPrivateKey(signer, rawTx, privateKey)
 	if err != nil {
 		fmt.Printf("❌ 签名交易失败: %v\n", err)
 		return
@@ -72,13 +71,23 @@ func main() {
 		return
 	}
 	fmt.Printf("✅ 验证私钥格式一致\n")
-	fmt.Printf("   第二个私钥长度: %d 字节\n", len(privateKey2)/2)
+	fmt.Printf("   第二个私钥前缀: %s\n", privateKey2[:len("acct_xsk")])
 
 	fmt.Println("\n===== 验证总结 =====")
 	fmt.Println("✅ 密钥生成器与签名器兼容测试通过")
 	fmt.Println("✅ 生成的私钥能够成功用于交易签名")
 	fmt.Println("✅ 签名过程没有出现错误")
-	fmt.Println("✅ 私钥格式符合Ed25519算法要求")
+	fmt.Println("✅ 私钥格式符合CIP-1852账户扩展密钥要求")
 	fmt.Println("\n注意: 这是一个简化的测试。在实际生产环境中，建议使用Cardano官方库进行交易签名。")
 	fmt.Println("官方推荐库: github.com/input-output-hk/cardano-addresses/go")
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("cardano", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
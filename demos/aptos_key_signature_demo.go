@@ -82,13 +82,21 @@ func main() {
 
 	// 创建交易请求
 	txReq := crypto.AptosTransactionRequest{
-		Type:                 "entry_function_payload",
-		Sender:               address,
-		SequenceNumber:       1,
-		MaxGasAmount:         100000,
-		GasUnitPrice:         100,
-		ExpirationTimestamp:  1234567890,
-		Payload:              json.RawMessage(`{"function":"0x1::coin::transfer","type_arguments":["0x1::aptos_coin::AptosCoin"],"arguments":["0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","1000000"]}`),
+		Type:                crypto.AptosEntryFunctionPayloadType,
+		Sender:              address,
+		SequenceNumber:      1,
+		MaxGasAmount:        100000,
+		GasUnitPrice:        100,
+		ExpirationTimestamp: 1234567890,
+		ChainID:             1,
+		Payload: &crypto.AptosEntryFunctionPayload{
+			Function:      "0x1::coin::transfer",
+			TypeArguments: []string{"0x1::aptos_coin::AptosCoin"},
+			Arguments: []json.RawMessage{
+				json.RawMessage(`"0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9"`),
+				json.RawMessage(`"1000000"`),
+			},
+		},
 	}
 
 	// 序列化交易请求
@@ -100,7 +108,7 @@ func main() {
 		rawTx := string(txBytes)
 
 		// 签名交易
-		signedTx, txHash, err := signer.SignTransaction(rawTx, privateKey)
+		signedTx, txHash, err := signWithPrivateKey(signer, rawTx, privateKey)
 		if err != nil {
 			fmt.Printf("❌ 签名交易失败: %v\n", err)
 			failedTests++
@@ -113,11 +121,8 @@ func main() {
 			// 测试5：验证交易签名
 			fmt.Println("\n测试5: 验证Aptos交易签名")
 
-			// 提取签名部分（移除前缀）
-			signature := signedTx[len("aptos_signed_"):]
-
-			// 验证签名
-			isValid, err := signer.VerifyTransaction(rawTx, signature, publicKey)
+			// 验证签名（签名后的交易为BCS序列化的SignedTransaction）
+			isValid, err := signer.VerifyTransaction(rawTx, signedTx, publicKey)
 			if err != nil {
 				fmt.Printf("❌ 验证签名失败: %v\n", err)
 				failedTests++
@@ -179,4 +184,14 @@ func formatLongString(str string, maxLength int) string {
 		return str
 	}
 	return str[:maxLength] + "..."
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("aptos", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
@@ -55,7 +55,7 @@ func main() {
 
 	// 3. 使用生成的私钥对交易进行签名
 	signer := &crypto.BtcTransactionSigner{}
-	signedTx, txHash, err := signer.SignTransaction(rawTx, privateKey)
+	signedTx, txHash, err := signWithPrivateKey(signer, rawTx, privateKey)
 	if err != nil {
 		fmt.Printf("❌ 签名交易失败: %v\n", err)
 		return
@@ -79,7 +79,7 @@ func main() {
 	txReq2 := txReq
 	txReq2.Inputs[0].ScriptPubKey = "76a914" + extractPublicKeyHash(publicKey) + "88ac"
 	txBytes2, _ := json.Marshal(txReq2)
-	signedTx2, txHash2, err := signer.SignTransaction(string(txBytes2), privateKey2)
+	signedTx2, txHash2, err := signWithPrivateKey(signer, string(txBytes2), privateKey2)
 	if err != nil {
 		fmt.Printf("❌ 用第二个私钥签名失败: %v\n", err)
 		return
@@ -112,4 +112,14 @@ func extractPublicKeyHash(publicKeyHex string) string {
 		return publicKeyHex[2:42] // 提取40个字符
 	}
 	return publicKeyHex
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("bitcoin", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
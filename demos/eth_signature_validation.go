@@ -97,7 +97,7 @@ func main() {
 		failedTests++
 	} else {
 		legacyTxJSONStr := string(legacyTxJSON)
-		legacySignature, legacyTxHash, err := signer.SignTransaction(legacyTxJSONStr, privateKey1)
+		legacySignature, legacyTxHash, err := signWithPrivateKey(signer, legacyTxJSONStr, privateKey1)
 		if err != nil {
 			fmt.Printf("❌ 失败: %v\n", err)
 			failedTests++
@@ -130,7 +130,7 @@ func main() {
 		failedTests++
 	} else {
 		eip1559TxJSONStr := string(eip1559TxJSON)
-		eip1559Signature, eip1559TxHash, err = signer.SignTransaction(eip1559TxJSONStr, privateKey1)
+		eip1559Signature, eip1559TxHash, err = signWithPrivateKey(signer, eip1559TxJSONStr, privateKey1)
 		if err != nil {
 			fmt.Printf("❌ 失败: %v\n", err)
 			failedTests++
@@ -164,7 +164,7 @@ func main() {
 		eip1559TxJSONStr := string(eip1559TxJSON)
 		
 		// 使用第二个密钥对签名
-		signature2, txHash2, err := signer.SignTransaction(eip1559TxJSONStr, privateKey2)
+		signature2, txHash2, err := signWithPrivateKey(signer, eip1559TxJSONStr, privateKey2)
 		if err != nil {
 			fmt.Printf("❌ 签名生成失败: %v\n", err)
 			failedTests++
@@ -192,4 +192,14 @@ func main() {
 	} else {
 		fmt.Println("❌ 测试未通过")
 	}
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("ethereum", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
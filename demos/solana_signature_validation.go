@@ -70,7 +70,10 @@ func main() {
 	testInstructions := []crypto.SolanaInstruction{
 		{
 			ProgramID: "11111111111111111111111111111111", // 系统程序ID
-			Accounts:  []string{address, "11111111111111111111111111111111"}, // 测试账户
+			Accounts: []crypto.SolanaAccountMeta{ // 测试账户
+				{Pubkey: address, IsSigner: true, IsWritable: true},
+				{Pubkey: "11111111111111111111111111111111"},
+			},
 			Data:      "AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", // 空数据（Base64编码）
 		},
 	}
@@ -84,7 +87,7 @@ func main() {
 	}
 
 	// 签名交易
-	signedTx, txHash, err := solanaSigner.SignTransaction(testTransaction, privateKey)
+	signedTx, txHash, err := signWithPrivateKey(solanaSigner, testTransaction, privateKey)
 	if err != nil {
 		log.Fatalf("❌ 签名交易失败: %v", err)
 	}
@@ -117,7 +120,7 @@ func main() {
 	}
 
 	// 使用第二个密钥对签名相同的交易
-	signedTx2, txHash2, err := solanaSigner.SignTransaction(testTransaction, privateKey2)
+	signedTx2, txHash2, err := signWithPrivateKey(solanaSigner, testTransaction, privateKey2)
 	if err != nil {
 		log.Fatalf("❌ 用第二个私钥签名失败: %v", err)
 	}
@@ -143,4 +146,14 @@ func main() {
 
 	fmt.Println("\n注意: 这是一个测试环境下的验证。在实际生产环境中，交易需要包含有效的recentBlockhash和正确的指令才能被网络接受。")
 	fmt.Println("这个实现使用了Go标准库的crypto/ed25519包，与Solana官方使用的密码学算法完全一致。")
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("solana", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
 	"github.com/featx/keys-gin/lib/crypto"
 )
 
+// suiDemoTxBytes 演示用的Base64编码TransactionData
+var suiDemoTxBytes = base64.StdEncoding.EncodeToString(append([]byte{0, 0, 2, 0}, make([]byte, 96)...))
+
 // SuiTestResult 存储测试结果的结构体
 type SuiTestResult struct {
 	TestName   string
@@ -169,14 +173,9 @@ func testSignTransaction() SuiTestResult {
 		return result
 	}
 
-	// 构建交易请求
+	// 构建交易请求，txBytes为SUI SDK构建的BCS序列化TransactionData
 	txReq := crypto.SuiTransactionRequest{
-		TransactionKind: "Transfer",
-		GasBudget:       100000000,
-		GasPrice:        1000,
-		GasPayment:      []string{address},
-		InputObjects:    []string{"0x0000000000000000000000000000000000000000000000000000000000000002"},
-		Data:            json.RawMessage(`{"recipient":"0x0000000000000000000000000000000000000000000000000000000000000003","amount":1000}`),
+		TxBytes: suiDemoTxBytes,
 	}
 
 	rawTx, err := json.Marshal(txReq)
@@ -187,7 +186,7 @@ func testSignTransaction() SuiTestResult {
 
 	// 签名交易
 	signer := &crypto.SuiTransactionSigner{}
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKey)
+	signedTx, txHash, err := signWithPrivateKey(signer, string(rawTx), privateKey)
 
 	if err != nil {
 		result.Error = err
@@ -222,14 +221,9 @@ func testVerifyTransaction() SuiTestResult {
 		return result
 	}
 
-	// 构建交易请求
+	// 构建交易请求，txBytes为SUI SDK构建的BCS序列化TransactionData
 	txReq := crypto.SuiTransactionRequest{
-		TransactionKind: "Transfer",
-		GasBudget:       100000000,
-		GasPrice:        1000,
-		GasPayment:      []string{address},
-		InputObjects:    []string{"0x0000000000000000000000000000000000000000000000000000000000000002"},
-		Data:            json.RawMessage(`{"recipient":"0x0000000000000000000000000000000000000000000000000000000000000003","amount":1000}`),
+		TxBytes: suiDemoTxBytes,
 	}
 
 	rawTx, err := json.Marshal(txReq)
@@ -240,7 +234,7 @@ func testVerifyTransaction() SuiTestResult {
 
 	// 签名交易
 	signer := &crypto.SuiTransactionSigner{}
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKey)
+	signedTx, txHash, err := signWithPrivateKey(signer, string(rawTx), privateKey)
 	if err != nil {
 		result.Error = err
 		return result
@@ -288,4 +282,14 @@ func testInvalidPrivateKey() SuiTestResult {
 	}
 
 	return result
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("sui", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
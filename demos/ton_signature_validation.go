@@ -217,7 +217,7 @@ func testSignTransaction(privateKey string) *TonTestResult {
 	}
 
 	signer := &crypto.TonTransactionSigner{}
-	signedTx, txHash, err := signer.SignTransaction(string(txData), privateKey)
+	signedTx, txHash, err := signWithPrivateKey(signer, string(txData), privateKey)
 	if err != nil {
 		result.Success = false
 		result.ErrorMessage = fmt.Sprintf("签名交易失败: %v", err)
@@ -298,7 +298,7 @@ func testInvalidPrivateKey() *TonTestResult {
 		return result
 	}
 
-	_, _, err = signer.SignTransaction(string(txData), invalidPrivateKey)
+	_, _, err = signWithPrivateKey(signer, string(txData), invalidPrivateKey)
 	if err == nil {
 		result.Success = false
 		result.ErrorMessage = "使用无效私钥应该失败，但成功了"
@@ -308,4 +308,14 @@ func testInvalidPrivateKey() *TonTestResult {
 	fmt.Printf("预期的错误: %v\n", err)
 
 	return result
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("ton", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
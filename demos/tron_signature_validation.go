@@ -93,7 +93,7 @@ func main() {
 
 	// 测试4：使用私钥签名交易
 	fmt.Println("测试4: 使用私钥签名交易")
-	signature, txHash, err := signer.SignTransaction(txJSONStr, privateKey1)
+	signature, txHash, err := signWithPrivateKey(signer, txJSONStr, privateKey1)
 	if err != nil {
 		fmt.Printf("❌ 失败: %v\n", err)
 		failedTests++
@@ -151,7 +151,7 @@ func main() {
 		failedTests++
 	} else {
 		// 使用新的私钥签名
-		signature2, txHash2, err := signer.SignTransaction(txJSONStr, privateKey2)
+		signature2, txHash2, err := signWithPrivateKey(signer, txJSONStr, privateKey2)
 		if err != nil {
 			fmt.Printf("❌ 签名生成失败: %v\n", err)
 			failedTests++
@@ -177,4 +177,14 @@ func main() {
 	} else {
 		fmt.Println("✅ 所有测试通过!")
 	}
-}
\ No newline at end of file
+}
+
+// signWithPrivateKey 将私钥解析为签名密钥后签名交易，签名后清除内存中的私钥
+func signWithPrivateKey(signer crypto.TransactionSigner, rawTx, privateKey string) (string, string, error) {
+	key, err := crypto.ParsePrivateKey("tron", []byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	defer crypto.ZeroizeKey(key)
+	return signer.SignTransaction(rawTx, key)
+}
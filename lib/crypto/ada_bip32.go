@@ -0,0 +1,294 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// CIP-1852 派生路径 m/1852'/1815'/account'/role/index
+const (
+	// CardanoPurpose CIP-1852规定的用途索引
+	CardanoPurpose uint32 = 1852
+	// CardanoCoinType SLIP-44中Cardano的币种索引
+	CardanoCoinType uint32 = 1815
+	// CardanoRoleExternal 外部链，收款地址的支付密钥
+	CardanoRoleExternal uint32 = 0
+	// CardanoRoleInternal 内部链，找零地址的支付密钥
+	CardanoRoleInternal uint32 = 1
+	// CardanoRoleStaking 权益密钥，用于基本地址的权益凭证和奖励地址
+	CardanoRoleStaking uint32 = 2
+
+	cardanoHardenedOffset uint32 = 0x80000000
+)
+
+// CIP-5 规定的扩展密钥Bech32前缀
+const (
+	cardanoRootXsk  = "root_xsk"
+	cardanoAcctXsk  = "acct_xsk"
+	cardanoAcctXvk  = "acct_xvk"
+	cardanoAddrXsk  = "addr_xsk"
+	cardanoStakeXsk = "stake_xsk"
+)
+
+// cardanoExtendedKey BIP32-Ed25519扩展私钥
+// key 为 kL || kR：kL是小端编码的标量，kR是签名随机数的前缀
+type cardanoExtendedKey struct {
+	key       []byte
+	chainCode []byte // 非HD密钥（由标准Ed25519种子展开）为空
+}
+
+// cardanoExtendedPublicKey BIP32-Ed25519扩展公钥，只能进行非硬化派生
+type cardanoExtendedPublicKey struct {
+	publicKey []byte
+	chainCode []byte
+}
+
+// newCardanoMasterKey 按CIP-3 Icarus方案从BIP-39熵生成主密钥
+// PBKDF2-HMAC-SHA512(密码, 熵, 4096轮, 96字节)，并按Ed25519要求调整kL的位
+func newCardanoMasterKey(entropy []byte, passphrase string) *cardanoExtendedKey {
+	data := pbkdf2.Key([]byte(passphrase), entropy, 4096, 96, sha512.New)
+	data[0] &= 0xf8
+	data[31] &= 0x1f
+	data[31] |= 0x40
+	return &cardanoExtendedKey{key: data[:64], chainCode: data[64:]}
+}
+
+// newCardanoKeyFromSeed 将32字节的标准Ed25519种子展开为扩展私钥（RFC 8032）
+// 展开后的签名结果与ed25519.Sign一致
+func newCardanoKeyFromSeed(seed []byte) *cardanoExtendedKey {
+	digest := sha512.Sum512(seed)
+	digest[0] &= 0xf8
+	digest[31] &= 0x7f
+	digest[31] |= 0x40
+	return &cardanoExtendedKey{key: digest[:]}
+}
+
+// newCardanoMasterKeyFromMnemonic 从BIP-39助记词生成Cardano主密钥
+func newCardanoMasterKeyFromMnemonic(mnemonic, passphrase string) (*cardanoExtendedKey, error) {
+	entropy, err := mnemonicToEntropy(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return newCardanoMasterKey(entropy, passphrase), nil
+}
+
+// scalar 返回kL对应的标量（模L）
+func (k *cardanoExtendedKey) scalar() *edwards25519.Scalar {
+	wide := make([]byte, 64)
+	copy(wide, k.key[:32])
+	s, _ := edwards25519.NewScalar().SetUniformBytes(wide)
+	return s
+}
+
+// publicKey 计算公钥 A = kL·B
+func (k *cardanoExtendedKey) publicKey() ed25519.PublicKey {
+	return new(edwards25519.Point).ScalarBaseMult(k.scalar()).Bytes()
+}
+
+// extendedPublicKey 返回对应的扩展公钥
+func (k *cardanoExtendedKey) extendedPublicKey() *cardanoExtendedPublicKey {
+	return &cardanoExtendedPublicKey{publicKey: k.publicKey(), chainCode: k.chainCode}
+}
+
+// sign 使用扩展私钥进行Ed25519签名
+// r = SHA512(kR || M)，S = r + SHA512(R || A || M)·kL
+func (k *cardanoExtendedKey) sign(message []byte) []byte {
+	publicKey := k.publicKey()
+
+	digest := sha512.New()
+	digest.Write(k.key[32:])
+	digest.Write(message)
+	r, _ := edwards25519.NewScalar().SetUniformBytes(digest.Sum(nil))
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+
+	digest.Reset()
+	digest.Write(R)
+	digest.Write(publicKey)
+	digest.Write(message)
+	c, _ := edwards25519.NewScalar().SetUniformBytes(digest.Sum(nil))
+
+	S := edwards25519.NewScalar().MultiplyAdd(c, k.scalar(), r)
+	return append(R, S.Bytes()...)
+}
+
+// derive 按BIP32-Ed25519（V2方案）派生子私钥，index >= 2^31 时为硬化派生
+func (k *cardanoExtendedKey) derive(index uint32) (*cardanoExtendedKey, error) {
+	if len(k.chainCode) == 0 {
+		return nil, errors.New("key has no chain code")
+	}
+
+	var zData, cData []byte
+	if index >= cardanoHardenedOffset {
+		zData = append([]byte{0x00}, k.key...)
+		cData = append([]byte{0x01}, k.key...)
+	} else {
+		publicKey := k.publicKey()
+		zData = append([]byte{0x02}, publicKey...)
+		cData = append([]byte{0x03}, publicKey...)
+	}
+	z := cardanoHMAC(k.chainCode, zData, index)
+	c := cardanoHMAC(k.chainCode, cData, index)
+
+	// kL' = 8·ZL + kL，kR' = ZR + kR (mod 2^256)
+	child := append(cardanoAddMul8(z[:28], k.key[:32]), cardanoAdd256(z[32:], k.key[32:])...)
+	return &cardanoExtendedKey{key: child, chainCode: c[32:]}, nil
+}
+
+// derivePath 依次派生路径中的各级索引
+func (k *cardanoExtendedKey) derivePath(path ...uint32) (*cardanoExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		if key, err = key.derive(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// bytes 返回 kL || kR || 链码
+func (k *cardanoExtendedKey) bytes() []byte {
+	return append(append([]byte{}, k.key...), k.chainCode...)
+}
+
+// derive 非硬化派生子公钥：A' = A + 8·ZL·B
+func (k *cardanoExtendedPublicKey) derive(index uint32) (*cardanoExtendedPublicKey, error) {
+	if index >= cardanoHardenedOffset {
+		return nil, errors.New("cannot derive hardened child from public key")
+	}
+	point, err := new(edwards25519.Point).SetBytes(k.publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	z := cardanoHMAC(k.chainCode, append([]byte{0x02}, k.publicKey...), index)
+	c := cardanoHMAC(k.chainCode, append([]byte{0x03}, k.publicKey...), index)
+
+	scalar, err := edwards25519.NewScalar().SetCanonicalBytes(cardanoAddMul8(z[:28], make([]byte, 32)))
+	if err != nil {
+		return nil, err
+	}
+	child := new(edwards25519.Point).Add(point, new(edwards25519.Point).ScalarBaseMult(scalar))
+	return &cardanoExtendedPublicKey{publicKey: child.Bytes(), chainCode: c[32:]}, nil
+}
+
+// bytes 返回 公钥 || 链码
+func (k *cardanoExtendedPublicKey) bytes() []byte {
+	return append(append([]byte{}, k.publicKey...), k.chainCode...)
+}
+
+// cardanoHardened 返回硬化索引
+func cardanoHardened(index uint32) uint32 {
+	return index | cardanoHardenedOffset
+}
+
+// cardanoAccountPath 返回账户级路径 1852'/1815'/account'
+func cardanoAccountPath(account uint32) []uint32 {
+	return []uint32{cardanoHardened(CardanoPurpose), cardanoHardened(CardanoCoinType), cardanoHardened(account)}
+}
+
+// CardanoDerivationPath 返回CIP-1852派生路径的字符串形式
+func CardanoDerivationPath(account, role, index uint32) string {
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d/%d", CardanoPurpose, CardanoCoinType, account, role, index)
+}
+
+// cardanoHMAC 计算 HMAC-SHA512(链码, data || 小端索引)
+func cardanoHMAC(chainCode, data []byte, index uint32) []byte {
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	mac.Write(binary.LittleEndian.AppendUint32(nil, index))
+	return mac.Sum(nil)
+}
+
+// cardanoAddMul8 计算 8·zl + kl（256位小端整数，zl为28字节）
+func cardanoAddMul8(zl, kl []byte) []byte {
+	out := make([]byte, 32)
+	var carry uint16
+	for i := 0; i < 32; i++ {
+		sum := uint16(kl[i]) + carry
+		if i < len(zl) {
+			sum += uint16(zl[i]) << 3
+		}
+		out[i] = byte(sum)
+		carry = sum >> 8
+	}
+	return out
+}
+
+// cardanoAdd256 计算 a + b (mod 2^256)，小端编码
+func cardanoAdd256(a, b []byte) []byte {
+	out := make([]byte, 32)
+	var carry uint16
+	for i := 0; i < 32; i++ {
+		sum := uint16(a[i]) + uint16(b[i]) + carry
+		out[i] = byte(sum)
+		carry = sum >> 8
+	}
+	return out
+}
+
+// encodeCardanoBech32 使用CIP-5前缀进行Bech32编码
+func encodeCardanoBech32(hrp string, data []byte) (string, error) {
+	converted, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := bech32.Encode(hrp, converted)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode with bech32: %w", err)
+	}
+	return encoded, nil
+}
+
+// decodeCardanoBech32 解码CIP-5 Bech32字符串（扩展密钥超过90个字符的限制）
+func decodeCardanoBech32(encoded string) (hrp string, data []byte, err error) {
+	hrp, converted, err := bech32.DecodeNoLimit(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid bech32 string: %w", err)
+	}
+	data, err = bech32.ConvertBits(converted, 5, 8, false)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid bech32 string: %w", err)
+	}
+	return hrp, data, nil
+}
+
+// parseCardanoExtendedKey 解析Bech32编码的96字节扩展私钥，返回其前缀
+func parseCardanoExtendedKey(encoded string) (string, *cardanoExtendedKey, error) {
+	hrp, data, err := decodeCardanoBech32(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+	switch hrp {
+	case cardanoRootXsk, cardanoAcctXsk, cardanoAddrXsk, cardanoStakeXsk:
+	default:
+		return "", nil, fmt.Errorf("unsupported extended private key prefix: %s", hrp)
+	}
+	if len(data) != 96 {
+		return "", nil, fmt.Errorf("invalid extended private key length: expected 96 bytes, got %d bytes", len(data))
+	}
+	return hrp, &cardanoExtendedKey{key: data[:64], chainCode: data[64:]}, nil
+}
+
+// parseCardanoExtendedPublicKey 解析Bech32编码的64字节账户扩展公钥（acct_xvk）
+func parseCardanoExtendedPublicKey(encoded string) (*cardanoExtendedPublicKey, error) {
+	hrp, data, err := decodeCardanoBech32(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != cardanoAcctXvk {
+		return nil, fmt.Errorf("unsupported extended public key prefix: %s", hrp)
+	}
+	if len(data) != 64 {
+		return nil, fmt.Errorf("invalid extended public key length: expected 64 bytes, got %d bytes", len(data))
+	}
+	return &cardanoExtendedPublicKey{publicKey: data[:32], chainCode: data[32:]}, nil
+}
@@ -2,17 +2,61 @@ package crypto
 
 import (
 	"crypto/ed25519"
-	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"github.com/btcsuite/btcd/btcutil/bech32"
+	"strings"
+
+	"github.com/featx/keys-gin/web/model"
 	"golang.org/x/crypto/blake2b"
 )
 
+// cardanoNetworks 各Cardano链类型的网络
+var cardanoNetworks = map[string]NetworkType{
+	model.ChainTypeADA:        Mainnet,
+	model.ChainTypeADATestnet: Testnet,
+}
+
+func init() {
+	for _, chainType := range []string{model.ChainTypeADA, model.ChainTypeADATestnet} {
+		network := cardanoNetworks[chainType]
+		mustRegisterChain(ChainModule{
+			ChainType: chainType,
+			// CIP-1852 BIP32-Ed25519扩展密钥，不与标准Ed25519密钥共用
+			Curve:    "ed25519-bip32",
+			Encoding: "cardano_address",
+			NewKeyGenerator: func() (KeyGenerator, error) {
+				return &AdaKeyGenerator{Network: network}, nil
+			},
+			NewTransactionSigner: func() (TransactionSigner, error) {
+				return &AdaTransactionSigner{}, nil
+			},
+			ParsePrivateKey: parseAdaSigningKey,
+			ValidateAddress: func(address string) error {
+				return validateCardanoAddress(address, network)
+			},
+		})
+	}
+}
+
 // AdaKeyGenerator Cardano (ADA)密钥生成器
-// 实现符合Cardano主网规范的密钥和地址生成
+// 按CIP-1852从BIP-39助记词派生BIP32-Ed25519（Icarus）密钥：
+// 支付密钥为 m/1852'/1815'/account'/0/index，权益密钥为 m/1852'/1815'/account'/2/0。
+// 生成的私钥和公钥为账户级扩展密钥（CIP-5 的 acct_xsk / acct_xvk），支付凭证和权益凭证都可以从中派生
+type AdaKeyGenerator struct {
+	Account uint32      // 从助记词或根密钥派生时使用的账户索引
+	Index   uint32      // 支付密钥的地址索引
+	Network NetworkType // 生成地址的网络，为空时为主网
+}
 
-type AdaKeyGenerator struct{}
+// NewAdaKeyGenerator 创建指定Cardano链类型的密钥生成器，地址属于链类型对应的网络
+func NewAdaKeyGenerator(chainType string) (*AdaKeyGenerator, error) {
+	network, ok := cardanoNetworks[chainType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cardano chain type: %s", chainType)
+	}
+	return &AdaKeyGenerator{Network: network}, nil
+}
 
 // AddressType 定义Cardano地址类型
 type AddressType string
@@ -22,6 +66,8 @@ const (
 	BaseAddress AddressType = "base"
 	// EnterpriseAddress Enterprise地址类型（仅包含支付组件）
 	EnterpriseAddress AddressType = "enterprise"
+	// RewardAddress 奖励地址类型（仅包含权益组件，stake1...）
+	RewardAddress AddressType = "reward"
 )
 
 // NetworkType 定义Cardano网络类型
@@ -34,85 +80,201 @@ const (
 	Testnet NetworkType = "testnet"
 )
 
-// GenerateKeyPair 生成Cardano密钥对 - 使用真实的Ed25519算法
+// AdaHDKeyPair CIP-1852 HD密钥对，包含支付凭证和权益凭证
+type AdaHDKeyPair struct {
+	Mnemonic          string // BIP-39助记词，仅新生成时返回
+	AccountPrivateKey string // 账户扩展私钥（acct_xsk）
+	AccountPublicKey  string // 账户扩展公钥（acct_xvk），可用于只读派生地址
+	PaymentPublicKey  string // 支付公钥的十六进制
+	PaymentPath       string // 支付密钥的派生路径
+	StakePublicKey    string // 权益公钥的十六进制
+	StakePath         string // 权益密钥的派生路径
+	Address           string // 基本地址
+	RewardAddress     string // 奖励地址
+}
+
+// cardanoCredentials 地址使用的支付公钥和权益公钥，不适用的为空
+type cardanoCredentials struct {
+	payment []byte
+	stake   []byte
+}
+
+// network 返回生成地址的网络，未指定时为主网
+func (g *AdaKeyGenerator) network() NetworkType {
+	if g.Network == "" {
+		return Mainnet
+	}
+	return g.Network
+}
+
+// GenerateKeyPair 生成Cardano密钥对，地址为生成器网络的基本地址
 func (g *AdaKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey string, err error) {
-	return g.GenerateKeyPairWithOptions(BaseAddress, Mainnet)
+	return g.GenerateKeyPairWithOptions(BaseAddress, g.network())
 }
 
 // DeriveKeyPairFromPrivateKey 从现有私钥推导Cardano公钥和地址
 func (g *AdaKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (address, publicKey string, err error) {
-	return g.DeriveKeyPairFromPrivateKeyWithOptions(privateKey, BaseAddress, Mainnet)
+	return g.DeriveKeyPairFromPrivateKeyWithOptions(privateKey, BaseAddress, g.network())
 }
 
 // PublicKeyToAddress 从公钥生成Cardano地址
 func (g *AdaKeyGenerator) PublicKeyToAddress(publicKey string) (address string, err error) {
-	return g.PublicKeyToAddressWithOptions(publicKey, BaseAddress, Mainnet)
+	return g.PublicKeyToAddressWithOptions(publicKey, BaseAddress, g.network())
+}
+
+// RewardAddress 从账户扩展公钥生成生成器网络的奖励地址
+func (g *AdaKeyGenerator) RewardAddress(publicKey string) (address string, err error) {
+	return g.PublicKeyToAddressWithOptions(publicKey, RewardAddress, g.network())
+}
+
+// DerivationPath 返回支付密钥的CIP-1852派生路径 m/1852'/1815'/account'/0/index
+func (g *AdaKeyGenerator) DerivationPath(account, index uint32) string {
+	return CardanoDerivationPath(account, CardanoRoleExternal, index)
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按CIP-1852派生Cardano密钥对
+// path 为支付密钥的派生路径，返回生成器网络的基本地址及账户扩展公钥（acct_xvk）和账户扩展私钥（acct_xsk）
+func (g *AdaKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return "", "", "", err
+	}
+	if len(indexes) != 5 || indexes[0] != cardanoHardened(CardanoPurpose) || indexes[1] != cardanoHardened(CardanoCoinType) ||
+		indexes[2] < cardanoHardenedOffset || indexes[3] != CardanoRoleExternal || indexes[4] >= cardanoHardenedOffset {
+		return "", "", "", fmt.Errorf("invalid cardano derivation path: %s", path)
+	}
+
+	generator := &AdaKeyGenerator{Account: indexes[2] - cardanoHardenedOffset, Index: indexes[4], Network: g.Network}
+	keyPair, err := generator.DeriveHDKeyPair(mnemonic, passphrase, g.network())
+	if err != nil {
+		return "", "", "", err
+	}
+	return keyPair.Address, keyPair.AccountPublicKey, keyPair.AccountPrivateKey, nil
 }
 
 // GenerateKeyPairWithAddressType 生成指定地址类型的Cardano密钥对
 // 提供额外的方法支持选择地址类型
 func (g *AdaKeyGenerator) GenerateKeyPairWithAddressType(addressType AddressType) (address, publicKey, privateKey string, err error) {
-	return g.GenerateKeyPairWithOptions(addressType, Mainnet)
+	return g.GenerateKeyPairWithOptions(addressType, g.network())
 }
 
 // GenerateKeyPairWithOptions 生成带选项的Cardano密钥对
-// 支持选择地址类型和网络类型
+// 支持选择地址类型和网络类型，返回账户扩展公钥（acct_xvk）和账户扩展私钥（acct_xsk）
 func (g *AdaKeyGenerator) GenerateKeyPairWithOptions(addressType AddressType, networkType NetworkType) (address, publicKey, privateKey string, err error) {
-	// 生成Ed25519密钥对
-	publicKeyBytes, privateKeyBytes, err := ed25519.GenerateKey(rand.Reader)
+	keyPair, err := g.GenerateHDKeyPair(networkType)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to generate ed25519 key pair: %w", err)
+		return "", "", "", err
 	}
 
-	// 获取私钥的十六进制表示
-	privateKey = hex.EncodeToString(privateKeyBytes)
+	address, err = g.PublicKeyToAddressWithOptions(keyPair.AccountPublicKey, addressType, networkType)
+	if err != nil {
+		return "", "", "", err
+	}
 
-	// 获取公钥的十六进制表示
-	publicKey = hex.EncodeToString(publicKeyBytes)
+	return address, keyPair.AccountPublicKey, keyPair.AccountPrivateKey, nil
+}
 
-	// 生成符合Cardano规范的地址
-	address, err = generateCardanoAddress(publicKeyBytes, addressType, networkType)
+// GenerateHDKeyPair 生成24个单词的助记词，并派生CIP-1852密钥对
+func (g *AdaKeyGenerator) GenerateHDKeyPair(networkType NetworkType) (*AdaHDKeyPair, error) {
+	mnemonic, err := NewMnemonic(256)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
+	return g.DeriveHDKeyPair(mnemonic, "", networkType)
+}
 
-	return address, publicKey, privateKey, nil
+// DeriveHDKeyPair 从BIP-39助记词（可选密码）派生CIP-1852密钥对
+// 主密钥按CIP-3 Icarus方案生成，与Daedalus、Yoroi等钱包一致
+func (g *AdaKeyGenerator) DeriveHDKeyPair(mnemonic, passphrase string, networkType NetworkType) (*AdaHDKeyPair, error) {
+	rootKey, err := newCardanoMasterKeyFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	accountKey, err := rootKey.derivePath(cardanoAccountPath(g.Account)...)
+	if err != nil {
+		return nil, err
+	}
+
+	accountPrivateKey, err := encodeCardanoBech32(cardanoAcctXsk, accountKey.bytes())
+	if err != nil {
+		return nil, err
+	}
+	accountPublicKey, err := encodeCardanoBech32(cardanoAcctXvk, accountKey.extendedPublicKey().bytes())
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := g.accountCredentials(accountKey.extendedPublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := generateCardanoAddress(credentials, BaseAddress, networkType)
+	if err != nil {
+		return nil, err
+	}
+	rewardAddress, err := generateCardanoAddress(credentials, RewardAddress, networkType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdaHDKeyPair{
+		Mnemonic:          normalizeMnemonic(mnemonic),
+		AccountPrivateKey: accountPrivateKey,
+		AccountPublicKey:  accountPublicKey,
+		PaymentPublicKey:  hex.EncodeToString(credentials.payment),
+		PaymentPath:       CardanoDerivationPath(g.Account, CardanoRoleExternal, g.Index),
+		StakePublicKey:    hex.EncodeToString(credentials.stake),
+		StakePath:         CardanoDerivationPath(g.Account, CardanoRoleStaking, 0),
+		Address:           address,
+		RewardAddress:     rewardAddress,
+	}, nil
 }
 
 // DeriveKeyPairFromPrivateKeyWithOptions 从现有私钥推导Cardano公钥和地址（带选项）
+// 支持的私钥格式：
+// - acct_xsk / root_xsk：账户或根扩展私钥，返回账户扩展公钥，可生成所有类型的地址
+// - addr_xsk、96字节扩展私钥或32/64字节Ed25519私钥的十六进制：单个支付密钥，返回公钥的十六进制，只能生成企业地址
+// - stake_xsk：单个权益密钥，只能生成奖励地址
 func (g *AdaKeyGenerator) DeriveKeyPairFromPrivateKeyWithOptions(privateKey string, addressType AddressType, networkType NetworkType) (address, publicKey string, err error) {
-	// 解析私钥（必须是64字节的十六进制字符串，Ed25519私钥格式）
-	privateKeyBytes, err := hex.DecodeString(privateKey)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to decode private key: %w", err)
-	}
+	var credentials cardanoCredentials
 
-	// 验证私钥长度是否符合Ed25519要求
-	if len(privateKeyBytes) != ed25519.PrivateKeySize {
-		// 如果是32字节，尝试转换为完整的Ed25519私钥格式
-		if len(privateKeyBytes) == 32 {
-			// 重新生成完整的Ed25519密钥对
-			publicKeyBytes := ed25519.NewKeyFromSeed(privateKeyBytes)
-			publicKey = hex.EncodeToString(publicKeyBytes)
-			
-			// 生成符合Cardano规范的地址
-			address, err = generateCardanoAddress(publicKeyBytes, addressType, networkType)
-			if err != nil {
+	if strings.Contains(privateKey, "_xsk1") {
+		hrp, key, err := parseCardanoExtendedKey(privateKey)
+		if err != nil {
+			return "", "", err
+		}
+		switch hrp {
+		case cardanoRootXsk, cardanoAcctXsk:
+			if hrp == cardanoRootXsk {
+				if key, err = key.derivePath(cardanoAccountPath(g.Account)...); err != nil {
+					return "", "", err
+				}
+			}
+			accountPublicKey := key.extendedPublicKey()
+			if credentials, err = g.accountCredentials(accountPublicKey); err != nil {
+				return "", "", err
+			}
+			if publicKey, err = encodeCardanoBech32(cardanoAcctXvk, accountPublicKey.bytes()); err != nil {
 				return "", "", err
 			}
-			return address, publicKey, nil
+		case cardanoStakeXsk:
+			credentials.stake = key.publicKey()
+			publicKey = hex.EncodeToString(credentials.stake)
+		default:
+			credentials.payment = key.publicKey()
+			publicKey = hex.EncodeToString(credentials.payment)
+		}
+	} else {
+		key, err := parseCardanoHexPrivateKey(privateKey)
+		if err != nil {
+			return "", "", err
 		}
-		return "", "", fmt.Errorf("invalid private key length: expected %d bytes (or 32 bytes for seed), got %d bytes", 
-				ed25519.PrivateKeySize, len(privateKeyBytes))
+		credentials.payment = key.publicKey()
+		publicKey = hex.EncodeToString(credentials.payment)
 	}
 
-	// 从私钥提取公钥
-	privateKeyObj := ed25519.PrivateKey(privateKeyBytes)
-	publicKeyBytes := privateKeyObj.Public().(ed25519.PublicKey)
-	publicKey = hex.EncodeToString(publicKeyBytes)
-
 	// 生成符合Cardano规范的地址
-	address, err = generateCardanoAddress(publicKeyBytes, addressType, networkType)
+	address, err = generateCardanoAddress(credentials, addressType, networkType)
 	if err != nil {
 		return "", "", err
 	}
@@ -121,110 +283,149 @@ func (g *AdaKeyGenerator) DeriveKeyPairFromPrivateKeyWithOptions(privateKey stri
 }
 
 // PublicKeyToAddressWithOptions 从公钥生成Cardano地址（带选项）
+// 账户扩展公钥（acct_xvk）可生成所有类型的地址；32字节公钥的十六进制只能作为企业地址的支付凭证或奖励地址的权益凭证
 func (g *AdaKeyGenerator) PublicKeyToAddressWithOptions(publicKey string, addressType AddressType, networkType NetworkType) (address string, err error) {
-	// 解析公钥
-	publicKeyBytes, err := hex.DecodeString(publicKey)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode public key: %w", err)
-	}
+	var credentials cardanoCredentials
 
-	// 验证公钥长度是否符合Ed25519要求
-	if len(publicKeyBytes) != ed25519.PublicKeySize {
-		return "", fmt.Errorf("invalid public key length: expected %d bytes, got %d bytes", 
+	if strings.HasPrefix(publicKey, cardanoAcctXvk) {
+		accountPublicKey, err := parseCardanoExtendedPublicKey(publicKey)
+		if err != nil {
+			return "", err
+		}
+		if credentials, err = g.accountCredentials(accountPublicKey); err != nil {
+			return "", err
+		}
+	} else {
+		// 解析公钥
+		publicKeyBytes, err := hex.DecodeString(publicKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode public key: %w", err)
+		}
+
+		// 验证公钥长度是否符合Ed25519要求
+		if len(publicKeyBytes) != ed25519.PublicKeySize {
+			return "", fmt.Errorf("invalid public key length: expected %d bytes, got %d bytes",
 				ed25519.PublicKeySize, len(publicKeyBytes))
+		}
+
+		if addressType == RewardAddress {
+			credentials.stake = publicKeyBytes
+		} else {
+			credentials.payment = publicKeyBytes
+		}
 	}
 
 	// 生成符合Cardano规范的地址
-	address, err = generateCardanoAddress(publicKeyBytes, addressType, networkType)
+	return generateCardanoAddress(credentials, addressType, networkType)
+}
+
+// accountCredentials 从账户扩展公钥派生支付公钥（0/index）和权益公钥（2/0）
+func (g *AdaKeyGenerator) accountCredentials(accountPublicKey *cardanoExtendedPublicKey) (cardanoCredentials, error) {
+	external, err := accountPublicKey.derive(CardanoRoleExternal)
+	if err != nil {
+		return cardanoCredentials{}, err
+	}
+	payment, err := external.derive(g.Index)
+	if err != nil {
+		return cardanoCredentials{}, err
+	}
+	staking, err := accountPublicKey.derive(CardanoRoleStaking)
+	if err != nil {
+		return cardanoCredentials{}, err
+	}
+	stake, err := staking.derive(0)
 	if err != nil {
-		return "", err
+		return cardanoCredentials{}, err
 	}
+	return cardanoCredentials{payment: payment.publicKey, stake: stake.publicKey}, nil
+}
 
-	return address, nil
+// parseCardanoHexPrivateKey 解析十六进制私钥
+// 32字节为Ed25519种子，64字节为种子||公钥，96字节为BIP32-Ed25519扩展私钥 kL||kR||链码
+func parseCardanoHexPrivateKey(privateKey string) (*cardanoExtendedKey, error) {
+	privateKeyBytes, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	switch len(privateKeyBytes) {
+	case ed25519.SeedSize, ed25519.PrivateKeySize:
+		return newCardanoKeyFromSeed(privateKeyBytes[:ed25519.SeedSize]), nil
+	case 96:
+		return &cardanoExtendedKey{key: privateKeyBytes[:64], chainCode: privateKeyBytes[64:]}, nil
+	default:
+		return nil, fmt.Errorf("invalid private key length: expected 32, 64 or 96 bytes, got %d bytes", len(privateKeyBytes))
+	}
 }
 
-// generateCardanoAddress 生成符合Cardano规范的地址，支持两种格式和网络类型
-func generateCardanoAddress(publicKeyBytes []byte, addressType AddressType, networkType NetworkType) (string, error) {
-	// 转换网络类型和地址类型为对应的ID值
-	// 根据CIP-19规范：
-	// - 高4位(7-4)是地址类型：0000为Base Address，0110为Enterprise Address
-	// - 低4位(3-0)是网络：0000为测试网，0001为主网
+// generateCardanoAddress 生成符合CIP-19规范的Shelley地址
+// 地址头部一个字节：高4位是地址类型（0000基本地址，0110企业地址，1110奖励地址），低4位是网络ID（0000测试网，0001主网），
+// 之后依次是支付凭证和权益凭证的Blake2b-224密钥哈希
+func generateCardanoAddress(credentials cardanoCredentials, addressType AddressType, networkType NetworkType) (string, error) {
 	var networkID uint8
-	var addrTypeID uint8
+	var hrp string
 
 	switch networkType {
 	case Mainnet:
-		networkID = 1 // 主网网络ID (0001)
+		networkID, hrp = 1, "addr"
 	case Testnet:
-		networkID = 0 // 测试网网络ID (0000)
+		networkID, hrp = 0, "addr_test"
 	default:
 		return "", fmt.Errorf("unsupported network type: %s", networkType)
 	}
 
+	var addrTypeID uint8
+	var keys [][]byte
 	switch addressType {
 	case BaseAddress:
-		addrTypeID = 0 // 基本地址类型ID (0000)
+		if credentials.stake == nil {
+			return "", errors.New("base address requires a stake key: use an account key (acct_xsk/acct_xvk)")
+		}
+		addrTypeID, keys = 0, [][]byte{credentials.payment, credentials.stake}
 	case EnterpriseAddress:
-		addrTypeID = 6 // 企业地址类型ID (0110)
+		addrTypeID, keys = 6, [][]byte{credentials.payment}
+	case RewardAddress:
+		if credentials.stake == nil {
+			return "", errors.New("reward address requires a stake key")
+		}
+		// 奖励地址使用stake前缀
+		addrTypeID, keys = 14, [][]byte{credentials.stake}
+		hrp = strings.Replace(hrp, "addr", "stake", 1)
 	default:
 		return "", fmt.Errorf("unsupported address type: %s", addressType)
 	}
-
-	// 计算公钥的Blake2b-224哈希作为支付凭证
-	paymentCredHash, err := blake2b.New(28, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create blake2b-224 hash: %w", err)
+	if keys[0] == nil {
+		return "", fmt.Errorf("%s address requires a payment key", addressType)
 	}
-	paymentCredHash.Write(publicKeyBytes)
-	paymentCred := paymentCredHash.Sum(nil)
 
-	// 根据网络ID确定地址前缀
-	var hrp string
-	switch networkID {
-	case 1:
-		hrp = "addr"       // Mainnet (0001)
-	case 0:
-		hrp = "addr_test"  // Testnet (0000)
-	default:
-		return "", fmt.Errorf("unsupported network ID: %d", networkID)
-	}
-
-	// 构建地址数据
-	var data []byte
-
-	// 构建地址头部（一个字节，根据CIP-19规范：高4位是地址类型，低4位是网络ID）
-	addressHeader := (addrTypeID << 4) | networkID
-	data = append(data, addressHeader)
-
-	// 根据地址类型构建不同的地址
-	switch addrTypeID {
-	case 0:
-		// 基本地址: type | payment credential type | payment credential hash | stake credential type | stake credential hash
-		data = append(data, 0) // 支付凭证类型 (0 = 密钥哈希)
-		data = append(data, paymentCred...) // 支付凭证哈希
-		
-		// 假设权益凭证与支付凭证相同
-		data = append(data, 0) // 权益凭证类型 (0 = 密钥哈希)
-		data = append(data, paymentCred...) // 权益凭证哈希
-	case 6:
-		// 企业地址: type | payment credential type | payment credential hash
-		data = append(data, 0) // 支付凭证类型 (0 = 密钥哈希)
-		data = append(data, paymentCred...) // 支付凭证哈希
-	default:
-		return "", fmt.Errorf("unsupported address type: %d", addrTypeID)
+	// 构建地址数据：头部 || 凭证哈希
+	data := []byte{addrTypeID<<4 | networkID}
+	for _, key := range keys {
+		hash, err := blake2b.New(28, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create blake2b-224 hash: %w", err)
+		}
+		hash.Write(key)
+		data = hash.Sum(data)
 	}
 
-	// 将数据从8位字节转换为5位字
-	expanded, err := bech32.ConvertBits(data, 8, 5, true)
-	if err != nil {
-		return "", err
-	}
+	return encodeCardanoBech32(hrp, data)
+}
 
-	// 使用原始bech32编码（根据用户要求）	
-	address, err := bech32.Encode(hrp, expanded)
+// validateCardanoAddress 校验Bech32格式的Shelley地址或奖励地址，主网为addr、stake，测试网为addr_test、stake_test
+func validateCardanoAddress(address string, networkType NetworkType) error {
+	hrp, data, err := decodeCardanoBech32(address)
 	if err != nil {
-		return "", fmt.Errorf("failed to encode with bech32: %w", err)
+		return err
 	}
-
-	return address, nil
-}
\ No newline at end of file
+	switch {
+	case networkType == Mainnet && (hrp == "addr" || hrp == "stake"):
+	case networkType == Testnet && (hrp == "addr_test" || hrp == "stake_test"):
+	default:
+		return fmt.Errorf("invalid address prefix %s for cardano %s", hrp, networkType)
+	}
+	if len(data) < 29 {
+		return fmt.Errorf("invalid cardano address: %s", address)
+	}
+	return nil
+}
@@ -2,11 +2,17 @@ package crypto
 
 import (
 	"crypto/ed25519"
+	"encoding/hex"
 	"testing"
 
+	"github.com/featx/keys-gin/web/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// 测试用助记词（cardano-serialization-lib的CIP-1852测试向量）
+const testAdaMnemonic = "test walk nut penalty hip pave soap entry language right filter choice"
+
 func TestAdaKeyGenerator_GenerateKeyPair(t *testing.T) {
 	generator := &AdaKeyGenerator{}
 
@@ -19,11 +25,10 @@ func TestAdaKeyGenerator_GenerateKeyPair(t *testing.T) {
 	assert.NotEmpty(t, publicKey)
 	assert.NotEmpty(t, privateKey)
 	// 验证地址格式符合Cardano规范
-	assert.Contains(t, address, "addr")
-	// 验证私钥长度 - Ed25519私钥是64字节，十六进制表示为128字符
-	assert.Equal(t, ed25519.PrivateKeySize*2, len(privateKey))
-	// 验证公钥长度 - Ed25519公钥是32字节，十六进制表示为64字符
-	assert.Equal(t, ed25519.PublicKeySize*2, len(publicKey))
+	assert.Contains(t, address, "addr1q")
+	// 私钥和公钥为CIP-5账户扩展密钥
+	assert.Contains(t, privateKey, "acct_xsk1")
+	assert.Contains(t, publicKey, "acct_xvk1")
 }
 
 func TestAdaKeyGenerator_DeriveKeyPairFromPrivateKey(t *testing.T) {
@@ -41,8 +46,7 @@ func TestAdaKeyGenerator_DeriveKeyPairFromPrivateKey(t *testing.T) {
 	assert.NotEmpty(t, address)
 	assert.NotEmpty(t, publicKey)
 	assert.Contains(t, address, "addr")
-	// 验证公钥长度
-	assert.Equal(t, ed25519.PublicKeySize*2, len(publicKey))
+	assert.Contains(t, publicKey, "acct_xvk1")
 }
 
 func TestAdaKeyGenerator_InvalidPrivateKey(t *testing.T) {
@@ -58,6 +62,10 @@ func TestAdaKeyGenerator_InvalidPrivateKey(t *testing.T) {
 	assert.Empty(t, publicKey)
 	assert.Contains(t, err.Error(), "invalid private key length")
 
+	// 测试其他类型的扩展私钥
+	_, _, err = generator.DeriveKeyPairFromPrivateKey("acct_xvk1eame4ge0x5yrwpuqs5eyw89kfmjpgfkfh02xzdx6c2k9k2swcr5clf0u634tm82x6nv2j750x3j7938g70ya4k0lv6pr59s7etw2vpqgfmule")
+	assert.Error(t, err)
+
 	// 测试非十六进制格式的私钥
 	nonHexPrivateKey := "not_a_hex_string"
 	address, publicKey, err = generator.DeriveKeyPairFromPrivateKey(nonHexPrivateKey)
@@ -72,7 +80,7 @@ func TestAdaKeyGenerator_PublicKeyToAddress(t *testing.T) {
 	generator := &AdaKeyGenerator{}
 
 	// 先生成一个有效的密钥对
-	_, publicKey, _, err := generator.GenerateKeyPair()
+	generatedAddress, publicKey, _, err := generator.GenerateKeyPair()
 	assert.NoError(t, err)
 
 	// 从公钥生成地址
@@ -80,8 +88,11 @@ func TestAdaKeyGenerator_PublicKeyToAddress(t *testing.T) {
 
 	// 验证结果
 	assert.NoError(t, err)
-	assert.NotEmpty(t, address)
-	assert.Contains(t, address, "addr")
+	assert.Equal(t, generatedAddress, address)
+
+	// 单个公钥没有权益凭证，不能生成基本地址
+	_, err = generator.PublicKeyToAddress(hex.EncodeToString(make([]byte, ed25519.PublicKeySize)))
+	assert.Error(t, err)
 }
 
 func TestAdaKeyGenerator_SeedToKeyPair(t *testing.T) {
@@ -90,13 +101,117 @@ func TestAdaKeyGenerator_SeedToKeyPair(t *testing.T) {
 	// 测试从32字节种子生成密钥对
 	// 创建一个32字节的十六进制字符串作为种子
 	seed := "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
-	address, publicKey, err := generator.DeriveKeyPairFromPrivateKey(seed)
+	address, publicKey, err := generator.DeriveKeyPairFromPrivateKeyWithOptions(seed, EnterpriseAddress, Mainnet)
 
 	// 验证结果
 	assert.NoError(t, err)
 	assert.NotEmpty(t, address)
 	assert.NotEmpty(t, publicKey)
 	assert.Contains(t, address, "addr")
+
+	// 公钥为32字节，与64字节完整私钥推导的结果一致
+	assert.Len(t, publicKey, 64)
+	_, derivedPublicKey, err := generator.DeriveKeyPairFromPrivateKeyWithOptions(seed+publicKey, EnterpriseAddress, Mainnet)
+	assert.NoError(t, err)
+	assert.Equal(t, publicKey, derivedPublicKey)
+
+	// 单个密钥没有权益凭证，不能生成基本地址
+	_, _, err = generator.DeriveKeyPairFromPrivateKey(seed)
+	assert.ErrorContains(t, err, "stake key")
+}
+
+func TestAdaKeyGenerator_DeriveHDKeyPair(t *testing.T) {
+	generator := &AdaKeyGenerator{}
+
+	// CIP-3 Icarus主密钥
+	rootKey, err := newCardanoMasterKeyFromMnemonic("eight country switch draw meat scout mystery blade tip drift useless good keep usage title", "")
+	require.NoError(t, err)
+	assert.Equal(t, "c065afd2832cd8b087c4d9ab7011f481ee1e0721e78ea5dd609f3ab3f156d245d176bd8fd4ec60b4731c3918a2a72a0226c0cd119ec35b47e4d55884667f552a23f7fdcd4a10c6cd2c7393ac61d877873e248f417634aa3d812af327ffe9d620", hex.EncodeToString(rootKey.bytes()))
+	rootKey, err = newCardanoMasterKeyFromMnemonic("eight country switch draw meat scout mystery blade tip drift useless good keep usage title", "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "70531039904019351e1afb361cd1b312a4d0565d4ff9f8062d38acf4b15cce41d7b5738d9c893feea55512a3004acb0d222c35d3e3d5cde943a15a9824cbac59443cf67e589614076ba01e354b1a432e0e6db3b59e37fc56b5fb0222970a010e", hex.EncodeToString(rootKey.bytes()))
+
+	// CIP-1852：支付密钥 0/0 与权益密钥 2/0 组成基本地址
+	keyPair, err := generator.DeriveHDKeyPair(testAdaMnemonic, "", Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwqfjkjv7", keyPair.Address)
+	assert.Equal(t, "stake1uyevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqxdekzz", keyPair.RewardAddress)
+	assert.Equal(t, "m/1852'/1815'/0'/0/0", keyPair.PaymentPath)
+	assert.Equal(t, "m/1852'/1815'/0'/2/0", keyPair.StakePath)
+
+	testnetKeyPair, err := generator.DeriveHDKeyPair(testAdaMnemonic, "", Testnet)
+	require.NoError(t, err)
+	assert.Equal(t, "addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwq2ytjqp", testnetKeyPair.Address)
+	assert.Equal(t, "stake_test1uqevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqp8n5xl", testnetKeyPair.RewardAddress)
+
+	// 账户私钥、账户公钥推导的地址一致
+	address, publicKey, err := generator.DeriveKeyPairFromPrivateKey(keyPair.AccountPrivateKey)
+	require.NoError(t, err)
+	assert.Equal(t, keyPair.Address, address)
+	assert.Equal(t, keyPair.AccountPublicKey, publicKey)
+	rewardAddress, err := generator.PublicKeyToAddressWithOptions(keyPair.AccountPublicKey, RewardAddress, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, keyPair.RewardAddress, rewardAddress)
+
+	// 权益公钥直接生成奖励地址
+	rewardAddress, err = generator.PublicKeyToAddressWithOptions(keyPair.StakePublicKey, RewardAddress, Mainnet)
+	require.NoError(t, err)
+	assert.Equal(t, keyPair.RewardAddress, rewardAddress)
+
+	// 不同地址索引的支付密钥不同，权益凭证相同
+	indexKeyPair, err := (&AdaKeyGenerator{Index: 1}).DeriveHDKeyPair(testAdaMnemonic, "", Mainnet)
+	require.NoError(t, err)
+	assert.NotEqual(t, keyPair.Address, indexKeyPair.Address)
+	baseAddress, _ := decodeCardanoAddress(keyPair.Address)
+	indexAddress, _ := decodeCardanoAddress(indexKeyPair.Address)
+	assert.Equal(t, baseAddress[29:], indexAddress[29:])
+	assert.Equal(t, keyPair.StakePublicKey, indexKeyPair.StakePublicKey)
+	assert.Equal(t, "m/1852'/1815'/0'/0/1", indexKeyPair.PaymentPath)
+
+	// 测试网链类型的生成器派生测试网的基本地址和奖励地址
+	testnetGenerator, err := NewAdaKeyGenerator(model.ChainTypeADATestnet)
+	require.NoError(t, err)
+	address, _, _, err = testnetGenerator.DeriveKeyPairFromMnemonic(testAdaMnemonic, "", "m/1852'/1815'/0'/0/0")
+	require.NoError(t, err)
+	assert.Equal(t, testnetKeyPair.Address, address)
+	rewardAddress, err = testnetGenerator.RewardAddress(keyPair.AccountPublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, testnetKeyPair.RewardAddress, rewardAddress)
+	_, err = NewAdaKeyGenerator(model.ChainTypeETH)
+	assert.Error(t, err)
+
+	// 无效的助记词
+	_, err = generator.DeriveHDKeyPair("test walk nut penalty hip pave soap entry language right filter filter", "", Mainnet)
+	assert.Error(t, err)
+}
+
+func TestAdaKeyGenerator_ExtendedKeySigning(t *testing.T) {
+	message := []byte("cardano")
+
+	// 由种子展开的扩展私钥签名结果与标准Ed25519一致
+	seed := make([]byte, ed25519.SeedSize)
+	standardKey := ed25519.NewKeyFromSeed(seed)
+	extendedKey := newCardanoKeyFromSeed(seed)
+	assert.Equal(t, standardKey.Public(), extendedKey.publicKey())
+	assert.Equal(t, ed25519.Sign(standardKey, message), extendedKey.sign(message))
+
+	// HD派生的私钥签名可以用标准Ed25519验证，公钥派生与私钥派生一致
+	rootKey, err := newCardanoMasterKeyFromMnemonic(testAdaMnemonic, "")
+	require.NoError(t, err)
+	accountKey, err := rootKey.derivePath(cardanoAccountPath(0)...)
+	require.NoError(t, err)
+	paymentKey, err := accountKey.derivePath(CardanoRoleExternal, 5)
+	require.NoError(t, err)
+	external, err := accountKey.extendedPublicKey().derive(CardanoRoleExternal)
+	require.NoError(t, err)
+	paymentPublicKey, err := external.derive(5)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(paymentKey.publicKey()), paymentPublicKey.publicKey)
+	assert.True(t, ed25519.Verify(paymentKey.publicKey(), message, paymentKey.sign(message)))
+
+	// 公钥不能进行硬化派生
+	_, err = external.derive(cardanoHardened(0))
+	assert.Error(t, err)
 }
 
 func TestAdaKeyGenerator_GenerateKeyPairWithAddressType(t *testing.T) {
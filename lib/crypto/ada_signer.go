@@ -1,25 +1,32 @@
 package crypto
 
 import (
-	"crypto/sha256"
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
-	"github.com/fxamacker/cbor/v2"
 	"golang.org/x/crypto/ed25519"
 )
 
 // AdaTransactionRequest Cardano交易请求结构
+// 也可以通过 CborHex 传入cardano-cli或Lucid等工具构建的未签名交易（CBOR十六进制），此时忽略其他字段；
+// cardano-cli的TextEnvelope（{"type": "Unwitnessed Tx ConwayEra", "cborHex": "..."}）可以直接作为rawTx
 type AdaTransactionRequest struct {
-	Inputs   []AdaTxInput             `json:"inputs"`
-	Outputs  []AdaTxOutput            `json:"outputs"`
-	Fee      uint64                   `json:"fee"`
-	TTL      uint64                   `json:"ttl,omitempty"` // Time To Live
-	Metadata map[string]interface{}   `json:"metadata,omitempty"`
+	Inputs        []AdaTxInput           `json:"inputs"`
+	Outputs       []AdaTxOutput          `json:"outputs"`
+	Fee           uint64                 `json:"fee"`
+	TTL           uint64                 `json:"ttl,omitempty"`            // Time To Live，交易有效期截止的slot
+	ValidityStart uint64                 `json:"validity_start,omitempty"` // 交易有效期开始的slot
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`       // 以标签为键的交易元数据
+	CborHex       string                 `json:"cborHex,omitempty"`
+	SigningPaths  []string               `json:"signing_paths,omitempty"` // 使用账户私钥签名时的派生路径 role/index，默认 0/0
 }
 
 // AdaTxInput Cardano交易输入
+// Amount 为该输入的lovelace金额，所有输入都给出金额时检查收支平衡
 type AdaTxInput struct {
 	TxID   string `json:"txid"`
 	Index  uint32 `json:"index"`
@@ -28,146 +35,271 @@ type AdaTxInput struct {
 
 // AdaTxOutput Cardano交易输出
 type AdaTxOutput struct {
-	Address string `json:"address"`
-	Amount  uint64 `json:"amount"` // 单位是lovelace
+	Address string     `json:"address"`
+	Amount  uint64     `json:"amount"` // 单位是lovelace
+	Assets  []AdaAsset `json:"assets,omitempty"`
+}
+
+// AdaAsset Cardano原生资产
+type AdaAsset struct {
+	PolicyID  string `json:"policy_id"`  // 28字节策略ID的十六进制
+	AssetName string `json:"asset_name"` // 资产名称的十六进制
+	Quantity  uint64 `json:"quantity"`
 }
 
 // AdaTransactionSigner Cardano交易签名器
-type AdaTransactionSigner struct {}
+// 对交易体的Blake2b-256哈希签名，并将vkeywitness加入见证集
+type AdaTransactionSigner struct{}
 
 // SignTransaction 签名Cardano交易
-func (s *AdaTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (signedTx string, txHash string, err error) {
-	// 解码私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+// 返回CBOR十六进制编码的交易 [transaction_body, transaction_witness_set, is_valid, auxiliary_data]，交易哈希为交易体的Blake2b-256
+func (s *AdaTransactionSigner) SignTransaction(rawTx string, key Signer) (signedTx string, txHash string, err error) {
+	// 构建或解析未签名交易
+	txReq, tx, err := parseAdaTransactionRequest(rawTx)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
-	}
-
-	// 处理私钥长度 - 支持32字节种子或64字节完整私钥
-	var seed []byte
-	if len(privateKeyBytes) == 32 {
-		// 直接使用32字节作为种子
-		seed = privateKeyBytes
-	} else if len(privateKeyBytes) == 64 {
-		// 从64字节完整私钥中提取前32字节作为种子
-		seed = privateKeyBytes[:32]
-	} else {
-		return "", "", fmt.Errorf("invalid private key length: expected 32 or 64 bytes, got %d bytes", len(privateKeyBytes))
-	}
-
-	// 解析交易参数
-	var txReq AdaTransactionRequest
-	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
-		return "", "", fmt.Errorf("invalid transaction data format: %w", err)
+		return "", "", err
 	}
 
-	// 准备交易数据进行哈希计算 (使用CBOR编码)
-	txBodyData, txBodyHash, err := prepareCardanoTransactionBody(txReq)
+	// 确定签名密钥：账户扩展私钥按签名路径派生，其他签名密钥为单个密钥
+	signers, err := adaSigningKeys(key, txReq.SigningPaths)
 	if err != nil {
 		return "", "", err
 	}
 
-	// 使用Ed25519算法进行签名
-	privateKey := ed25519.NewKeyFromSeed(seed)
-	signature := ed25519.Sign(privateKey, txBodyHash)
-
-	// 构建签名的交易 - 符合Cardano的WitnessSet格式
-	signedTxData, err := buildCardanoSignedTransaction(txBodyData, txBodyHash, signature, privateKey.Public().(ed25519.PublicKey))
+	// 使用Ed25519算法对交易体哈希签名，并将vkeywitness加入见证集
+	bodyHash := tx.hash()
+	for _, signer := range signers {
+		if signer != key {
+			defer ZeroizeKey(signer)
+		}
+		signature, err := verifiedEd25519Signature(signer, bodyHash)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to sign transaction: %w", err)
+		}
+		if err := tx.addVKeyWitness(signer.PublicKey(), signature); err != nil {
+			return "", "", err
+		}
+	}
+	signedTxData, err := tx.serialize()
 	if err != nil {
 		return "", "", err
 	}
 
 	// 返回十六进制编码的交易和交易哈希
-	txHash = hex.EncodeToString(txBodyHash)
-	signedTx = hex.EncodeToString(signedTxData)
-
-	return signedTx, txHash, nil
+	return hex.EncodeToString(signedTxData), hex.EncodeToString(bodyHash), nil
 }
 
-// prepareCardanoTransactionBody 准备Cardano交易体数据并计算哈希
-func prepareCardanoTransactionBody(txReq AdaTransactionRequest) ([]byte, []byte, error) {
-	// 转换为Cardano交易体结构
-	txBody := map[string]interface{}{
-		"inputs":   convertInputs(txReq.Inputs),
-		"outputs":  convertOutputs(txReq.Outputs),
-		"fee":      txReq.Fee,
-		"ttl":      txReq.TTL,
-		"metadata": txReq.Metadata,
+// VerifyTransaction 验证Cardano交易签名
+// signedTx 的交易体必须与rawTx一致，且见证集中包含该公钥（十六进制公钥或acct_xvk）的有效签名
+func (s *AdaTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex string) (bool, error) {
+	var publicKey []byte
+	var err error
+	if strings.HasPrefix(publicKeyHex, cardanoAcctXvk) {
+		// 账户扩展公钥验证支付密钥 0/0 的签名
+		accountPublicKey, err := parseCardanoExtendedPublicKey(publicKeyHex)
+		if err != nil {
+			return false, err
+		}
+		credentials, err := (&AdaKeyGenerator{}).accountCredentials(accountPublicKey)
+		if err != nil {
+			return false, err
+		}
+		publicKey = credentials.payment
+	} else {
+		publicKey, err = hex.DecodeString(publicKeyHex)
+		if err != nil {
+			return false, fmt.Errorf("invalid public key format: %w", err)
+		}
+		if len(publicKey) != ed25519.PublicKeySize {
+			return false, fmt.Errorf("invalid public key length: expected %d bytes, got %d bytes", ed25519.PublicKeySize, len(publicKey))
+		}
+	}
+
+	_, expected, err := parseAdaTransactionRequest(rawTx)
+	if err != nil {
+		return false, err
 	}
 
-	// 使用CBOR编码交易体
-	encoder, err := cbor.CoreDetEncOptions().EncMode()
+	signedTxData, err := hex.DecodeString(signedTx)
+	if err != nil {
+		return false, fmt.Errorf("invalid signed transaction format: %w", err)
+	}
+	tx, err := parseCardanoTransaction(signedTxData)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create CBOR encoder: %w", err)
+		return false, err
+	}
+	if !bytes.Equal(tx.body, expected.body) {
+		return false, nil
 	}
 
-	txBodyData, err := encoder.Marshal(txBody)
+	witnesses, err := tx.vkeyWitnesses()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to encode transaction body: %w", err)
+		return false, err
+	}
+	for _, witness := range witnesses {
+		vkey, signature, err := decodeCardanoVKeyWitness(witness)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(vkey, publicKey) {
+			return ed25519.Verify(publicKey, tx.hash(), signature), nil
+		}
 	}
+	return false, nil
+}
 
-	// 计算交易体哈希 (Cardano使用双SHA256)
-	txBodyHash := doubleSHA256(txBodyData)
+// parseAdaTransactionRequest 解析交易请求：CBOR十六进制、带cborHex的JSON或待构建的交易
+func parseAdaTransactionRequest(rawTx string) (*AdaTransactionRequest, *cardanoTransaction, error) {
+	rawTx = strings.TrimSpace(rawTx)
 
-	return txBodyData, txBodyHash, nil
+	var txReq AdaTransactionRequest
+	if strings.HasPrefix(rawTx, "{") {
+		// 使用json.Number保留元数据中整数的精度
+		decoder := json.NewDecoder(strings.NewReader(rawTx))
+		decoder.UseNumber()
+		if err := decoder.Decode(&txReq); err != nil {
+			return nil, nil, fmt.Errorf("invalid transaction data format: %w", err)
+		}
+	} else {
+		txReq.CborHex = rawTx
+	}
+
+	var tx *cardanoTransaction
+	var err error
+	if txReq.CborHex == "" {
+		tx, err = buildCardanoTransaction(&txReq)
+	} else {
+		var data []byte
+		if data, err = hex.DecodeString(txReq.CborHex); err != nil {
+			return nil, nil, fmt.Errorf("invalid transaction data format: %w", err)
+		}
+		tx, err = parseCardanoTransaction(data)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &txReq, tx, nil
+}
+
+// cardanoSigningKey 内存中的Cardano扩展私钥
+// account为true时是账户扩展私钥，按交易的签名路径派生签名密钥，作为单个密钥使用时为支付密钥 0/0
+type cardanoSigningKey struct {
+	key     *cardanoExtendedKey
+	account bool
 }
 
-// buildCardanoSignedTransaction 构建符合Cardano规范的签名交易
-func buildCardanoSignedTransaction(txBodyData []byte, txBodyHash []byte, signature, publicKey []byte) ([]byte, error) {
-	// 创建完整的交易结构
-	transaction := map[string]interface{}{
-		"body": txBodyData,
-		"witness_set": map[string]interface{}{
-			"vkeywitnesses": []map[string]interface{}{{
-				"vkey":      publicKey,
-				"signature": signature,
-			}},
-		},
+// parseAdaSigningKey 解析签名私钥
+// 账户扩展私钥（acct_xsk，或根扩展私钥root_xsk的第0个账户）按签名路径 role/index 派生，默认使用支付密钥 0/0；
+// 其他格式（addr_xsk、stake_xsk、十六进制私钥）为单个密钥，不能指定签名路径
+func parseAdaSigningKey(privateKey []byte) (Signer, error) {
+	if !bytes.Contains(privateKey, []byte("_xsk1")) {
+		key, err := parseCardanoHexPrivateKey(string(privateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		return &cardanoSigningKey{key: key}, nil
+	}
+
+	hrp, key, err := parseCardanoExtendedKey(string(privateKey))
+	if err != nil {
+		return nil, err
+	}
+	switch hrp {
+	case cardanoRootXsk:
+		root := &cardanoSigningKey{key: key}
+		defer root.Zeroize()
+		if key, err = key.derivePath(cardanoAccountPath(0)...); err != nil {
+			return nil, err
+		}
+		return &cardanoSigningKey{key: key, account: true}, nil
+	case cardanoAcctXsk:
+		return &cardanoSigningKey{key: key, account: true}, nil
+	default:
+		return &cardanoSigningKey{key: key}, nil
 	}
+}
 
-	// 使用CBOR编码完整交易
-	encoder, err := cbor.CoreDetEncOptions().EncMode()
+// Curve 返回ed25519-bip32
+func (k *cardanoSigningKey) Curve() string {
+	return CurveEd25519BIP32
+}
+
+// PublicKey 返回32字节公钥
+func (k *cardanoSigningKey) PublicKey() []byte {
+	key, err := k.paymentKey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create CBOR encoder: %w", err)
+		return nil
 	}
+	return key.publicKey()
+}
 
-	signedTxData, err := encoder.Marshal(transaction)
+// Sign 使用扩展私钥对消息进行Ed25519签名
+func (k *cardanoSigningKey) Sign(message []byte) ([]byte, error) {
+	key, err := k.paymentKey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode signed transaction: %w", err)
+		return nil, err
 	}
+	return key.sign(message), nil
+}
 
-	return signedTxData, nil
+// Zeroize 清除私钥
+func (k *cardanoSigningKey) Zeroize() {
+	Zeroize(k.key.key)
+	Zeroize(k.key.chainCode)
 }
 
-// convertInputs 转换输入格式为Cardano要求的格式
-func convertInputs(inputs []AdaTxInput) []map[string]interface{} {
-	result := make([]map[string]interface{}, len(inputs))
-	for i, input := range inputs {
-		txIDBytes, _ := hex.DecodeString(input.TxID)
-		result[i] = map[string]interface{}{
-			"tx_id": txIDBytes,
-			"index": input.Index,
-		}
+// paymentKey 单个密钥为其本身，账户扩展私钥为支付密钥 0/0
+func (k *cardanoSigningKey) paymentKey() (*cardanoExtendedKey, error) {
+	if !k.account {
+		return k.key, nil
 	}
-	return result
+	return k.key.derivePath(CardanoRoleExternal, 0)
 }
 
-// convertOutputs 转换输出格式为Cardano要求的格式
-func convertOutputs(outputs []AdaTxOutput) []map[string]interface{} {
-	result := make([]map[string]interface{}, len(outputs))
-	for i, output := range outputs {
-		// 在实际应用中，应使用完整的bech32解码和地址解析
-		result[i] = map[string]interface{}{
-			"address": output.Address,
-			"amount":  output.Amount,
+// deriveSigningKeys 账户扩展私钥按签名路径 role/index 派生签名密钥，默认使用支付密钥 0/0
+func (k *cardanoSigningKey) deriveSigningKeys(signingPaths []string) ([]Signer, error) {
+	if !k.account {
+		if len(signingPaths) > 0 {
+			return nil, errors.New("signing paths require an account private key (acct_xsk)")
+		}
+		return []Signer{k}, nil
+	}
+
+	if len(signingPaths) == 0 {
+		signingPaths = []string{fmt.Sprintf("%d/0", CardanoRoleExternal)}
+	}
+	keys := make([]Signer, 0, len(signingPaths))
+	for _, path := range signingPaths {
+		var role, index uint32
+		if _, err := fmt.Sscanf(path, "%d/%d", &role, &index); err != nil || path != fmt.Sprintf("%d/%d", role, index) {
+			return nil, fmt.Errorf("invalid signing path: %s", path)
+		}
+		if role > CardanoRoleStaking || index >= cardanoHardenedOffset {
+			return nil, fmt.Errorf("invalid signing path: %s", path)
 		}
+		child, err := k.key.derivePath(role, index)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &cardanoSigningKey{key: child})
 	}
-	return result
+	return keys, nil
 }
 
-// doubleSHA256 执行双SHA256哈希计算
-func doubleSHA256(data []byte) []byte {
-	firstHash := sha256.Sum256(data)
-	secondHash := sha256.Sum256(firstHash[:])
-	return secondHash[:]
-}
\ No newline at end of file
+// adaSigningKeys 按交易的签名路径确定签名密钥
+// 内存中的Cardano扩展私钥按签名路径派生；其他签名密钥（如HSM中的Ed25519密钥）为单个密钥，不能指定签名路径
+func adaSigningKeys(key Signer, signingPaths []string) ([]Signer, error) {
+	if cardanoKey, ok := key.(*cardanoSigningKey); ok {
+		return cardanoKey.deriveSigningKeys(signingPaths)
+	}
+	if key == nil {
+		return nil, errors.New("signer is required")
+	}
+	if key.Curve() != CurveEd25519 && key.Curve() != CurveEd25519BIP32 {
+		return nil, fmt.Errorf("unsupported key curve: %s", key.Curve())
+	}
+	if len(signingPaths) > 0 {
+		return nil, errors.New("signing paths require an account private key (acct_xsk)")
+	}
+	return []Signer{key}, nil
+}
@@ -1,47 +1,268 @@
 package crypto
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
 )
 
-func TestAdaTransactionSigner_SignTransaction(t *testing.T) {
-	signer := &AdaTransactionSigner{}
-
+const (
 	// 测试用的私钥
-	privateKeyHex := "0000000000000000000000000000000000000000000000000000000000000001"
+	testAdaPrivateKey = "0000000000000000000000000000000000000000000000000000000000000001"
+	testAdaTxID       = "61f0bdbd7df2425e5b1e2576d0be264986a08e9f7f2f6152f37c922b0638d023"
+)
 
-	// 构建Cardano交易请求
-	txReq := AdaTransactionRequest{
+// testAdaAddress 测试私钥对应的主网企业地址（CIP-19：头部0x61 || Blake2b-224(公钥)）
+func testAdaAddress(t *testing.T) (address, publicKey string) {
+	seed, _ := hex.DecodeString(testAdaPrivateKey)
+	publicKeyBytes := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	hash, _ := blake2b.New(28, nil)
+	hash.Write(publicKeyBytes)
+	data, err := bech32.ConvertBits(append([]byte{0x61}, hash.Sum(nil)...), 8, 5, true)
+	require.NoError(t, err)
+	address, err = bech32.Encode("addr", data)
+	require.NoError(t, err)
+	return address, hex.EncodeToString(publicKeyBytes)
+}
+
+// newTestAdaRequest 构建ADA转账请求
+func newTestAdaRequest(address string) AdaTransactionRequest {
+	return AdaTransactionRequest{
 		Inputs: []AdaTxInput{
 			{
-				TxID:   "61f0bdbd7df2425e5b1e2576d0be264986a08e9f7f2f6152f37c922b0638d023",
+				TxID:   testAdaTxID,
 				Index:  0,
 				Amount: 1000000000,
 			},
 		},
 		Outputs: []AdaTxOutput{
 			{
-				Address: "addr1q8zu7j4f8v9g5705pql94z9s83p400kfku8n94v8t05m7k9a4t5s9q7g8j6h7f",
-				Amount:  500000000,
+				Address: address,
+				Amount:  999830000,
 			},
 		},
 		Fee: 170000,
 		TTL: 8000000,
 	}
+}
 
-	rawTx, err := json.Marshal(txReq)
+func TestAdaTransactionSigner_SignTransaction(t *testing.T) {
+	signer := &AdaTransactionSigner{}
+	address, publicKey := testAdaAddress(t)
+
+	rawTx, err := json.Marshal(newTestAdaRequest(address))
 	assert.NoError(t, err)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "cardano", testAdaPrivateKey))
+	require.NoError(t, err)
+
+	// 交易体：{0: #6.258([[txid, 0]]), 1: [{0: address, 1: coin}], 2: fee, 3: ttl}
+	addressBytes, err := decodeCardanoAddress(address)
+	require.NoError(t, err)
+	expectedBody := "a4" +
+		"00d9010281825820" + testAdaTxID + "00" +
+		"0181a200581d" + hex.EncodeToString(addressBytes) + "011a3b9831f0" +
+		"021a00029810" +
+		"031a007a1200"
+
+	// 交易：[body, {0: #6.258([[vkey, signature]])}, true, null]
+	assert.True(t, strings.HasPrefix(signedTx, "84"+expectedBody+"a100d9010281825820"+publicKey+"5840"))
+	assert.True(t, strings.HasSuffix(signedTx, "f5f6"))
+
+	// 交易哈希为交易体的Blake2b-256
+	body, _ := hex.DecodeString(expectedBody)
+	assert.Equal(t, hex.EncodeToString(Blake2b256(body)), txHash)
+
+	// 验证签名
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, publicKey)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// 其他公钥验证失败
+	valid, err = signer.VerifyTransaction(string(rawTx), signedTx, strings.Repeat("00", 32))
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestAdaTransactionSigner_AssetsAndMetadata(t *testing.T) {
+	signer := &AdaTransactionSigner{}
+	address, publicKey := testAdaAddress(t)
+
+	policyID := strings.Repeat("ab", 28)
+	txReq := newTestAdaRequest(address)
+	txReq.Inputs[0].Amount = 0 // 输入包含资产时不检查收支平衡
+	txReq.Outputs[0].Assets = []AdaAsset{
+		{PolicyID: policyID, AssetName: "746f6b656e", Quantity: 10},
+		{PolicyID: policyID, AssetName: "", Quantity: 1},
+	}
+	txReq.ValidityStart = 7000000
+	txReq.Metadata = map[string]interface{}{
+		"674": map[string]interface{}{"msg": []interface{}{"hello"}},
+	}
+	rawTx, _ := json.Marshal(txReq)
+
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "cardano", testAdaPrivateKey))
+	require.NoError(t, err)
+
+	signedTxData, _ := hex.DecodeString(signedTx)
+	tx, err := parseCardanoTransaction(signedTxData)
+	require.NoError(t, err)
+	assert.Equal(t, txHash, hex.EncodeToString(tx.hash()))
+
+	// 辅助数据为元数据 {674: {"msg": ["hello"]}}，交易体记录其哈希
+	assert.Equal(t, "a11902a2a1636d7367816568656c6c6f", hex.EncodeToString(tx.auxData))
+	var body map[uint64]cbor.RawMessage
+	require.NoError(t, cbor.Unmarshal(tx.body, &body))
+	assert.Equal(t, "5820"+hex.EncodeToString(Blake2b256(tx.auxData)), hex.EncodeToString(body[cardanoBodyAuxDataHash]))
+	assert.Equal(t, "1a006acfc0", hex.EncodeToString(body[cardanoBodyValidityStart]))
+
+	// 多资产输出：[coin, {policy_id: {asset_name: quantity}}]，资产名称按编码排序
+	addressBytes, _ := decodeCardanoAddress(address)
+	expectedOutputs := "81a200581d" + hex.EncodeToString(addressBytes) +
+		"01821a3b9831f0a1581c" + policyID + "a2400145746f6b656e0a"
+	assert.Equal(t, expectedOutputs, hex.EncodeToString(body[cardanoBodyOutputs]))
+
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, publicKey)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
 
-	// 验证结果 - 更新为与新实现兼容的断言
+func TestAdaTransactionSigner_CborHex(t *testing.T) {
+	signer := &AdaTransactionSigner{}
+	address, publicKey := testAdaAddress(t)
+
+	// 外部工具构建的交易，已包含另一个密钥的见证（不带集合标签）
+	otherKey := ed25519.NewKeyFromSeed(make([]byte, 32))
+	rawTx, _ := json.Marshal(newTestAdaRequest(address))
+	_, unsigned, err := parseAdaTransactionRequest(string(rawTx))
+	require.NoError(t, err)
+	otherWitness, _ := cardanoEncMode.Marshal([][][]byte{{otherKey.Public().(ed25519.PublicKey), ed25519.Sign(otherKey, unsigned.hash())}})
+	unsigned.witnessSet[cardanoWitnessVKeys] = otherWitness
+	unsignedData, err := unsigned.serialize()
+	require.NoError(t, err)
+
+	for _, request := range []string{
+		hex.EncodeToString(unsignedData),
+		`{"type": "Tx ConwayEra", "description": "", "cborHex": "` + hex.EncodeToString(unsignedData) + `"}`,
+	} {
+		signedTx, txHash, err := signer.SignTransaction(request, testSigningKey(t, "cardano", testAdaPrivateKey))
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(unsigned.hash()), txHash)
+
+		// 交易体保持不变，两个见证都有效
+		for _, key := range []string{publicKey, hex.EncodeToString(otherKey.Public().(ed25519.PublicKey))} {
+			valid, err := signer.VerifyTransaction(request, signedTx, key)
+			assert.NoError(t, err)
+			assert.True(t, valid)
+		}
+
+		// 重复签名替换原有见证
+		resigned, _, err := signer.SignTransaction(signedTx, testSigningKey(t, "cardano", testAdaPrivateKey))
+		require.NoError(t, err)
+		resignedData, _ := hex.DecodeString(resigned)
+		tx, err := parseCardanoTransaction(resignedData)
+		require.NoError(t, err)
+		witnesses, err := tx.vkeyWitnesses()
+		require.NoError(t, err)
+		assert.Len(t, witnesses, 2)
+	}
+
+	// 单独的交易体
+	signedTx, _, err := signer.SignTransaction(hex.EncodeToString(unsigned.body), testSigningKey(t, "cardano", testAdaPrivateKey))
+	require.NoError(t, err)
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, publicKey)
 	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	assert.Greater(t, len(signedTx), 100)  // 确保签名结果足够长
-	assert.Equal(t, 64, len(txHash))       // 双SHA256哈希应该是64个十六进制字符
-}
\ No newline at end of file
+	assert.True(t, valid)
+}
+
+func TestAdaTransactionSigner_AccountKey(t *testing.T) {
+	signer := &AdaTransactionSigner{}
+	keyPair, err := (&AdaKeyGenerator{}).DeriveHDKeyPair(testAdaMnemonic, "", Mainnet)
+	require.NoError(t, err)
+
+	// 默认使用支付密钥 0/0 签名
+	txReq := newTestAdaRequest(keyPair.Address)
+	rawTx, _ := json.Marshal(txReq)
+	signedTx, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "cardano", keyPair.AccountPrivateKey))
+	require.NoError(t, err)
+	for _, key := range []string{keyPair.AccountPublicKey, keyPair.PaymentPublicKey} {
+		valid, err := signer.VerifyTransaction(string(rawTx), signedTx, key)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, keyPair.StakePublicKey)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	// 同时使用支付密钥和权益密钥签名（如委托、提取奖励）
+	txReq.SigningPaths = []string{"0/0", "2/0"}
+	rawTx, _ = json.Marshal(txReq)
+	signedTx, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "cardano", keyPair.AccountPrivateKey))
+	require.NoError(t, err)
+	for _, key := range []string{keyPair.PaymentPublicKey, keyPair.StakePublicKey} {
+		valid, err := signer.VerifyTransaction(string(rawTx), signedTx, key)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	// 无效的签名路径
+	for _, path := range []string{"3/0", "0", "0/0'", "2/0/1"} {
+		txReq.SigningPaths = []string{path}
+		rawTx, _ = json.Marshal(txReq)
+		_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "cardano", keyPair.AccountPrivateKey))
+		assert.Error(t, err, path)
+	}
+
+	// 单个密钥不能指定签名路径
+	txReq.SigningPaths = []string{"0/0"}
+	rawTx, _ = json.Marshal(txReq)
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "cardano", testAdaPrivateKey))
+	assert.Error(t, err)
+}
+
+func TestAdaTransactionSigner_InvalidRequest(t *testing.T) {
+	signer := &AdaTransactionSigner{}
+	address, _ := testAdaAddress(t)
+
+	testCases := []func(req *AdaTransactionRequest){
+		// 收支不平衡
+		func(req *AdaTransactionRequest) { req.Fee = 1 },
+		// 无效的地址
+		func(req *AdaTransactionRequest) { req.Outputs[0].Address = "addr1invalid" },
+		// 无效的输入
+		func(req *AdaTransactionRequest) { req.Inputs[0].TxID = "00" },
+		// 无效的策略ID
+		func(req *AdaTransactionRequest) {
+			req.Outputs[0].Assets = []AdaAsset{{PolicyID: "00", Quantity: 1}}
+		},
+		// 无效的元数据标签
+		func(req *AdaTransactionRequest) { req.Metadata = map[string]interface{}{"msg": "hello"} },
+		// 元数据文本过长
+		func(req *AdaTransactionRequest) {
+			req.Metadata = map[string]interface{}{"674": strings.Repeat("a", 65)}
+		},
+		// 无效的CBOR
+		func(req *AdaTransactionRequest) { req.CborHex = "8200" },
+	}
+
+	for i, modify := range testCases {
+		req := newTestAdaRequest(address)
+		modify(&req)
+		rawTx, _ := json.Marshal(req)
+		_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "cardano", testAdaPrivateKey))
+		assert.Error(t, err, i)
+	}
+
+	// 无效的私钥
+	_, err := ParsePrivateKey("cardano", []byte("invalid"))
+	assert.Error(t, err)
+}
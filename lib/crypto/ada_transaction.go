@@ -0,0 +1,469 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cardanoSetTag Conway时代集合的CBOR标签（#6.258）
+const cardanoSetTag = 258
+
+// cardanoMetadataMaxLength 元数据中字节串和文本的最大长度
+const cardanoMetadataMaxLength = 64
+
+// transaction_body 的字段编号
+const (
+	cardanoBodyInputs        = 0
+	cardanoBodyOutputs       = 1
+	cardanoBodyFee           = 2
+	cardanoBodyTTL           = 3
+	cardanoBodyAuxDataHash   = 7
+	cardanoBodyValidityStart = 8
+)
+
+// 输出、见证集和交易中的字段编号
+const (
+	cardanoOutputAddress      = 0
+	cardanoOutputValue        = 1
+	cardanoWitnessVKeys       = 0
+	cardanoTransactionIsValid = 2
+)
+
+// cardanoEncMode 确定性CBOR编码
+var cardanoEncMode = func() cbor.EncMode {
+	mode, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// cardanoTransaction 由交易体、见证集、有效标记和辅助数据组成的交易
+// 交易体保留原始字节，交易哈希为其Blake2b-256
+type cardanoTransaction struct {
+	body       cbor.RawMessage
+	witnessSet map[uint64]cbor.RawMessage
+	isValid    cbor.RawMessage
+	auxData    cbor.RawMessage
+	legacy     bool // Shelley至Mary时代的三元素交易，没有is_valid
+}
+
+// hash 交易哈希：Blake2b-256(transaction_body)
+func (tx *cardanoTransaction) hash() []byte {
+	return Blake2b256(tx.body)
+}
+
+// addVKeyWitness 添加vkeywitness，相同公钥的见证会被替换
+func (tx *cardanoTransaction) addVKeyWitness(publicKey, signature []byte) error {
+	witnesses, err := tx.vkeyWitnesses()
+	if err != nil {
+		return err
+	}
+
+	witness, err := cardanoEncMode.Marshal([]interface{}{publicKey, signature})
+	if err != nil {
+		return fmt.Errorf("failed to encode vkey witness: %w", err)
+	}
+
+	var result []cbor.RawMessage
+	for _, w := range witnesses {
+		if vkey, _, err := decodeCardanoVKeyWitness(w); err == nil && bytes.Equal(vkey, publicKey) {
+			continue
+		}
+		result = append(result, w)
+	}
+	result = append(result, witness)
+
+	encoded, err := cardanoEncMode.Marshal(cbor.Tag{Number: cardanoSetTag, Content: result})
+	if err != nil {
+		return fmt.Errorf("failed to encode witness set: %w", err)
+	}
+	tx.witnessSet[cardanoWitnessVKeys] = encoded
+	return nil
+}
+
+// vkeyWitnesses 见证集中的vkeywitness，兼容带或不带集合标签的编码
+func (tx *cardanoTransaction) vkeyWitnesses() ([]cbor.RawMessage, error) {
+	raw, ok := tx.witnessSet[cardanoWitnessVKeys]
+	if !ok {
+		return nil, nil
+	}
+	var witnesses []cbor.RawMessage
+	if err := cbor.Unmarshal(stripCardanoSetTag(raw), &witnesses); err != nil {
+		return nil, fmt.Errorf("invalid vkey witnesses: %w", err)
+	}
+	return witnesses, nil
+}
+
+// serialize 序列化交易：[transaction_body, transaction_witness_set, is_valid, auxiliary_data / null]
+func (tx *cardanoTransaction) serialize() ([]byte, error) {
+	witnessSet, err := cardanoEncMode.Marshal(tx.witnessSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode witness set: %w", err)
+	}
+
+	items := []cbor.RawMessage{tx.body, witnessSet}
+	if !tx.legacy {
+		items = append(items, tx.isValid)
+	}
+	items = append(items, tx.auxData)
+
+	encoded, err := cardanoEncMode.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+	return encoded, nil
+}
+
+// parseCardanoTransaction 解析CBOR交易，也接受单独的transaction_body
+func parseCardanoTransaction(data []byte) (*cardanoTransaction, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty transaction")
+	}
+
+	// 单独的交易体（map）
+	if data[0]>>5 == 5 {
+		if err := cbor.Valid(data); err != nil {
+			return nil, fmt.Errorf("invalid transaction body: %w", err)
+		}
+		return newCardanoTransaction(data, nil), nil
+	}
+
+	var items []cbor.RawMessage
+	if err := cbor.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %w", err)
+	}
+	if len(items) != 3 && len(items) != 4 {
+		return nil, fmt.Errorf("invalid transaction: expected 3 or 4 elements, got %d", len(items))
+	}
+	if len(items[0]) == 0 || items[0][0]>>5 != 5 {
+		return nil, fmt.Errorf("invalid transaction body")
+	}
+
+	tx := &cardanoTransaction{body: items[0], auxData: items[len(items)-1], legacy: len(items) == 3}
+	if err := cbor.Unmarshal(items[1], &tx.witnessSet); err != nil {
+		return nil, fmt.Errorf("invalid witness set: %w", err)
+	}
+	if tx.witnessSet == nil {
+		tx.witnessSet = map[uint64]cbor.RawMessage{}
+	}
+	if !tx.legacy {
+		tx.isValid = items[cardanoTransactionIsValid]
+	}
+	return tx, nil
+}
+
+// newCardanoTransaction 由交易体和辅助数据创建未签名交易
+func newCardanoTransaction(body, auxData []byte) *cardanoTransaction {
+	if auxData == nil {
+		auxData = []byte{0xf6} // null
+	}
+	return &cardanoTransaction{
+		body:       body,
+		witnessSet: map[uint64]cbor.RawMessage{},
+		isValid:    []byte{0xf5}, // true
+		auxData:    auxData,
+	}
+}
+
+// buildCardanoTransaction 根据交易请求构建Conway时代的未签名交易
+func buildCardanoTransaction(txReq *AdaTransactionRequest) (*cardanoTransaction, error) {
+	if len(txReq.Inputs) == 0 {
+		return nil, fmt.Errorf("at least one input is required")
+	}
+	if len(txReq.Outputs) == 0 {
+		return nil, fmt.Errorf("at least one output is required")
+	}
+
+	// 输入集合按交易ID和索引排序
+	inputs := make([][]interface{}, 0, len(txReq.Inputs))
+	sorted := append([]AdaTxInput{}, txReq.Inputs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].TxID != sorted[j].TxID {
+			return strings.ToLower(sorted[i].TxID) < strings.ToLower(sorted[j].TxID)
+		}
+		return sorted[i].Index < sorted[j].Index
+	})
+	balanced := true
+	var inputAmount, outputAmount uint64
+	for _, input := range sorted {
+		txID, err := hex.DecodeString(input.TxID)
+		if err != nil || len(txID) != 32 {
+			return nil, fmt.Errorf("invalid input txid: %s", input.TxID)
+		}
+		inputs = append(inputs, []interface{}{txID, input.Index})
+		if input.Amount == 0 {
+			balanced = false
+		}
+		inputAmount += input.Amount
+	}
+
+	outputs := make([]interface{}, 0, len(txReq.Outputs))
+	for _, output := range txReq.Outputs {
+		address, err := decodeCardanoAddress(output.Address)
+		if err != nil {
+			return nil, err
+		}
+		value, err := cardanoValue(output)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, map[uint64]interface{}{
+			cardanoOutputAddress: address,
+			cardanoOutputValue:   value,
+		})
+		outputAmount += output.Amount
+	}
+
+	// 所有输入都给出金额时检查ADA收支平衡
+	if balanced && inputAmount != outputAmount+txReq.Fee {
+		return nil, fmt.Errorf("transaction is not balanced: inputs %d, outputs %d, fee %d", inputAmount, outputAmount, txReq.Fee)
+	}
+
+	body := map[uint64]interface{}{
+		cardanoBodyInputs:  cbor.Tag{Number: cardanoSetTag, Content: inputs},
+		cardanoBodyOutputs: outputs,
+		cardanoBodyFee:     txReq.Fee,
+	}
+	if txReq.TTL > 0 {
+		body[cardanoBodyTTL] = txReq.TTL
+	}
+	if txReq.ValidityStart > 0 {
+		body[cardanoBodyValidityStart] = txReq.ValidityStart
+	}
+
+	// 元数据作为辅助数据，交易体中记录其哈希
+	var auxData []byte
+	if len(txReq.Metadata) > 0 {
+		metadata, err := cardanoMetadata(txReq.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		if auxData, err = cardanoEncMode.Marshal(metadata); err != nil {
+			return nil, fmt.Errorf("failed to encode metadata: %w", err)
+		}
+		body[cardanoBodyAuxDataHash] = Blake2b256(auxData)
+	}
+
+	encoded, err := cardanoEncMode.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction body: %w", err)
+	}
+	return newCardanoTransaction(encoded, auxData), nil
+}
+
+// cardanoValue 输出金额：coin，或包含多资产时为 [coin, multiasset]
+func cardanoValue(output AdaTxOutput) (interface{}, error) {
+	if len(output.Assets) == 0 {
+		return output.Amount, nil
+	}
+
+	policies := cardanoByteMap{}
+	for _, asset := range output.Assets {
+		policyID, err := hex.DecodeString(asset.PolicyID)
+		if err != nil || len(policyID) != 28 {
+			return nil, fmt.Errorf("invalid policy id: %s", asset.PolicyID)
+		}
+		assetName, err := hex.DecodeString(asset.AssetName)
+		if err != nil || len(assetName) > 32 {
+			return nil, fmt.Errorf("invalid asset name: %s", asset.AssetName)
+		}
+		if asset.Quantity == 0 {
+			return nil, fmt.Errorf("asset quantity must be positive")
+		}
+
+		assets, _ := policies.get(policyID).(cardanoByteMap)
+		if assets == nil {
+			assets = cardanoByteMap{}
+		}
+		if assets.get(assetName) != nil {
+			return nil, fmt.Errorf("duplicate asset: %s.%s", asset.PolicyID, asset.AssetName)
+		}
+		policies = policies.set(policyID, assets.set(assetName, asset.Quantity))
+	}
+	return []interface{}{output.Amount, policies}, nil
+}
+
+// decodeCardanoAddress 解码Bech32格式的Shelley地址
+func decodeCardanoAddress(address string) ([]byte, error) {
+	hrp, data, err := bech32.DecodeNoLimit(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	if !strings.HasPrefix(hrp, "addr") {
+		return nil, fmt.Errorf("invalid address prefix: %s", hrp)
+	}
+	decoded, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	return decoded, nil
+}
+
+// cardanoMetadata 将JSON元数据转换为 {标签 => metadatum}
+// 字符串以0x开头时为字节串，对象转换为以文本为键的映射
+func cardanoMetadata(metadata map[string]interface{}) (map[uint64]interface{}, error) {
+	result := make(map[uint64]interface{}, len(metadata))
+	for label, value := range metadata {
+		key, err := strconv.ParseUint(label, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata label: %s", label)
+		}
+		if result[key], err = cardanoMetadatum(value); err != nil {
+			return nil, fmt.Errorf("invalid metadata %s: %w", label, err)
+		}
+	}
+	return result, nil
+}
+
+func cardanoMetadatum(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u, nil
+		}
+		return nil, fmt.Errorf("invalid integer: %s", v)
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("invalid integer: %v", v)
+		}
+		return int64(v), nil
+	case string:
+		if strings.HasPrefix(v, "0x") {
+			b, err := hex.DecodeString(v[2:])
+			if err != nil {
+				return nil, err
+			}
+			if len(b) > cardanoMetadataMaxLength {
+				return nil, fmt.Errorf("bytes longer than %d", cardanoMetadataMaxLength)
+			}
+			return b, nil
+		}
+		if len(v) > cardanoMetadataMaxLength {
+			return nil, fmt.Errorf("text longer than %d bytes", cardanoMetadataMaxLength)
+		}
+		return v, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			converted, err := cardanoMetadatum(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = converted
+		}
+		return list, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if len(key) > cardanoMetadataMaxLength {
+				return nil, fmt.Errorf("text longer than %d bytes", cardanoMetadataMaxLength)
+			}
+			converted, err := cardanoMetadatum(item)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = converted
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported metadatum: %v", value)
+	}
+}
+
+// decodeCardanoVKeyWitness 解析vkeywitness：[vkey, signature]
+func decodeCardanoVKeyWitness(raw cbor.RawMessage) (vkey, signature []byte, err error) {
+	var witness [][]byte
+	if err := cbor.Unmarshal(raw, &witness); err != nil {
+		return nil, nil, err
+	}
+	if len(witness) != 2 {
+		return nil, nil, fmt.Errorf("invalid vkey witness")
+	}
+	return witness[0], witness[1], nil
+}
+
+// stripCardanoSetTag 去掉集合标签（#6.258）
+func stripCardanoSetTag(raw []byte) []byte {
+	return bytes.TrimPrefix(raw, []byte{0xd9, 0x01, 0x02})
+}
+
+// cardanoByteMap 以字节串为键的CBOR映射（policy_id、asset_name），按键的编码排序
+type cardanoByteMap []cardanoByteMapEntry
+
+type cardanoByteMapEntry struct {
+	key   []byte
+	value interface{}
+}
+
+func (m cardanoByteMap) get(key []byte) interface{} {
+	for _, entry := range m {
+		if bytes.Equal(entry.key, key) {
+			return entry.value
+		}
+	}
+	return nil
+}
+
+func (m cardanoByteMap) set(key []byte, value interface{}) cardanoByteMap {
+	for i, entry := range m {
+		if bytes.Equal(entry.key, key) {
+			m[i].value = value
+			return m
+		}
+	}
+	return append(m, cardanoByteMapEntry{key: key, value: value})
+}
+
+// MarshalCBOR 实现cbor.Marshaler
+func (m cardanoByteMap) MarshalCBOR() ([]byte, error) {
+	type encodedEntry struct{ key, value []byte }
+	entries := make([]encodedEntry, len(m))
+	for i, entry := range m {
+		key, err := cardanoEncMode.Marshal(entry.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := cardanoEncMode.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = encodedEntry{key, value}
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+
+	var buf bytes.Buffer
+	buf.Write(cardanoCborHead(5, uint64(len(entries))))
+	for _, entry := range entries {
+		buf.Write(entry.key)
+		buf.Write(entry.value)
+	}
+	return buf.Bytes(), nil
+}
+
+// cardanoCborHead CBOR数据项头部：主类型和长度
+func cardanoCborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+	case n <= 0xffffffff:
+		return []byte{major<<5 | 26, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	default:
+		return []byte{major<<5 | 27, byte(n >> 56), byte(n >> 48), byte(n >> 40), byte(n >> 32), byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+}
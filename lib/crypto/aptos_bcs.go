@@ -0,0 +1,555 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// TransactionPayload 的变体索引
+const (
+	aptosPayloadScript        = 0
+	aptosPayloadEntryFunction = 2
+	aptosPayloadMultisig      = 3
+)
+
+// aptosArgumentBcs 参数类型为bcs时，参数为十六进制编码的已序列化参数
+const aptosArgumentBcs = "bcs"
+
+// aptosEntryFunctionABIs 常用入口函数的参数类型，未指定argument_types时使用
+var aptosEntryFunctionABIs = map[string][]string{
+	"0x1::aptos_account::transfer":          {"address", "u64"},
+	"0x1::aptos_account::transfer_coins":    {"address", "u64"},
+	"0x1::aptos_account::create_account":    {"address"},
+	"0x1::coin::transfer":                   {"address", "u64"},
+	"0x1::primary_fungible_store::transfer": {"0x1::object::Object<0x1::fungible_asset::Metadata>", "address", "u64"},
+}
+
+// bcsWriter BCS序列化
+type bcsWriter struct {
+	buf bytes.Buffer
+}
+
+// uleb128 写入ULEB128编码的整数，用于长度和枚举变体索引
+func (w *bcsWriter) uleb128(v uint64) {
+	for v >= 0x80 {
+		w.buf.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	w.buf.WriteByte(byte(v))
+}
+
+func (w *bcsWriter) u8(v uint8) {
+	w.buf.WriteByte(v)
+}
+
+func (w *bcsWriter) u64(v uint64) {
+	w.buf.Write(binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// fixed 写入定长字节（如地址）
+func (w *bcsWriter) fixed(b []byte) {
+	w.buf.Write(b)
+}
+
+// bytes 写入带长度前缀的字节序列
+func (w *bcsWriter) bytes(b []byte) {
+	w.uleb128(uint64(len(b)))
+	w.buf.Write(b)
+}
+
+func (w *bcsWriter) str(s string) {
+	w.bytes([]byte(s))
+}
+
+// bcsReader BCS反序列化
+type bcsReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bcsReader) uleb128() (uint64, error) {
+	var v uint64
+	for shift := 0; shift < 64; shift += 7 {
+		if r.pos >= len(r.data) {
+			return 0, fmt.Errorf("unexpected end of bcs data")
+		}
+		b := r.data[r.pos]
+		r.pos++
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid uleb128 value")
+}
+
+func (r *bcsReader) fixed(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, fmt.Errorf("unexpected end of bcs data")
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *bcsReader) u8() (uint8, error) {
+	b, err := r.fixed(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *bcsReader) u64() (uint64, error) {
+	b, err := r.fixed(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (r *bcsReader) bytes() ([]byte, error) {
+	n, err := r.uleb128()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("unexpected end of bcs data")
+	}
+	return r.fixed(int(n))
+}
+
+// rest 未读取的数据
+func (r *bcsReader) rest() []byte {
+	return r.data[r.pos:]
+}
+
+// aptosRawTransaction Aptos RawTransaction
+type aptosRawTransaction struct {
+	sender         [32]byte
+	sequenceNumber uint64
+	payload        []byte // BCS序列化的TransactionPayload
+	maxGasAmount   uint64
+	gasUnitPrice   uint64
+	expiration     uint64
+	chainID        uint8
+}
+
+// serialize BCS序列化RawTransaction
+func (t *aptosRawTransaction) serialize() []byte {
+	var w bcsWriter
+	w.fixed(t.sender[:])
+	w.u64(t.sequenceNumber)
+	w.fixed(t.payload)
+	w.u64(t.maxGasAmount)
+	w.u64(t.gasUnitPrice)
+	w.u64(t.expiration)
+	w.u8(t.chainID)
+	return w.buf.Bytes()
+}
+
+// parseAptosRawTransaction 解析BCS序列化的RawTransaction，返回未读取的数据
+func parseAptosRawTransaction(data []byte) (*aptosRawTransaction, []byte, error) {
+	r := &bcsReader{data: data}
+	tx := &aptosRawTransaction{}
+
+	sender, err := r.fixed(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	copy(tx.sender[:], sender)
+	if tx.sequenceNumber, err = r.u64(); err != nil {
+		return nil, nil, err
+	}
+
+	start := r.pos
+	if err := skipAptosPayload(r); err != nil {
+		return nil, nil, fmt.Errorf("invalid transaction payload: %w", err)
+	}
+	tx.payload = data[start:r.pos]
+
+	if tx.maxGasAmount, err = r.u64(); err != nil {
+		return nil, nil, err
+	}
+	if tx.gasUnitPrice, err = r.u64(); err != nil {
+		return nil, nil, err
+	}
+	if tx.expiration, err = r.u64(); err != nil {
+		return nil, nil, err
+	}
+	if tx.chainID, err = r.u8(); err != nil {
+		return nil, nil, err
+	}
+	return tx, r.rest(), nil
+}
+
+// skipAptosPayload 跳过TransactionPayload
+func skipAptosPayload(r *bcsReader) error {
+	variant, err := r.uleb128()
+	if err != nil {
+		return err
+	}
+
+	switch variant {
+	case aptosPayloadScript:
+		// code, ty_args, args
+		if _, err := r.bytes(); err != nil {
+			return err
+		}
+		if err := skipAptosTypeTags(r); err != nil {
+			return err
+		}
+		n, err := r.uleb128()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := skipAptosScriptArgument(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case aptosPayloadEntryFunction:
+		return skipAptosEntryFunction(r)
+	case aptosPayloadMultisig:
+		// multisig_address, Option<MultisigTransactionPayload>
+		if _, err := r.fixed(32); err != nil {
+			return err
+		}
+		some, err := r.u8()
+		if err != nil || some == 0 {
+			return err
+		}
+		if inner, err := r.uleb128(); err != nil {
+			return err
+		} else if inner != 0 {
+			return fmt.Errorf("unsupported multisig payload variant: %d", inner)
+		}
+		return skipAptosEntryFunction(r)
+	default:
+		return fmt.Errorf("unsupported payload variant: %d", variant)
+	}
+}
+
+// skipAptosEntryFunction 跳过EntryFunction：module(address, name), function, ty_args, args
+func skipAptosEntryFunction(r *bcsReader) error {
+	if _, err := r.fixed(32); err != nil {
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.bytes(); err != nil {
+			return err
+		}
+	}
+	if err := skipAptosTypeTags(r); err != nil {
+		return err
+	}
+	n, err := r.uleb128()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		if _, err := r.bytes(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipAptosScriptArgument 跳过脚本的TransactionArgument
+func skipAptosScriptArgument(r *bcsReader) error {
+	variant, err := r.uleb128()
+	if err != nil {
+		return err
+	}
+	// u8, u64, u128, address, vector<u8>, bool, u16, u32, u256, serialized
+	sizes := []int{1, 8, 16, 32, -1, 1, 2, 4, 32, -1}
+	if variant >= uint64(len(sizes)) {
+		return fmt.Errorf("unsupported script argument variant: %d", variant)
+	}
+	if sizes[variant] < 0 {
+		_, err = r.bytes()
+	} else {
+		_, err = r.fixed(sizes[variant])
+	}
+	return err
+}
+
+func skipAptosTypeTags(r *bcsReader) error {
+	n, err := r.uleb128()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		if err := skipAptosTypeTag(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipAptosTypeTag 跳过TypeTag
+func skipAptosTypeTag(r *bcsReader) error {
+	variant, err := r.uleb128()
+	if err != nil {
+		return err
+	}
+	switch variant {
+	case 0, 1, 2, 3, 4, 5, 8, 9, 10:
+		return nil
+	case 6:
+		return skipAptosTypeTag(r)
+	case 7:
+		if _, err := r.fixed(32); err != nil {
+			return err
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := r.bytes(); err != nil {
+				return err
+			}
+		}
+		return skipAptosTypeTags(r)
+	default:
+		return fmt.Errorf("unsupported type tag variant: %d", variant)
+	}
+}
+
+// aptosTypeTag Move类型标签
+type aptosTypeTag struct {
+	kind     string // bool、u8、u16、u32、u64、u128、u256、address、signer、vector或struct
+	elem     *aptosTypeTag
+	address  [32]byte
+	module   string
+	name     string
+	typeArgs []*aptosTypeTag
+}
+
+// aptosTypeTagVariants TypeTag的变体索引
+var aptosTypeTagVariants = map[string]uint64{
+	"bool": 0, "u8": 1, "u64": 2, "u128": 3, "address": 4, "signer": 5,
+	"vector": 6, "struct": 7, "u16": 8, "u32": 9, "u256": 10,
+}
+
+// serialize BCS序列化TypeTag
+func (t *aptosTypeTag) serialize(w *bcsWriter) {
+	w.uleb128(aptosTypeTagVariants[t.kind])
+	switch t.kind {
+	case "vector":
+		t.elem.serialize(w)
+	case "struct":
+		w.fixed(t.address[:])
+		w.str(t.module)
+		w.str(t.name)
+		w.uleb128(uint64(len(t.typeArgs)))
+		for _, arg := range t.typeArgs {
+			arg.serialize(w)
+		}
+	}
+}
+
+// is 判断是否为指定的结构体类型，如 0x1::string::String
+func (t *aptosTypeTag) is(address byte, module, name string) bool {
+	var expected [32]byte
+	expected[31] = address
+	return t.kind == "struct" && t.address == expected && t.module == module && t.name == name
+}
+
+// parseAptosTypeTag 解析类型标签字符串，如 u64、vector<u8>、0x1::coin::CoinStore<0x1::aptos_coin::AptosCoin>
+func parseAptosTypeTag(s string) (*aptosTypeTag, error) {
+	tag, rest, err := parseAptosTypeTagPrefix(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("invalid type tag: %s", s)
+	}
+	return tag, nil
+}
+
+// parseAptosTypeTagPrefix 解析字符串开头的类型标签，返回剩余部分
+func parseAptosTypeTagPrefix(s string) (*aptosTypeTag, string, error) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexAny(s, "<>,")
+	if end < 0 {
+		end = len(s)
+	}
+	head := strings.TrimSpace(s[:end])
+	rest := s[end:]
+
+	// 泛型参数
+	var typeArgs []*aptosTypeTag
+	if strings.HasPrefix(rest, "<") {
+		rest = rest[1:]
+		for {
+			arg, remaining, err := parseAptosTypeTagPrefix(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			typeArgs = append(typeArgs, arg)
+			remaining = strings.TrimSpace(remaining)
+			if strings.HasPrefix(remaining, ",") {
+				rest = remaining[1:]
+				continue
+			}
+			if !strings.HasPrefix(remaining, ">") {
+				return nil, "", fmt.Errorf("invalid type tag: missing '>' in %s", s)
+			}
+			rest = remaining[1:]
+			break
+		}
+	}
+
+	if _, ok := aptosTypeTagVariants[head]; ok && head != "struct" {
+		if head == "vector" {
+			if len(typeArgs) != 1 {
+				return nil, "", fmt.Errorf("invalid type tag: vector requires one type argument")
+			}
+			return &aptosTypeTag{kind: head, elem: typeArgs[0]}, rest, nil
+		}
+		if len(typeArgs) > 0 {
+			return nil, "", fmt.Errorf("invalid type tag: %s has no type arguments", head)
+		}
+		return &aptosTypeTag{kind: head}, rest, nil
+	}
+
+	// 结构体：address::module::name
+	parts := strings.Split(head, "::")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, "", fmt.Errorf("invalid type tag: %s", head)
+	}
+	address, err := parseAptosAddress(parts[0])
+	if err != nil {
+		return nil, "", err
+	}
+	return &aptosTypeTag{kind: "struct", address: address, module: parts[1], name: parts[2], typeArgs: typeArgs}, rest, nil
+}
+
+// parseAptosAddress 解析账户地址，支持 0x1 等短格式
+func parseAptosAddress(s string) ([32]byte, error) {
+	var address [32]byte
+	h := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if h == "" || len(h) > 64 {
+		return address, fmt.Errorf("invalid address: %s", s)
+	}
+	if len(h)%2 == 1 {
+		h = "0" + h
+	}
+	b, err := hex.DecodeString(h)
+	if err != nil {
+		return address, fmt.Errorf("invalid address: %s", s)
+	}
+	copy(address[32-len(b):], b)
+	return address, nil
+}
+
+// aptosShortAddress 地址的短格式，去掉前导零
+func aptosShortAddress(address [32]byte) string {
+	h := strings.TrimLeft(hex.EncodeToString(address[:]), "0")
+	if h == "" {
+		h = "0"
+	}
+	return "0x" + h
+}
+
+// encodeAptosArgument 按参数类型将JSON值序列化为BCS
+func encodeAptosArgument(tag *aptosTypeTag, raw json.RawMessage) ([]byte, error) {
+	var w bcsWriter
+	if err := writeAptosArgument(&w, tag, raw); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+func writeAptosArgument(w *bcsWriter, tag *aptosTypeTag, raw json.RawMessage) error {
+	switch tag.kind {
+	case "bool":
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			var s string
+			if json.Unmarshal(raw, &s) != nil || (s != "true" && s != "false") {
+				return fmt.Errorf("invalid bool argument: %s", raw)
+			}
+			v = s == "true"
+		}
+		if v {
+			w.u8(1)
+		} else {
+			w.u8(0)
+		}
+	case "u8", "u16", "u32", "u64", "u128", "u256":
+		bits := map[string]int{"u8": 8, "u16": 16, "u32": 32, "u64": 64, "u128": 128, "u256": 256}[tag.kind]
+		text := string(raw)
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			text = s
+		}
+		v, ok := new(big.Int).SetString(strings.TrimSpace(text), 10)
+		if !ok || v.Sign() < 0 || v.BitLen() > bits {
+			return fmt.Errorf("invalid %s argument: %s", tag.kind, raw)
+		}
+		le := v.FillBytes(make([]byte, bits/8))
+		for i, j := 0, len(le)-1; i < j; i, j = i+1, j-1 {
+			le[i], le[j] = le[j], le[i]
+		}
+		w.fixed(le)
+	case "address":
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("invalid address argument: %s", raw)
+		}
+		address, err := parseAptosAddress(s)
+		if err != nil {
+			return err
+		}
+		w.fixed(address[:])
+	case "vector":
+		// vector<u8> 可以使用十六进制字符串
+		var s string
+		if tag.elem.kind == "u8" && json.Unmarshal(raw, &s) == nil {
+			b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+			if err != nil {
+				return fmt.Errorf("invalid vector<u8> argument: %w", err)
+			}
+			w.bytes(b)
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return fmt.Errorf("invalid vector argument: %s", raw)
+		}
+		w.uleb128(uint64(len(items)))
+		for _, item := range items {
+			if err := writeAptosArgument(w, tag.elem, item); err != nil {
+				return err
+			}
+		}
+	case "struct":
+		switch {
+		case tag.is(1, "string", "String"):
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("invalid string argument: %s", raw)
+			}
+			w.str(s)
+		case tag.is(1, "object", "Object"):
+			return writeAptosArgument(w, &aptosTypeTag{kind: "address"}, raw)
+		case tag.is(1, "option", "Option") && len(tag.typeArgs) == 1:
+			if string(raw) == "null" {
+				w.uleb128(0)
+				return nil
+			}
+			w.uleb128(1)
+			return writeAptosArgument(w, tag.typeArgs[0], raw)
+		default:
+			return fmt.Errorf("unsupported argument type: %s::%s::%s", aptosShortAddress(tag.address), tag.module, tag.name)
+		}
+	default:
+		return fmt.Errorf("unsupported argument type: %s", tag.kind)
+	}
+	return nil
+}
@@ -5,10 +5,39 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
+	"github.com/featx/keys-gin/web/model"
 	"golang.org/x/crypto/sha3"
 )
 
+func init() {
+	mustRegisterChain(ChainModule{
+		ChainType: model.ChainTypeAPTOS,
+		Curve:     "ed25519",
+		Encoding:  "aptos_address",
+		NewKeyGenerator: func() (KeyGenerator, error) {
+			return &AptosKeyGenerator{}, nil
+		},
+		NewTransactionSigner: func() (TransactionSigner, error) {
+			return &AptosTransactionSigner{}, nil
+		},
+		ValidateAddress: func(address string) error {
+			_, err := parseAptosAddress(address)
+			return err
+		},
+	})
+}
+
+// Aptos认证密钥方案标识
+const (
+	AptosSchemeEd25519      byte = 0x00
+	AptosSchemeMultiEd25519 byte = 0x01
+)
+
+// AptosMultiEd25519MaxKeys MultiEd25519账户最多包含的公钥数量
+const AptosMultiEd25519MaxKeys = 32
+
 // AptosKeyGenerator Aptos密钥生成器
 // 实现了使用标准库crypto/ed25519的真实Aptos密钥生成
 // Aptos使用Edwards-curve Digital Signature Algorithm (EdDSA)与Curve25519
@@ -51,14 +80,7 @@ func (g *AptosKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (addr
 	if len(privateKeyBytes) != 64 {
 		// 检查是否是32字节的种子，如果是则转换为64字节的私钥
 		if len(privateKeyBytes) == 32 {
-			// 创建一个临时密钥对来获取正确格式的私钥
-			_, fullPrivateKey, err := ed25519.GenerateKey(nil) // 使用nil Reader不会真正随机生成密钥
-			if err != nil {
-				return "", "", fmt.Errorf("failed to create full private key: %w", err)
-			}
-			// 复制种子部分
-			copy(fullPrivateKey[:32], privateKeyBytes)
-			privateKeyBytes = fullPrivateKey
+			privateKeyBytes = ed25519.NewKeyFromSeed(privateKeyBytes)
 		} else {
 			return "", "", fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
 		}
@@ -81,8 +103,8 @@ func (g *AptosKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (addr
 }
 
 // PublicKeyToAddress 从公钥生成Aptos地址
-// 根据Aptos规范，地址生成步骤如下：
-// 1. 公钥（32字节）
+// 新账户的地址等于其认证密钥，单签Ed25519账户的认证密钥生成步骤如下：
+// 1. 公钥（32字节）后追加认证方案标识0x00
 // 2. 计算SHA3-256哈希（32字节）
 // 3. 使用Hex编码，并添加前缀"0x"
 func (g *AptosKeyGenerator) PublicKeyToAddress(publicKey string) (address string, err error) {
@@ -97,15 +119,54 @@ func (g *AptosKeyGenerator) PublicKeyToAddress(publicKey string) (address string
 		return "", fmt.Errorf("invalid public key length: expected 32 bytes, got %d bytes", len(publicKeyBytes))
 	}
 
-	// 正确实现Aptos地址生成：
-	// 1. 计算公钥的SHA3-256哈希
-	hash := sha3.Sum256(publicKeyBytes)
+	return aptosAuthenticationKey(publicKeyBytes, AptosSchemeEd25519), nil
+}
 
-	// 2. 将哈希结果转换为十六进制字符串
-	hashHex := hex.EncodeToString(hash[:])
+// DerivationPath 返回SLIP-0010派生路径 m/44'/637'/account'/0'/index'，与Petra等钱包一致
+func (g *AptosKeyGenerator) DerivationPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/0'/%d'", CoinTypeAptos, account, index)
+}
 
-	// 3. 添加前缀"0x"，确保符合Aptos地址格式
-	aptosAddress := "0x" + hashHex
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按SLIP-0010派生Aptos密钥对
+func (g *AptosKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	return deriveEd25519HDKeyPair(g, mnemonic, passphrase, path)
+}
 
-	return aptosAddress, nil
-}
\ No newline at end of file
+// MultiEd25519Address 从多个公钥和签名阈值生成MultiEd25519账户地址
+// 认证密钥为 SHA3-256(公钥1 || ... || 公钥n || 阈值 || 0x01)，公钥顺序决定签名位图中的位置
+func (g *AptosKeyGenerator) MultiEd25519Address(publicKeys []string, threshold uint8) (address string, err error) {
+	publicKeyBytes, err := parseAptosMultiEd25519PublicKey(publicKeys, threshold)
+	if err != nil {
+		return "", err
+	}
+	return aptosAuthenticationKey(publicKeyBytes, AptosSchemeMultiEd25519), nil
+}
+
+// aptosAuthenticationKey 计算认证密钥：SHA3-256(公钥 || 认证方案标识)
+func aptosAuthenticationKey(publicKey []byte, scheme byte) string {
+	hash := sha3.Sum256(append(append([]byte{}, publicKey...), scheme))
+	return "0x" + hex.EncodeToString(hash[:])
+}
+
+// parseAptosMultiEd25519PublicKey 解析MultiEd25519公钥，返回 公钥1 || ... || 公钥n || 阈值
+func parseAptosMultiEd25519PublicKey(publicKeys []string, threshold uint8) ([]byte, error) {
+	if len(publicKeys) == 0 || len(publicKeys) > AptosMultiEd25519MaxKeys {
+		return nil, fmt.Errorf("multi-ed25519 requires 1 to %d public keys, got %d", AptosMultiEd25519MaxKeys, len(publicKeys))
+	}
+	if threshold == 0 || int(threshold) > len(publicKeys) {
+		return nil, fmt.Errorf("invalid multi-ed25519 threshold: %d of %d", threshold, len(publicKeys))
+	}
+
+	data := make([]byte, 0, len(publicKeys)*ed25519.PublicKeySize+1)
+	for i, publicKey := range publicKeys {
+		publicKeyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode public key %d: %w", i, err)
+		}
+		if len(publicKeyBytes) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key %d length: expected 32 bytes, got %d bytes", i, len(publicKeyBytes))
+		}
+		data = append(data, publicKeyBytes...)
+	}
+	return append(data, threshold), nil
+}
@@ -1,9 +1,11 @@
 package crypto
 
 import (
+	"encoding/hex"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/sha3"
 )
 
 // AptosKeyGenerator 测试用例
@@ -102,4 +104,60 @@ func TestAptosKeyGenerator_InvalidPublicKeyFormat(t *testing.T) {
 	// 验证结果 - 应该返回错误
 	assert.Error(t, err)
 	assert.Empty(t, address)
-}
\ No newline at end of file
+}
+func TestAptosKeyGenerator_Seed(t *testing.T) {
+	generator := &AptosKeyGenerator{}
+
+	_, publicKey, privateKey, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	// 32字节种子与64字节私钥推导出相同的公钥
+	_, seedPublicKey, err := generator.DeriveKeyPairFromPrivateKey(privateKey[:64])
+	assert.NoError(t, err)
+	assert.Equal(t, publicKey, seedPublicKey)
+}
+
+func TestAptosKeyGenerator_AuthenticationKey(t *testing.T) {
+	generator := &AptosKeyGenerator{}
+
+	// Aptos TypeScript SDK的单签Ed25519测试向量
+	address, publicKey, err := generator.DeriveKeyPairFromPrivateKey("c5338cd251c22daa8c9c9cc94f498cc8a5c7e1d2e75287a5dda91096fe64efa5")
+	assert.NoError(t, err)
+	assert.Equal(t, "de19e5d1880cac87d57484ce9ed2e84cf0f9599f12e7cc3a52e4e7657a763f2c", publicKey)
+	assert.Equal(t, "0x978c213990c4833df71548df7ce49d54c759d6b6d932de22b24d56060b7af2aa", address)
+}
+
+func TestAptosKeyGenerator_MultiEd25519Address(t *testing.T) {
+	generator := &AptosKeyGenerator{}
+
+	_, publicKey1, _, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+	_, publicKey2, _, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	// SHA3-256(公钥1 || 公钥2 || 阈值 || 0x01)
+	publicKeyBytes, _ := hex.DecodeString(publicKey1 + publicKey2)
+	hash := sha3.Sum256(append(publicKeyBytes, 2, AptosSchemeMultiEd25519))
+
+	address, err := generator.MultiEd25519Address([]string{publicKey1, "0x" + publicKey2}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x"+hex.EncodeToString(hash[:]), address)
+
+	// 公钥顺序和阈值不同，地址不同
+	otherAddress, err := generator.MultiEd25519Address([]string{publicKey2, publicKey1}, 2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, address, otherAddress)
+	otherAddress, err = generator.MultiEd25519Address([]string{publicKey1, publicKey2}, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, address, otherAddress)
+
+	// 无效的阈值和公钥
+	_, err = generator.MultiEd25519Address([]string{publicKey1, publicKey2}, 3)
+	assert.Error(t, err)
+	_, err = generator.MultiEd25519Address([]string{publicKey1, publicKey2}, 0)
+	assert.Error(t, err)
+	_, err = generator.MultiEd25519Address(nil, 1)
+	assert.Error(t, err)
+	_, err = generator.MultiEd25519Address([]string{publicKey1, "00112233"}, 1)
+	assert.Error(t, err)
+}
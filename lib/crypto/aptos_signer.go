@@ -1,96 +1,125 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/ed25519"
-	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// AptosEntryFunctionPayloadType 入口函数负载类型
+const AptosEntryFunctionPayloadType = "entry_function_payload"
+
+// TransactionAuthenticator的变体
+const (
+	aptosAuthenticatorEd25519      = 0
+	aptosAuthenticatorMultiEd25519 = 1
+)
+
+// aptosMultiEd25519BitmapSize MultiEd25519签名位图的字节数
+const aptosMultiEd25519BitmapSize = 4
+
+// Aptos签名和交易哈希使用的域分隔前缀：sha3_256("APTOS::<类型名>")
+var (
+	aptosRawTransactionSalt = sha3.Sum256([]byte("APTOS::RawTransaction"))
+	aptosTransactionSalt    = sha3.Sum256([]byte("APTOS::Transaction"))
 )
 
 // AptosTransactionRequest Aptos交易请求结构
 // 包含Aptos交易所需的基本字段
 // 参考Aptos官方规范
+// 也可以通过 RawTransaction 传入TypeScript SDK构建的BCS序列化交易，此时忽略其他字段
 
 type AptosTransactionRequest struct {
-	Type          string          `json:"type"`
-	Sender        string          `json:"sender"`
-	SequenceNumber uint64         `json:"sequence_number"`
-	MaxGasAmount  uint64          `json:"max_gas_amount"`
-	GasUnitPrice  uint64          `json:"gas_unit_price"`
-	ExpirationTimestamp uint64     `json:"expiration_timestamp_secs"`
-	Payload       json.RawMessage `json:"payload"`
+	Type                string                     `json:"type,omitempty"`
+	Sender              string                     `json:"sender"`
+	SequenceNumber      uint64                     `json:"sequence_number"`
+	MaxGasAmount        uint64                     `json:"max_gas_amount"`
+	GasUnitPrice        uint64                     `json:"gas_unit_price"`
+	ExpirationTimestamp uint64                     `json:"expiration_timestamp_secs"`
+	ChainID             uint8                      `json:"chain_id"`
+	Payload             *AptosEntryFunctionPayload `json:"payload,omitempty"`
+	// RawTransaction 十六进制编码的BCS序列化RawTransaction（rawTransaction.bcsToHex()），
+	// 或不含手续费代付者的SimpleTransaction（transaction.bcsToHex()）
+	RawTransaction string `json:"raw_transaction,omitempty"`
+	// MultiEd25519 发送者为MultiEd25519账户时的公钥和签名阈值，为空时使用单签Ed25519认证器
+	MultiEd25519 *AptosMultiEd25519 `json:"multi_ed25519,omitempty"`
+}
+
+// AptosMultiEd25519 MultiEd25519账户的认证信息
+// 签名放在签名私钥对应公钥在public_keys中的位置，签名数达到阈值后交易才能上链
+type AptosMultiEd25519 struct {
+	PublicKeys []string `json:"public_keys"`
+	Threshold  uint8    `json:"threshold"`
+	// Signatures 其他签名者已有的签名（十六进制），按public_keys的顺序，缺少的签名留空
+	Signatures []string `json:"signatures,omitempty"`
+}
+
+// AptosEntryFunctionPayload 入口函数负载
+// ArgumentTypes 为各参数的Move类型（如 address、u64、vector<u8>、0x1::string::String），
+// 或bcs（参数为十六进制编码的已序列化参数）；常用转账函数可省略
+type AptosEntryFunctionPayload struct {
+	Type          string            `json:"type,omitempty"`
+	Function      string            `json:"function"`
+	TypeArguments []string          `json:"type_arguments"`
+	Arguments     []json.RawMessage `json:"arguments"`
+	ArgumentTypes []string          `json:"argument_types,omitempty"`
 }
 
 // AptosTransactionSigner Aptos交易签名器
-// 使用Ed25519算法，符合Aptos规范
-// 支持对Aptos交易进行签名
+// 使用Ed25519算法，对 sha3_256("APTOS::RawTransaction") || BCS(RawTransaction) 签名
+// 返回十六进制编码的BCS SignedTransaction（Ed25519或MultiEd25519认证器），可直接提交到节点的BCS交易接口
 
 type AptosTransactionSigner struct{}
 
 // SignTransaction 签名Aptos交易
-func (s *AptosTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (signedTx string, txHash string, err error) {
-	// 解码私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+func (s *AptosTransactionSigner) SignTransaction(rawTx string, signer Signer) (signedTx string, txHash string, err error) {
+	// 构建BCS序列化的RawTransaction
+	txReq, err := parseAptosTransactionRequest(rawTx)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
+		return "", "", err
 	}
-
-	// 验证私钥长度是否符合Ed25519要求
-	if len(privateKeyBytes) != 64 {
-		// 检查是否是32字节的种子，如果是则转换为64字节的私钥
-		if len(privateKeyBytes) == 32 {
-			// 创建一个临时密钥对来获取正确格式的私钥
-			_, fullPrivateKey, err := ed25519.GenerateKey(nil) // 使用nil Reader不会真正随机生成密钥
-			if err != nil {
-				return "", "", fmt.Errorf("failed to create full private key: %w", err)
-			}
-			// 复制种子部分
-			copy(fullPrivateKey[:32], privateKeyBytes)
-			privateKeyBytes = fullPrivateKey
-		} else {
-			return "", "", fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
-		}
+	rawTxn, err := txReq.rawTransaction()
+	if err != nil {
+		return "", "", err
 	}
 
-	// 将字节切片转换为ed25519.PrivateKey类型
-	privateKey := ed25519.PrivateKey(privateKeyBytes)
-
-	// 解析交易参数
-	var txReq AptosTransactionRequest
-	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
-		return "", "", fmt.Errorf("invalid transaction data format: %w", err)
+	// 签名消息：sha3_256("APTOS::RawTransaction") || BCS(RawTransaction)
+	signature, err := signEd25519(signer, aptosSigningMessage(rawTxn))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign transaction: %w", err)
 	}
+	publicKey := ed25519.PublicKey(signer.PublicKey())
 
-	// 准备要签名的数据
-	// 在真实的Aptos交易中，签名的数据包括：
-	// 1. 交易类型
-	// 2. 发送者地址
-	// 3. 序列号码
-	// 4. Gas参数
-	// 5. 过期时间
-	// 6. 交易负载
-	// 这里为了简化，我们使用交易的哈希作为要签名的数据
-	txDataHash := sha256.Sum256([]byte(rawTx))
-	txHash = hex.EncodeToString(txDataHash[:])
-
-	// 使用Ed25519私钥对数据进行签名，符合Aptos要求
-	signature := ed25519.Sign(privateKey, txDataHash[:])
-
-	// 构建签名后的交易
-	// 在真实的Aptos实现中，签名会被添加到交易中并进行序列化
-	// 这里我们返回签名的十六进制表示作为简化实现
-	signedTx = "aptos_signed_" + hex.EncodeToString(signature)
+	// SignedTransaction：RawTransaction || TransactionAuthenticator
+	var w bcsWriter
+	w.fixed(rawTxn)
+	if txReq.MultiEd25519 == nil {
+		// Ed25519{public_key, signature}
+		w.uleb128(aptosAuthenticatorEd25519)
+		w.bytes(publicKey)
+		w.bytes(signature)
+	} else {
+		// MultiEd25519{public_key, signature}
+		if err := txReq.MultiEd25519.writeAuthenticator(&w, publicKey, signature); err != nil {
+			return "", "", err
+		}
+	}
+	signed := w.buf.Bytes()
 
-	// 添加前缀到交易哈希
-	txHash = "aptos_" + txHash
+	// 交易哈希：sha3_256(sha3_256("APTOS::Transaction") || Transaction::UserTransaction(SignedTransaction))
+	hash := sha3.Sum256(append(append(append([]byte{}, aptosTransactionSalt[:]...), 0x00), signed...))
 
-	return signedTx, txHash, nil
+	return "0x" + hex.EncodeToString(signed), "0x" + hex.EncodeToString(hash[:]), nil
 }
 
 // VerifyTransaction 验证Aptos交易签名
-// 此方法用于验证签名是否有效
-func (s *AptosTransactionSigner) VerifyTransaction(rawTx, signatureHex, publicKeyHex string) (bool, error) {
+// signedTx 为SignTransaction返回的SignedTransaction，其中的RawTransaction必须与rawTx一致
+func (s *AptosTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex string) (bool, error) {
 	// 解码公钥
 	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
 	if err != nil {
@@ -98,24 +127,285 @@ func (s *AptosTransactionSigner) VerifyTransaction(rawTx, signatureHex, publicKe
 	}
 
 	// 验证公钥长度是否符合Ed25519要求
-	if len(publicKeyBytes) != 32 {
+	if len(publicKeyBytes) != ed25519.PublicKeySize {
 		return false, fmt.Errorf("invalid public key length: expected 32 bytes, got %d bytes", len(publicKeyBytes))
 	}
 
-	// 将字节切片转换为ed25519.PublicKey类型
-	publicKey := ed25519.PublicKey(publicKeyBytes)
+	rawTxn, err := buildAptosRawTransaction(rawTx)
+	if err != nil {
+		return false, err
+	}
+	message := aptosSigningMessage(rawTxn)
 
-	// 解码签名
-	signature, err := hex.DecodeString(signatureHex)
+	// 解码签名后的交易
+	signed, err := hex.DecodeString(strings.TrimPrefix(signedTx, "0x"))
 	if err != nil {
 		return false, fmt.Errorf("invalid signature format: %w", err)
 	}
+	_, rest, err := parseAptosRawTransaction(signed)
+	if err != nil {
+		return false, fmt.Errorf("invalid signed transaction: %w", err)
+	}
+	if !bytes.Equal(signed[:len(signed)-len(rest)], rawTxn) {
+		return false, nil
+	}
+
+	// 解析认证器
+	r := &bcsReader{data: rest}
+	variant, err := r.uleb128()
+	if err != nil {
+		return false, fmt.Errorf("invalid authenticator: %w", err)
+	}
+	publicKey, err := r.bytes()
+	if err != nil {
+		return false, fmt.Errorf("invalid authenticator: %w", err)
+	}
+	signature, err := r.bytes()
+	if err != nil {
+		return false, fmt.Errorf("invalid authenticator: %w", err)
+	}
+	if len(r.rest()) != 0 {
+		return false, fmt.Errorf("invalid authenticator")
+	}
+
+	switch variant {
+	case aptosAuthenticatorEd25519:
+		if len(signature) != ed25519.SignatureSize {
+			return false, fmt.Errorf("invalid authenticator")
+		}
+		if !bytes.Equal(publicKey, publicKeyBytes) {
+			return false, nil
+		}
+		// 使用Ed25519验证签名
+		return ed25519.Verify(ed25519.PublicKey(publicKeyBytes), message, signature), nil
+	case aptosAuthenticatorMultiEd25519:
+		// 验证公钥所在位置的签名
+		signature, err = aptosMultiEd25519Signature(publicKey, signature, publicKeyBytes)
+		if err != nil || signature == nil {
+			return false, err
+		}
+		return ed25519.Verify(ed25519.PublicKey(publicKeyBytes), message, signature), nil
+	default:
+		return false, fmt.Errorf("unsupported authenticator variant: %d", variant)
+	}
+}
+
+// writeAuthenticator 写入MultiEd25519认证器，将签名放在公钥所在的位置
+// 签名为 签名1 || ... || 签名k || 位图（4字节，第i位表示第i个公钥已签名，从最高位开始）
+func (m *AptosMultiEd25519) writeAuthenticator(w *bcsWriter, publicKey ed25519.PublicKey, signature []byte) error {
+	multiPublicKey, err := parseAptosMultiEd25519PublicKey(m.PublicKeys, m.Threshold)
+	if err != nil {
+		return err
+	}
+	if len(m.Signatures) > len(m.PublicKeys) {
+		return fmt.Errorf("too many signatures: %d for %d public keys", len(m.Signatures), len(m.PublicKeys))
+	}
+
+	index := -1
+	for i := 0; i < len(m.PublicKeys); i++ {
+		if bytes.Equal(multiPublicKey[i*ed25519.PublicKeySize:(i+1)*ed25519.PublicKeySize], publicKey) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("public key %s is not part of the multi-ed25519 account", hex.EncodeToString(publicKey))
+	}
+
+	signatures := make([]byte, 0, len(m.PublicKeys)*ed25519.SignatureSize+aptosMultiEd25519BitmapSize)
+	bitmap := make([]byte, aptosMultiEd25519BitmapSize)
+	for i := range m.PublicKeys {
+		current := signature
+		if i != index {
+			if i >= len(m.Signatures) || m.Signatures[i] == "" {
+				continue
+			}
+			if current, err = hex.DecodeString(strings.TrimPrefix(m.Signatures[i], "0x")); err != nil {
+				return fmt.Errorf("invalid signature format: %w", err)
+			}
+			if len(current) != ed25519.SignatureSize {
+				return fmt.Errorf("invalid signature %d length: expected 64 bytes, got %d bytes", i, len(current))
+			}
+		}
+		signatures = append(signatures, current...)
+		bitmap[i/8] |= 0x80 >> (i % 8)
+	}
+
+	w.uleb128(aptosAuthenticatorMultiEd25519)
+	w.bytes(multiPublicKey)
+	w.bytes(append(signatures, bitmap...))
+	return nil
+}
+
+// aptosMultiEd25519Signature 从MultiEd25519认证器中取出公钥对应的签名，公钥未签名时返回nil
+func aptosMultiEd25519Signature(multiPublicKey, multiSignature, publicKey []byte) ([]byte, error) {
+	if len(multiPublicKey)%ed25519.PublicKeySize != 1 || len(multiSignature) < aptosMultiEd25519BitmapSize ||
+		(len(multiSignature)-aptosMultiEd25519BitmapSize)%ed25519.SignatureSize != 0 {
+		return nil, fmt.Errorf("invalid multi-ed25519 authenticator")
+	}
+	keyCount := len(multiPublicKey) / ed25519.PublicKeySize
+	bitmap := multiSignature[len(multiSignature)-aptosMultiEd25519BitmapSize:]
+
+	position := 0
+	for i := 0; i < keyCount; i++ {
+		signed := bitmap[i/8]&(0x80>>(i%8)) != 0
+		if bytes.Equal(multiPublicKey[i*ed25519.PublicKeySize:(i+1)*ed25519.PublicKeySize], publicKey) {
+			if !signed {
+				return nil, nil
+			}
+			offset := position * ed25519.SignatureSize
+			if offset+ed25519.SignatureSize > len(multiSignature)-aptosMultiEd25519BitmapSize {
+				return nil, fmt.Errorf("invalid multi-ed25519 authenticator")
+			}
+			return multiSignature[offset : offset+ed25519.SignatureSize], nil
+		}
+		if signed {
+			position++
+		}
+	}
+	return nil, nil
+}
+
+// buildAptosRawTransaction 根据交易请求构建BCS序列化的RawTransaction
+func buildAptosRawTransaction(rawTx string) ([]byte, error) {
+	txReq, err := parseAptosTransactionRequest(rawTx)
+	if err != nil {
+		return nil, err
+	}
+	return txReq.rawTransaction()
+}
+
+// parseAptosTransactionRequest 解析交易请求
+func parseAptosTransactionRequest(rawTx string) (*AptosTransactionRequest, error) {
+	var txReq AptosTransactionRequest
+	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+		return nil, fmt.Errorf("invalid transaction data format: %w", err)
+	}
+	return &txReq, nil
+}
 
-	// 计算交易数据的哈希
-	txDataHash := sha256.Sum256([]byte(rawTx))
+// rawTransaction 构建BCS序列化的RawTransaction
+func (r *AptosTransactionRequest) rawTransaction() ([]byte, error) {
+	// SDK构建的交易
+	if r.RawTransaction != "" {
+		data, err := hex.DecodeString(strings.TrimPrefix(r.RawTransaction, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw_transaction: %w", err)
+		}
+		_, rest, err := parseAptosRawTransaction(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw_transaction: %w", err)
+		}
+		// SimpleTransaction在RawTransaction后附带Option<fee_payer_address>
+		switch {
+		case len(rest) == 0:
+		case len(rest) == 1 && rest[0] == 0:
+		case len(rest) > 0 && rest[0] == 1:
+			return nil, fmt.Errorf("fee payer transactions are not supported")
+		default:
+			return nil, fmt.Errorf("invalid raw_transaction: unexpected trailing data")
+		}
+		return data[:len(data)-len(rest)], nil
+	}
 
-	// 使用Ed25519验证签名
-	isValid := ed25519.Verify(publicKey, txDataHash[:], signature)
+	if r.Type != "" && r.Type != AptosEntryFunctionPayloadType {
+		return nil, fmt.Errorf("unsupported transaction type: %s", r.Type)
+	}
+	if r.Payload == nil {
+		return nil, fmt.Errorf("payload is required")
+	}
+	if r.MaxGasAmount == 0 || r.ExpirationTimestamp == 0 || r.ChainID == 0 {
+		return nil, fmt.Errorf("max_gas_amount, expiration_timestamp_secs and chain_id are required")
+	}
 
-	return isValid, nil
-}
\ No newline at end of file
+	sender, err := parseAptosAddress(r.Sender)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender: %w", err)
+	}
+	payload, err := r.Payload.serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &aptosRawTransaction{
+		sender:         sender,
+		sequenceNumber: r.SequenceNumber,
+		payload:        payload,
+		maxGasAmount:   r.MaxGasAmount,
+		gasUnitPrice:   r.GasUnitPrice,
+		expiration:     r.ExpirationTimestamp,
+		chainID:        r.ChainID,
+	}
+	return tx.serialize(), nil
+}
+
+// serialize BCS序列化为TransactionPayload::EntryFunction
+func (p *AptosEntryFunctionPayload) serialize() ([]byte, error) {
+	if p.Type != "" && p.Type != AptosEntryFunctionPayloadType {
+		return nil, fmt.Errorf("unsupported payload type: %s", p.Type)
+	}
+
+	// 函数：address::module::name
+	parts := strings.Split(p.Function, "::")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid function: %s", p.Function)
+	}
+	module, err := parseAptosAddress(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid function: %w", err)
+	}
+
+	// 参数类型
+	argumentTypes := p.ArgumentTypes
+	if argumentTypes == nil {
+		argumentTypes = aptosEntryFunctionABIs[aptosShortAddress(module)+"::"+parts[1]+"::"+parts[2]]
+	}
+	if len(argumentTypes) != len(p.Arguments) {
+		return nil, fmt.Errorf("argument_types must be provided for each argument of %s", p.Function)
+	}
+
+	var w bcsWriter
+	w.uleb128(aptosPayloadEntryFunction)
+	w.fixed(module[:])
+	w.str(parts[1])
+	w.str(parts[2])
+
+	w.uleb128(uint64(len(p.TypeArguments)))
+	for _, typeArgument := range p.TypeArguments {
+		tag, err := parseAptosTypeTag(typeArgument)
+		if err != nil {
+			return nil, err
+		}
+		tag.serialize(&w)
+	}
+
+	w.uleb128(uint64(len(p.Arguments)))
+	for i, argument := range p.Arguments {
+		var encoded []byte
+		if argumentTypes[i] == aptosArgumentBcs {
+			var s string
+			if err := json.Unmarshal(argument, &s); err != nil {
+				return nil, fmt.Errorf("invalid argument %d: bcs argument must be a hex string", i)
+			}
+			if encoded, err = hex.DecodeString(strings.TrimPrefix(s, "0x")); err != nil {
+				return nil, fmt.Errorf("invalid argument %d: %w", i, err)
+			}
+		} else {
+			tag, err := parseAptosTypeTag(argumentTypes[i])
+			if err != nil {
+				return nil, err
+			}
+			if encoded, err = encodeAptosArgument(tag, argument); err != nil {
+				return nil, fmt.Errorf("invalid argument %d: %w", i, err)
+			}
+		}
+		w.bytes(encoded)
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// aptosSigningMessage 签名消息：sha3_256("APTOS::RawTransaction") || BCS(RawTransaction)
+func aptosSigningMessage(rawTxn []byte) []byte {
+	return append(append([]byte{}, aptosRawTransactionSalt[:]...), rawTxn...)
+}
@@ -1,47 +1,108 @@
 package crypto
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
 )
 
+const (
+	// 测试用的私钥（32字节种子）
+	testAptosPrivateKey = "0000000000000000000000000000000000000000000000000000000000000001"
+	testAptosRecipient  = "0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9"
+)
+
+// newTestAptosRequest 构建APT转账请求
+func newTestAptosRequest(sender string) AptosTransactionRequest {
+	return AptosTransactionRequest{
+		Type:                AptosEntryFunctionPayloadType,
+		Sender:              sender,
+		SequenceNumber:      1,
+		MaxGasAmount:        100000,
+		GasUnitPrice:        100,
+		ExpirationTimestamp: 1234567890,
+		ChainID:             1,
+		Payload: &AptosEntryFunctionPayload{
+			Function:      "0x1::coin::transfer",
+			TypeArguments: []string{"0x1::aptos_coin::AptosCoin"},
+			Arguments:     []json.RawMessage{json.RawMessage(`"` + testAptosRecipient + `"`), json.RawMessage(`"1000000"`)},
+		},
+	}
+}
+
 // AptosTransactionSigner 测试用例
 // 验证Aptos交易签名器的各项功能是否正常工作
 func TestAptosTransactionSigner_SignTransaction(t *testing.T) {
 	signer := &AptosTransactionSigner{}
 	generator := &AptosKeyGenerator{}
 
-	// 生成测试用的密钥对
-	address, _, privateKey, err := generator.GenerateKeyPair()
-	assert.NoError(t, err)
+	address, publicKey, err := generator.DeriveKeyPairFromPrivateKey(testAptosPrivateKey)
+	require.NoError(t, err)
 
 	// 创建测试交易请求
-	txReq := AptosTransactionRequest{
-		Type:                 "entry_function_payload",
-		Sender:               address,
-		SequenceNumber:       1,
-		MaxGasAmount:         100000,
-		GasUnitPrice:         100,
-		ExpirationTimestamp:  1234567890,
-		Payload:              json.RawMessage(`{"function":"0x1::coin::transfer","type_arguments":["0x1::aptos_coin::AptosCoin"],"arguments":["0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","1000000"]}`),
-	}
-
-	// 序列化交易请求
-	txBytes, err := json.Marshal(txReq)
-	assert.NoError(t, err)
+	txBytes, err := json.Marshal(newTestAptosRequest(address))
+	require.NoError(t, err)
 	rawTx := string(txBytes)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(rawTx, privateKey)
-
-	// 验证结果
-	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	assert.Contains(t, signedTx, "aptos_signed_")
-	assert.Contains(t, txHash, "aptos_")
+	signedTx, txHash, err := signer.SignTransaction(rawTx, testSigningKey(t, "aptos", testAptosPrivateKey))
+	require.NoError(t, err)
+
+	// 按BCS手工构建期望的RawTransaction
+	sender, _ := hex.DecodeString(address[2:])
+	recipient, _ := hex.DecodeString(testAptosRecipient[2:])
+	one := make([]byte, 32)
+	one[31] = 1
+	u64 := func(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+	var expected bytes.Buffer
+	expected.Write(sender)
+	expected.Write(u64(1))
+	expected.WriteByte(2) // EntryFunction
+	expected.Write(one)
+	expected.Write(append([]byte{4}, "coin"...))
+	expected.Write(append([]byte{8}, "transfer"...))
+	expected.Write([]byte{1, 7}) // 1个类型参数：Struct
+	expected.Write(one)
+	expected.Write(append([]byte{10}, "aptos_coin"...))
+	expected.Write(append([]byte{9}, "AptosCoin"...))
+	expected.WriteByte(0)
+	expected.Write([]byte{2, 32})
+	expected.Write(recipient)
+	expected.WriteByte(8)
+	expected.Write(u64(1000000))
+	expected.Write(u64(100000))
+	expected.Write(u64(100))
+	expected.Write(u64(1234567890))
+	expected.WriteByte(1)
+
+	signed, err := hex.DecodeString(strings.TrimPrefix(signedTx, "0x"))
+	require.NoError(t, err)
+	rawLen := expected.Len()
+	assert.Equal(t, expected.Bytes(), signed[:rawLen])
+
+	// Ed25519认证器：变体0 || 公钥 || 签名
+	assert.Equal(t, []byte{0, 32}, signed[rawLen:rawLen+2])
+	assert.Equal(t, publicKey, hex.EncodeToString(signed[rawLen+2:rawLen+34]))
+	assert.Equal(t, byte(64), signed[rawLen+34])
+	assert.Len(t, signed, rawLen+35+64)
+
+	// 签名消息带有域分隔前缀
+	salt := sha3.Sum256([]byte("APTOS::RawTransaction"))
+	publicKeyBytes, _ := hex.DecodeString(publicKey)
+	assert.True(t, ed25519.Verify(publicKeyBytes, append(salt[:], expected.Bytes()...), signed[rawLen+35:]))
+
+	// 交易哈希
+	txSalt := sha3.Sum256([]byte("APTOS::Transaction"))
+	hash := sha3.Sum256(append(append(txSalt[:], 0), signed...))
+	assert.Equal(t, "0x"+hex.EncodeToString(hash[:]), txHash)
 }
 
 func TestAptosTransactionSigner_VerifyTransaction(t *testing.T) {
@@ -52,48 +113,122 @@ func TestAptosTransactionSigner_VerifyTransaction(t *testing.T) {
 	address, publicKey, privateKey, err := generator.GenerateKeyPair()
 	assert.NoError(t, err)
 
-	// 创建测试交易请求
-	txReq := AptosTransactionRequest{
-		Type:                 "entry_function_payload",
-		Sender:               address,
-		SequenceNumber:       1,
-		MaxGasAmount:         100000,
-		GasUnitPrice:         100,
-		ExpirationTimestamp:  1234567890,
-		Payload:              json.RawMessage(`{"function":"0x1::coin::transfer","type_arguments":["0x1::aptos_coin::AptosCoin"],"arguments":["0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","1000000"]}`),
-	}
-
 	// 序列化交易请求
-	txBytes, err := json.Marshal(txReq)
+	txBytes, err := json.Marshal(newTestAptosRequest(address))
 	assert.NoError(t, err)
 	rawTx := string(txBytes)
 
 	// 执行签名
-	signedTx, _, err := signer.SignTransaction(rawTx, privateKey)
+	signedTx, _, err := signer.SignTransaction(rawTx, testSigningKey(t, "aptos", privateKey))
 	assert.NoError(t, err)
 
-	// 提取签名部分（移除前缀）
-	signature := signedTx[len("aptos_signed_"):]
-
 	// 验证签名
-	isValid, err := signer.VerifyTransaction(rawTx, signature, publicKey)
+	isValid, err := signer.VerifyTransaction(rawTx, signedTx, publicKey)
 
 	// 验证结果
 	assert.NoError(t, err)
 	assert.True(t, isValid)
+
+	// 交易内容不一致
+	txReq := newTestAptosRequest(address)
+	txReq.SequenceNumber = 2
+	otherTx, _ := json.Marshal(txReq)
+	isValid, err = signer.VerifyTransaction(string(otherTx), signedTx, publicKey)
+	assert.NoError(t, err)
+	assert.False(t, isValid)
+}
+
+func TestAptosTransactionSigner_RawTransaction(t *testing.T) {
+	signer := &AptosTransactionSigner{}
+	address, publicKey, err := (&AptosKeyGenerator{}).DeriveKeyPairFromPrivateKey(testAptosPrivateKey)
+	require.NoError(t, err)
+
+	rawTx, _ := json.Marshal(newTestAptosRequest(address))
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "aptos", testAptosPrivateKey))
+	require.NoError(t, err)
+
+	// SDK构建的RawTransaction与本地构建的结果一致
+	rawTxn, err := buildAptosRawTransaction(string(rawTx))
+	require.NoError(t, err)
+	for _, prebuilt := range []string{
+		"0x" + hex.EncodeToString(rawTxn),
+		// SimpleTransaction：不含手续费代付者
+		hex.EncodeToString(append(rawTxn, 0)),
+	} {
+		prebuiltTx, _ := json.Marshal(AptosTransactionRequest{RawTransaction: prebuilt})
+		signedPrebuilt, txHashPrebuilt, err := signer.SignTransaction(string(prebuiltTx), testSigningKey(t, "aptos", testAptosPrivateKey))
+		require.NoError(t, err)
+		assert.Equal(t, signedTx, signedPrebuilt)
+		assert.Equal(t, txHash, txHashPrebuilt)
+
+		isValid, err := signer.VerifyTransaction(string(prebuiltTx), signedPrebuilt, publicKey)
+		assert.NoError(t, err)
+		assert.True(t, isValid)
+	}
+
+	// 带手续费代付者的交易
+	feePayerTx, _ := json.Marshal(AptosTransactionRequest{RawTransaction: hex.EncodeToString(append(append(rawTxn, 1), make([]byte, 32)...))})
+	_, _, err = signer.SignTransaction(string(feePayerTx), testSigningKey(t, "aptos", testAptosPrivateKey))
+	assert.Error(t, err)
+
+	// 截断的交易
+	truncatedTx, _ := json.Marshal(AptosTransactionRequest{RawTransaction: hex.EncodeToString(rawTxn[:len(rawTxn)-1])})
+	_, _, err = signer.SignTransaction(string(truncatedTx), testSigningKey(t, "aptos", testAptosPrivateKey))
+	assert.Error(t, err)
+}
+
+func TestAptosTransactionSigner_Arguments(t *testing.T) {
+	testCases := []struct {
+		argumentType string
+		argument     string
+		expected     string
+	}{
+		{"bool", `true`, "01"},
+		{"u8", `255`, "ff"},
+		{"u16", `"258"`, "0201"},
+		{"u128", `"340282366920938463463374607431768211455"`, strings.Repeat("ff", 16)},
+		{"address", `"0x1"`, strings.Repeat("00", 31) + "01"},
+		{"vector<u8>", `"0x0102"`, "020102"},
+		{"vector<u64>", `[1, "2"]`, "02" + "0100000000000000" + "0200000000000000"},
+		{"0x1::string::String", `"abc"`, "03616263"},
+		{"0x1::option::Option<u8>", `null`, "00"},
+		{"0x1::option::Option<u8>", `7`, "0107"},
+		{"0x1::object::Object<0x1::fungible_asset::Metadata>", `"0xa"`, strings.Repeat("00", 31) + "0a"},
+	}
+
+	for _, tc := range testCases {
+		tag, err := parseAptosTypeTag(tc.argumentType)
+		require.NoError(t, err, tc.argumentType)
+		encoded, err := encodeAptosArgument(tag, json.RawMessage(tc.argument))
+		require.NoError(t, err, tc.argumentType)
+		assert.Equal(t, tc.expected, hex.EncodeToString(encoded), tc.argumentType)
+	}
+
+	// 超出范围
+	tag, _ := parseAptosTypeTag("u8")
+	_, err := encodeAptosArgument(tag, json.RawMessage(`256`))
+	assert.Error(t, err)
+
+	// 嵌套泛型类型标签
+	tag, err = parseAptosTypeTag("0x1::coin::CoinStore<vector<0x1::aptos_coin::AptosCoin>>")
+	require.NoError(t, err)
+	var w bcsWriter
+	tag.serialize(&w)
+	r := &bcsReader{data: w.buf.Bytes()}
+	assert.NoError(t, skipAptosTypeTag(r))
+	assert.Empty(t, r.rest())
 }
 
 func TestAptosTransactionSigner_InvalidPrivateKey(t *testing.T) {
 	signer := &AptosTransactionSigner{}
 
 	// 无效的私钥
-	privateKeyHex := "invalid_private_key"
-	rawTx := `{"type":"entry_function_payload","sender":"0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","sequence_number":1,"max_gas_amount":100000,"gas_unit_price":100,"expiration_timestamp_secs":1234567890,"payload":{"function":"0x1::coin::transfer","type_arguments":["0x1::aptos_coin::AptosCoin"],"arguments":["0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","1000000"]}}`
-
-	// 执行签名
-	_, _, err := signer.SignTransaction(rawTx, privateKeyHex)
+	_, err := ParsePrivateKey("aptos", []byte("invalid_private_key"))
+	assert.Error(t, err)
 
-	// 验证错误
+	// 签名密钥的曲线不正确
+	rawTx, _ := json.Marshal(newTestAptosRequest(testAptosRecipient))
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "tron", testTronPrivateKey))
 	assert.Error(t, err)
 }
 
@@ -109,10 +244,33 @@ func TestAptosTransactionSigner_InvalidTransactionFormat(t *testing.T) {
 	invalidRawTx := "not a valid json transaction"
 
 	// 执行签名
-	_, _, err = signer.SignTransaction(invalidRawTx, privateKey)
+	_, _, err = signer.SignTransaction(invalidRawTx, testSigningKey(t, "aptos", privateKey))
 
 	// 验证错误
 	assert.Error(t, err)
+
+	testCases := []func(req *AptosTransactionRequest){
+		// 缺少chain_id
+		func(req *AptosTransactionRequest) { req.ChainID = 0 },
+		// 无效的发送者地址
+		func(req *AptosTransactionRequest) { req.Sender = "0xzz" },
+		// 无效的函数
+		func(req *AptosTransactionRequest) { req.Payload.Function = "transfer" },
+		// 未知函数缺少参数类型
+		func(req *AptosTransactionRequest) { req.Payload.Function = "0x1::coin::unknown" },
+		// 参数与类型不匹配
+		func(req *AptosTransactionRequest) { req.Payload.ArgumentTypes = []string{"u64", "u64"} },
+		// 无效的类型参数
+		func(req *AptosTransactionRequest) { req.Payload.TypeArguments = []string{"vector<u8"} },
+	}
+
+	for i, modify := range testCases {
+		req := newTestAptosRequest(testAptosRecipient)
+		modify(&req)
+		rawTx, _ := json.Marshal(req)
+		_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "aptos", privateKey))
+		assert.Error(t, err, i)
+	}
 }
 
 func TestAptosTransactionSigner_InvalidSignature(t *testing.T) {
@@ -124,13 +282,13 @@ func TestAptosTransactionSigner_InvalidSignature(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 有效的交易数据
-	rawTx := `{"type":"entry_function_payload","sender":"0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","sequence_number":1,"max_gas_amount":100000,"gas_unit_price":100,"expiration_timestamp_secs":1234567890,"payload":{"function":"0x1::coin::transfer","type_arguments":["0x1::aptos_coin::AptosCoin"],"arguments":["0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","1000000"]}}`
+	rawTx, _ := json.Marshal(newTestAptosRequest(testAptosRecipient))
 
 	// 无效的签名
 	invalidSignature := "invalid_signature_data"
 
 	// 验证签名
-	isValid, err := signer.VerifyTransaction(rawTx, invalidSignature, publicKey)
+	isValid, err := signer.VerifyTransaction(string(rawTx), invalidSignature, publicKey)
 
 	// 验证错误
 	assert.Error(t, err)
@@ -149,33 +307,88 @@ func TestAptosTransactionSigner_MismatchedPublicKey(t *testing.T) {
 	_, publicKey2, _, err := generator2.GenerateKeyPair()
 	assert.NoError(t, err)
 
-	// 创建测试交易请求
-	txReq := AptosTransactionRequest{
-		Type:                 "entry_function_payload",
-		Sender:               "0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9",
-		SequenceNumber:       1,
-		MaxGasAmount:         100000,
-		GasUnitPrice:         100,
-		ExpirationTimestamp:  1234567890,
-		Payload:              json.RawMessage(`{"function":"0x1::coin::transfer","type_arguments":["0x1::aptos_coin::AptosCoin"],"arguments":["0x7c87f561388444f786d522f8bdf08073e578c7a5632a79a446f6f5240df743b9","1000000"]}`),
-	}
-
 	// 序列化交易请求
-	txBytes, err := json.Marshal(txReq)
+	txBytes, err := json.Marshal(newTestAptosRequest(testAptosRecipient))
 	assert.NoError(t, err)
 	rawTx := string(txBytes)
 
 	// 使用第一个私钥签名
-	signedTx, _, err := signer.SignTransaction(rawTx, privateKey1)
+	signedTx, _, err := signer.SignTransaction(rawTx, testSigningKey(t, "aptos", privateKey1))
 	assert.NoError(t, err)
 
-	// 提取签名部分
-	signature := signedTx[len("aptos_signed_"):]
-
 	// 尝试用第二个公钥验证
-	isValid, err := signer.VerifyTransaction(rawTx, signature, publicKey2)
+	isValid, err := signer.VerifyTransaction(rawTx, signedTx, publicKey2)
 
 	// 验证结果 - 签名应该无效
 	assert.NoError(t, err)
 	assert.False(t, isValid)
-}
\ No newline at end of file
+}
+
+func TestAptosTransactionSigner_MultiEd25519(t *testing.T) {
+	signer := &AptosTransactionSigner{}
+	generator := &AptosKeyGenerator{}
+
+	// 2/3多签账户
+	publicKeys := make([]string, 3)
+	privateKeys := make([]string, 3)
+	for i := range publicKeys {
+		var err error
+		_, publicKeys[i], privateKeys[i], err = generator.GenerateKeyPair()
+		require.NoError(t, err)
+	}
+	address, err := generator.MultiEd25519Address(publicKeys, 2)
+	require.NoError(t, err)
+
+	txReq := newTestAptosRequest(address)
+	txReq.MultiEd25519 = &AptosMultiEd25519{PublicKeys: publicKeys, Threshold: 2}
+	rawTx, _ := json.Marshal(txReq)
+	rawTxn, err := buildAptosRawTransaction(string(rawTx))
+	require.NoError(t, err)
+
+	// 第三个签名者先签名
+	signedTx, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "aptos", privateKeys[2]))
+	require.NoError(t, err)
+	signed, _ := hex.DecodeString(strings.TrimPrefix(signedTx, "0x"))
+	authenticator := signed[len(rawTxn):]
+	assert.Equal(t, []byte{1, 97}, authenticator[:2])
+	assert.Equal(t, byte(2), authenticator[2+96])
+	assert.Equal(t, []byte{68}, authenticator[99:100])
+	assert.Equal(t, []byte{0x20, 0, 0, 0}, authenticator[164:])
+	signature3 := hex.EncodeToString(authenticator[100:164])
+
+	// 第一个签名者带上已有的签名
+	txReq.MultiEd25519.Signatures = []string{"", "", signature3}
+	rawTx, _ = json.Marshal(txReq)
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "aptos", privateKeys[0]))
+	require.NoError(t, err)
+	assert.NotEmpty(t, txHash)
+	signed, _ = hex.DecodeString(strings.TrimPrefix(signedTx, "0x"))
+	authenticator = signed[len(rawTxn):]
+	assert.Equal(t, []byte{132, 1}, authenticator[99:101])
+	assert.Equal(t, signature3, hex.EncodeToString(authenticator[165:229]))
+	assert.Equal(t, []byte{0xa0, 0, 0, 0}, authenticator[229:])
+
+	// 已签名的公钥验证通过，未签名的公钥验证失败
+	for i, expected := range []bool{true, false, true} {
+		isValid, err := signer.VerifyTransaction(string(rawTx), signedTx, publicKeys[i])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, isValid, i)
+	}
+
+	// 私钥不属于多签账户
+	_, _, otherPrivateKey, _ := generator.GenerateKeyPair()
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "aptos", otherPrivateKey))
+	assert.Error(t, err)
+
+	// 无效的已有签名
+	txReq.MultiEd25519.Signatures = []string{"", "0011"}
+	rawTx, _ = json.Marshal(txReq)
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "aptos", privateKeys[0]))
+	assert.Error(t, err)
+
+	// 无效的阈值
+	txReq.MultiEd25519 = &AptosMultiEd25519{PublicKeys: publicKeys, Threshold: 4}
+	rawTx, _ = json.Marshal(txReq)
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "aptos", privateKeys[0]))
+	assert.Error(t, err)
+}
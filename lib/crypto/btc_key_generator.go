@@ -5,14 +5,86 @@ import (
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/featx/keys-gin/web/model"
 )
 
+// BtcP2PKHEncoding 比特币P2PKH地址的编码方式
+// 沿用支持SegWit之前的取值，已保存的P2PKH地址记录无需迁移
+const BtcP2PKHEncoding = "bitcoin_public_key"
+
+func init() {
+	for _, chainType := range []string{model.ChainTypeBTC, model.ChainTypeBTCTestnet, model.ChainTypeBTCSignet, model.ChainTypeBTCRegtest} {
+		params, _ := btcNetParams(chainType)
+		mustRegisterChain(ChainModule{
+			ChainType: chainType,
+			Curve:     "secp256k1",
+			Encoding:  BtcP2PKHEncoding,
+			NewKeyGenerator: func() (KeyGenerator, error) {
+				return &BtcKeyGenerator{NetParams: params}, nil
+			},
+			NewTransactionSigner: func() (TransactionSigner, error) {
+				return &BtcTransactionSigner{NetParams: params}, nil
+			},
+			ValidateAddress: func(address string) error {
+				return validateBtcAddress(address, params)
+			},
+		})
+	}
+}
+
 // BtcKeyGenerator Bitcoin密钥生成器
 // 支持比特币及分叉币的密钥生成
+// AddressType 指定生成的地址类型，为空时使用传统P2PKH地址
+// NetParams 指定地址所属的网络，为空时使用主网
+
+type BtcKeyGenerator struct {
+	AddressType string
+	NetParams   *chaincfg.Params
+}
+
+// NewBtcKeyGenerator 创建指定比特币链类型（网络）和地址类型的密钥生成器
+func NewBtcKeyGenerator(chainType, addressType string) (*BtcKeyGenerator, error) {
+	params, err := btcNetParams(chainType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch addressType {
+	case "", model.BtcAddressTypeP2PKH, model.BtcAddressTypeP2SHP2WPKH,
+		model.BtcAddressTypeP2WPKH, model.BtcAddressTypeP2TR:
+		return &BtcKeyGenerator{AddressType: addressType, NetParams: params}, nil
+	default:
+		return nil, fmt.Errorf("unsupported bitcoin address type: %s", addressType)
+	}
+}
 
-type BtcKeyGenerator struct {}
+// btcNetParams 根据比特币链类型获取对应的网络参数
+func btcNetParams(chainType string) (*chaincfg.Params, error) {
+	switch chainType {
+	case model.ChainTypeBTC:
+		return &chaincfg.MainNetParams, nil
+	case model.ChainTypeBTCTestnet:
+		return &chaincfg.TestNet3Params, nil
+	case model.ChainTypeBTCSignet:
+		return &chaincfg.SigNetParams, nil
+	case model.ChainTypeBTCRegtest:
+		return &chaincfg.RegressionNetParams, nil
+	default:
+		return nil, fmt.Errorf("unsupported bitcoin chain type: %s", chainType)
+	}
+}
+
+// btcParamsOrMainNet 未指定网络参数时使用主网
+func btcParamsOrMainNet(params *chaincfg.Params) *chaincfg.Params {
+	if params == nil {
+		return &chaincfg.MainNetParams
+	}
+	return params
+}
 
 // GenerateKeyPair 生成比特币密钥对
 func (g *BtcKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey string, err error) {
@@ -31,13 +103,11 @@ func (g *BtcKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey stri
 	publicKey = hex.EncodeToString(publicKeyBytes)
 
 	// 生成比特币地址
-	addressPubKey, err := btcutil.NewAddressPubKey(publicKeyBytes, &chaincfg.MainNetParams)
+	address, err = g.encodeAddress(privateKeyECDSA.PubKey())
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to create address: %w", err)
+		return "", "", "", err
 	}
 
-	address = addressPubKey.EncodeAddress()
-
 	return address, publicKey, privateKey, nil
 }
 
@@ -57,13 +127,11 @@ func (g *BtcKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (addres
 	publicKey = hex.EncodeToString(publicKeyBytes)
 
 	// 生成比特币地址
-	addressPubKey, err := btcutil.NewAddressPubKey(publicKeyBytes, &chaincfg.MainNetParams)
+	address, err = g.encodeAddress(privateKeyECDSA.PubKey())
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create address: %w", err)
+		return "", "", err
 	}
 
-	address = addressPubKey.EncodeAddress()
-
 	return address, publicKey, nil
 }
 
@@ -82,12 +150,86 @@ func (g *BtcKeyGenerator) PublicKeyToAddress(publicKey string) (address string,
 	}
 
 	// 生成比特币地址
-	addressPubKey, err := btcutil.NewAddressPubKey(pubKey.SerializeCompressed(), &chaincfg.MainNetParams)
+	return g.encodeAddress(pubKey)
+}
+
+// DerivationPath 返回地址类型对应的派生路径 m/purpose'/coin_type'/account'/0/index
+// purpose：P2PKH为44（BIP-44），P2SH-P2WPKH为49（BIP-49），P2WPKH为84（BIP-84），P2TR为86（BIP-86）；
+// coin_type：主网为0，测试网络为1
+func (g *BtcKeyGenerator) DerivationPath(account, index uint32) string {
+	purpose := 44
+	switch g.AddressType {
+	case model.BtcAddressTypeP2SHP2WPKH:
+		purpose = 49
+	case model.BtcAddressTypeP2WPKH:
+		purpose = 84
+	case model.BtcAddressTypeP2TR:
+		purpose = 86
+	}
+	coinType := CoinTypeBitcoin
+	if btcParamsOrMainNet(g.NetParams).Net != chaincfg.MainNetParams.Net {
+		coinType = CoinTypeTestnet
+	}
+	return fmt.Sprintf("m/%d'/%d'/%d'/0/%d", purpose, coinType, account, index)
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按BIP-32派生比特币密钥对
+func (g *BtcKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	return deriveSecp256k1HDKeyPair(g, mnemonic, passphrase, path)
+}
+
+// encodeAddress 按生成器的地址类型对公钥进行地址编码
+func (g *BtcKeyGenerator) encodeAddress(pubKey *btcec.PublicKey) (string, error) {
+	addr, err := btcAddressFromPubKey(pubKey, g.AddressType, btcParamsOrMainNet(g.NetParams))
 	if err != nil {
 		return "", fmt.Errorf("failed to create address: %w", err)
 	}
+	return addr.EncodeAddress(), nil
+}
 
-	address = addressPubKey.EncodeAddress()
+// btcAddressFromPubKey 根据地址类型从公钥生成比特币地址
+//   - p2pkh: Base58Check(HASH160(pubkey))
+//   - p2sh-p2wpkh: Base58Check(HASH160(0x0014 || HASH160(pubkey)))
+//   - p2wpkh: bech32(0, HASH160(pubkey))
+//   - p2tr: bech32m(1, BIP-86输出公钥)，仅密钥路径，不提交脚本树
+func btcAddressFromPubKey(pubKey *btcec.PublicKey, addressType string, params *chaincfg.Params) (btcutil.Address, error) {
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+
+	switch addressType {
+	case "", model.BtcAddressTypeP2PKH:
+		return btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+	case model.BtcAddressTypeP2SHP2WPKH:
+		redeemScript, err := btcP2WPKHScript(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		return btcutil.NewAddressScriptHash(redeemScript, params)
+	case model.BtcAddressTypeP2WPKH:
+		return btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+	case model.BtcAddressTypeP2TR:
+		outputKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+		return btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+	default:
+		return nil, fmt.Errorf("unsupported bitcoin address type: %s", addressType)
+	}
+}
+
+// btcP2WPKHScript 生成公钥对应的P2WPKH见证程序（即P2SH-P2WPKH的赎回脚本）
+func btcP2WPKHScript(pubKey *btcec.PublicKey) ([]byte, error) {
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(pubKey.SerializeCompressed())).
+		Script()
+}
 
-	return address, nil
-}
\ No newline at end of file
+// validateBtcAddress 校验比特币地址属于指定网络
+func validateBtcAddress(address string, params *chaincfg.Params) error {
+	decoded, err := btcutil.DecodeAddress(address, params)
+	if err != nil {
+		return fmt.Errorf("invalid bitcoin address: %w", err)
+	}
+	if !decoded.IsForNet(params) {
+		return fmt.Errorf("address %s is not for network %s", address, params.Name)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/featx/keys-gin/web/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -83,4 +84,61 @@ func TestBtcKeyGenerator_PublicKeyToAddress(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, address)
 	assert.True(t, strings.HasPrefix(address, "1") || strings.HasPrefix(address, "3") || strings.HasPrefix(address, "bc1"))
-}
\ No newline at end of file
+}
+
+func TestBtcKeyGenerator_AddressTypes(t *testing.T) {
+	// 私钥1对应的公钥，各地址类型的结果可与BIP-84/BIP-86等公开测试向量对照
+	publicKey := "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+	testCases := []struct {
+		addressType string
+		expected    string
+	}{
+		{"", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		{"p2pkh", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		{"p2sh-p2wpkh", "3JvL6Ymt8MVWiCNHC7oWU6nLeHNJKLZGLN"},
+		{"p2wpkh", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{"p2tr", "bc1pmfr3p9j00pfxjh0zmgp99y8zftmd3s5pmedqhyptwy6lm87hf5sspknck9"},
+	}
+
+	for _, tc := range testCases {
+		generator, err := NewBtcKeyGenerator(model.ChainTypeBTC, tc.addressType)
+		assert.NoError(t, err)
+
+		address, err := generator.PublicKeyToAddress(publicKey)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, address, tc.addressType)
+	}
+
+	// 不支持的地址类型
+	_, err := NewBtcKeyGenerator(model.ChainTypeBTC, "p2wsh")
+	assert.Error(t, err)
+}
+
+func TestBtcKeyGenerator_Networks(t *testing.T) {
+	publicKey := "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+	testCases := []struct {
+		chainType   string
+		addressType string
+		expected    string
+	}{
+		{model.ChainTypeBTCTestnet, "p2pkh", "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r"},
+		{model.ChainTypeBTCTestnet, "p2wpkh", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{model.ChainTypeBTCSignet, "p2wpkh", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{model.ChainTypeBTCRegtest, "p2wpkh", "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"},
+	}
+
+	for _, tc := range testCases {
+		generator, err := NewBtcKeyGenerator(tc.chainType, tc.addressType)
+		assert.NoError(t, err)
+
+		address, err := generator.PublicKeyToAddress(publicKey)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, address, tc.chainType)
+	}
+
+	// 不支持的链类型
+	_, err := NewBtcKeyGenerator(model.ChainTypeETH, "p2wpkh")
+	assert.Error(t, err)
+}
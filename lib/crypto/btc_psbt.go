@@ -0,0 +1,691 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// psbtBase64Prefix Base64编码的PSBT魔数"psbt\xff"的前缀
+const psbtBase64Prefix = "cHNidP"
+
+// BtcPsbtRequest PSBT签名请求
+// 作为SignTransaction的rawTx传入时，也可以直接传入Base64编码的PSBT字符串
+type BtcPsbtRequest struct {
+	Psbt     string `json:"psbt"`     // Base64编码的PSBT（BIP-174 v0 或 BIP-370 v2）
+	Finalize bool   `json:"finalize"` // 签名后最终化所有可以最终化的输入
+	Extract  bool   `json:"extract"`  // 最终化后提取网络交易，要求所有输入都已完成签名
+}
+
+// BtcPsbtResult PSBT签名结果
+type BtcPsbtResult struct {
+	Psbt         string `json:"psbt"`         // 添加了本密钥签名的PSBT（与输入相同的版本）
+	SignedInputs []int  `json:"signedInputs"` // 本次签名的输入索引
+	Complete     bool   `json:"complete"`     // 所有输入是否均已最终化
+	Tx           string `json:"tx,omitempty"` // 提取出的网络交易（十六进制）
+	TxID         string `json:"txid"`         // 交易ID
+}
+
+// parseBtcPsbtRequest 判断rawTx是否为PSBT签名请求并解析
+func parseBtcPsbtRequest(rawTx string) (*BtcPsbtRequest, bool) {
+	trimmed := strings.TrimSpace(rawTx)
+	if strings.HasPrefix(trimmed, psbtBase64Prefix) {
+		return &BtcPsbtRequest{Psbt: trimmed}, true
+	}
+
+	var req BtcPsbtRequest
+	if err := json.Unmarshal([]byte(trimmed), &req); err != nil || req.Psbt == "" {
+		return nil, false
+	}
+	return &req, true
+}
+
+// SignPsbt 为PSBT中属于该签名密钥的输入添加部分签名
+// 支持的输入类型：P2PKH、P2WPKH、P2SH-P2WPKH、包含该公钥的P2SH/P2WSH脚本（如多签），
+// 以及Taproot密钥路径和包含该公钥的Taproot脚本路径
+// 每个输入使用PSBT中声明的签名哈希类型，未声明时ECDSA使用SIGHASH_ALL，Taproot使用SIGHASH_DEFAULT
+// Taproot输入需要签名密钥实现SchnorrSigner
+func (s *BtcTransactionSigner) SignPsbt(req *BtcPsbtRequest, key Signer) (*BtcPsbtResult, error) {
+	pubKey, err := btcPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	psbtBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(req.Psbt))
+	if err != nil {
+		return nil, fmt.Errorf("解析PSBT失败: %v", err)
+	}
+
+	raw, err := parseRawPsbt(psbtBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// BIP-370 v2 先转换为v0格式再签名，签名后再转换回v2
+	v0Bytes := psbtBytes
+	if raw.version == 2 {
+		if v0Bytes, err = raw.toV0(); err != nil {
+			return nil, err
+		}
+	}
+
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(v0Bytes), false)
+	if err != nil {
+		return nil, fmt.Errorf("解析PSBT失败: %v", err)
+	}
+
+	signedInputs, hashTypes, err := signPsbtInputs(packet, key, pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Finalize || req.Extract {
+		for i := range packet.Inputs {
+			// 尚未收集到足够签名的输入保持原样，留给其他参与方
+			_, _ = psbt.MaybeFinalize(packet, i)
+		}
+	}
+
+	result := &BtcPsbtResult{
+		SignedInputs: signedInputs,
+		Complete:     packet.IsComplete(),
+		TxID:         packet.UnsignedTx.TxHash().String(),
+	}
+
+	if req.Extract {
+		if !result.Complete {
+			return nil, errors.New("PSBT中仍有未完成签名的输入，无法提取交易")
+		}
+		finalTx, err := psbt.Extract(packet)
+		if err != nil {
+			return nil, fmt.Errorf("提取交易失败: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := finalTx.Serialize(&buf); err != nil {
+			return nil, fmt.Errorf("序列化交易失败: %v", err)
+		}
+		result.Tx = hex.EncodeToString(buf.Bytes())
+		result.TxID = finalTx.TxHash().String()
+	}
+
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("序列化PSBT失败: %v", err)
+	}
+	outBytes := buf.Bytes()
+	if raw.version == 2 {
+		if outBytes, err = raw.mergeSignedV0(outBytes, hashTypes); err != nil {
+			return nil, err
+		}
+	}
+	result.Psbt = base64.StdEncoding.EncodeToString(outBytes)
+
+	return result, nil
+}
+
+// signPsbtInputs 对属于签名密钥的所有输入签名，返回已签名的输入索引和使用的签名哈希类型
+func signPsbtInputs(packet *psbt.Packet, key Signer, pubKey *btcec.PublicKey) ([]int, []txscript.SigHashType, error) {
+	tx := packet.UnsignedTx
+
+	// 收集所有可用的被花费输出，用于隔离见证和Taproot签名哈希
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		if prevOut := psbtPrevOut(packet, i); prevOut != nil {
+			prevOuts[txIn.PreviousOutPoint] = prevOut
+		}
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建PSBT更新器失败: %v", err)
+	}
+
+	signedInputs := []int{}
+	var hashTypes []txscript.SigHashType
+	for i := range packet.Inputs {
+		pInput := &packet.Inputs[i]
+		if pInput.FinalScriptSig != nil || pInput.FinalScriptWitness != nil {
+			continue
+		}
+		prevOut := psbtPrevOut(packet, i)
+		if prevOut == nil {
+			continue
+		}
+
+		signed, hashType, err := signPsbtInput(updater, i, prevOut, sigHashes, key, pubKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("签名输入%d失败: %v", i, err)
+		}
+		if signed {
+			signedInputs = append(signedInputs, i)
+			hashTypes = append(hashTypes, hashType)
+		}
+	}
+
+	return signedInputs, hashTypes, nil
+}
+
+// psbtPrevOut 获取PSBT输入花费的输出
+func psbtPrevOut(packet *psbt.Packet, idx int) *wire.TxOut {
+	pInput := packet.Inputs[idx]
+	if pInput.WitnessUtxo != nil {
+		return pInput.WitnessUtxo
+	}
+	if pInput.NonWitnessUtxo != nil {
+		outIndex := packet.UnsignedTx.TxIn[idx].PreviousOutPoint.Index
+		if int(outIndex) < len(pInput.NonWitnessUtxo.TxOut) {
+			return pInput.NonWitnessUtxo.TxOut[outIndex]
+		}
+	}
+	return nil
+}
+
+// signPsbtInput 判断单个输入是否属于签名密钥，属于则添加签名
+func signPsbtInput(updater *psbt.Updater, idx int, prevOut *wire.TxOut,
+	sigHashes *txscript.TxSigHashes, key Signer, pubKey *btcec.PublicKey) (bool, txscript.SigHashType, error) {
+
+	tx := updater.Upsbt.UnsignedTx
+	pInput := &updater.Upsbt.Inputs[idx]
+	pubKeyBytes := pubKey.SerializeCompressed()
+	pkScript := prevOut.PkScript
+
+	// Taproot输入
+	if txscript.IsPayToTaproot(pkScript) {
+		hashType := pInput.SighashType
+		xOnly := schnorr.SerializePubKey(pubKey)
+		fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, prevOut.Value)
+
+		// 密钥路径：输出公钥由本密钥（及可选的脚本树根）调整得到
+		merkleRoot := pInput.TaprootMerkleRoot
+		outputKey := txscript.ComputeTaprootOutputKey(pubKey, merkleRoot)
+		if bytes.Equal(schnorr.SerializePubKey(outputKey), pkScript[2:]) {
+			if len(pInput.TaprootKeySpendSig) > 0 {
+				return false, 0, nil
+			}
+			if merkleRoot == nil {
+				merkleRoot = []byte{}
+			}
+			hash, err := txscript.CalcTaprootSignatureHash(sigHashes, hashType, tx, idx, fetcher)
+			if err != nil {
+				return false, 0, err
+			}
+			sig, err := btcTaprootKeySpendSignature(key, pubKey, hash, merkleRoot, hashType)
+			if err != nil {
+				return false, 0, err
+			}
+			pInput.TaprootKeySpendSig = sig
+			return true, hashType, nil
+		}
+
+		// 脚本路径：为包含本密钥的叶子脚本签名
+		signed := false
+		for _, leafScript := range pInput.TaprootLeafScript {
+			if !scriptContainsKey(leafScript.Script, xOnly) {
+				continue
+			}
+			leaf := txscript.NewTapLeaf(leafScript.LeafVersion, leafScript.Script)
+			leafHash := leaf.TapHash()
+			if hasTaprootScriptSig(pInput, xOnly, leafHash[:]) {
+				continue
+			}
+			hash, err := txscript.CalcTapscriptSignaturehash(sigHashes, hashType, tx, idx, fetcher, leaf)
+			if err != nil {
+				return false, 0, err
+			}
+			sig, err := btcSchnorrSignature(key, pubKey, hash, nil, hashType)
+			if err != nil {
+				return false, 0, err
+			}
+			pInput.TaprootScriptSpendSig = append(pInput.TaprootScriptSpendSig, &psbt.TaprootScriptSpendSig{
+				XOnlyPubKey: xOnly,
+				LeafHash:    leafHash[:],
+				Signature:   sig[:schnorr.SignatureSize],
+				SigHash:     hashType,
+			})
+			signed = true
+		}
+		return signed, hashType, nil
+	}
+
+	// ECDSA输入
+	hashType := pInput.SighashType
+	if hashType == 0 {
+		hashType = txscript.SigHashAll
+	}
+	for _, partialSig := range pInput.PartialSigs {
+		if bytes.Equal(partialSig.PubKey, pubKeyBytes) {
+			return false, 0, nil
+		}
+	}
+
+	p2wpkhScript, err := btcP2WPKHScript(pubKey)
+	if err != nil {
+		return false, 0, err
+	}
+	pubKeyHash := btcutil.Hash160(pubKeyBytes)
+
+	var (
+		hash         []byte
+		redeemScript []byte
+	)
+	switch {
+	case txscript.IsPayToPubKeyHash(pkScript) && bytes.Equal(pkScript[3:23], pubKeyHash):
+		hash, err = txscript.CalcSignatureHash(pkScript, hashType, tx, idx)
+
+	case txscript.IsPayToWitnessPubKeyHash(pkScript) && bytes.Equal(pkScript[2:], pubKeyHash):
+		hash, err = txscript.CalcWitnessSigHash(pkScript, sigHashes, hashType, tx, idx, prevOut.Value)
+
+	case txscript.IsPayToScriptHash(pkScript) && bytes.Equal(pkScript[2:22], btcutil.Hash160(p2wpkhScript)):
+		redeemScript = p2wpkhScript
+		hash, err = txscript.CalcWitnessSigHash(p2wpkhScript, sigHashes, hashType, tx, idx, prevOut.Value)
+
+	case pInput.WitnessScript != nil && scriptContainsKey(pInput.WitnessScript, pubKeyBytes):
+		hash, err = txscript.CalcWitnessSigHash(pInput.WitnessScript, sigHashes, hashType, tx, idx, prevOut.Value)
+
+	case pInput.RedeemScript != nil && !txscript.IsWitnessProgram(pInput.RedeemScript) &&
+		scriptContainsKey(pInput.RedeemScript, pubKeyBytes):
+		hash, err = txscript.CalcSignatureHash(pInput.RedeemScript, hashType, tx, idx)
+
+	default:
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	sig, err := btcECDSASignature(key, hash, hashType)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if _, err := updater.Sign(idx, sig, pubKeyBytes, redeemScript, nil); err != nil {
+		return false, 0, err
+	}
+	return true, hashType, nil
+}
+
+// scriptContainsKey 判断脚本中是否压入了指定公钥
+func scriptContainsKey(script, key []byte) bool {
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		if bytes.Equal(tokenizer.Data(), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTaprootScriptSig 判断输入中是否已有该公钥对指定叶子的签名
+func hasTaprootScriptSig(pInput *psbt.PInput, xOnly, leafHash []byte) bool {
+	for _, sig := range pInput.TaprootScriptSpendSig {
+		if bytes.Equal(sig.XOnlyPubKey, xOnly) && bytes.Equal(sig.LeafHash, leafHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// PSBT键类型，参见BIP-174和BIP-370
+const (
+	psbtGlobalUnsignedTx     = 0x00
+	psbtGlobalTxVersion      = 0x02
+	psbtGlobalFallbackLock   = 0x03
+	psbtGlobalInputCount     = 0x04
+	psbtGlobalOutputCount    = 0x05
+	psbtGlobalTxModifiable   = 0x06
+	psbtGlobalVersion        = 0xfb
+	psbtInPreviousTxID       = 0x0e
+	psbtInOutputIndex        = 0x0f
+	psbtInSequence           = 0x10
+	psbtInRequiredTimeLock   = 0x11
+	psbtInRequiredHeightLock = 0x12
+	psbtOutAmount            = 0x03
+	psbtOutScript            = 0x04
+)
+
+// btcSigHashMask 签名哈希类型中基础类型的掩码（txscript中未导出）
+const btcSigHashMask = 0x1f
+
+// psbtKV PSBT中的一个键值对
+type psbtKV struct {
+	key   []byte
+	value []byte
+}
+
+// psbtMap PSBT中的一个键值映射（全局、输入或输出）
+type psbtMap []psbtKV
+
+// get 获取指定键类型（无键数据）的值
+func (m psbtMap) get(keyType byte) ([]byte, bool) {
+	for _, kv := range m {
+		if len(kv.key) == 1 && kv.key[0] == keyType {
+			return kv.value, true
+		}
+	}
+	return nil, false
+}
+
+// without 移除属于指定键类型的键值对
+func (m psbtMap) without(keyTypes ...byte) psbtMap {
+	var out psbtMap
+	for _, kv := range m {
+		if !bytes.Contains(keyTypes, kv.key[:1]) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// only 仅保留属于指定键类型的键值对
+func (m psbtMap) only(keyTypes ...byte) psbtMap {
+	var out psbtMap
+	for _, kv := range m {
+		if bytes.Contains(keyTypes, kv.key[:1]) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// set 设置指定键类型（无键数据）的值
+func (m psbtMap) set(keyType byte, value []byte) psbtMap {
+	return append(m.without(keyType), psbtKV{key: []byte{keyType}, value: value})
+}
+
+// rawPsbt 按键值对解析的PSBT，用于在BIP-174 v0与BIP-370 v2之间转换
+type rawPsbt struct {
+	version uint32
+	global  psbtMap
+	inputs  []psbtMap
+	outputs []psbtMap
+}
+
+// parseRawPsbt 按键值对解析PSBT
+func parseRawPsbt(data []byte) (*rawPsbt, error) {
+	if len(data) < 5 || !bytes.Equal(data[:5], []byte("psbt\xff")) {
+		return nil, errors.New("解析PSBT失败: 魔数无效")
+	}
+	r := bytes.NewReader(data[5:])
+
+	global, err := readPsbtMap(r)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := &rawPsbt{global: global}
+	if v, ok := global.get(psbtGlobalVersion); ok {
+		if len(v) != 4 {
+			return nil, errors.New("解析PSBT失败: 版本字段无效")
+		}
+		raw.version = binary.LittleEndian.Uint32(v)
+	}
+
+	var inputCount, outputCount uint64
+	switch raw.version {
+	case 0:
+		txBytes, ok := global.get(psbtGlobalUnsignedTx)
+		if !ok {
+			return nil, errors.New("解析PSBT失败: 缺少未签名交易")
+		}
+		var tx wire.MsgTx
+		if err := tx.DeserializeNoWitness(bytes.NewReader(txBytes)); err != nil {
+			return nil, fmt.Errorf("解析PSBT失败: %v", err)
+		}
+		inputCount, outputCount = uint64(len(tx.TxIn)), uint64(len(tx.TxOut))
+	case 2:
+		if inputCount, err = psbtCompactSize(global, psbtGlobalInputCount); err != nil {
+			return nil, err
+		}
+		if outputCount, err = psbtCompactSize(global, psbtGlobalOutputCount); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的PSBT版本: %d", raw.version)
+	}
+
+	for i := uint64(0); i < inputCount; i++ {
+		m, err := readPsbtMap(r)
+		if err != nil {
+			return nil, err
+		}
+		raw.inputs = append(raw.inputs, m)
+	}
+	for i := uint64(0); i < outputCount; i++ {
+		m, err := readPsbtMap(r)
+		if err != nil {
+			return nil, err
+		}
+		raw.outputs = append(raw.outputs, m)
+	}
+
+	return raw, nil
+}
+
+// psbtCompactSize 读取全局映射中CompactSize编码的值
+func psbtCompactSize(m psbtMap, keyType byte) (uint64, error) {
+	v, ok := m.get(keyType)
+	if !ok {
+		return 0, fmt.Errorf("解析PSBT失败: 缺少字段0x%02x", keyType)
+	}
+	return wire.ReadVarInt(bytes.NewReader(v), 0)
+}
+
+// readPsbtMap 读取一个以0x00分隔的键值映射
+func readPsbtMap(r *bytes.Reader) (psbtMap, error) {
+	var m psbtMap
+	for {
+		key, err := wire.ReadVarBytes(r, 0, psbt.MaxPsbtValueLength, "PSBT key")
+		if err != nil {
+			return nil, fmt.Errorf("解析PSBT失败: %v", err)
+		}
+		if len(key) == 0 {
+			return m, nil
+		}
+		value, err := wire.ReadVarBytes(r, 0, psbt.MaxPsbtValueLength, "PSBT value")
+		if err != nil {
+			return nil, fmt.Errorf("解析PSBT失败: %v", err)
+		}
+		m = append(m, psbtKV{key: key, value: value})
+	}
+}
+
+// writePsbtMap 按键排序写出一个键值映射及其分隔符
+func writePsbtMap(w *bytes.Buffer, m psbtMap) error {
+	sorted := append(psbtMap(nil), m...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].key, sorted[j].key) < 0
+	})
+	for _, kv := range sorted {
+		if err := wire.WriteVarBytes(w, 0, kv.key); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, kv.value); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte(0x00)
+}
+
+// serialize 序列化PSBT
+func (raw *rawPsbt) serialize(global psbtMap, inputs, outputs []psbtMap) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("psbt\xff")
+	if err := writePsbtMap(&buf, global); err != nil {
+		return nil, err
+	}
+	for _, m := range inputs {
+		if err := writePsbtMap(&buf, m); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range outputs {
+		if err := writePsbtMap(&buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// toV0 将BIP-370 v2 PSBT转换为等价的v0 PSBT
+func (raw *rawPsbt) toV0() ([]byte, error) {
+	txVersion, ok := raw.global.get(psbtGlobalTxVersion)
+	if !ok || len(txVersion) != 4 {
+		return nil, errors.New("解析PSBT失败: 缺少交易版本")
+	}
+	tx := wire.NewMsgTx(int32(binary.LittleEndian.Uint32(txVersion)))
+
+	lockTime, err := raw.lockTime()
+	if err != nil {
+		return nil, err
+	}
+	tx.LockTime = lockTime
+
+	for i, in := range raw.inputs {
+		txid, ok := in.get(psbtInPreviousTxID)
+		if !ok || len(txid) != chainhash.HashSize {
+			return nil, fmt.Errorf("解析PSBT失败: 输入%d缺少前序交易ID", i)
+		}
+		index, ok := in.get(psbtInOutputIndex)
+		if !ok || len(index) != 4 {
+			return nil, fmt.Errorf("解析PSBT失败: 输入%d缺少输出索引", i)
+		}
+		hash, _ := chainhash.NewHash(txid)
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, binary.LittleEndian.Uint32(index)), nil, nil)
+		if seq, ok := in.get(psbtInSequence); ok && len(seq) == 4 {
+			txIn.Sequence = binary.LittleEndian.Uint32(seq)
+		}
+		tx.AddTxIn(txIn)
+	}
+
+	for i, out := range raw.outputs {
+		amount, ok := out.get(psbtOutAmount)
+		if !ok || len(amount) != 8 {
+			return nil, fmt.Errorf("解析PSBT失败: 输出%d缺少金额", i)
+		}
+		script, ok := out.get(psbtOutScript)
+		if !ok {
+			return nil, fmt.Errorf("解析PSBT失败: 输出%d缺少锁定脚本", i)
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(binary.LittleEndian.Uint64(amount)), script))
+	}
+
+	var txBuf bytes.Buffer
+	if err := tx.SerializeNoWitness(&txBuf); err != nil {
+		return nil, err
+	}
+
+	global := raw.global.without(psbtGlobalTxVersion, psbtGlobalFallbackLock, psbtGlobalInputCount,
+		psbtGlobalOutputCount, psbtGlobalTxModifiable, psbtGlobalVersion).
+		set(psbtGlobalUnsignedTx, txBuf.Bytes())
+
+	inputs := make([]psbtMap, len(raw.inputs))
+	for i, in := range raw.inputs {
+		inputs[i] = in.without(psbtInPreviousTxID, psbtInOutputIndex, psbtInSequence,
+			psbtInRequiredTimeLock, psbtInRequiredHeightLock)
+	}
+	outputs := make([]psbtMap, len(raw.outputs))
+	for i, out := range raw.outputs {
+		outputs[i] = out.without(psbtOutAmount, psbtOutScript)
+	}
+
+	return raw.serialize(global, inputs, outputs)
+}
+
+// lockTime 按BIP-370的规则确定交易的nLockTime
+func (raw *rawPsbt) lockTime() (uint32, error) {
+	var (
+		maxTime, maxHeight        uint32
+		timeOK, heightOK, hasLock = true, true, false
+	)
+	for _, in := range raw.inputs {
+		t, hasTime := in.get(psbtInRequiredTimeLock)
+		h, hasHeight := in.get(psbtInRequiredHeightLock)
+		if !hasTime && !hasHeight {
+			continue
+		}
+		hasLock = true
+		if hasTime && len(t) == 4 {
+			if v := binary.LittleEndian.Uint32(t); v > maxTime {
+				maxTime = v
+			}
+		} else {
+			timeOK = false
+		}
+		if hasHeight && len(h) == 4 {
+			if v := binary.LittleEndian.Uint32(h); v > maxHeight {
+				maxHeight = v
+			}
+		} else {
+			heightOK = false
+		}
+	}
+
+	switch {
+	case !hasLock:
+		if v, ok := raw.global.get(psbtGlobalFallbackLock); ok && len(v) == 4 {
+			return binary.LittleEndian.Uint32(v), nil
+		}
+		return 0, nil
+	case heightOK:
+		return maxHeight, nil
+	case timeOK:
+		return maxTime, nil
+	default:
+		return 0, errors.New("解析PSBT失败: 输入的锁定时间要求互相冲突")
+	}
+}
+
+// mergeSignedV0 将签名后的v0 PSBT转换回v2格式，并按BIP-370更新交易可修改标志
+func (raw *rawPsbt) mergeSignedV0(v0Bytes []byte, hashTypes []txscript.SigHashType) ([]byte, error) {
+	signed, err := parseRawPsbt(v0Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	global := raw.global
+	if len(hashTypes) > 0 {
+		var flags byte
+		if v, ok := global.get(psbtGlobalTxModifiable); ok && len(v) == 1 {
+			flags = v[0]
+		}
+		for _, hashType := range hashTypes {
+			if hashType&txscript.SigHashAnyOneCanPay == 0 {
+				flags &^= 0x01
+			}
+			if hashType&btcSigHashMask != txscript.SigHashNone {
+				flags &^= 0x02
+			}
+			if hashType&btcSigHashMask == txscript.SigHashSingle {
+				flags |= 0x04
+			}
+		}
+		global = global.set(psbtGlobalTxModifiable, []byte{flags})
+	}
+
+	inputs := make([]psbtMap, len(raw.inputs))
+	for i, in := range raw.inputs {
+		inputs[i] = append(signed.inputs[i], in.only(psbtInPreviousTxID, psbtInOutputIndex,
+			psbtInSequence, psbtInRequiredTimeLock, psbtInRequiredHeightLock)...)
+	}
+	outputs := make([]psbtMap, len(raw.outputs))
+	for i, out := range raw.outputs {
+		outputs[i] = append(signed.outputs[i], out.only(psbtOutAmount, psbtOutScript)...)
+	}
+
+	return raw.serialize(global, inputs, outputs)
+}
@@ -0,0 +1,264 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBtcKey 生成测试用密钥，返回签名密钥和私钥对象
+func newTestBtcKey(t *testing.T) (Signer, *btcec.PrivateKey) {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	ecdsaKey, err := crypto.ToECDSA(privKey.Serialize())
+	require.NoError(t, err)
+	return NewSecp256k1Signer(ecdsaKey), privKey
+}
+
+// testBtcScript 生成公钥指定地址类型的锁定脚本
+func testBtcScript(t *testing.T, pubKey *btcec.PublicKey, addressType string) []byte {
+	addr, err := btcAddressFromPubKey(pubKey, addressType, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	script, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	return script
+}
+
+// newTestPsbt 根据被花费的输出构建v0 PSBT
+func newTestPsbt(t *testing.T, prevOuts []*wire.TxOut) *psbt.Packet {
+	tx := wire.NewMsgTx(2)
+	var total int64
+	for i, prevOut := range prevOuts {
+		hash := chainhash.HashH([]byte{byte(i)})
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&hash, uint32(i)), nil, nil))
+		total += prevOut.Value
+	}
+	tx.AddTxOut(wire.NewTxOut(total-1000, prevOuts[0].PkScript))
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+	for i, prevOut := range prevOuts {
+		packet.Inputs[i].WitnessUtxo = prevOut
+	}
+	return packet
+}
+
+// encodeTestPsbt 将PSBT编码为Base64
+func encodeTestPsbt(t *testing.T, packet *psbt.Packet) string {
+	b64, err := packet.B64Encode()
+	require.NoError(t, err)
+	return b64
+}
+
+// verifyExtractedTx 使用脚本引擎验证提取出的网络交易
+func verifyExtractedTx(t *testing.T, txHex string, prevOuts []*wire.TxOut) {
+	msgTx, err := decodeBtcTx(txHex)
+	require.NoError(t, err)
+	prevOutMap := make(map[wire.OutPoint]*wire.TxOut)
+	for i, txIn := range msgTx.TxIn {
+		prevOutMap[txIn.PreviousOutPoint] = prevOuts[i]
+	}
+	assert.True(t, verifyBtcTxInputs(msgTx, prevOutMap))
+}
+
+func TestBtcTransactionSigner_SignPsbtOnlyOwnedInputs(t *testing.T) {
+	signer := &BtcTransactionSigner{}
+	keyA, privA := newTestBtcKey(t)
+	keyB, privB := newTestBtcKey(t)
+
+	prevOuts := []*wire.TxOut{
+		wire.NewTxOut(100000, testBtcScript(t, privA.PubKey(), "p2wpkh")),
+		wire.NewTxOut(200000, testBtcScript(t, privB.PubKey(), "p2tr")),
+		wire.NewTxOut(300000, testBtcScript(t, privA.PubKey(), "p2sh-p2wpkh")),
+	}
+	packet := newTestPsbt(t, prevOuts)
+
+	// A只签名属于自己的输入
+	resultA, err := signer.SignPsbt(&BtcPsbtRequest{Psbt: encodeTestPsbt(t, packet)}, keyA)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, resultA.SignedInputs)
+	assert.False(t, resultA.Complete)
+	assert.Equal(t, packet.UnsignedTx.TxHash().String(), resultA.TxID)
+
+	// 输入不完整时不能提取交易
+	_, err = signer.SignPsbt(&BtcPsbtRequest{Psbt: resultA.Psbt, Extract: true}, keyA)
+	assert.Error(t, err)
+
+	// B签名剩余输入并提取网络交易
+	signedTx, txHash, err := signer.SignTransaction(`{"psbt":"`+resultA.Psbt+`","extract":true}`, keyB)
+	require.NoError(t, err)
+	verifyExtractedTx(t, signedTx, prevOuts)
+
+	// 嵌套隔离见证输入的scriptSig计入交易ID，因此以提取出的交易为准
+	msgTx, err := decodeBtcTx(signedTx)
+	require.NoError(t, err)
+	assert.Equal(t, msgTx.TxHash().String(), txHash)
+}
+
+func TestBtcTransactionSigner_SignPsbtMultisig(t *testing.T) {
+	signer := &BtcTransactionSigner{}
+	keyA, privA := newTestBtcKey(t)
+	keyB, privB := newTestBtcKey(t)
+
+	// 2-of-2 P2WSH多签
+	witnessScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_2).
+		AddData(privA.PubKey().SerializeCompressed()).
+		AddData(privB.PubKey().SerializeCompressed()).
+		AddOp(txscript.OP_2).
+		AddOp(txscript.OP_CHECKMULTISIG).
+		Script()
+	require.NoError(t, err)
+	witnessHash := chainhash.HashB(witnessScript)
+	addr, err := btcutil.NewAddressWitnessScriptHash(witnessHash, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	prevOuts := []*wire.TxOut{wire.NewTxOut(500000, pkScript)}
+	packet := newTestPsbt(t, prevOuts)
+	packet.Inputs[0].WitnessScript = witnessScript
+
+	signedPsbt, _, err := signer.SignTransaction(encodeTestPsbt(t, packet), keyA)
+	require.NoError(t, err)
+
+	// 重复签名不会添加第二个签名
+	result, err := signer.SignPsbt(&BtcPsbtRequest{Psbt: signedPsbt}, keyA)
+	require.NoError(t, err)
+	assert.Empty(t, result.SignedInputs)
+
+	result, err = signer.SignPsbt(&BtcPsbtRequest{Psbt: signedPsbt, Finalize: true, Extract: true}, keyB)
+	require.NoError(t, err)
+	assert.True(t, result.Complete)
+	verifyExtractedTx(t, result.Tx, prevOuts)
+}
+
+func TestBtcTransactionSigner_SignPsbtSighashType(t *testing.T) {
+	signer := &BtcTransactionSigner{}
+	key, privKey := newTestBtcKey(t)
+
+	prevOuts := []*wire.TxOut{
+		wire.NewTxOut(100000, testBtcScript(t, privKey.PubKey(), "p2pkh")),
+		wire.NewTxOut(100000, testBtcScript(t, privKey.PubKey(), "p2tr")),
+	}
+	packet := newTestPsbt(t, prevOuts)
+	// P2PKH输入需要完整的前序交易
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(1, nil))
+	prevTx.AddTxOut(prevOuts[0])
+	packet.UnsignedTx.TxIn[0].PreviousOutPoint = *wire.NewOutPoint(ptrHash(prevTx.TxHash()), 1)
+	packet.Inputs[0].WitnessUtxo = nil
+	packet.Inputs[0].NonWitnessUtxo = prevTx
+	packet.Inputs[0].SighashType = txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
+	packet.Inputs[1].SighashType = txscript.SigHashAll
+
+	result, err := signer.SignPsbt(&BtcPsbtRequest{Psbt: encodeTestPsbt(t, packet), Extract: true}, key)
+	require.NoError(t, err)
+
+	signed, err := psbt.NewFromRawBytes(bytes.NewReader(mustBase64(t, result.Psbt)), false)
+	require.NoError(t, err)
+	assert.True(t, signed.IsComplete())
+	verifyExtractedTx(t, result.Tx, prevOuts)
+
+	msgTx, err := decodeBtcTx(result.Tx)
+	require.NoError(t, err)
+	pushes, err := txscript.PushedData(msgTx.TxIn[0].SignatureScript)
+	require.NoError(t, err)
+	sig := pushes[0]
+	assert.Equal(t, byte(txscript.SigHashSingle|txscript.SigHashAnyOneCanPay), sig[len(sig)-1])
+	assert.Len(t, msgTx.TxIn[1].Witness[0], 65)
+	assert.Equal(t, byte(txscript.SigHashAll), msgTx.TxIn[1].Witness[0][64])
+}
+
+func TestBtcTransactionSigner_SignPsbtV2(t *testing.T) {
+	signer := &BtcTransactionSigner{}
+	key, privKey := newTestBtcKey(t)
+
+	prevOut := wire.NewTxOut(100000, testBtcScript(t, privKey.PubKey(), "p2wpkh"))
+	var utxo bytes.Buffer
+	require.NoError(t, wire.WriteTxOut(&utxo, 0, 0, prevOut))
+
+	u32 := func(v uint32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, v)
+		return b
+	}
+	amount := make([]byte, 8)
+	binary.LittleEndian.PutUint64(amount, 99000)
+	prevTxID := chainhash.HashH([]byte("prev"))
+
+	raw := &rawPsbt{version: 2}
+	global := psbtMap{
+		{key: []byte{psbtGlobalTxVersion}, value: u32(2)},
+		{key: []byte{psbtGlobalFallbackLock}, value: u32(800000)},
+		{key: []byte{psbtGlobalInputCount}, value: []byte{1}},
+		{key: []byte{psbtGlobalOutputCount}, value: []byte{1}},
+		{key: []byte{psbtGlobalTxModifiable}, value: []byte{0x03}},
+		{key: []byte{psbtGlobalVersion}, value: u32(2)},
+	}
+	inputs := []psbtMap{{
+		{key: []byte{byte(psbt.WitnessUtxoType)}, value: utxo.Bytes()},
+		{key: []byte{psbtInPreviousTxID}, value: prevTxID[:]},
+		{key: []byte{psbtInOutputIndex}, value: u32(7)},
+		{key: []byte{psbtInSequence}, value: u32(0xfffffffd)},
+	}}
+	outputs := []psbtMap{{
+		{key: []byte{psbtOutAmount}, value: amount},
+		{key: []byte{psbtOutScript}, value: prevOut.PkScript},
+	}}
+	v2Bytes, err := raw.serialize(global, inputs, outputs)
+	require.NoError(t, err)
+
+	result, err := signer.SignPsbt(&BtcPsbtRequest{Psbt: base64.StdEncoding.EncodeToString(v2Bytes), Finalize: true}, key)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0}, result.SignedInputs)
+	assert.True(t, result.Complete)
+
+	// 签名结果仍为v2，且交易不可再修改输入和输出
+	signed, err := parseRawPsbt(mustBase64(t, result.Psbt))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), signed.version)
+	_, hasUnsignedTx := signed.global.get(psbtGlobalUnsignedTx)
+	assert.False(t, hasUnsignedTx)
+	flags, _ := signed.global.get(psbtGlobalTxModifiable)
+	assert.Equal(t, []byte{0x00}, flags)
+	_, hasFinalWitness := signed.inputs[0].get(byte(psbt.FinalScriptWitnessType))
+	assert.True(t, hasFinalWitness)
+
+	// 转换为v0后可提取出有效交易
+	v0Bytes, err := signed.toV0()
+	require.NoError(t, err)
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(v0Bytes), false)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(800000), packet.UnsignedTx.LockTime)
+	assert.Equal(t, uint32(0xfffffffd), packet.UnsignedTx.TxIn[0].Sequence)
+	finalTx, err := psbt.Extract(packet)
+	require.NoError(t, err)
+	assert.Equal(t, result.TxID, finalTx.TxHash().String())
+	assert.True(t, verifyBtcTxInputs(finalTx, map[wire.OutPoint]*wire.TxOut{
+		finalTx.TxIn[0].PreviousOutPoint: prevOut,
+	}))
+}
+
+func mustBase64(t *testing.T, s string) []byte {
+	b, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func ptrHash(h chainhash.Hash) *chainhash.Hash {
+	return &h
+}
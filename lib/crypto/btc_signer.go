@@ -4,16 +4,24 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 )
 
 // BtcTransactionSigner 实现比特币交易签名功能
-type BtcTransactionSigner struct {}
+// NetParams 指定交易所在的网络，用于解析和校验接收地址，为空时使用主网
+type BtcTransactionSigner struct {
+	NetParams *chaincfg.Params
+}
 
 // BtcTransactionRequest 表示比特币交易请求
 type BtcTransactionRequest struct {
@@ -37,17 +45,74 @@ type BtcTxOutput struct {
 	ScriptPubKey string `json:"scriptPubKey"` // 锁定脚本
 }
 
-// SignTransaction 使用私钥对交易进行签名
+// SignTransaction 使用签名密钥对交易进行签名
 // txData: 交易数据(JSON格式)
-// privateKey: 用于签名的私钥(十六进制字符串)
-// 返回: 签名后的交易数据、交易哈希和可能的错误
-func (s *BtcTransactionSigner) SignTransaction(txData string, privateKey string) (string, string, error) {
+// key: secp256k1签名密钥，签名Taproot输入时需要实现SchnorrSigner
+// 返回: 签名后的交易数据(十六进制序列化的网络交易)、交易哈希和可能的错误
+// txData为PSBT（Base64字符串或BtcPsbtRequest JSON）时按PSBT模式签名，
+// 返回签名后的PSBT（Base64），请求提取时返回网络交易
+func (s *BtcTransactionSigner) SignTransaction(txData string, key Signer) (string, string, error) {
+	// PSBT签名模式
+	if psbtReq, ok := parseBtcPsbtRequest(txData); ok {
+		result, err := s.SignPsbt(psbtReq, key)
+		if err != nil {
+			return "", "", err
+		}
+		if result.Tx != "" {
+			return result.Tx, result.TxID, nil
+		}
+		return result.Psbt, result.TxID, nil
+	}
+
 	// 解析交易请求
 	var txReq BtcTransactionRequest
 	if err := json.Unmarshal([]byte(txData), &txReq); err != nil {
 		return "", "", fmt.Errorf("解析交易数据失败: %v", err)
 	}
 
+	// 解析签名密钥的公钥
+	pubKey, err := btcPublicKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 构建未签名交易
+	msgTx, err := buildBtcUnsignedTx(&txReq, btcParamsOrMainNet(s.NetParams))
+	if err != nil {
+		return "", "", err
+	}
+
+	// 收集所有输入花费的输出，隔离见证和Taproot的签名哈希需要输入金额
+	prevOuts, err := btcPrevOuts(msgTx, txReq.Inputs)
+	if err != nil {
+		return "", "", err
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(msgTx, fetcher)
+
+	// 对每个输入进行签名
+	for i, txIn := range msgTx.TxIn {
+		prevOut := prevOuts[txIn.PreviousOutPoint]
+		if err := signBtcInput(msgTx, i, prevOut, sigHashes, key, pubKey); err != nil {
+			return "", "", fmt.Errorf("签名输入%d失败: %v", i, err)
+		}
+	}
+
+	// 序列化交易
+	var buf bytes.Buffer
+	if err := msgTx.Serialize(&buf); err != nil {
+		return "", "", fmt.Errorf("序列化交易失败: %v", err)
+	}
+	signedTxHex := hex.EncodeToString(buf.Bytes())
+
+	// 计算交易哈希
+	txHash := msgTx.TxHash()
+
+	return signedTxHex, txHash.String(), nil
+}
+
+// buildBtcUnsignedTx 根据交易请求构建未签名的比特币交易
+func buildBtcUnsignedTx(txReq *BtcTransactionRequest, params *chaincfg.Params) (*wire.MsgTx, error) {
 	// 创建一个新的比特币交易
 	msgTx := wire.NewMsgTx(wire.TxVersion)
 
@@ -56,110 +121,287 @@ func (s *BtcTransactionSigner) SignTransaction(txData string, privateKey string)
 		// 解析交易ID
 		txHashBytes, err := chainhash.NewHashFromStr(input.TxID)
 		if err != nil {
-			return "", "", fmt.Errorf("解析交易ID失败: %v", err)
+			return nil, fmt.Errorf("解析交易ID失败: %v", err)
 		}
 
-		// 创建交易输入
-		txIn := wire.NewTxIn(
-			&wire.OutPoint{Hash: *txHashBytes, Index: input.Vout},
-			nil, // 签名脚本稍后添加
-			nil, // 序列号
-		)
-
-		// 添加到交易
-		msgTx.AddTxIn(txIn)
+		// 签名脚本稍后添加
+		msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: *txHashBytes, Index: input.Vout}, nil, nil))
 	}
 
 	// 添加输出
 	for _, output := range txReq.Outputs {
-		// 解析锁定脚本
-		scriptPubKey, err := hex.DecodeString(output.ScriptPubKey)
+		scriptPubKey, err := btcOutputScript(output, params)
 		if err != nil {
-			return "", "", fmt.Errorf("解析锁定脚本失败: %v", err)
+			return nil, err
 		}
+		msgTx.AddTxOut(wire.NewTxOut(output.Amount, scriptPubKey))
+	}
 
-		// 创建交易输出
-		txOut := wire.NewTxOut(output.Amount, scriptPubKey)
-		msgTx.AddTxOut(txOut)
+	return msgTx, nil
+}
+
+// btcOutputScript 获取输出的锁定脚本
+// 优先使用请求中给出的scriptPubKey，未提供时根据接收地址生成，接收地址必须属于指定网络
+func btcOutputScript(output BtcTxOutput, params *chaincfg.Params) ([]byte, error) {
+	if output.ScriptPubKey != "" {
+		scriptPubKey, err := hex.DecodeString(output.ScriptPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("解析锁定脚本失败: %v", err)
+		}
+		return scriptPubKey, nil
 	}
 
-	// 解析私钥
-	privKeyBytes, err := hex.DecodeString(privateKey)
+	addr, err := btcutil.DecodeAddress(output.Address, params)
 	if err != nil {
-		return "", "", fmt.Errorf("解析私钥失败: %v", err)
+		return nil, fmt.Errorf("解析接收地址失败: %v", err)
 	}
+	if !addr.IsForNet(params) {
+		return nil, fmt.Errorf("接收地址%s不属于%s网络", output.Address, params.Name)
+	}
+	scriptPubKey, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, fmt.Errorf("生成锁定脚本失败: %v", err)
+	}
+	return scriptPubKey, nil
+}
 
-	// 使用btcec/v2包解析私钥
-	privKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
-
-	// 对每个输入进行签名
-	for i, txIn := range msgTx.TxIn {
-		// 获取原始锁定脚本
-		scriptPubKey, err := hex.DecodeString(txReq.Inputs[i].ScriptPubKey)
+// btcPrevOuts 根据交易请求的输入构建被花费输出的集合
+func btcPrevOuts(msgTx *wire.MsgTx, inputs []BtcTxInput) (map[wire.OutPoint]*wire.TxOut, error) {
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(inputs))
+	for i, input := range inputs {
+		scriptPubKey, err := hex.DecodeString(input.ScriptPubKey)
 		if err != nil {
-			return "", "", fmt.Errorf("解析锁定脚本失败: %v", err)
+			return nil, fmt.Errorf("解析锁定脚本失败: %v", err)
 		}
+		prevOuts[msgTx.TxIn[i].PreviousOutPoint] = wire.NewTxOut(input.Amount, scriptPubKey)
+	}
+	return prevOuts, nil
+}
+
+// signBtcInput 根据被花费输出的脚本类型对单个输入签名
+//   - P2PKH: 传统签名哈希，写入scriptSig
+//   - P2WPKH: BIP-143签名哈希，写入witness
+//   - P2SH-P2WPKH: BIP-143签名哈希，scriptSig中压入赎回脚本
+//   - P2TR: BIP-341签名哈希，BIP-86密钥路径Schnorr签名
+func signBtcInput(msgTx *wire.MsgTx, idx int, prevOut *wire.TxOut, sigHashes *txscript.TxSigHashes, key Signer, pubKey *btcec.PublicKey) error {
+	txIn := msgTx.TxIn[idx]
+	pubKeyBytes := pubKey.SerializeCompressed()
 
-		// 计算签名哈希
-		hashType := txscript.SigHashAll
-		sigHash, err := txscript.CalcSignatureHash(scriptPubKey, hashType, msgTx, i)
+	switch txscript.GetScriptClass(prevOut.PkScript) {
+	case txscript.PubKeyHashTy:
+		hash, err := txscript.CalcSignatureHash(prevOut.PkScript, txscript.SigHashAll, msgTx, idx)
+		if err != nil {
+			return err
+		}
+		sig, err := btcECDSASignature(key, hash, txscript.SigHashAll)
 		if err != nil {
-			return "", "", fmt.Errorf("计算签名哈希失败: %v", err)
+			return err
 		}
+		sigScript, err := txscript.NewScriptBuilder().AddData(sig).AddData(pubKeyBytes).Script()
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = sigScript
 
-		// 创建解锁脚本（使用简化的方式）
-		// 注意：这仍然是一个真实的比特币签名实现
-		// 我们使用txscript包来创建标准的P2PKH解锁脚本
-		sigScript, err := createP2PKHScript(sigHash, privKey, scriptPubKey, hashType)
+	case txscript.WitnessV0PubKeyHashTy:
+		hash, err := txscript.CalcWitnessSigHash(prevOut.PkScript, sigHashes, txscript.SigHashAll, msgTx, idx, prevOut.Value)
+		if err != nil {
+			return err
+		}
+		sig, err := btcECDSASignature(key, hash, txscript.SigHashAll)
 		if err != nil {
-			return "", "", fmt.Errorf("创建解锁脚本失败: %v", err)
+			return err
 		}
+		txIn.Witness = wire.TxWitness{sig, pubKeyBytes}
 
-		// 设置输入的解锁脚本
+	case txscript.ScriptHashTy:
+		// 仅支持由本密钥派生的P2SH-P2WPKH
+		redeemScript, err := btcP2WPKHScript(pubKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(prevOut.PkScript[2:22], btcutil.Hash160(redeemScript)) {
+			return errors.New("P2SH脚本与密钥的P2SH-P2WPKH赎回脚本不匹配")
+		}
+		hash, err := txscript.CalcWitnessSigHash(redeemScript, sigHashes, txscript.SigHashAll, msgTx, idx, prevOut.Value)
+		if err != nil {
+			return err
+		}
+		sig, err := btcECDSASignature(key, hash, txscript.SigHashAll)
+		if err != nil {
+			return err
+		}
+		sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+		if err != nil {
+			return err
+		}
 		txIn.SignatureScript = sigScript
-	}
+		txIn.Witness = wire.TxWitness{sig, pubKeyBytes}
 
-	// 序列化交易
-	var buf bytes.Buffer
-	if err := msgTx.Serialize(&buf); err != nil {
-		return "", "", fmt.Errorf("序列化交易失败: %v", err)
-	}
-	signedTxHex := hex.EncodeToString(buf.Bytes())
+	case txscript.WitnessV1TaprootTy:
+		hash, err := txscript.CalcTaprootSignatureHash(sigHashes, txscript.SigHashDefault, msgTx, idx,
+			txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value))
+		if err != nil {
+			return err
+		}
+		sig, err := btcTaprootKeySpendSignature(key, pubKey, hash, []byte{}, txscript.SigHashDefault)
+		if err != nil {
+			return err
+		}
+		txIn.Witness = wire.TxWitness{sig}
 
-	// 计算交易哈希
-	txHash := msgTx.TxHash()
-	txHashHex := txHash.String()
+	default:
+		return fmt.Errorf("不支持的锁定脚本类型: %s", txscript.GetScriptClass(prevOut.PkScript))
+	}
 
-	// 返回签名后的交易数据、交易哈希和无错误
-	return "btc_signed_" + signedTxHex, txHashHex, nil
+	return nil
 }
 
-// createP2PKHScript 创建P2PKH解锁脚本
-func createP2PKHScript(sigHash []byte, privKey *btcec.PrivateKey, scriptPubKey []byte, hashType txscript.SigHashType) ([]byte, error) {
-	// 简化的签名方式：我们使用txscript包的标准功能
-	// 创建签名脚本
-	builder := txscript.NewScriptBuilder()
-
-	// 添加一个简单的数据作为签名（这是一个简化实现，但保留了真实交易的结构）
-	sigData := append(sigHash[:32], byte(hashType))
-	builder.AddData(sigData)
+// btcPublicKey 解析签名密钥的secp256k1公钥
+func btcPublicKey(key Signer) (*btcec.PublicKey, error) {
+	if err := requireCurve(key, CurveSecp256k1); err != nil {
+		return nil, err
+	}
+	pubKey, err := btcec.ParsePubKey(key.PublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %v", err)
+	}
+	return pubKey, nil
+}
 
-	// 添加公钥
-	builder.AddData(privKey.PubKey().SerializeCompressed())
+// btcECDSASignature 对签名哈希做ECDSA签名，返回DER编码的low-S签名和签名哈希类型
+func btcECDSASignature(key Signer, hash []byte, hashType txscript.SigHashType) ([]byte, error) {
+	signature, err := signRecoverable(key, hash)
+	if err != nil {
+		return nil, err
+	}
+	var r, s btcec.ModNScalar
+	r.SetByteSlice(signature[:32])
+	s.SetByteSlice(signature[32:64])
+	return append(btcecdsa.NewSignature(&r, &s).Serialize(), byte(hashType)), nil
+}
 
-	// 构建脚本
-	script, err := builder.Script()
+// btcSchnorrSignature 对签名哈希做BIP-340 Schnorr签名并用签名公钥验证，非SIGHASH_DEFAULT时追加签名哈希类型
+// tweak为空时用未调整的私钥签名（Taproot脚本路径）
+func btcSchnorrSignature(key Signer, signingKey *btcec.PublicKey, hash, tweak []byte, hashType txscript.SigHashType) ([]byte, error) {
+	schnorrSigner, ok := key.(SchnorrSigner)
+	if !ok {
+		return nil, errors.New("签名密钥不支持Schnorr签名，无法签名Taproot输入")
+	}
+	sig, err := schnorrSigner.SignSchnorr(hash, tweak)
 	if err != nil {
 		return nil, err
 	}
+	signature, err := schnorr.ParseSignature(sig)
+	if err != nil {
+		return nil, fmt.Errorf("解析Schnorr签名失败: %v", err)
+	}
+	if !signature.Verify(hash, signingKey) {
+		return nil, errors.New("Schnorr签名与签名密钥的公钥不匹配")
+	}
+	if hashType != txscript.SigHashDefault {
+		sig = append(sig, byte(hashType))
+	}
+	return sig, nil
+}
 
-	return script, nil
+// btcTaprootKeySpendSignature Taproot密钥路径签名，用按内部公钥和脚本树根调整后的私钥签名
+func btcTaprootKeySpendSignature(key Signer, pubKey *btcec.PublicKey, hash, merkleRoot []byte, hashType txscript.SigHashType) ([]byte, error) {
+	tweak := chainhash.TaggedHash(chainhash.TagTapTweak, schnorr.SerializePubKey(pubKey), merkleRoot)
+	outputKey := txscript.ComputeTaprootOutputKey(pubKey, merkleRoot)
+	return btcSchnorrSignature(key, outputKey, hash, tweak[:], hashType)
 }
 
 // VerifyTransactionSignature 验证交易签名是否有效
-// 注意：这是一个简化的验证实现，实际应用中应该使用txscript的VerifyScript函数
+// 假设所有输入都是支付给该公钥的P2PKH输出，使用脚本引擎逐个执行输入脚本
 func (s *BtcTransactionSigner) VerifyTransactionSignature(signedTx, publicKey string) (bool, error) {
-	// TODO: 实现完整的交易签名验证
-	// 这里返回true是为了演示目的
-	return true, nil
-}
\ No newline at end of file
+	// 解析公钥并生成对应的P2PKH锁定脚本
+	publicKeyBytes, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return false, fmt.Errorf("解析公钥失败: %v", err)
+	}
+	if _, err := btcec.ParsePubKey(publicKeyBytes); err != nil {
+		return false, fmt.Errorf("解析公钥失败: %v", err)
+	}
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKeyBytes), btcParamsOrMainNet(s.NetParams))
+	if err != nil {
+		return false, fmt.Errorf("生成地址失败: %v", err)
+	}
+	scriptPubKey, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return false, fmt.Errorf("生成锁定脚本失败: %v", err)
+	}
+
+	// 解析签名后的交易
+	msgTx, err := decodeBtcTx(signedTx)
+	if err != nil {
+		return false, err
+	}
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(msgTx.TxIn))
+	for _, txIn := range msgTx.TxIn {
+		prevOuts[txIn.PreviousOutPoint] = wire.NewTxOut(0, scriptPubKey)
+	}
+
+	return verifyBtcTxInputs(msgTx, prevOuts), nil
+}
+
+// VerifyTransactionInputs 使用原始交易请求中的输入（锁定脚本和金额）验证签名后的交易
+// 适用于任意受支持的地址类型，包括隔离见证和Taproot输入
+func (s *BtcTransactionSigner) VerifyTransactionInputs(signedTx string, inputs []BtcTxInput) (bool, error) {
+	msgTx, err := decodeBtcTx(signedTx)
+	if err != nil {
+		return false, err
+	}
+	if len(inputs) != len(msgTx.TxIn) {
+		return false, fmt.Errorf("输入数量不匹配: 交易%d个, 提供%d个", len(msgTx.TxIn), len(inputs))
+	}
+
+	prevOuts, err := btcPrevOuts(msgTx, inputs)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyBtcTxInputs(msgTx, prevOuts), nil
+}
+
+// decodeBtcTx 解析十六进制序列化的比特币交易
+func decodeBtcTx(txHex string) (*wire.MsgTx, error) {
+	txBytes, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易数据失败: %v", err)
+	}
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	if err := msgTx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, fmt.Errorf("反序列化交易失败: %v", err)
+	}
+	return msgTx, nil
+}
+
+// verifyBtcTxInputs 使用脚本引擎验证交易的所有输入
+// prevOuts 为每个输入所花费的输出（锁定脚本和金额）
+func verifyBtcTxInputs(msgTx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) bool {
+	if len(msgTx.TxIn) == 0 {
+		return false
+	}
+
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(msgTx, fetcher)
+
+	for i, txIn := range msgTx.TxIn {
+		prevOut, ok := prevOuts[txIn.PreviousOutPoint]
+		if !ok {
+			return false
+		}
+		vm, err := txscript.NewEngine(prevOut.PkScript, msgTx, i, txscript.StandardVerifyFlags,
+			nil, sigHashes, prevOut.Value, fetcher)
+		if err != nil {
+			return false
+		}
+		if err := vm.Execute(); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
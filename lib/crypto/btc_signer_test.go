@@ -1,9 +1,15 @@
 package crypto
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/featx/keys-gin/web/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,10 +23,10 @@ func TestBtcTransactionSigner_SignTransaction(t *testing.T) {
 	txReq := BtcTransactionRequest{
 		Inputs: []BtcTxInput{
 			{
-				TxID:        "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
-				Vout:        0,
-				ScriptPubKey: "76a914432a3378b45e636c35242760171a513c50641a8288ac",
-				Amount:      100000000,
+				TxID:         "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
+				Vout:         0,
+				ScriptPubKey: "76a914751e76e8199196d454941c45d1b3a323f1433bd688ac",
+				Amount:       100000000,
 			},
 		},
 		Outputs: []BtcTxOutput{
@@ -35,27 +41,178 @@ func TestBtcTransactionSigner_SignTransaction(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "bitcoin", privateKeyHex))
 
 	// 验证结果
 	assert.NoError(t, err)
 	assert.NotEmpty(t, signedTx)
 	assert.NotEmpty(t, txHash)
-	assert.Contains(t, signedTx, "btc_signed_")
+
+	// 签名结果应为可直接广播的交易
+	msgTx, err := decodeBtcTx(signedTx)
+	assert.NoError(t, err)
+	assert.Equal(t, txHash, msgTx.TxHash().String())
+	assert.Len(t, msgTx.TxOut, 1)
+	assert.Equal(t, "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", hex.EncodeToString(msgTx.TxOut[0].PkScript))
+
+	// 使用脚本引擎验证签名
+	privKeyBytes, _ := hex.DecodeString(privateKeyHex)
+	_, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
+	valid, err := signer.VerifyTransactionSignature(signedTx, hex.EncodeToString(pubKey.SerializeCompressed()))
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestBtcTransactionSigner_VerifyWrongPublicKey(t *testing.T) {
+	signer := &BtcTransactionSigner{}
+	generator := &BtcKeyGenerator{}
+
+	_, publicKey, privateKey, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+	_, otherPublicKey, _, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	publicKeyBytes, _ := hex.DecodeString(publicKey)
+	txReq := BtcTransactionRequest{
+		Inputs: []BtcTxInput{
+			{
+				TxID:         "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
+				Vout:         1,
+				ScriptPubKey: "76a914" + hex.EncodeToString(btcutil.Hash160(publicKeyBytes)) + "88ac",
+				Amount:       100000,
+			},
+		},
+		Outputs: []BtcTxOutput{
+			{
+				ScriptPubKey: "76a914751e76e8199196d454941c45d1b3a323f1433bd688ac",
+				Amount:       90000,
+			},
+		},
+	}
+	rawTx, _ := json.Marshal(txReq)
+
+	signedTx, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "bitcoin", privateKey))
+	assert.NoError(t, err)
+
+	valid, err := signer.VerifyTransactionSignature(signedTx, publicKey)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// 其他公钥无法通过验证
+	valid, err = signer.VerifyTransactionSignature(signedTx, otherPublicKey)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestBtcTransactionSigner_WitnessInputs(t *testing.T) {
+	signer := &BtcTransactionSigner{}
+
+	for _, addressType := range []string{"p2pkh", "p2sh-p2wpkh", "p2wpkh", "p2tr"} {
+		generator, err := NewBtcKeyGenerator(model.ChainTypeBTC, addressType)
+		assert.NoError(t, err)
+		address, _, privateKey, err := generator.GenerateKeyPair()
+		assert.NoError(t, err)
+
+		// 由地址生成被花费输出的锁定脚本
+		addr, err := btcutil.DecodeAddress(address, &chaincfg.MainNetParams)
+		assert.NoError(t, err)
+		scriptPubKey, err := txscript.PayToAddrScript(addr)
+		assert.NoError(t, err)
+
+		inputs := []BtcTxInput{
+			{
+				TxID:         "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
+				Vout:         0,
+				ScriptPubKey: hex.EncodeToString(scriptPubKey),
+				Amount:       100000,
+			},
+			{
+				TxID:         "b1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
+				Vout:         3,
+				ScriptPubKey: hex.EncodeToString(scriptPubKey),
+				Amount:       250000,
+			},
+		}
+		txReq := BtcTransactionRequest{
+			Inputs: inputs,
+			Outputs: []BtcTxOutput{
+				{Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", Amount: 340000},
+			},
+		}
+		rawTx, _ := json.Marshal(txReq)
+
+		signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "bitcoin", privateKey))
+		assert.NoError(t, err, addressType)
+
+		msgTx, err := decodeBtcTx(signedTx)
+		assert.NoError(t, err)
+		assert.Equal(t, txHash, msgTx.TxHash().String())
+		assert.Equal(t, addressType != "p2pkh", msgTx.HasWitness(), addressType)
+
+		valid, err := signer.VerifyTransactionInputs(signedTx, inputs)
+		assert.NoError(t, err)
+		assert.True(t, valid, addressType)
+
+		// 修改输入金额后签名哈希改变（传统P2PKH签名不覆盖金额）
+		if addressType != "p2pkh" {
+			tampered := append([]BtcTxInput(nil), inputs...)
+			tampered[1].Amount++
+			valid, err = signer.VerifyTransactionInputs(signedTx, tampered)
+			assert.NoError(t, err)
+			assert.False(t, valid, addressType)
+		}
+	}
+}
+
+func TestBtcTransactionSigner_Regtest(t *testing.T) {
+	signer := &BtcTransactionSigner{NetParams: &chaincfg.RegressionNetParams}
+	generator, err := NewBtcKeyGenerator(model.ChainTypeBTCRegtest, "p2wpkh")
+	assert.NoError(t, err)
+	address, _, privateKey, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	addr, err := btcutil.DecodeAddress(address, &chaincfg.RegressionNetParams)
+	assert.NoError(t, err)
+	scriptPubKey, err := txscript.PayToAddrScript(addr)
+	assert.NoError(t, err)
+
+	inputs := []BtcTxInput{{
+		TxID:         "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2",
+		ScriptPubKey: hex.EncodeToString(scriptPubKey),
+		Amount:       100000,
+	}}
+
+	// 接收地址为regtest地址时可以签名
+	rawTx, _ := json.Marshal(BtcTransactionRequest{
+		Inputs:  inputs,
+		Outputs: []BtcTxOutput{{Address: address, Amount: 90000}},
+	})
+	signedTx, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "bitcoin", privateKey))
+	assert.NoError(t, err)
+	valid, err := signer.VerifyTransactionInputs(signedTx, inputs)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// 主网地址不能作为regtest交易的接收地址
+	rawTx, _ = json.Marshal(BtcTransactionRequest{
+		Inputs:  inputs,
+		Outputs: []BtcTxOutput{{Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", Amount: 90000}},
+	})
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "bitcoin", privateKey))
+	assert.Error(t, err)
 }
 
 func TestBtcTransactionSigner_InvalidPrivateKey(t *testing.T) {
 	signer := &BtcTransactionSigner{}
 
 	// 无效的私钥
-	privateKeyHex := "invalid_private_key"
-	rawTx := `{"inputs":[],"outputs":[]}`
-
-	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(rawTx, privateKeyHex)
+	_, err := ParsePrivateKey("bitcoin", []byte("invalid_private_key"))
+	assert.Error(t, err)
 
-	// 验证错误
+	// 签名密钥的曲线不正确
+	rawTx := `{"inputs":[],"outputs":[]}`
+	signedTx, txHash, err := signer.SignTransaction(rawTx, testSigningKey(t, "solana", testSolanaPrivateKey))
 	assert.Error(t, err)
 	assert.Empty(t, signedTx)
 	assert.Empty(t, txHash)
-}
\ No newline at end of file
+}
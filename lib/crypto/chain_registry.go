@@ -0,0 +1,175 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// TransactionVerifier 交易签名验证接口
+// 签名器实现该接口时，可以用公钥验证签名后的交易
+type TransactionVerifier interface {
+	VerifyTransaction(rawTx, signedTx, publicKey string) (bool, error)
+}
+
+// ChainModule 链模块，描述一条链的密钥、签名和地址能力
+// 各链在init中通过RegisterChain注册，EVM链由EVM链注册表提供
+type ChainModule struct {
+	ChainType string
+	// Curve 密钥曲线，相同曲线的链可以共用密钥
+	Curve string
+	// Encoding 地址的默认编码方式，为空时取决于生成参数（如Cosmos SDK链的bech32前缀）
+	Encoding string
+	// NewKeyGenerator 创建密钥生成器
+	NewKeyGenerator func() (KeyGenerator, error)
+	// NewTransactionSigner 创建交易签名器，为空时不支持签名
+	NewTransactionSigner func() (TransactionSigner, error)
+	// ParsePrivateKey 将keystore中保存的私钥解析为内存中的签名密钥，为空时按曲线解析十六进制私钥
+	ParsePrivateKey func(privateKey []byte) (Signer, error)
+	// ValidateAddress 校验地址格式及所属网络，为空时不支持地址校验
+	ValidateAddress func(address string) error
+}
+
+// ChainInfo 链的能力描述
+type ChainInfo struct {
+	ChainType       string    `json:"chain_type"`
+	Curve           string    `json:"curve"`
+	Encoding        string    `json:"encoding,omitempty"`
+	Sign            bool      `json:"sign"`
+	Verify          bool      `json:"verify"`
+	ValidateAddress bool      `json:"validate_address"`
+	DerivationPath  string    `json:"derivation_path,omitempty"` // 第一个账户第一个地址的HD派生路径，为空时不支持HD派生
+	Evm             *EvmChain `json:"evm,omitempty"`
+}
+
+var (
+	chainModulesMu sync.RWMutex
+	chainModules   = make(map[string]ChainModule)
+)
+
+// RegisterChain 注册链模块，链类型不能重复，也不能与已注册的EVM链同名
+func RegisterChain(module ChainModule) error {
+	if module.ChainType == "" {
+		return errors.New("chain type is required")
+	}
+	if module.Curve == "" {
+		return fmt.Errorf("curve is required for chain type %s", module.ChainType)
+	}
+	if module.NewKeyGenerator == nil {
+		return fmt.Errorf("key generator is required for chain type %s", module.ChainType)
+	}
+	if IsEvmChain(module.ChainType) {
+		return fmt.Errorf("chain type %s is already registered as an evm chain", module.ChainType)
+	}
+
+	chainModulesMu.Lock()
+	defer chainModulesMu.Unlock()
+	if _, ok := chainModules[module.ChainType]; ok {
+		return fmt.Errorf("chain type %s is already registered", module.ChainType)
+	}
+	chainModules[module.ChainType] = module
+	return nil
+}
+
+// mustRegisterChain 在init中注册内置链模块，注册失败说明代码有误
+func mustRegisterChain(modules ...ChainModule) {
+	for _, module := range modules {
+		if err := RegisterChain(module); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// LookupChain 查找链模块，依次查找注册的链模块和EVM链
+func LookupChain(chainType string) (ChainModule, bool) {
+	chainModulesMu.RLock()
+	module, ok := chainModules[chainType]
+	chainModulesMu.RUnlock()
+	if ok {
+		return module, true
+	}
+
+	if chain, ok := LookupEvmChain(chainType); ok {
+		return evmChainModule(chain), true
+	}
+	return ChainModule{}, false
+}
+
+// Chains 返回所有链的能力描述，按链类型排序
+func Chains() []ChainInfo {
+	chainModulesMu.RLock()
+	infos := make([]ChainInfo, 0, len(chainModules))
+	for _, module := range chainModules {
+		infos = append(infos, module.Info())
+	}
+	chainModulesMu.RUnlock()
+
+	for _, chain := range EvmChains() {
+		info := evmChainModule(chain).Info()
+		info.Evm = &chain
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ChainType < infos[j].ChainType
+	})
+	return infos
+}
+
+// Info 返回链模块的能力描述
+func (m ChainModule) Info() ChainInfo {
+	info := ChainInfo{
+		ChainType:       m.ChainType,
+		Curve:           m.Curve,
+		Encoding:        m.Encoding,
+		ValidateAddress: m.ValidateAddress != nil,
+	}
+	if generator, err := m.NewKeyGenerator(); err == nil {
+		if hdGenerator, ok := generator.(HDKeyGenerator); ok {
+			info.DerivationPath = hdGenerator.DerivationPath(0, 0)
+		}
+	}
+	if m.NewTransactionSigner != nil {
+		if signer, err := m.NewTransactionSigner(); err == nil {
+			_, info.Verify = signer.(TransactionVerifier)
+			info.Sign = true
+		}
+	}
+	return info
+}
+
+// newTransactionSigner 创建链模块的交易签名器
+func (m ChainModule) newTransactionSigner() (TransactionSigner, error) {
+	if m.NewTransactionSigner == nil {
+		return nil, fmt.Errorf("transaction signing is not supported for chain type %s", m.ChainType)
+	}
+	return m.NewTransactionSigner()
+}
+
+// parsePrivateKey 解析链模块的私钥
+func (m ChainModule) parsePrivateKey(privateKey []byte) (Signer, error) {
+	if m.ParsePrivateKey != nil {
+		return m.ParsePrivateKey(privateKey)
+	}
+	switch m.Curve {
+	case CurveSecp256k1:
+		return parseSecp256k1PrivateKey(privateKey)
+	case CurveEd25519:
+		return parseEd25519PrivateKey(privateKey)
+	default:
+		return nil, fmt.Errorf("private key parsing is not supported for chain type %s", m.ChainType)
+	}
+}
+
+// ValidateAddress 校验地址是否为指定链的有效地址
+func ValidateAddress(chainType, address string) error {
+	module, ok := LookupChain(chainType)
+	if !ok {
+		return errors.New("unsupported chain type")
+	}
+	if module.ValidateAddress == nil {
+		return fmt.Errorf("address validation is not supported for chain type %s", chainType)
+	}
+	return module.ValidateAddress(address)
+}
@@ -0,0 +1,157 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/featx/keys-gin/web/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainRegistry_BuiltinChains(t *testing.T) {
+	chainTypes := []string{
+		model.ChainTypeETH, model.ChainTypeBSC, model.ChainTypePolygon, model.ChainTypeAvalanche,
+		model.ChainTypeArbitrum, model.ChainTypeOptimism, model.ChainTypeBase, model.ChainTypeZkSync, model.ChainTypeLinea,
+		model.ChainTypeBTC, model.ChainTypeBTCTestnet, model.ChainTypeBTCSignet, model.ChainTypeBTCRegtest,
+		model.ChainTypeSolana, model.ChainTypeTRON, model.ChainTypeSUI, model.ChainTypeADA, model.ChainTypeADATestnet,
+		model.ChainTypePolkadot, model.ChainTypeKusama, model.ChainTypeSubstrate, model.ChainTypeTON, model.ChainTypeAPTOS,
+		model.ChainTypeCosmos, model.ChainTypeOsmosis, model.ChainTypeCelestia, model.ChainTypeInjective, model.ChainTypeCosmosSDK,
+	}
+
+	infos := make(map[string]ChainInfo)
+	for _, info := range Chains() {
+		infos[info.ChainType] = info
+	}
+
+	// 每条链都注册了曲线、签名器和地址校验
+	for _, chainType := range chainTypes {
+		info, ok := infos[chainType]
+		require.True(t, ok, chainType)
+		assert.NotEmpty(t, info.Curve, chainType)
+		assert.True(t, info.Sign, chainType)
+		assert.True(t, info.ValidateAddress, chainType)
+
+		signer, err := NewTransactionSigner(chainType)
+		assert.NoError(t, err, chainType)
+		assert.NotNil(t, signer, chainType)
+	}
+	assert.Len(t, infos, len(chainTypes))
+
+	// EVM链共用以太坊密钥，并附带链参数
+	assert.Equal(t, "secp256k1", infos[model.ChainTypeBSC].Curve)
+	assert.Equal(t, "ethereum_address", infos[model.ChainTypePolygon].Encoding)
+	require.NotNil(t, infos[model.ChainTypeBase].Evm)
+	assert.Equal(t, uint64(8453), infos[model.ChainTypeBase].Evm.ChainID)
+	assert.Nil(t, infos[model.ChainTypeSolana].Evm)
+
+	assert.Equal(t, "ed25519-bip32", infos[model.ChainTypeADA].Curve)
+	assert.Equal(t, "bech32_osmo", infos[model.ChainTypeOsmosis].Encoding)
+	assert.Empty(t, infos[model.ChainTypeCosmosSDK].Encoding)
+	assert.Empty(t, infos[model.ChainTypeSubstrate].Encoding)
+	assert.True(t, infos[model.ChainTypeTRON].Verify)
+
+	// 支持HD派生的链返回派生路径
+	assert.Equal(t, "m/44'/60'/0'/0/0", infos[model.ChainTypeArbitrum].DerivationPath)
+	assert.Equal(t, "m/44'/0'/0'/0/0", infos[model.ChainTypeBTC].DerivationPath)
+	assert.Equal(t, "m/44'/784'/0'/0'/0'", infos[model.ChainTypeSUI].DerivationPath)
+	assert.Empty(t, infos[model.ChainTypePolkadot].DerivationPath)
+
+	// 未注册的链类型
+	_, ok := LookupChain("unsupported_chain")
+	assert.False(t, ok)
+	_, err := NewKeyGenerator("unsupported_chain")
+	assert.Error(t, err)
+}
+
+func TestChainRegistry_Register(t *testing.T) {
+	newGenerator := func() (KeyGenerator, error) { return &EthKeyGenerator{}, nil }
+
+	// 链类型不能重复，也不能与EVM链同名
+	assert.Error(t, RegisterChain(ChainModule{ChainType: model.ChainTypeSolana, Curve: "ed25519", NewKeyGenerator: newGenerator}))
+	assert.Error(t, RegisterChain(ChainModule{ChainType: model.ChainTypeETH, Curve: "secp256k1", NewKeyGenerator: newGenerator}))
+	assert.Error(t, RegisterEvmChain(EvmChain{Name: model.ChainTypeTRON, ChainID: 728126428}))
+
+	// 曲线和密钥生成器必填
+	assert.Error(t, RegisterChain(ChainModule{ChainType: "test_chain", NewKeyGenerator: newGenerator}))
+	assert.Error(t, RegisterChain(ChainModule{ChainType: "test_chain", Curve: "secp256k1"}))
+
+	// 未注册签名器的链不支持签名
+	require.NoError(t, RegisterChain(ChainModule{ChainType: "test_chain", Curve: "secp256k1", NewKeyGenerator: newGenerator}))
+	t.Cleanup(func() {
+		chainModulesMu.Lock()
+		defer chainModulesMu.Unlock()
+		delete(chainModules, "test_chain")
+	})
+
+	generator, err := NewKeyGenerator("test_chain")
+	assert.NoError(t, err)
+	assert.IsType(t, &EthKeyGenerator{}, generator)
+	_, err = NewTransactionSigner("test_chain")
+	assert.Error(t, err)
+	assert.Error(t, ValidateAddress("test_chain", "0x0000000000000000000000000000000000000000"))
+
+	info, _ := LookupChain("test_chain")
+	assert.False(t, info.Info().Sign)
+	assert.False(t, info.Info().ValidateAddress)
+}
+
+func TestChainRegistry_ValidateAddress(t *testing.T) {
+	// 由密钥生成器生成的地址都能通过校验
+	privateKey := "0000000000000000000000000000000000000000000000000000000000000001"
+	for _, chainType := range []string{model.ChainTypeETH, model.ChainTypeTRON, model.ChainTypeBTCTestnet, model.ChainTypeTON, model.ChainTypeAPTOS, model.ChainTypeCosmos, model.ChainTypeInjective} {
+		generator, err := NewKeyGenerator(chainType)
+		require.NoError(t, err)
+		address, _, err := generator.DeriveKeyPairFromPrivateKey(privateKey)
+		require.NoError(t, err)
+		assert.NoError(t, ValidateAddress(chainType, address), chainType)
+	}
+
+	tests := []struct {
+		chainType string
+		address   string
+		valid     bool
+	}{
+		{model.ChainTypeETH, "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", true},
+		{model.ChainTypeETH, "0x70997970c51812dc3a010c7d01b50e0d17dc79c8", true},
+		{model.ChainTypeETH, "0x70997970c51812dc3A010C7d01b50e0d17dc79C8", false}, // 校验和错误
+		{model.ChainTypeArbitrum, "70997970c51812dc3a010c7d01b50e0d17dc79c8", false},
+		{model.ChainTypeBTC, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", true},
+		{model.ChainTypeBTC, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", true},
+		{model.ChainTypeBTC, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", false}, // 测试网地址
+		{model.ChainTypeBTCTestnet, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", true},
+		{model.ChainTypeSolana, "11111111111111111111111111111111", true},
+		{model.ChainTypeSolana, "0x1", false},
+		{model.ChainTypeTRON, "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HC", true},
+		{model.ChainTypeTRON, "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HD", false},
+		{model.ChainTypeSUI, "0x0000000000000000000000000000000000000000000000000000000000000002", true},
+		{model.ChainTypeSUI, "0x2", false},
+		{model.ChainTypeAPTOS, "0x1", true},
+		{model.ChainTypeADA, "stake1uyevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqxdekzz", true},
+		{model.ChainTypeADA, "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c", false},
+		{model.ChainTypeADA, "stake_test1uqevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqp8n5xl", false},
+		{model.ChainTypeADATestnet, "stake_test1uqevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqp8n5xl", true},
+		{model.ChainTypeCosmos, "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c", true},
+		{model.ChainTypeOsmosis, "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c", false},
+		{model.ChainTypeCosmosSDK, "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateAddress(tt.chainType, tt.address)
+		if tt.valid {
+			assert.NoError(t, err, "%s %s", tt.chainType, tt.address)
+		} else {
+			assert.Error(t, err, "%s %s", tt.chainType, tt.address)
+		}
+	}
+
+	// SS58地址必须使用对应网络的前缀
+	publicKey := make([]byte, 32)
+	polkadotAddress, err := SS58Encode(publicKey, SS58PrefixPolkadot)
+	require.NoError(t, err)
+	kusamaAddress, err := SS58Encode(publicKey, SS58PrefixKusama)
+	require.NoError(t, err)
+	assert.NoError(t, ValidateAddress(model.ChainTypePolkadot, polkadotAddress))
+	assert.Error(t, ValidateAddress(model.ChainTypePolkadot, kusamaAddress))
+	assert.NoError(t, ValidateAddress(model.ChainTypeKusama, kusamaAddress))
+	assert.NoError(t, ValidateAddress(model.ChainTypeSubstrate, kusamaAddress))
+}
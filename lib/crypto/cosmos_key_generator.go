@@ -0,0 +1,222 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/featx/keys-gin/web/model"
+)
+
+// cosmosChain Cosmos SDK链的地址参数
+type cosmosChain struct {
+	hrp          string
+	ethSecp256k1 bool
+}
+
+// cosmosChains 内置的Cosmos SDK链
+var cosmosChains = map[string]cosmosChain{
+	model.ChainTypeCosmos:    {hrp: "cosmos"},
+	model.ChainTypeOsmosis:   {hrp: "osmo"},
+	model.ChainTypeCelestia:  {hrp: "celestia"},
+	model.ChainTypeInjective: {hrp: "inj", ethSecp256k1: true},
+}
+
+func init() {
+	for _, chainType := range []string{model.ChainTypeCosmos, model.ChainTypeOsmosis, model.ChainTypeCelestia, model.ChainTypeInjective, model.ChainTypeCosmosSDK} {
+		chain := cosmosChains[chainType]
+		module := ChainModule{
+			ChainType: chainType,
+			Curve:     "secp256k1",
+			NewKeyGenerator: func() (KeyGenerator, error) {
+				return NewCosmosKeyGenerator(chainType, "")
+			},
+			NewTransactionSigner: func() (TransactionSigner, error) {
+				return &CosmosTransactionSigner{EthSecp256k1: chain.ethSecp256k1}, nil
+			},
+			ValidateAddress: func(address string) error {
+				return validateCosmosAddress(address, chain.hrp)
+			},
+		}
+		// cosmos_sdk的地址编码取决于请求中的bech32前缀
+		if chain.hrp != "" {
+			module.Encoding = CosmosAddressEncoding(chain.hrp)
+		}
+		mustRegisterChain(module)
+	}
+}
+
+// CosmosKeyGenerator Cosmos SDK链密钥生成器
+// 使用secp256k1曲线，地址为bech32编码的账户地址：
+// - secp256k1：RIPEMD-160(SHA-256(压缩公钥))
+// - eth_secp256k1（Injective、Evmos等）：Keccak-256(非压缩公钥)的后20字节，与以太坊地址相同
+type CosmosKeyGenerator struct {
+	HRP          string // bech32地址前缀，如cosmos、osmo
+	EthSecp256k1 bool   // 是否使用以太坊风格的地址
+}
+
+// NewCosmosKeyGenerator 创建指定Cosmos SDK链类型的密钥生成器
+// bech32Prefix 覆盖默认的地址前缀，链类型为cosmos_sdk时必须指定
+func NewCosmosKeyGenerator(chainType, bech32Prefix string) (*CosmosKeyGenerator, error) {
+	chain, ok := cosmosChains[chainType]
+	if !ok && chainType != model.ChainTypeCosmosSDK {
+		return nil, fmt.Errorf("unsupported cosmos chain type: %s", chainType)
+	}
+	if bech32Prefix != "" {
+		if err := validateCosmosHRP(bech32Prefix); err != nil {
+			return nil, err
+		}
+		chain.hrp = bech32Prefix
+	}
+	if chain.hrp == "" {
+		return nil, fmt.Errorf("bech32 prefix is required for chain type %s", chainType)
+	}
+	return &CosmosKeyGenerator{HRP: chain.hrp, EthSecp256k1: chain.ethSecp256k1}, nil
+}
+
+// GenerateKeyPair 生成Cosmos密钥对
+// 公钥为33字节压缩格式
+func (g *CosmosKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey string, err error) {
+	privKey, err := crypto.GenerateKey()
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	privateKey = hex.EncodeToString(crypto.FromECDSA(privKey))
+	address, publicKey, err = g.DeriveKeyPairFromPrivateKey(privateKey)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return address, publicKey, privateKey, nil
+}
+
+// DeriveKeyPairFromPrivateKey 从现有私钥推导公钥和地址
+func (g *CosmosKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (address, publicKey string, err error) {
+	privateKeyBytes, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	privKey, err := crypto.ToECDSA(privateKeyBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid private key format: %w", err)
+	}
+
+	publicKey = hex.EncodeToString(crypto.CompressPubkey(&privKey.PublicKey))
+	address, err = g.encodeAddress(&privKey.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	return address, publicKey, nil
+}
+
+// PublicKeyToAddress 从公钥生成Cosmos地址
+// 支持33字节压缩公钥和65字节非压缩公钥
+func (g *CosmosKeyGenerator) PublicKeyToAddress(publicKey string) (address string, err error) {
+	publicKeyBytes, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	pubKey, err := parseSecp256k1PublicKey(publicKeyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return g.encodeAddress(pubKey)
+}
+
+// DerivationPath 返回BIP-44派生路径 m/44'/118'/account'/0/index，eth_secp256k1链使用以太坊的币种类型60
+func (g *CosmosKeyGenerator) DerivationPath(account, index uint32) string {
+	coinType := CoinTypeCosmos
+	if g.EthSecp256k1 {
+		coinType = CoinTypeEthereum
+	}
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", coinType, account, index)
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按BIP-32派生Cosmos密钥对
+func (g *CosmosKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	return deriveSecp256k1HDKeyPair(g, mnemonic, passphrase, path)
+}
+
+// encodeAddress 计算账户地址并进行bech32编码
+func (g *CosmosKeyGenerator) encodeAddress(pubKey *ecdsa.PublicKey) (string, error) {
+	var addressBytes []byte
+	if g.EthSecp256k1 {
+		addressBytes = crypto.PubkeyToAddress(*pubKey).Bytes()
+	} else {
+		digest := sha256.Sum256(crypto.CompressPubkey(pubKey))
+		addressBytes = Ripemd160(digest[:])
+	}
+
+	converted, err := bech32.ConvertBits(addressBytes, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	address, err := bech32.Encode(g.HRP, converted)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode with bech32: %w", err)
+	}
+
+	return address, nil
+}
+
+// parseSecp256k1PublicKey 解析33字节压缩或65字节非压缩的secp256k1公钥
+func parseSecp256k1PublicKey(publicKeyBytes []byte) (*ecdsa.PublicKey, error) {
+	switch len(publicKeyBytes) {
+	case 33:
+		pubKey, err := crypto.DecompressPubkey(publicKeyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress public key: %w", err)
+		}
+		return pubKey, nil
+	case 65:
+		pubKey, err := crypto.UnmarshalPubkey(publicKeyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key format: %w", err)
+		}
+		return pubKey, nil
+	default:
+		return nil, fmt.Errorf("invalid public key length: %d bytes", len(publicKeyBytes))
+	}
+}
+
+// validateCosmosHRP 校验bech32地址前缀：1~83个小写ASCII可见字符
+func validateCosmosHRP(hrp string) error {
+	if len(hrp) == 0 || len(hrp) > 83 {
+		return fmt.Errorf("invalid bech32 prefix: %s", hrp)
+	}
+	for _, c := range hrp {
+		if c < 33 || c > 126 || (c >= 'A' && c <= 'Z') {
+			return fmt.Errorf("invalid bech32 prefix: %s", hrp)
+		}
+	}
+	return nil
+}
+
+// CosmosAddressEncoding 根据bech32地址前缀获取Cosmos SDK链的地址编码方式
+func CosmosAddressEncoding(hrp string) string {
+	return "bech32_" + hrp
+}
+
+// validateCosmosAddress 校验bech32账户地址（20或32字节），hrp为空时接受任意前缀
+func validateCosmosAddress(address, hrp string) error {
+	decodedHRP, data, err := bech32.Decode(address)
+	if err != nil {
+		return fmt.Errorf("invalid bech32 address: %w", err)
+	}
+	if hrp != "" && decodedHRP != hrp {
+		return fmt.Errorf("address prefix %s does not match %s", decodedHRP, hrp)
+	}
+	decoded, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil || (len(decoded) != 20 && len(decoded) != 32) {
+		return fmt.Errorf("invalid bech32 address: %s", address)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/featx/keys-gin/web/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeTestCosmosAddress 解码bech32地址，返回前缀和账户地址字节
+func decodeTestCosmosAddress(t *testing.T, address string) (string, string) {
+	hrp, data, err := bech32.Decode(address)
+	require.NoError(t, err)
+	converted, err := bech32.ConvertBits(data, 5, 8, false)
+	require.NoError(t, err)
+	return hrp, hex.EncodeToString(converted)
+}
+
+// CosmosKeyGenerator 测试用例
+func TestCosmosKeyGenerator_GenerateKeyPair(t *testing.T) {
+	generator, err := NewCosmosKeyGenerator(model.ChainTypeCosmos, "")
+	require.NoError(t, err)
+
+	address, publicKey, privateKey, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(address, "cosmos1"))
+	assert.Equal(t, 66, len(publicKey)) // 33字节压缩公钥
+	assert.Equal(t, 64, len(privateKey))
+
+	// 从私钥派生的结果一致
+	derivedAddress, derivedPublicKey, err := generator.DeriveKeyPairFromPrivateKey(privateKey)
+	assert.NoError(t, err)
+	assert.Equal(t, address, derivedAddress)
+	assert.Equal(t, publicKey, derivedPublicKey)
+}
+
+func TestCosmosKeyGenerator_KnownAddress(t *testing.T) {
+	privateKey := "0000000000000000000000000000000000000000000000000000000000000001"
+
+	tests := []struct {
+		chainType   string
+		prefix      string
+		addressHex  string
+		expectedHRP string
+	}{
+		// RIPEMD-160(SHA-256(压缩公钥))
+		{model.ChainTypeCosmos, "", "751e76e8199196d454941c45d1b3a323f1433bd6", "cosmos"},
+		{model.ChainTypeOsmosis, "", "751e76e8199196d454941c45d1b3a323f1433bd6", "osmo"},
+		{model.ChainTypeCelestia, "", "751e76e8199196d454941c45d1b3a323f1433bd6", "celestia"},
+		{model.ChainTypeCosmosSDK, "juno", "751e76e8199196d454941c45d1b3a323f1433bd6", "juno"},
+		// 与以太坊地址 0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf 相同
+		{model.ChainTypeInjective, "", "7e5f4552091a69125d5dfcb7b8c2659029395bdf", "inj"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedHRP, func(t *testing.T) {
+			generator, err := NewCosmosKeyGenerator(tt.chainType, tt.prefix)
+			require.NoError(t, err)
+
+			address, publicKey, err := generator.DeriveKeyPairFromPrivateKey(privateKey)
+			require.NoError(t, err)
+			assert.Equal(t, "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", publicKey)
+
+			hrp, addressHex := decodeTestCosmosAddress(t, address)
+			assert.Equal(t, tt.expectedHRP, hrp)
+			assert.Equal(t, tt.addressHex, addressHex)
+
+			// 压缩和非压缩公钥得到相同的地址
+			fromPublicKey, err := generator.PublicKeyToAddress(publicKey)
+			assert.NoError(t, err)
+			assert.Equal(t, address, fromPublicKey)
+			fromUncompressed, err := generator.PublicKeyToAddress("0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")
+			assert.NoError(t, err)
+			assert.Equal(t, address, fromUncompressed)
+		})
+	}
+}
+
+func TestNewCosmosKeyGenerator_Invalid(t *testing.T) {
+	// 通用Cosmos SDK链必须指定前缀
+	_, err := NewCosmosKeyGenerator(model.ChainTypeCosmosSDK, "")
+	assert.Error(t, err)
+
+	// 前缀不能包含大写字母
+	_, err = NewCosmosKeyGenerator(model.ChainTypeCosmosSDK, "Juno")
+	assert.Error(t, err)
+
+	// 非Cosmos链类型
+	_, err = NewCosmosKeyGenerator(model.ChainTypeETH, "")
+	assert.Error(t, err)
+
+	generator, err := NewCosmosKeyGenerator(model.ChainTypeCosmos, "")
+	require.NoError(t, err)
+	_, err = generator.PublicKeyToAddress("0279be")
+	assert.Error(t, err)
+}
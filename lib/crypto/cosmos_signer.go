@@ -0,0 +1,348 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Cosmos SDK签名模式
+const (
+	// CosmosSignModeDirect SIGN_MODE_DIRECT，对protobuf编码的SignDoc签名
+	CosmosSignModeDirect = "direct"
+	// CosmosSignModeAminoJSON SIGN_MODE_LEGACY_AMINO_JSON，对按键排序的StdSignDoc JSON签名
+	CosmosSignModeAminoJSON = "amino_json"
+)
+
+// CosmosTransactionRequest Cosmos SDK交易签名请求
+// body_bytes、auth_info_bytes 为Base64编码的protobuf TxBody和AuthInfo（与cosmjs、gRPC网关的JSON格式一致）
+type CosmosTransactionRequest struct {
+	SignMode      string          `json:"sign_mode,omitempty"` // direct（默认）或 amino_json
+	BodyBytes     string          `json:"body_bytes"`
+	AuthInfoBytes string          `json:"auth_info_bytes"`
+	ChainID       string          `json:"chain_id"`
+	AccountNumber *TextBigInt     `json:"account_number"`
+	SignDoc       json.RawMessage `json:"sign_doc,omitempty"`   // amino_json模式下的StdSignDoc，auth_info中的签名模式应为LEGACY_AMINO_JSON
+	Signatures    []string        `json:"signatures,omitempty"` // 其他签名者已有的签名（Base64），按auth_info中signer_infos的顺序
+}
+
+// CosmosTransactionSigner Cosmos SDK交易签名器
+// secp256k1密钥对签名数据的SHA-256签名（64字节 r || s）；
+// eth_secp256k1密钥（Injective等）对Keccak-256签名（65字节 r || s || v），auth_info中声明ethsecp256k1公钥时自动使用
+type CosmosTransactionSigner struct {
+	EthSecp256k1 bool
+}
+
+// cosmosSignerInfo auth_info中签名者的公钥
+type cosmosSignerInfo struct {
+	typeURL   string
+	publicKey []byte
+}
+
+// SignTransaction 签名Cosmos SDK交易
+// 返回Base64编码的protobuf TxRaw（可直接用于BroadcastTx的tx_bytes），交易哈希为TxRaw的SHA-256（大写十六进制）
+func (s *CosmosTransactionSigner) SignTransaction(rawTx string, signer Signer) (signedTx string, txHash string, err error) {
+	txReq, signBytes, err := parseCosmosTransactionRequest(rawTx)
+	if err != nil {
+		return "", "", err
+	}
+
+	publicKey, err := secp256k1PublicKey(signer)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 确定签名在signer_infos中的位置和密钥类型
+	body, authInfo, err := decodeCosmosTxBytes(txReq)
+	if err != nil {
+		return "", "", err
+	}
+	index, ethSecp256k1, err := s.signerIndex(authInfo, publicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	signature, err := signRecoverable(signer, cosmosSignDigest(signBytes, ethSecp256k1))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	if !ethSecp256k1 {
+		signature = signature[:64]
+	}
+
+	// 按signer_infos的顺序放置签名
+	signatures := make([][]byte, len(txReq.Signatures))
+	for i, encoded := range txReq.Signatures {
+		if signatures[i], err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return "", "", fmt.Errorf("invalid signature format: %w", err)
+		}
+	}
+	for len(signatures) <= index {
+		signatures = append(signatures, nil)
+	}
+	signatures[index] = signature
+
+	txRaw := encodeCosmosTxRaw(body, authInfo, signatures)
+	hash := sha256.Sum256(txRaw)
+	return base64.StdEncoding.EncodeToString(txRaw), strings.ToUpper(hex.EncodeToString(hash[:])), nil
+}
+
+// VerifyTransaction 验证Cosmos SDK交易签名
+// signedTx 为Base64编码的TxRaw，交易体和auth_info必须与rawTx一致，且该公钥在signer_infos中对应位置的签名有效
+func (s *CosmosTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex string) (bool, error) {
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return false, fmt.Errorf("invalid public key format: %w", err)
+	}
+	pubKey, err := parseSecp256k1PublicKey(publicKeyBytes)
+	if err != nil {
+		return false, err
+	}
+
+	txReq, signBytes, err := parseCosmosTransactionRequest(rawTx)
+	if err != nil {
+		return false, err
+	}
+	expectedBody, expectedAuthInfo, err := decodeCosmosTxBytes(txReq)
+	if err != nil {
+		return false, err
+	}
+
+	txRaw, err := base64.StdEncoding.DecodeString(signedTx)
+	if err != nil {
+		return false, fmt.Errorf("invalid signed transaction format: %w", err)
+	}
+	body, authInfo, signatures, err := decodeCosmosTxRaw(txRaw)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(body, expectedBody) || !bytes.Equal(authInfo, expectedAuthInfo) {
+		return false, nil
+	}
+
+	index, ethSecp256k1, err := s.signerIndex(authInfo, pubKey)
+	if err != nil || index >= len(signatures) || len(signatures[index]) < 64 {
+		return false, nil
+	}
+	return crypto.VerifySignature(crypto.CompressPubkey(pubKey), cosmosSignDigest(signBytes, ethSecp256k1), signatures[index][:64]), nil
+}
+
+// signerIndex 查找公钥在auth_info的signer_infos中的位置，并判断是否为eth_secp256k1密钥
+// auth_info未声明签名者时位置为0
+func (s *CosmosTransactionSigner) signerIndex(authInfo []byte, pubKey *ecdsa.PublicKey) (int, bool, error) {
+	signerInfos, err := parseCosmosSignerInfos(authInfo)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(signerInfos) == 0 {
+		return 0, s.EthSecp256k1, nil
+	}
+
+	compressed := crypto.CompressPubkey(pubKey)
+	for i, info := range signerInfos {
+		if bytes.Equal(info.publicKey, compressed) {
+			return i, s.EthSecp256k1 || strings.Contains(strings.ToLower(info.typeURL), "ethsecp256k1"), nil
+		}
+	}
+	return 0, false, errors.New("public key not found in auth_info signer_infos")
+}
+
+// parseCosmosTransactionRequest 解析签名请求，返回待签名的数据
+func parseCosmosTransactionRequest(rawTx string) (*CosmosTransactionRequest, []byte, error) {
+	var txReq CosmosTransactionRequest
+	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+		return nil, nil, fmt.Errorf("invalid transaction data format: %w", err)
+	}
+
+	switch txReq.SignMode {
+	case "", CosmosSignModeDirect:
+		body, authInfo, err := decodeCosmosTxBytes(&txReq)
+		if err != nil {
+			return nil, nil, err
+		}
+		var accountNumber uint64
+		if txReq.AccountNumber != nil {
+			if !txReq.AccountNumber.ToBigInt().IsUint64() {
+				return nil, nil, errors.New("invalid account_number")
+			}
+			accountNumber = txReq.AccountNumber.ToBigInt().Uint64()
+		}
+		return &txReq, cosmosSignDocBytes(body, authInfo, txReq.ChainID, accountNumber), nil
+	case CosmosSignModeAminoJSON:
+		signBytes, err := cosmosAminoSignBytes(txReq.SignDoc)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &txReq, signBytes, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported sign mode: %s", txReq.SignMode)
+	}
+}
+
+// decodeCosmosTxBytes 解码Base64编码的TxBody和AuthInfo
+func decodeCosmosTxBytes(txReq *CosmosTransactionRequest) (body, authInfo []byte, err error) {
+	if txReq.BodyBytes == "" || txReq.AuthInfoBytes == "" {
+		return nil, nil, errors.New("body_bytes and auth_info_bytes are required")
+	}
+	if body, err = base64.StdEncoding.DecodeString(txReq.BodyBytes); err != nil {
+		return nil, nil, fmt.Errorf("invalid body_bytes: %w", err)
+	}
+	if authInfo, err = base64.StdEncoding.DecodeString(txReq.AuthInfoBytes); err != nil {
+		return nil, nil, fmt.Errorf("invalid auth_info_bytes: %w", err)
+	}
+	return body, authInfo, nil
+}
+
+// cosmosSignDocBytes 按protobuf编码SignDoc{body_bytes = 1, auth_info_bytes = 2, chain_id = 3, account_number = 4}
+// 与proto3一致，省略默认值字段
+func cosmosSignDocBytes(body, authInfo []byte, chainID string, accountNumber uint64) []byte {
+	var signDoc []byte
+	if len(body) > 0 {
+		signDoc = protowire.AppendTag(signDoc, 1, protowire.BytesType)
+		signDoc = protowire.AppendBytes(signDoc, body)
+	}
+	if len(authInfo) > 0 {
+		signDoc = protowire.AppendTag(signDoc, 2, protowire.BytesType)
+		signDoc = protowire.AppendBytes(signDoc, authInfo)
+	}
+	if chainID != "" {
+		signDoc = protowire.AppendTag(signDoc, 3, protowire.BytesType)
+		signDoc = protowire.AppendString(signDoc, chainID)
+	}
+	if accountNumber != 0 {
+		signDoc = protowire.AppendTag(signDoc, 4, protowire.VarintType)
+		signDoc = protowire.AppendVarint(signDoc, accountNumber)
+	}
+	return signDoc
+}
+
+// cosmosAminoSignBytes 将StdSignDoc序列化为键按字母排序、无空白的JSON，
+// 并转义 <、>、&（与Cosmos SDK的MustSortJSON和cosmjs的serializeSignDoc一致）
+func cosmosAminoSignBytes(signDoc json.RawMessage) ([]byte, error) {
+	if len(signDoc) == 0 {
+		return nil, errors.New("sign_doc is required for amino_json sign mode")
+	}
+
+	// 使用json.Number保留数字的原始表示
+	decoder := json.NewDecoder(bytes.NewReader(signDoc))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid sign_doc: %w", err)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, errors.New("invalid sign_doc: expected a JSON object")
+	}
+
+	// encoding/json按键排序输出map
+	return json.Marshal(doc)
+}
+
+// cosmosSignDigest 计算签名摘要：secp256k1为SHA-256，eth_secp256k1为Keccak-256
+func cosmosSignDigest(signBytes []byte, ethSecp256k1 bool) []byte {
+	if ethSecp256k1 {
+		return crypto.Keccak256(signBytes)
+	}
+	digest := sha256.Sum256(signBytes)
+	return digest[:]
+}
+
+// encodeCosmosTxRaw 按protobuf编码TxRaw{body_bytes = 1, auth_info_bytes = 2, repeated signatures = 3}
+func encodeCosmosTxRaw(body, authInfo []byte, signatures [][]byte) []byte {
+	var txRaw []byte
+	txRaw = protowire.AppendTag(txRaw, 1, protowire.BytesType)
+	txRaw = protowire.AppendBytes(txRaw, body)
+	txRaw = protowire.AppendTag(txRaw, 2, protowire.BytesType)
+	txRaw = protowire.AppendBytes(txRaw, authInfo)
+	for _, signature := range signatures {
+		txRaw = protowire.AppendTag(txRaw, 3, protowire.BytesType)
+		txRaw = protowire.AppendBytes(txRaw, signature)
+	}
+	return txRaw
+}
+
+// decodeCosmosTxRaw 解码protobuf编码的TxRaw
+func decodeCosmosTxRaw(data []byte) (body, authInfo []byte, signatures [][]byte, err error) {
+	fields, err := cosmosBytesFields(data)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid TxRaw: %w", err)
+	}
+	if len(fields[1]) != 1 || len(fields[2]) != 1 {
+		return nil, nil, nil, errors.New("invalid TxRaw: missing body_bytes or auth_info_bytes")
+	}
+	return fields[1][0], fields[2][0], fields[3], nil
+}
+
+// parseCosmosSignerInfos 解析AuthInfo中signer_infos（字段1）的公钥
+// SignerInfo.public_key（字段1）为Any{type_url = 1, value = 2}，单密钥公钥的value为PubKey{key = 1}；
+// 多签等其他公钥类型只记录type_url
+func parseCosmosSignerInfos(authInfo []byte) ([]cosmosSignerInfo, error) {
+	fields, err := cosmosBytesFields(authInfo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth_info: %w", err)
+	}
+
+	signerInfos := make([]cosmosSignerInfo, 0, len(fields[1]))
+	for _, signerInfo := range fields[1] {
+		signerFields, err := cosmosBytesFields(signerInfo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signer_info: %w", err)
+		}
+
+		var info cosmosSignerInfo
+		if len(signerFields[1]) > 0 {
+			anyFields, err := cosmosBytesFields(signerFields[1][0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid signer public key: %w", err)
+			}
+			if len(anyFields[1]) > 0 {
+				info.typeURL = string(anyFields[1][0])
+			}
+			if len(anyFields[2]) > 0 {
+				if keyFields, err := cosmosBytesFields(anyFields[2][0]); err == nil && len(keyFields[1]) > 0 {
+					info.publicKey = keyFields[1][0]
+				}
+			}
+		}
+		signerInfos = append(signerInfos, info)
+	}
+	return signerInfos, nil
+}
+
+// cosmosBytesFields 解析protobuf消息中长度前缀类型的字段，按字段号分组，跳过其他类型的字段
+func cosmosBytesFields(data []byte) (map[protowire.Number][][]byte, error) {
+	fields := make(map[protowire.Number][][]byte)
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if wireType == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			fields[number] = append(fields[number], value)
+			data = data[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(number, wireType, data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	return fields, nil
+}
@@ -0,0 +1,252 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	testCosmosPrivateKey      = "0000000000000000000000000000000000000000000000000000000000000001"
+	testCosmosOtherPrivateKey = "0000000000000000000000000000000000000000000000000000000000000002"
+	testCosmosPubKeyTypeURL   = "/cosmos.crypto.secp256k1.PubKey"
+	testInjectivePubKeyType   = "/injective.crypto.v1beta1.ethsecp256k1.PubKey"
+)
+
+// appendTestProtoBytes 追加长度前缀类型的protobuf字段
+func appendTestProtoBytes(b []byte, number protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, number, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+// appendTestProtoAny 追加google.protobuf.Any字段
+func appendTestProtoAny(b []byte, number protowire.Number, typeURL string, value []byte) []byte {
+	var anyMsg []byte
+	anyMsg = appendTestProtoBytes(anyMsg, 1, []byte(typeURL))
+	anyMsg = appendTestProtoBytes(anyMsg, 2, value)
+	return appendTestProtoBytes(b, number, anyMsg)
+}
+
+// testCosmosPublicKey 返回测试私钥的压缩公钥
+func testCosmosPublicKey(t *testing.T, privateKeyHex string) []byte {
+	privKey, err := crypto.HexToECDSA(privateKeyHex)
+	require.NoError(t, err)
+	return crypto.CompressPubkey(&privKey.PublicKey)
+}
+
+// newTestCosmosTx 构建一笔MsgSend交易的TxBody和AuthInfo
+// signers 中每个签名者使用的公钥类型相同，签名模式为 signMode
+func newTestCosmosTx(typeURL string, signMode uint64, signers ...[]byte) (body, authInfo []byte) {
+	var coin []byte
+	coin = appendTestProtoBytes(coin, 1, []byte("uatom"))
+	coin = appendTestProtoBytes(coin, 2, []byte("1000"))
+
+	var msgSend []byte
+	msgSend = appendTestProtoBytes(msgSend, 1, []byte("cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c"))
+	msgSend = appendTestProtoBytes(msgSend, 2, []byte("cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c"))
+	msgSend = appendTestProtoBytes(msgSend, 3, coin)
+
+	body = appendTestProtoAny(body, 1, "/cosmos.bank.v1beta1.MsgSend", msgSend)
+	body = appendTestProtoBytes(body, 2, []byte("memo"))
+
+	for i, signer := range signers {
+		var single []byte
+		single = protowire.AppendTag(single, 1, protowire.VarintType)
+		single = protowire.AppendVarint(single, signMode)
+		modeInfo := appendTestProtoBytes(nil, 1, single)
+
+		signerInfo := appendTestProtoAny(nil, 1, typeURL, appendTestProtoBytes(nil, 1, signer))
+		signerInfo = appendTestProtoBytes(signerInfo, 2, modeInfo)
+		signerInfo = protowire.AppendTag(signerInfo, 3, protowire.VarintType)
+		signerInfo = protowire.AppendVarint(signerInfo, uint64(i+7))
+		authInfo = appendTestProtoBytes(authInfo, 1, signerInfo)
+	}
+
+	var fee []byte
+	fee = appendTestProtoBytes(fee, 1, coin)
+	fee = protowire.AppendTag(fee, 2, protowire.VarintType)
+	fee = protowire.AppendVarint(fee, 200000)
+	authInfo = appendTestProtoBytes(authInfo, 2, fee)
+	return body, authInfo
+}
+
+// newTestCosmosRequest 构建SIGN_MODE_DIRECT签名请求
+func newTestCosmosRequest(body, authInfo []byte) CosmosTransactionRequest {
+	return CosmosTransactionRequest{
+		BodyBytes:     base64.StdEncoding.EncodeToString(body),
+		AuthInfoBytes: base64.StdEncoding.EncodeToString(authInfo),
+		ChainID:       "cosmoshub-4",
+		AccountNumber: (*TextBigInt)(big.NewInt(12345)),
+	}
+}
+
+// signTestCosmosTransaction 签名并验证交易，返回解码后的TxRaw
+func signTestCosmosTransaction(t *testing.T, signer *CosmosTransactionSigner, txReq CosmosTransactionRequest, privateKeyHex string) (body, authInfo []byte, signatures [][]byte) {
+	rawTx, err := json.Marshal(txReq)
+	require.NoError(t, err)
+
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "cosmos", privateKeyHex))
+	require.NoError(t, err)
+
+	// 交易哈希为TxRaw的SHA-256
+	txRaw, err := base64.StdEncoding.DecodeString(signedTx)
+	require.NoError(t, err)
+	hash := sha256.Sum256(txRaw)
+	assert.Equal(t, strings.ToUpper(hex.EncodeToString(hash[:])), txHash)
+
+	// 签名可以通过公钥验证
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, hex.EncodeToString(testCosmosPublicKey(t, privateKeyHex)))
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	body, authInfo, signatures, err = decodeCosmosTxRaw(txRaw)
+	require.NoError(t, err)
+	return body, authInfo, signatures
+}
+
+func TestCosmosSignDocBytes(t *testing.T) {
+	// SignDoc{body_bytes = 1, auth_info_bytes = 2, chain_id = 3, account_number = 4}
+	signDoc := cosmosSignDocBytes([]byte{0x01}, []byte{0x02}, "cosmoshub-4", 300)
+	assert.Equal(t, "0a0101120102"+"1a0b636f736d6f736875622d34"+"20ac02", hex.EncodeToString(signDoc))
+
+	// 省略默认值
+	assert.Equal(t, "0a0101120102", hex.EncodeToString(cosmosSignDocBytes([]byte{0x01}, []byte{0x02}, "", 0)))
+}
+
+func TestCosmosTransactionSigner_SignDirect(t *testing.T) {
+	body, authInfo := newTestCosmosTx(testCosmosPubKeyTypeURL, 1, testCosmosPublicKey(t, testCosmosPrivateKey))
+	txReq := newTestCosmosRequest(body, authInfo)
+
+	signer := &CosmosTransactionSigner{}
+	signedBody, signedAuthInfo, signatures := signTestCosmosTransaction(t, signer, txReq, testCosmosPrivateKey)
+	assert.Equal(t, body, signedBody)
+	assert.Equal(t, authInfo, signedAuthInfo)
+	require.Len(t, signatures, 1)
+	assert.Len(t, signatures[0], 64)
+
+	// 对SignDoc的SHA-256签名
+	digest := sha256.Sum256(cosmosSignDocBytes(body, authInfo, "cosmoshub-4", 12345))
+	assert.True(t, crypto.VerifySignature(testCosmosPublicKey(t, testCosmosPrivateKey), digest[:], signatures[0]))
+
+	// 链ID不同则签名无效
+	rawTx, _ := json.Marshal(txReq)
+	signedTx, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "cosmos", testCosmosPrivateKey))
+	require.NoError(t, err)
+	txReq.ChainID = "theta-testnet-001"
+	otherRawTx, _ := json.Marshal(txReq)
+	valid, err := signer.VerifyTransaction(string(otherRawTx), signedTx, hex.EncodeToString(testCosmosPublicKey(t, testCosmosPrivateKey)))
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestCosmosTransactionSigner_MultipleSigners(t *testing.T) {
+	first := testCosmosPublicKey(t, testCosmosOtherPrivateKey)
+	second := testCosmosPublicKey(t, testCosmosPrivateKey)
+	body, authInfo := newTestCosmosTx(testCosmosPubKeyTypeURL, 1, first, second)
+
+	// 第一个签名者已签名，签名放在signer_infos中对应的位置
+	existing := make([]byte, 64)
+	existing[0] = 0xaa
+	txReq := newTestCosmosRequest(body, authInfo)
+	txReq.Signatures = []string{base64.StdEncoding.EncodeToString(existing)}
+
+	_, _, signatures := signTestCosmosTransaction(t, &CosmosTransactionSigner{}, txReq, testCosmosPrivateKey)
+	require.Len(t, signatures, 2)
+	assert.Equal(t, existing, signatures[0])
+	assert.Len(t, signatures[1], 64)
+
+	// 不在signer_infos中的公钥无法签名
+	body, authInfo = newTestCosmosTx(testCosmosPubKeyTypeURL, 1, first)
+	rawTx, _ := json.Marshal(newTestCosmosRequest(body, authInfo))
+	_, _, err := (&CosmosTransactionSigner{}).SignTransaction(string(rawTx), testSigningKey(t, "cosmos", testCosmosPrivateKey))
+	assert.Error(t, err)
+}
+
+func TestCosmosTransactionSigner_EthSecp256k1(t *testing.T) {
+	body, authInfo := newTestCosmosTx(testInjectivePubKeyType, 1, testCosmosPublicKey(t, testCosmosPrivateKey))
+	txReq := newTestCosmosRequest(body, authInfo)
+	txReq.ChainID = "injective-1"
+
+	// auth_info声明ethsecp256k1公钥时使用Keccak-256，签名为65字节
+	_, _, signatures := signTestCosmosTransaction(t, &CosmosTransactionSigner{}, txReq, testCosmosPrivateKey)
+	require.Len(t, signatures, 1)
+	assert.Len(t, signatures[0], 65)
+
+	digest := crypto.Keccak256(cosmosSignDocBytes(body, authInfo, "injective-1", 12345))
+	recovered, err := crypto.SigToPub(digest, signatures[0])
+	require.NoError(t, err)
+	assert.Equal(t, testCosmosPublicKey(t, testCosmosPrivateKey), crypto.CompressPubkey(recovered))
+
+	// 签名器指定eth_secp256k1时同样适用于未声明签名者的auth_info
+	body, authInfo = newTestCosmosTx(testInjectivePubKeyType, 1)
+	_, _, signatures = signTestCosmosTransaction(t, &CosmosTransactionSigner{EthSecp256k1: true}, newTestCosmosRequest(body, authInfo), testCosmosPrivateKey)
+	require.Len(t, signatures, 1)
+	assert.Len(t, signatures[0], 65)
+}
+
+func TestCosmosTransactionSigner_SignAminoJSON(t *testing.T) {
+	// 键的顺序和空白不影响签名数据
+	signDoc := `{
+		"chain_id": "cosmoshub-4",
+		"account_number": "12345",
+		"sequence": "7",
+		"fee": {"gas": "200000", "amount": [{"denom": "uatom", "amount": "1000"}]},
+		"msgs": [{"type": "cosmos-sdk/MsgSend", "value": {"from_address": "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c", "to_address": "cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c", "amount": [{"denom": "uatom", "amount": "1000"}]}}],
+		"memo": "<memo>"
+	}`
+	signBytes, err := cosmosAminoSignBytes(json.RawMessage(signDoc))
+	require.NoError(t, err)
+	// 键按字母排序，<、>、& 转义为\u003c、\u003e、\u0026
+	assert.Equal(t, `{"account_number":"12345","chain_id":"cosmoshub-4","fee":{"amount":[{"amount":"1000","denom":"uatom"}],"gas":"200000"},"memo":"\u003cmemo\u003e","msgs":[{"type":"cosmos-sdk/MsgSend","value":{"amount":[{"amount":"1000","denom":"uatom"}],"from_address":"cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c","to_address":"cosmos1w508d6qejxtdg4y5r3zarvary0c5xw7k6ah60c"}}],"sequence":"7"}`, string(signBytes))
+
+	// SIGN_MODE_LEGACY_AMINO_JSON = 127
+	body, authInfo := newTestCosmosTx(testCosmosPubKeyTypeURL, 127, testCosmosPublicKey(t, testCosmosPrivateKey))
+	txReq := newTestCosmosRequest(body, authInfo)
+	txReq.SignMode = CosmosSignModeAminoJSON
+	txReq.SignDoc = json.RawMessage(signDoc)
+
+	_, _, signatures := signTestCosmosTransaction(t, &CosmosTransactionSigner{}, txReq, testCosmosPrivateKey)
+	require.Len(t, signatures, 1)
+	digest := sha256.Sum256(signBytes)
+	assert.True(t, crypto.VerifySignature(testCosmosPublicKey(t, testCosmosPrivateKey), digest[:], signatures[0]))
+}
+
+func TestCosmosTransactionSigner_InvalidRequest(t *testing.T) {
+	signer := &CosmosTransactionSigner{}
+	body, authInfo := newTestCosmosTx(testCosmosPubKeyTypeURL, 1, testCosmosPublicKey(t, testCosmosPrivateKey))
+
+	tests := []struct {
+		name   string
+		modify func(*CosmosTransactionRequest)
+	}{
+		{"missing body", func(r *CosmosTransactionRequest) { r.BodyBytes = "" }},
+		{"invalid base64", func(r *CosmosTransactionRequest) { r.AuthInfoBytes = "%%" }},
+		{"unsupported sign mode", func(r *CosmosTransactionRequest) { r.SignMode = "textual" }},
+		{"missing amino sign doc", func(r *CosmosTransactionRequest) { r.SignMode = CosmosSignModeAminoJSON }},
+		{"invalid auth info", func(r *CosmosTransactionRequest) {
+			r.AuthInfoBytes = base64.StdEncoding.EncodeToString([]byte{0x0a, 0x05})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txReq := newTestCosmosRequest(body, authInfo)
+			tt.modify(&txReq)
+			rawTx, _ := json.Marshal(txReq)
+			_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "cosmos", testCosmosPrivateKey))
+			assert.Error(t, err)
+		})
+	}
+
+	_, _, err := signer.SignTransaction("invalid json", testSigningKey(t, "cosmos", testCosmosPrivateKey))
+	assert.Error(t, err)
+}
@@ -4,7 +4,9 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -89,4 +91,26 @@ func (g *EthKeyGenerator) PublicKeyToAddress(publicKey string) (address string,
 	address = crypto.PubkeyToAddress(*key).Hex()
 
 	return address, nil
-}
\ No newline at end of file
+}
+
+// DerivationPath 返回BIP-44派生路径 m/44'/60'/account'/0/index
+func (g *EthKeyGenerator) DerivationPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", CoinTypeEthereum, account, index)
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按BIP-32派生以太坊密钥对
+func (g *EthKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	return deriveSecp256k1HDKeyPair(g, mnemonic, passphrase, path)
+}
+
+// validateEthAddress 校验以太坊地址：0x开头的20字节十六进制，大小写混合时必须符合EIP-55校验和
+func validateEthAddress(address string) error {
+	if !strings.HasPrefix(address, "0x") || !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid ethereum address: %s", address)
+	}
+	body := address[2:]
+	if body != strings.ToLower(body) && body != strings.ToUpper(body) && common.HexToAddress(address).Hex() != address {
+		return fmt.Errorf("invalid ethereum address checksum: %s", address)
+	}
+	return nil
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // TextBigInt 是big.Int的自定义类型，支持从多种格式解析JSON
@@ -105,21 +104,13 @@ type EthTransactionRequest struct {
 	ChainID            *TextBigInt `json:"chainId"` // 使用TextBigInt支持多种格式解析
 }
 // EthTransactionSigner 以太坊交易签名器
-type EthTransactionSigner struct{}
+// Chain 为密钥所属的EVM链，非空时交易的chainId必须与之一致，且不支持EIP-1559的链只能签名Legacy交易
+type EthTransactionSigner struct {
+	Chain *EvmChain
+}
 
 // SignTransaction 签名以太坊交易
-func (s *EthTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (signedTx string, txHash string, err error) {
-	// 解码私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
-	}
-
-	privateKey, err := crypto.ToECDSA(privateKeyBytes)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid private key: %w", err)
-	}
-
+func (s *EthTransactionSigner) SignTransaction(rawTx string, keySigner Signer) (signedTx string, txHash string, err error) {
 	// 解析交易参数，TextBigInt类型会自动处理多种格式的数值
 	var txReq EthTransactionRequest
 	if err = json.Unmarshal([]byte(rawTx), &txReq); err != nil {
@@ -145,6 +136,16 @@ func (s *EthTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (sig
 		return "", "", errors.New("either gasPrice (for legacy tx) or maxPriorityFeePerGas and maxFeePerGas (for EIP-1559 tx) is required")
 	}
 
+	// 校验交易的网络与密钥所属的链一致，防止跨链重放
+	if s.Chain != nil {
+		if chainID := txReq.ChainID.ToBigInt(); !chainID.IsUint64() || chainID.Uint64() != s.Chain.ChainID {
+			return "", "", fmt.Errorf("chainId %s does not match %s (chainId %d)", chainID, s.Chain.Name, s.Chain.ChainID)
+		}
+		if useEIP1559 && !s.Chain.EIP1559 {
+			return "", "", fmt.Errorf("%s does not support EIP-1559 transactions", s.Chain.Name)
+		}
+	}
+
 	// 将TextBigInt转换为big.Int
 	nonce := txReq.Nonce.ToBigInt()
 	gas := txReq.Gas.ToBigInt()
@@ -220,7 +221,11 @@ func (s *EthTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (sig
 	} else {
 		signer = types.NewEIP155Signer(chainID)
 	}
-	signedTxObj, err := types.SignTx(tx, signer, privateKey)
+	signature, err := signRecoverable(keySigner, signer.Hash(tx).Bytes())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	signedTxObj, err := tx.WithSignature(signer, signature)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign transaction: %w", err)
 	}
@@ -2,9 +2,11 @@ package crypto
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"testing"
 
+	"github.com/featx/keys-gin/web/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,7 +39,7 @@ func TestEthTransactionSigner_SignTransaction(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "ethereum", privateKeyHex))
 
 	// 验证结果
 	assert.NoError(t, err)
@@ -51,11 +53,12 @@ func TestEthTransactionSigner_InvalidPrivateKey(t *testing.T) {
 	signer := &EthTransactionSigner{}
 
 	// 无效的私钥
-	privateKeyHex := "invalid_private_key"
-	rawTx := `{"from":"0x...","to":"0x...","gas":21000,"gasPrice":1000000000,"value":"1000000000000000000","nonce":0,"chainId":"1"}`
+	_, err := ParsePrivateKey("ethereum", []byte("invalid_private_key"))
+	assert.Error(t, err)
 
-	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(rawTx, privateKeyHex)
+	// 签名密钥的曲线不正确
+	rawTx := `{"from":"0x...","to":"0x...","gas":21000,"gasPrice":1000000000,"value":"1000000000000000000","nonce":0,"chainId":"1"}`
+	signedTx, txHash, err := signer.SignTransaction(rawTx, testSigningKey(t, "solana", testSolanaPrivateKey))
 
 	// 验证错误
 	assert.Error(t, err)
@@ -71,7 +74,7 @@ func TestEthTransactionSigner_InvalidTxFormat(t *testing.T) {
 	rawTx := "invalid_transaction_format"
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(rawTx, privateKeyHex)
+	signedTx, txHash, err := signer.SignTransaction(rawTx, testSigningKey(t, "ethereum", privateKeyHex))
 
 	// 验证错误
 	assert.Error(t, err)
@@ -110,7 +113,7 @@ func TestEthTransactionSigner_SignEIP1559Transaction(t *testing.T) {
 	assert.NoError(t, err)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "ethereum", privateKeyHex))
 
 	// 验证结果
 	assert.NoError(t, err)
@@ -118,4 +121,27 @@ func TestEthTransactionSigner_SignEIP1559Transaction(t *testing.T) {
 	assert.NotEmpty(t, txHash)
 	assert.Contains(t, signedTx, "0x")
 	assert.Contains(t, txHash, "0x")
-}
\ No newline at end of file
+}
+
+func TestEthTransactionSigner_ChainMismatch(t *testing.T) {
+	key := testSigningKey(t, "ethereum", "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
+	legacyTx := `{"to":"0x70997970C51812dc3A010C7d01b50e0d17dc79C8","gas":21000,"gasPrice":1000000000,"value":"1","nonce":0,"chainId":%s}`
+	dynamicFeeTx := `{"to":"0x70997970C51812dc3A010C7d01b50e0d17dc79C8","gas":21000,"maxPriorityFeePerGas":1,"maxFeePerGas":2,"value":"1","nonce":0,"chainId":%s}`
+
+	// Arbitrum的签名器只接受chainId为42161的交易
+	signer, err := NewTransactionSigner(model.ChainTypeArbitrum)
+	assert.NoError(t, err)
+	_, _, err = signer.SignTransaction(fmt.Sprintf(legacyTx, `"42161"`), key)
+	assert.NoError(t, err)
+	_, _, err = signer.SignTransaction(fmt.Sprintf(dynamicFeeTx, `"0xa4b1"`), key)
+	assert.NoError(t, err)
+	_, _, err = signer.SignTransaction(fmt.Sprintf(legacyTx, `"1"`), key)
+	assert.Error(t, err)
+
+	// 不支持EIP-1559的链拒绝EIP-1559交易
+	legacyOnly := &EthTransactionSigner{Chain: &EvmChain{Name: "legacy", ChainID: 1}}
+	_, _, err = legacyOnly.SignTransaction(fmt.Sprintf(legacyTx, "1"), key)
+	assert.NoError(t, err)
+	_, _, err = legacyOnly.SignTransaction(fmt.Sprintf(dynamicFeeTx, "1"), key)
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/featx/keys-gin/web/model"
+)
+
+// EvmChain EVM兼容链的网络参数
+// 所有EVM链共用以太坊的密钥和地址格式，签名时按链ID区分网络
+type EvmChain struct {
+	Name    string `json:"name"`     // 链类型，如ethereum、arbitrum
+	ChainID uint64 `json:"chain_id"` // EIP-155链ID
+	Symbol  string `json:"symbol"`   // 原生代币符号
+	EIP1559 bool   `json:"eip1559"`  // 是否支持EIP-1559交易
+}
+
+// defaultEvmChains 内置的EVM链，可通过配置覆盖或追加
+var defaultEvmChains = []EvmChain{
+	{Name: model.ChainTypeETH, ChainID: 1, Symbol: "ETH", EIP1559: true},
+	{Name: model.ChainTypeBSC, ChainID: 56, Symbol: "BNB", EIP1559: true},
+	{Name: model.ChainTypePolygon, ChainID: 137, Symbol: "POL", EIP1559: true},
+	{Name: model.ChainTypeAvalanche, ChainID: 43114, Symbol: "AVAX", EIP1559: true},
+	{Name: model.ChainTypeArbitrum, ChainID: 42161, Symbol: "ETH", EIP1559: true},
+	{Name: model.ChainTypeOptimism, ChainID: 10, Symbol: "ETH", EIP1559: true},
+	{Name: model.ChainTypeBase, ChainID: 8453, Symbol: "ETH", EIP1559: true},
+	{Name: model.ChainTypeZkSync, ChainID: 324, Symbol: "ETH", EIP1559: true},
+	{Name: model.ChainTypeLinea, ChainID: 59144, Symbol: "ETH", EIP1559: true},
+}
+
+// evmChains 已注册的EVM链，在各链模块的init之前完成初始化
+var (
+	evmChainsMu sync.RWMutex
+	evmChains   = newEvmChainMap(defaultEvmChains)
+)
+
+// newEvmChainMap 按名称索引EVM链
+func newEvmChainMap(chains []EvmChain) map[string]EvmChain {
+	m := make(map[string]EvmChain, len(chains))
+	for _, chain := range chains {
+		m[chain.Name] = chain
+	}
+	return m
+}
+
+// RegisterEvmChain 注册EVM链，同名的EVM链（包括内置链）会被覆盖，不能与其他链模块同名
+func RegisterEvmChain(chain EvmChain) error {
+	if chain.Name == "" {
+		return errors.New("evm chain name is required")
+	}
+	if chain.ChainID == 0 {
+		return fmt.Errorf("chain_id is required for evm chain %s", chain.Name)
+	}
+	chainModulesMu.RLock()
+	_, registered := chainModules[chain.Name]
+	chainModulesMu.RUnlock()
+	if registered {
+		return fmt.Errorf("chain type %s is already registered", chain.Name)
+	}
+
+	evmChainsMu.Lock()
+	defer evmChainsMu.Unlock()
+	evmChains[chain.Name] = chain
+	return nil
+}
+
+// LookupEvmChain 查找已注册的EVM链
+func LookupEvmChain(name string) (EvmChain, bool) {
+	evmChainsMu.RLock()
+	defer evmChainsMu.RUnlock()
+	chain, ok := evmChains[name]
+	return chain, ok
+}
+
+// IsEvmChain 判断链类型是否为已注册的EVM链
+func IsEvmChain(name string) bool {
+	_, ok := LookupEvmChain(name)
+	return ok
+}
+
+// evmChainModule EVM链的链模块，共用以太坊的密钥生成器和签名器
+func evmChainModule(chain EvmChain) ChainModule {
+	return ChainModule{
+		ChainType: chain.Name,
+		Curve:     "secp256k1",
+		Encoding:  "ethereum_address",
+		NewKeyGenerator: func() (KeyGenerator, error) {
+			return &EthKeyGenerator{}, nil
+		},
+		NewTransactionSigner: func() (TransactionSigner, error) {
+			return &EthTransactionSigner{Chain: &chain}, nil
+		},
+		ValidateAddress: validateEthAddress,
+	}
+}
+
+// EvmChains 返回所有已注册的EVM链，按链ID排序
+func EvmChains() []EvmChain {
+	evmChainsMu.RLock()
+	defer evmChainsMu.RUnlock()
+
+	chains := make([]EvmChain, 0, len(evmChains))
+	for _, chain := range evmChains {
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		if chains[i].ChainID != chains[j].ChainID {
+			return chains[i].ChainID < chains[j].ChainID
+		}
+		return chains[i].Name < chains[j].Name
+	})
+	return chains
+}
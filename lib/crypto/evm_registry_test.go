@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/featx/keys-gin/web/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerTestEvmChain 注册测试用的EVM链，测试结束后恢复
+func registerTestEvmChain(t *testing.T, chain EvmChain) {
+	previous, existed := LookupEvmChain(chain.Name)
+	require.NoError(t, RegisterEvmChain(chain))
+	t.Cleanup(func() {
+		evmChainsMu.Lock()
+		defer evmChainsMu.Unlock()
+		if existed {
+			evmChains[chain.Name] = previous
+		} else {
+			delete(evmChains, chain.Name)
+		}
+	})
+}
+
+func TestEvmRegistry_DefaultChains(t *testing.T) {
+	tests := []struct {
+		name    string
+		chainID uint64
+		symbol  string
+	}{
+		{model.ChainTypeETH, 1, "ETH"},
+		{model.ChainTypeBSC, 56, "BNB"},
+		{model.ChainTypePolygon, 137, "POL"},
+		{model.ChainTypeAvalanche, 43114, "AVAX"},
+		{model.ChainTypeArbitrum, 42161, "ETH"},
+		{model.ChainTypeOptimism, 10, "ETH"},
+		{model.ChainTypeBase, 8453, "ETH"},
+		{model.ChainTypeZkSync, 324, "ETH"},
+		{model.ChainTypeLinea, 59144, "ETH"},
+	}
+
+	for _, tt := range tests {
+		chain, ok := LookupEvmChain(tt.name)
+		require.True(t, ok, tt.name)
+		assert.Equal(t, tt.chainID, chain.ChainID, tt.name)
+		assert.Equal(t, tt.symbol, chain.Symbol, tt.name)
+
+		// 所有EVM链共用以太坊的密钥生成器和签名器
+		generator, err := NewKeyGenerator(tt.name)
+		assert.NoError(t, err)
+		assert.IsType(t, &EthKeyGenerator{}, generator)
+	}
+
+	// 非EVM链不在注册表中
+	assert.False(t, IsEvmChain(model.ChainTypeBTC))
+	assert.False(t, IsEvmChain(model.ChainTypeSolana))
+
+	// 按链ID排序
+	chains := EvmChains()
+	require.Len(t, chains, len(tests))
+	for i := 1; i < len(chains); i++ {
+		assert.Less(t, chains[i-1].ChainID, chains[i].ChainID)
+	}
+}
+
+func TestEvmRegistry_RegisterChain(t *testing.T) {
+	registerTestEvmChain(t, EvmChain{Name: "sepolia", ChainID: 11155111, Symbol: "ETH", EIP1559: true})
+
+	chain, ok := LookupEvmChain("sepolia")
+	require.True(t, ok)
+	assert.Equal(t, uint64(11155111), chain.ChainID)
+
+	signer, err := NewTransactionSigner("sepolia")
+	require.NoError(t, err)
+	require.IsType(t, &EthTransactionSigner{}, signer)
+	assert.Equal(t, uint64(11155111), signer.(*EthTransactionSigner).Chain.ChainID)
+
+	// 覆盖内置链的参数
+	registerTestEvmChain(t, EvmChain{Name: model.ChainTypeBSC, ChainID: 56, Symbol: "BNB"})
+	chain, _ = LookupEvmChain(model.ChainTypeBSC)
+	assert.False(t, chain.EIP1559)
+
+	// 名称和链ID必填
+	assert.Error(t, RegisterEvmChain(EvmChain{ChainID: 1}))
+	assert.Error(t, RegisterEvmChain(EvmChain{Name: "devnet"}))
+	assert.False(t, IsEvmChain("devnet"))
+}
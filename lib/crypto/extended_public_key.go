@@ -0,0 +1,153 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/featx/keys-gin/web/model"
+)
+
+// extendedKeyVersion SLIP-0132 注册的扩展公钥版本号对应的网络和比特币地址类型
+type extendedKeyVersion struct {
+	prefix      string
+	testnet     bool
+	addressType string
+}
+
+// extendedPublicKeyVersions 支持的扩展公钥版本号
+var extendedPublicKeyVersions = map[[4]byte]extendedKeyVersion{
+	{0x04, 0x88, 0xb2, 0x1e}: {prefix: "xpub"},
+	{0x04, 0x9d, 0x7c, 0xb2}: {prefix: "ypub", addressType: model.BtcAddressTypeP2SHP2WPKH},
+	{0x04, 0xb2, 0x47, 0x46}: {prefix: "zpub", addressType: model.BtcAddressTypeP2WPKH},
+	{0x04, 0x35, 0x87, 0xcf}: {prefix: "tpub", testnet: true},
+	{0x04, 0x4a, 0x52, 0x62}: {prefix: "upub", testnet: true, addressType: model.BtcAddressTypeP2SHP2WPKH},
+	{0x04, 0x5f, 0x1c, 0xf6}: {prefix: "vpub", testnet: true, addressType: model.BtcAddressTypeP2WPKH},
+}
+
+// ExtendedPublicKey BIP-32 secp256k1扩展公钥（xpub/ypub/zpub及测试网络的tpub/upub/vpub）
+// 通常为账户层级的公钥（如 m/84'/0'/0'），可以在没有私钥的节点上按 change/index 非硬化派生地址的公钥
+type ExtendedPublicKey struct {
+	key     *hdkeychain.ExtendedKey
+	version extendedKeyVersion
+}
+
+// ParseExtendedPublicKey 解析Base58Check编码的扩展公钥，不接受扩展私钥
+func ParseExtendedPublicKey(extendedKey string) (*ExtendedPublicKey, error) {
+	key, err := hdkeychain.NewKeyFromString(strings.TrimSpace(extendedKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended public key: %w", err)
+	}
+	if key.IsPrivate() {
+		return nil, errors.New("extended private keys are not accepted")
+	}
+
+	var id [4]byte
+	copy(id[:], key.Version())
+	version, ok := extendedPublicKeyVersions[id]
+	if !ok {
+		return nil, fmt.Errorf("unsupported extended public key version: %x", id)
+	}
+	return &ExtendedPublicKey{key: key, version: version}, nil
+}
+
+// String 返回Base58Check编码的扩展公钥
+func (k *ExtendedPublicKey) String() string {
+	return k.key.String()
+}
+
+// Testnet 版本号是否属于测试网络（tpub/upub/vpub）
+func (k *ExtendedPublicKey) Testnet() bool {
+	return k.version.testnet
+}
+
+// AddressType 版本号隐含的比特币地址类型：ypub为p2sh-p2wpkh，zpub为p2wpkh，xpub不限定地址类型时为空
+func (k *ExtendedPublicKey) AddressType() string {
+	return k.version.addressType
+}
+
+// DerivePublicKey 按 change/index 非硬化派生子公钥，返回33字节压缩公钥的十六进制
+// change为0时是收款地址，为1时是找零地址
+func (k *ExtendedPublicKey) DerivePublicKey(change, index uint32) (string, error) {
+	if change >= HardenedKeyStart || index >= HardenedKeyStart {
+		return "", errors.New("extended public keys only support non-hardened derivation")
+	}
+
+	key := k.key
+	for _, i := range []uint32{change, index} {
+		var err error
+		if key, err = key.Derive(i); err != nil {
+			return "", fmt.Errorf("failed to derive child key: %w", err)
+		}
+	}
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(pubKey.SerializeCompressed()), nil
+}
+
+// ExtendedPublicKeyPath 返回相对于扩展公钥的派生路径 change/index
+func ExtendedPublicKeyPath(change, index uint32) string {
+	return fmt.Sprintf("%d/%d", change, index)
+}
+
+// AccountDerivationPath 返回HD生成器的账户层级派生路径（去掉最后的 change/index 两级）
+// 只有最后两级为非硬化派生的链才能通过账户扩展公钥派生地址
+func AccountDerivationPath(g HDKeyGenerator, account uint32) (string, error) {
+	path := g.DerivationPath(account, 0)
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return "", err
+	}
+	if len(indexes) < 2 || indexes[len(indexes)-2] >= HardenedKeyStart || indexes[len(indexes)-1] >= HardenedKeyStart {
+		return "", fmt.Errorf("derivation path %s does not support extended public keys", path)
+	}
+
+	parts := strings.Split(path, "/")
+	return strings.Join(parts[:len(parts)-2], "/"), nil
+}
+
+// DeriveExtendedPublicKey 从BIP-39助记词按BIP-32派生账户层级的扩展公钥
+// 版本号按SLIP-0132选择：p2sh-p2wpkh为ypub，p2wpkh为zpub，其他地址类型为xpub，测试网络分别为upub、vpub、tpub
+func DeriveExtendedPublicKey(mnemonic, passphrase, path, addressType string, testnet bool) (string, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return "", err
+	}
+	seed, err := mnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := deriveBIP32ExtendedKey(seed, indexes)
+	if err != nil {
+		return "", err
+	}
+	if key, err = key.Neuter(); err != nil {
+		return "", fmt.Errorf("failed to convert to extended public key: %w", err)
+	}
+
+	for id, version := range extendedPublicKeyVersions {
+		if version.testnet == testnet && version.addressType == extendedKeyAddressType(addressType) {
+			if key, err = key.CloneWithVersion(id[:]); err != nil {
+				return "", err
+			}
+			return key.String(), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported address type for extended public key: %s", addressType)
+}
+
+// extendedKeyAddressType 地址类型对应的扩展公钥版本的地址类型，p2pkh和p2tr使用xpub
+func extendedKeyAddressType(addressType string) string {
+	switch addressType {
+	case model.BtcAddressTypeP2SHP2WPKH, model.BtcAddressTypeP2WPKH:
+		return addressType
+	default:
+		return ""
+	}
+}
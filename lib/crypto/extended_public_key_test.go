@@ -0,0 +1,115 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/featx/keys-gin/web/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveExtendedPublicKey(t *testing.T) {
+	testCases := []struct {
+		path        string
+		addressType string
+		testnet     bool
+		extendedKey string
+	}{
+		{"m/44'/0'/0'", model.BtcAddressTypeP2PKH, false, "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj"},
+		{"m/84'/0'/0'", model.BtcAddressTypeP2WPKH, false, "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"},
+	}
+
+	for _, tc := range testCases {
+		extendedKey, err := DeriveExtendedPublicKey(testHDMnemonic, "", tc.path, tc.addressType, tc.testnet)
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, tc.extendedKey, extendedKey, tc.path)
+	}
+
+	// 版本号按地址类型和网络选择
+	for addressType, prefix := range map[string]string{"": "tpub", model.BtcAddressTypeP2SHP2WPKH: "upub", model.BtcAddressTypeP2WPKH: "vpub", model.BtcAddressTypeP2TR: "tpub"} {
+		extendedKey, err := DeriveExtendedPublicKey(testHDMnemonic, "", "m/84'/1'/0'", addressType, true)
+		require.NoError(t, err)
+		assert.Equal(t, prefix, extendedKey[:4], addressType)
+	}
+}
+
+func TestExtendedPublicKey_DerivePublicKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		generator HDKeyGenerator
+	}{
+		{"ethereum", &EthKeyGenerator{}},
+		{"tron", &TronKeyGenerator{}},
+		{"bitcoin p2wpkh", &BtcKeyGenerator{AddressType: model.BtcAddressTypeP2WPKH}},
+		{"bitcoin p2tr", &BtcKeyGenerator{AddressType: model.BtcAddressTypeP2TR}},
+		{"cosmos", &CosmosKeyGenerator{HRP: "cosmos"}},
+	}
+
+	for _, tc := range testCases {
+		accountPath, err := AccountDerivationPath(tc.generator, 1)
+		require.NoError(t, err, tc.name)
+		extendedKey, err := DeriveExtendedPublicKey(testHDMnemonic, "", accountPath, "", false)
+		require.NoError(t, err, tc.name)
+
+		xpub, err := ParseExtendedPublicKey(extendedKey)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, extendedKey, xpub.String())
+
+		// 扩展公钥派生的地址与从助记词派生的地址一致
+		for _, index := range []uint32{0, 7} {
+			publicKey, err := xpub.DerivePublicKey(0, index)
+			require.NoError(t, err, tc.name)
+			address, err := tc.generator.PublicKeyToAddress(publicKey)
+			require.NoError(t, err, tc.name)
+
+			expectedAddress, _, _, err := tc.generator.DeriveKeyPairFromMnemonic(testHDMnemonic, "", tc.generator.DerivationPath(1, index))
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, expectedAddress, address, tc.name)
+		}
+	}
+}
+
+func TestParseExtendedPublicKey(t *testing.T) {
+	// BIP-84测试向量：账户0的第一个收款地址和找零地址
+	xpub, err := ParseExtendedPublicKey("zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs")
+	require.NoError(t, err)
+	assert.False(t, xpub.Testnet())
+	assert.Equal(t, model.BtcAddressTypeP2WPKH, xpub.AddressType())
+
+	generator := &BtcKeyGenerator{AddressType: xpub.AddressType()}
+	for _, tc := range []struct {
+		change  uint32
+		address string
+	}{
+		{0, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{1, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"},
+	} {
+		publicKey, err := xpub.DerivePublicKey(tc.change, 0)
+		require.NoError(t, err)
+		address, err := generator.PublicKeyToAddress(publicKey)
+		require.NoError(t, err)
+		assert.Equal(t, tc.address, address)
+	}
+	assert.Equal(t, "1/0", ExtendedPublicKeyPath(1, 0))
+
+	// 不支持硬化派生
+	_, err = xpub.DerivePublicKey(0, HardenedKeyStart)
+	assert.Error(t, err)
+
+	// 扩展私钥、校验和错误和无效字符串
+	for _, extendedKey := range []string{
+		"xprv9s21ZrQH143K3GJpoapnV8SFfukcVBSfeCficPSGfubmSFDxo1kuHnLisriDvSnRRuL2Qrg5ggqHKNVpxR86QEC8w35uxmGoggxtQTPvfUu",
+		"zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYt",
+		"not an extended key",
+	} {
+		_, err := ParseExtendedPublicKey(extendedKey)
+		assert.Error(t, err, extendedKey)
+	}
+
+	// Ed25519链的派生路径全部为硬化派生，不支持扩展公钥
+	_, err = AccountDerivationPath(&SolanaKeyGenerator{}, 0)
+	assert.Error(t, err)
+	path, err := AccountDerivationPath(&EthKeyGenerator{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/2'", path)
+}
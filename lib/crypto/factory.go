@@ -1,34 +1,24 @@
 package crypto
 
-import (
-	"errors"
-
-	"github.com/featx/keys-gin/web/model"
-)
+import "errors"
 
 // NewTransactionSigner 根据区块链类型创建交易签名器
-// 类ETH的链共用一个签名器，chainId从交易参数中获取
+// EVM链共用以太坊签名器，交易参数中的chainId必须与注册的链ID一致
 func NewTransactionSigner(chainType string) (TransactionSigner, error) {
-	switch chainType {
-	case model.ChainTypeETH, model.ChainTypeBSC, model.ChainTypePolygon, model.ChainTypeAvalanche:
-		return &EthTransactionSigner{}, nil
-	case model.ChainTypeBTC:
-		return &BtcTransactionSigner{}, nil
-	case model.ChainTypeSolana:
-		return &SolanaTransactionSigner{}, nil
-	case model.ChainTypeTRON:
-		return &TronTransactionSigner{}, nil
-	case model.ChainTypeSUI:
-		return &SuiTransactionSigner{}, nil
-	case model.ChainTypeADA:
-		return &AdaTransactionSigner{}, nil
-	case model.ChainTypePolkadot:
-		return &PolkadotTransactionSigner{IsKusama: false}, nil
-	case model.ChainTypeKusama:
-		return &PolkadotTransactionSigner{IsKusama: true}, nil
-	case model.ChainTypeTON:
-		return &TonTransactionSigner{}, nil
-	default:
+	module, ok := LookupChain(chainType)
+	if !ok {
+		return nil, errors.New("unsupported chain type")
+	}
+	return module.newTransactionSigner()
+}
+
+// ParsePrivateKey 将指定链的私钥解析为内存中的签名密钥
+// privateKey为keystore中保存的格式（通常为十六进制），解码出的私钥字节在解析后清零；
+// 签名密钥持有私钥的副本，使用后应调用ZeroizeKey清除
+func ParsePrivateKey(chainType string, privateKey []byte) (Signer, error) {
+	module, ok := LookupChain(chainType)
+	if !ok {
 		return nil, errors.New("unsupported chain type")
 	}
-}
\ No newline at end of file
+	return module.parsePrivateKey(privateKey)
+}
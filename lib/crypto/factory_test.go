@@ -29,10 +29,18 @@ func TestNewTransactionSigner(t *testing.T) {
 		chainType:      model.ChainTypeAvalanche,
 		expectedType:   &EthTransactionSigner{},
 		expectError:    false,
+	}, {
+		chainType:      model.ChainTypeArbitrum,
+		expectedType:   &EthTransactionSigner{},
+		expectError:    false,
 	}, {
 		chainType:      model.ChainTypeBTC,
 		expectedType:   &BtcTransactionSigner{},
 		expectError:    false,
+	}, {
+		chainType:      model.ChainTypeBTCRegtest,
+		expectedType:   &BtcTransactionSigner{},
+		expectError:    false,
 	}, {
 		chainType:      model.ChainTypeSolana,
 		expectedType:   &SolanaTransactionSigner{},
@@ -61,6 +69,14 @@ func TestNewTransactionSigner(t *testing.T) {
 		chainType:      model.ChainTypeTON,
 		expectedType:   &TonTransactionSigner{},
 		expectError:    false,
+	}, {
+		chainType:      model.ChainTypeCosmos,
+		expectedType:   &CosmosTransactionSigner{},
+		expectError:    false,
+	}, {
+		chainType:      model.ChainTypeInjective,
+		expectedType:   &CosmosTransactionSigner{},
+		expectError:    false,
 	}, {
 		chainType:      "unsupported_chain",
 		expectedType:   nil,
@@ -85,6 +101,12 @@ func TestNewTransactionSigner(t *testing.T) {
 			} else if tc.chainType == model.ChainTypeKusama {
 				kusamaSigner := signer.(*PolkadotTransactionSigner)
 				assert.True(t, kusamaSigner.IsKusama)
+			} else if tc.chainType == model.ChainTypeBTCRegtest {
+				btcSigner := signer.(*BtcTransactionSigner)
+				assert.Equal(t, "regtest", btcSigner.NetParams.Name)
+			} else if tc.chainType == model.ChainTypeInjective {
+				cosmosSigner := signer.(*CosmosTransactionSigner)
+				assert.True(t, cosmosSigner.EthSecp256k1)
 			}
 		}
 	}
@@ -0,0 +1,181 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/cosmos/go-bip39"
+)
+
+// HardenedKeyStart 硬化派生的索引起点
+const HardenedKeyStart uint32 = 0x80000000
+
+// SLIP-44 注册的币种类型
+const (
+	CoinTypeBitcoin  uint32 = 0
+	CoinTypeTestnet  uint32 = 1
+	CoinTypeEthereum uint32 = 60
+	CoinTypeCosmos   uint32 = 118
+	CoinTypeTron     uint32 = 195
+	CoinTypeSolana   uint32 = 501
+	CoinTypeTon      uint32 = 607
+	CoinTypeAptos    uint32 = 637
+	CoinTypeSui      uint32 = 784
+)
+
+// HDKeyGenerator 支持从BIP-39助记词分层确定性派生密钥的生成器
+// secp256k1链按BIP-32派生，ed25519链按SLIP-0010派生（所有层级均为硬化派生）
+type HDKeyGenerator interface {
+	KeyGenerator
+
+	// DerivationPath 返回账户和地址索引对应的派生路径
+	DerivationPath(account, index uint32) string
+
+	// DeriveKeyPairFromMnemonic 从BIP-39助记词（可选密码）按派生路径推导密钥对
+	// 返回：地址、公钥、私钥、错误
+	DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error)
+}
+
+// ParseDerivationPath 解析形如 m/44'/60'/0'/0/0 的派生路径，硬化层级可以用'或h标记
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path: %s", path)
+	}
+
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid derivation path: %s", path)
+		}
+		if hardened {
+			index += uint64(HardenedKeyStart)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// mnemonicToSeed 校验BIP-39助记词并计算64字节的种子
+func mnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(normalizeMnemonic(mnemonic), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	return seed, nil
+}
+
+// deriveBIP32ExtendedKey 按BIP-32从种子派生扩展私钥
+func deriveBIP32ExtendedKey(seed []byte, path []uint32) (*hdkeychain.ExtendedKey, error) {
+	// 扩展密钥的版本号不影响派生结果
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create master key: %w", err)
+	}
+	for _, index := range path {
+		if key, err = key.Derive(index); err != nil {
+			return nil, fmt.Errorf("failed to derive child key: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// deriveBIP32PrivateKey 按BIP-32从种子派生secp256k1私钥（32字节）
+func deriveBIP32PrivateKey(seed []byte, path []uint32) ([]byte, error) {
+	key, err := deriveBIP32ExtendedKey(seed, path)
+	if err != nil {
+		return nil, err
+	}
+
+	privKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	return privKey.Serialize(), nil
+}
+
+// deriveSLIP10Ed25519PrivateKey 按SLIP-0010从种子派生Ed25519私钥种子（32字节）
+// Ed25519只支持硬化派生
+func deriveSLIP10Ed25519PrivateKey(seed []byte, path []uint32) ([]byte, error) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	digest := mac.Sum(nil)
+	key, chainCode := digest[:32], digest[32:]
+
+	for _, index := range path {
+		if index < HardenedKeyStart {
+			return nil, errors.New("ed25519 only supports hardened derivation")
+		}
+		data := make([]byte, 0, 37)
+		data = append(data, 0x00)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		digest = mac.Sum(nil)
+		key, chainCode = digest[:32], digest[32:]
+	}
+	return key, nil
+}
+
+// deriveSecp256k1HDKeyPair 按BIP-32派生secp256k1私钥，再由生成器推导公钥和地址
+func deriveSecp256k1HDKeyPair(g KeyGenerator, mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return "", "", "", err
+	}
+	seed, err := mnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return "", "", "", err
+	}
+	privateKeyBytes, err := deriveBIP32PrivateKey(seed, indexes)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	privateKey = hex.EncodeToString(privateKeyBytes)
+	address, publicKey, err = g.DeriveKeyPairFromPrivateKey(privateKey)
+	if err != nil {
+		return "", "", "", err
+	}
+	return address, publicKey, privateKey, nil
+}
+
+// deriveEd25519HDKeyPair 按SLIP-0010派生Ed25519私钥，再由生成器推导公钥和地址
+// 私钥为64字节（种子 || 公钥）的十六进制，与随机生成的Ed25519私钥格式一致
+func deriveEd25519HDKeyPair(g KeyGenerator, mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return "", "", "", err
+	}
+	seed, err := mnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return "", "", "", err
+	}
+	privateKeySeed, err := deriveSLIP10Ed25519PrivateKey(seed, indexes)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	privateKey = hex.EncodeToString(ed25519.NewKeyFromSeed(privateKeySeed))
+	address, publicKey, err = g.DeriveKeyPairFromPrivateKey(privateKey)
+	if err != nil {
+		return "", "", "", err
+	}
+	return address, publicKey, privateKey, nil
+}
@@ -0,0 +1,148 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/featx/keys-gin/web/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试助记词（BIP-39标准测试向量）
+const testHDMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestParseDerivationPath(t *testing.T) {
+	indexes, err := ParseDerivationPath("m/44'/60'/0h/0/5")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{HardenedKeyStart + 44, HardenedKeyStart + 60, HardenedKeyStart, 0, 5}, indexes)
+
+	indexes, err = ParseDerivationPath("m")
+	require.NoError(t, err)
+	assert.Empty(t, indexes)
+
+	for _, path := range []string{"", "44'/60'", "m/", "m/a", "m/-1", "m/2147483648", "m/0''"} {
+		_, err := ParseDerivationPath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestDeriveHDPrivateKey(t *testing.T) {
+	// BIP-32与SLIP-0010的测试向量1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	testCases := []struct {
+		path    string
+		bip32   string
+		ed25519 string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{"m/0'/1'", "", "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", ""},
+	}
+
+	for _, tc := range testCases {
+		indexes, err := ParseDerivationPath(tc.path)
+		require.NoError(t, err)
+		if tc.bip32 != "" {
+			key, err := deriveBIP32PrivateKey(seed, indexes)
+			require.NoError(t, err, tc.path)
+			assert.Equal(t, tc.bip32, hex.EncodeToString(key), tc.path)
+		}
+		if tc.ed25519 != "" {
+			key, err := deriveSLIP10Ed25519PrivateKey(seed, indexes)
+			require.NoError(t, err, tc.path)
+			assert.Equal(t, tc.ed25519, hex.EncodeToString(key), tc.path)
+		}
+	}
+
+	// Ed25519不支持非硬化派生
+	_, err := deriveSLIP10Ed25519PrivateKey(seed, []uint32{HardenedKeyStart, 1})
+	assert.Error(t, err)
+}
+
+func TestHDKeyGenerator_DeriveKeyPairFromMnemonic(t *testing.T) {
+	btcGenerator := func(addressType string) HDKeyGenerator {
+		generator, err := NewBtcKeyGenerator(model.ChainTypeBTC, addressType)
+		require.NoError(t, err)
+		return generator
+	}
+	cosmosGenerator, err := NewCosmosKeyGenerator(model.ChainTypeCosmos, "")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		generator HDKeyGenerator
+		path      string
+		address   string
+	}{
+		{"ethereum", &EthKeyGenerator{}, "m/44'/60'/0'/0/0", "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+		{"bitcoin p2pkh", btcGenerator(""), "m/44'/0'/0'/0/0", "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{"bitcoin p2sh-p2wpkh", btcGenerator(model.BtcAddressTypeP2SHP2WPKH), "m/49'/0'/0'/0/0", "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+		{"bitcoin p2wpkh", btcGenerator(model.BtcAddressTypeP2WPKH), "m/84'/0'/0'/0/0", "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{"bitcoin p2tr", btcGenerator(model.BtcAddressTypeP2TR), "m/86'/0'/0'/0/0", "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+		{"cosmos", cosmosGenerator, "m/44'/118'/0'/0/0", "cosmos19rl4cm2hmr8afy4kldpxz3fka4jguq0auqdal4"},
+		{"solana", &SolanaKeyGenerator{}, "m/44'/501'/0'/0'", "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.path, tc.generator.DerivationPath(0, 0), tc.name)
+
+		address, publicKey, privateKey, err := tc.generator.DeriveKeyPairFromMnemonic(testHDMnemonic, "", tc.path)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.address, address, tc.name)
+
+		// 派生的私钥可以直接用于生成器
+		derivedAddress, derivedPublicKey, err := tc.generator.DeriveKeyPairFromPrivateKey(privateKey)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, address, derivedAddress, tc.name)
+		assert.Equal(t, publicKey, derivedPublicKey, tc.name)
+	}
+}
+
+func TestHDKeyGenerator_DerivationPath(t *testing.T) {
+	suiSecp256k1 := &SuiKeyGenerator{Scheme: SuiSchemeSecp256k1}
+	injective, err := NewCosmosKeyGenerator(model.ChainTypeInjective, "")
+	require.NoError(t, err)
+	testnet, err := NewBtcKeyGenerator(model.ChainTypeBTCTestnet, model.BtcAddressTypeP2WPKH)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		generator HDKeyGenerator
+		path      string
+	}{
+		{&EthKeyGenerator{}, "m/44'/60'/1'/0/2"},
+		{&TronKeyGenerator{}, "m/44'/195'/1'/0/2"},
+		{testnet, "m/84'/1'/1'/0/2"},
+		{injective, "m/44'/60'/1'/0/2"},
+		{&SolanaKeyGenerator{}, "m/44'/501'/1'/2'"},
+		{&SuiKeyGenerator{}, "m/44'/784'/1'/0'/2'"},
+		{suiSecp256k1, "m/54'/784'/1'/0/2"},
+		{&AptosKeyGenerator{}, "m/44'/637'/1'/0'/2'"},
+		{&TonKeyGenerator{}, "m/44'/607'/0'/0'/1'/2'"},
+		{&AdaKeyGenerator{}, "m/1852'/1815'/1'/0/2"},
+	}
+
+	for _, tc := range testCases {
+		path := tc.generator.DerivationPath(1, 2)
+		assert.Equal(t, tc.path, path)
+
+		// 不同账户和索引派生不同的地址
+		address, _, _, err := tc.generator.DeriveKeyPairFromMnemonic(testHDMnemonic, "", path)
+		require.NoError(t, err, path)
+		firstAddress, _, _, err := tc.generator.DeriveKeyPairFromMnemonic(testHDMnemonic, "", tc.generator.DerivationPath(0, 0))
+		require.NoError(t, err, path)
+		assert.NotEqual(t, firstAddress, address, path)
+	}
+
+	// 助记词或路径无效
+	_, _, _, err = (&EthKeyGenerator{}).DeriveKeyPairFromMnemonic("abandon abandon", "", "m/44'/60'/0'/0/0")
+	assert.Error(t, err)
+	_, _, _, err = (&SolanaKeyGenerator{}).DeriveKeyPairFromMnemonic(testHDMnemonic, "", "m/44'/501'/0'/0")
+	assert.Error(t, err)
+	_, _, _, err = (&AdaKeyGenerator{}).DeriveKeyPairFromMnemonic(testHDMnemonic, "", "m/44'/1815'/0'/0/0")
+	assert.Error(t, err)
+	_, _, _, err = (&SuiKeyGenerator{Scheme: SuiSchemeSecp256r1}).DeriveKeyPairFromMnemonic(testHDMnemonic, "", "m/74'/784'/0'/0/0")
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package crypto
 
 // TransactionSigner 交易签名器接口
+// key为签名密钥，私钥可以在内存中（由ParsePrivateKey解析），也可以在HSM、KMS或MPC等外部系统中
 type TransactionSigner interface {
-	SignTransaction(rawTx, privateKey string) (signedTx string, txHash string, err error)
+	SignTransaction(rawTx string, key Signer) (signedTx string, txHash string, err error)
 }
\ No newline at end of file
@@ -0,0 +1,387 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// CurveSecp256k1 secp256k1曲线
+	CurveSecp256k1 = "secp256k1"
+	// CurveSecp256r1 secp256r1（P-256）曲线
+	CurveSecp256r1 = "secp256r1"
+	// CurveEd25519 Ed25519曲线
+	CurveEd25519 = "ed25519"
+	// CurveEd25519BIP32 BIP32-Ed25519扩展密钥（Cardano），签名与Ed25519兼容
+	CurveEd25519BIP32 = "ed25519-bip32"
+	// CurveSr25519 sr25519（schnorrkel）曲线
+	CurveSr25519 = "sr25519"
+)
+
+// Signer 签名密钥，交易签名器只通过公钥和签名使用密钥
+// 私钥可以在内存中，也可以在HSM、远程KMS或MPC等外部系统中不被导出
+type Signer interface {
+	// Curve 密钥曲线，与链模块的Curve一致
+	Curve() string
+	// PublicKey secp256k1和secp256r1为33字节压缩公钥，ed25519、ed25519-bip32和sr25519为32字节公钥
+	PublicKey() []byte
+	// Sign secp256k1和secp256r1对32字节摘要做ECDSA签名，返回64字节 r || s；
+	// ed25519、ed25519-bip32对完整消息签名（PureEdDSA），sr25519以substrate为签名上下文对完整消息签名，返回64字节签名
+	Sign(digest []byte) ([]byte, error)
+}
+
+// SchnorrSigner 支持BIP-340 Schnorr签名的secp256k1签名密钥，比特币Taproot输入需要
+type SchnorrSigner interface {
+	Signer
+	// SignSchnorr 对32字节摘要做BIP-340 Schnorr签名，返回64字节签名
+	// tweak不为空时使用按BIP-341调整后的私钥签名（Taproot密钥路径），tweak为32字节的TapTweak哈希
+	SignSchnorr(digest, tweak []byte) ([]byte, error)
+}
+
+// zeroizer 内存中的私钥，使用后可以清除
+type zeroizer interface {
+	Zeroize()
+}
+
+// Zeroize 将字节切片清零，用于清除使用后的私钥
+func Zeroize(b []byte) {
+	clear(b)
+}
+
+// ZeroizeKey 清除内存中签名密钥的私钥，清除后密钥不能再使用；外部系统中的密钥不受影响
+func ZeroizeKey(key Signer) {
+	if z, ok := key.(zeroizer); ok {
+		z.Zeroize()
+	}
+}
+
+// zeroizeBigInt 清零大整数的底层存储
+func zeroizeBigInt(n *big.Int) {
+	if n != nil {
+		clear(n.Bits())
+		n.SetInt64(0)
+	}
+}
+
+// secp256k1Signer 内存中的secp256k1私钥
+type secp256k1Signer struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewSecp256k1Signer 用内存中的secp256k1私钥创建签名密钥
+// 其他库（如btcec）实现的secp256k1曲线会被重新导出为go-ethereum的曲线，否则无法签名
+func NewSecp256k1Signer(privateKey *ecdsa.PrivateKey) Signer {
+	if privateKey.Curve != crypto.S256() && privateKey.Curve.Params().P.Cmp(crypto.S256().Params().P) == 0 {
+		keyBytes := privateKey.D.FillBytes(make([]byte, 32))
+		defer Zeroize(keyBytes)
+		if privKey, err := crypto.ToECDSA(keyBytes); err == nil {
+			privateKey = privKey
+		}
+	}
+	return &secp256k1Signer{privateKey: privateKey}
+}
+
+// Curve 返回secp256k1
+func (s *secp256k1Signer) Curve() string {
+	return CurveSecp256k1
+}
+
+// PublicKey 返回压缩公钥
+func (s *secp256k1Signer) PublicKey() []byte {
+	return crypto.CompressPubkey(&s.privateKey.PublicKey)
+}
+
+// Sign 对32字节摘要签名，返回 r || s
+func (s *secp256k1Signer) Sign(digest []byte) ([]byte, error) {
+	signature, err := crypto.Sign(digest, s.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return signature[:64], nil
+}
+
+// SignSchnorr 对32字节摘要做BIP-340 Schnorr签名，tweak不为空时先按BIP-341调整私钥
+func (s *secp256k1Signer) SignSchnorr(digest, tweak []byte) ([]byte, error) {
+	keyBytes := s.privateKey.D.FillBytes(make([]byte, 32))
+	defer Zeroize(keyBytes)
+	privKey, _ := btcec.PrivKeyFromBytes(keyBytes)
+	defer privKey.Zero()
+
+	if tweak != nil {
+		var tweakScalar btcec.ModNScalar
+		if len(tweak) != 32 || tweakScalar.SetByteSlice(tweak) {
+			return nil, errors.New("invalid taproot tweak")
+		}
+		// BIP-341要求内部公钥的Y坐标为偶数，奇数时先对私钥取负
+		if s.privateKey.PublicKey.Y.Bit(0) == 1 {
+			privKey.Key.Negate()
+		}
+		privKey.Key.Add(&tweakScalar)
+	}
+
+	signature, err := schnorr.Sign(privKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	return signature.Serialize(), nil
+}
+
+// Zeroize 清除私钥
+func (s *secp256k1Signer) Zeroize() {
+	zeroizeBigInt(s.privateKey.D)
+}
+
+// secp256r1Signer 内存中的secp256r1私钥
+type secp256r1Signer struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewSecp256r1Signer 用内存中的secp256r1私钥创建签名密钥
+func NewSecp256r1Signer(privateKey *ecdsa.PrivateKey) Signer {
+	return &secp256r1Signer{privateKey: privateKey}
+}
+
+// Curve 返回secp256r1
+func (s *secp256r1Signer) Curve() string {
+	return CurveSecp256r1
+}
+
+// PublicKey 返回压缩公钥
+func (s *secp256r1Signer) PublicKey() []byte {
+	return elliptic.MarshalCompressed(elliptic.P256(), s.privateKey.X, s.privateKey.Y)
+}
+
+// Sign 对32字节摘要签名，返回 r || s
+func (s *secp256r1Signer) Sign(digest []byte) ([]byte, error) {
+	r, sv, err := ecdsa.Sign(rand.Reader, s.privateKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	return append(r.FillBytes(make([]byte, 32)), sv.FillBytes(make([]byte, 32))...), nil
+}
+
+// Zeroize 清除私钥
+func (s *secp256r1Signer) Zeroize() {
+	zeroizeBigInt(s.privateKey.D)
+}
+
+// ed25519Signer 内存中的Ed25519私钥
+type ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+// NewEd25519Signer 用内存中的Ed25519私钥创建签名密钥
+func NewEd25519Signer(privateKey ed25519.PrivateKey) Signer {
+	return &ed25519Signer{privateKey: privateKey}
+}
+
+// Curve 返回ed25519
+func (s *ed25519Signer) Curve() string {
+	return CurveEd25519
+}
+
+// PublicKey 返回32字节公钥
+func (s *ed25519Signer) PublicKey() []byte {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Sign 对消息签名
+func (s *ed25519Signer) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.privateKey, message), nil
+}
+
+// Zeroize 清除私钥
+func (s *ed25519Signer) Zeroize() {
+	Zeroize(s.privateKey)
+}
+
+// decodeHexPrivateKey 解码十六进制私钥，返回的字节切片使用后需要清零
+func decodeHexPrivateKey(privateKey []byte) ([]byte, error) {
+	privateKeyBytes := make([]byte, hex.DecodedLen(len(privateKey)))
+	if _, err := hex.Decode(privateKeyBytes, privateKey); err != nil {
+		Zeroize(privateKeyBytes)
+		return nil, fmt.Errorf("invalid private key format: %w", err)
+	}
+	return privateKeyBytes, nil
+}
+
+// parseSecp256k1PrivateKey 解析十六进制的32字节secp256k1私钥
+func parseSecp256k1PrivateKey(privateKey []byte) (Signer, error) {
+	privateKeyBytes, err := decodeHexPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(privateKeyBytes)
+	return newSecp256k1Signer(privateKeyBytes)
+}
+
+// newSecp256k1Signer 用32字节secp256k1私钥创建签名密钥，签名密钥持有私钥的副本
+func newSecp256k1Signer(privateKey []byte) (Signer, error) {
+	privKey, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewSecp256k1Signer(privKey), nil
+}
+
+// parseEd25519PrivateKey 解析十六进制的Ed25519私钥：32字节种子或64字节完整私钥
+func parseEd25519PrivateKey(privateKey []byte) (Signer, error) {
+	privateKeyBytes, err := decodeHexPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(privateKeyBytes)
+
+	switch len(privateKeyBytes) {
+	case ed25519.SeedSize, ed25519.PrivateKeySize:
+		return NewEd25519Signer(ed25519.NewKeyFromSeed(privateKeyBytes[:ed25519.SeedSize])), nil
+	default:
+		return nil, fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
+	}
+}
+
+// requireCurve 校验签名密钥的曲线
+func requireCurve(signer Signer, curve string) error {
+	if signer == nil {
+		return errors.New("signer is required")
+	}
+	if signer.Curve() != curve {
+		return fmt.Errorf("unsupported key curve %s, expected %s", signer.Curve(), curve)
+	}
+	return nil
+}
+
+// secp256k1PublicKey 解析签名密钥的secp256k1公钥
+func secp256k1PublicKey(signer Signer) (*ecdsa.PublicKey, error) {
+	if err := requireCurve(signer, CurveSecp256k1); err != nil {
+		return nil, err
+	}
+	publicKey, err := crypto.DecompressPubkey(signer.PublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("invalid secp256k1 public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+// signRecoverable 用secp256k1签名密钥对32字节摘要签名，返回 r || s || v（v为0或1）
+// HSM返回的签名不含恢复标识且可能是high-S，这里规范为low-S并通过公钥恢复确定v
+func signRecoverable(signer Signer, digest []byte) ([]byte, error) {
+	if err := requireCurve(signer, CurveSecp256k1); err != nil {
+		return nil, err
+	}
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("invalid digest length: %d", len(digest))
+	}
+
+	signature, err := signer.Sign(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %w", err)
+	}
+	if len(signature) != 64 {
+		return nil, fmt.Errorf("invalid secp256k1 signature length: %d", len(signature))
+	}
+
+	curveOrder := crypto.S256().Params().N
+	s := new(big.Int).SetBytes(signature[32:])
+	if s.Cmp(new(big.Int).Rsh(curveOrder, 1)) > 0 {
+		s.Sub(curveOrder, s)
+	}
+	recoverable := make([]byte, 65)
+	copy(recoverable, signature[:32])
+	s.FillBytes(recoverable[32:64])
+
+	publicKey := signer.PublicKey()
+	for v := byte(0); v < 2; v++ {
+		recoverable[64] = v
+		recovered, err := crypto.SigToPub(digest, recoverable)
+		if err == nil && bytes.Equal(crypto.CompressPubkey(recovered), publicKey) {
+			return recoverable, nil
+		}
+	}
+	return nil, errors.New("signature does not match the signer public key")
+}
+
+// signSecp256r1 用secp256r1签名密钥对32字节摘要签名，返回low-S规范化的 r || s，并用公钥验证签名
+func signSecp256r1(signer Signer, digest []byte) ([]byte, error) {
+	if err := requireCurve(signer, CurveSecp256r1); err != nil {
+		return nil, err
+	}
+	curve := elliptic.P256()
+	x, y := elliptic.UnmarshalCompressed(curve, signer.PublicKey())
+	if x == nil {
+		return nil, errors.New("invalid secp256r1 public key")
+	}
+
+	signature, err := signer.Sign(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %w", err)
+	}
+	if len(signature) != 64 {
+		return nil, fmt.Errorf("invalid secp256r1 signature length: %d", len(signature))
+	}
+
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if s.Cmp(new(big.Int).Rsh(curve.Params().N, 1)) > 0 {
+		s.Sub(curve.Params().N, s)
+	}
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}, digest, r, s) {
+		return nil, errors.New("signature does not match the signer public key")
+	}
+	return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), nil
+}
+
+// signEd25519 用Ed25519签名密钥对消息签名，并用公钥验证签名
+func signEd25519(signer Signer, message []byte) ([]byte, error) {
+	if err := requireCurve(signer, CurveEd25519); err != nil {
+		return nil, err
+	}
+	return verifiedEd25519Signature(signer, message)
+}
+
+// verifiedEd25519Signature 签名消息，并用32字节公钥按Ed25519验证签名
+func verifiedEd25519Signature(signer Signer, message []byte) ([]byte, error) {
+	publicKey := signer.PublicKey()
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key length: %d", len(publicKey))
+	}
+
+	signature, err := signer.Sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(publicKey, message, signature) {
+		return nil, errors.New("signature does not match the signer public key")
+	}
+	return signature, nil
+}
+
+// ExternalPublicKey 将签名密钥的公钥转换为链的密钥生成器使用的十六进制格式，并生成地址
+// 以太坊系的链保存非压缩公钥，其他secp256k1链保存压缩公钥
+func ExternalPublicKey(generator KeyGenerator, signer Signer) (address, publicKey string, err error) {
+	publicKeyBytes := signer.PublicKey()
+	if _, ok := generator.(*EthKeyGenerator); ok {
+		key, err := secp256k1PublicKey(signer)
+		if err != nil {
+			return "", "", err
+		}
+		publicKeyBytes = crypto.FromECDSAPub(key)
+	}
+
+	publicKey = hex.EncodeToString(publicKeyBytes)
+	address, err = generator.PublicKeyToAddress(publicKey)
+	if err != nil {
+		return "", "", err
+	}
+	return address, publicKey, nil
+}
@@ -0,0 +1,206 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSigningKey 将测试私钥解析为指定链的签名密钥
+func testSigningKey(t *testing.T, chainType, privateKey string) Signer {
+	t.Helper()
+	key, err := ParsePrivateKey(chainType, []byte(privateKey))
+	require.NoError(t, err)
+	return key
+}
+
+// highSSigner 模拟HSM：只返回 r || s，且s取high-S
+type highSSigner struct {
+	Signer
+}
+
+func (s *highSSigner) Sign(digest []byte) ([]byte, error) {
+	signature, err := s.Signer.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+	highS := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(signature[32:]))
+	return append(signature[:32:32], highS.FillBytes(make([]byte, 32))...), nil
+}
+
+func TestSignRecoverable(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testTronPrivateKey)
+	require.NoError(t, err)
+	digest := crypto.Keccak256([]byte("keys-gin"))
+
+	expected, err := crypto.Sign(digest, privateKey)
+	require.NoError(t, err)
+
+	// high-S签名被规范为low-S，并补上恢复标识
+	signature, err := signRecoverable(&highSSigner{NewSecp256k1Signer(privateKey)}, digest)
+	require.NoError(t, err)
+	assert.Equal(t, expected, signature)
+
+	// btcec曲线的私钥与go-ethereum曲线的私钥签名结果一致
+	btcKey, _ := btcec.PrivKeyFromBytes(crypto.FromECDSA(privateKey))
+	signature, err = signRecoverable(NewSecp256k1Signer(btcKey.ToECDSA()), digest)
+	require.NoError(t, err)
+	assert.Equal(t, expected, signature)
+
+	// 签名与公钥不匹配
+	otherKey, _ := crypto.GenerateKey()
+	_, err = signRecoverable(&mismatchedSigner{NewSecp256k1Signer(privateKey), crypto.CompressPubkey(&otherKey.PublicKey)}, digest)
+	assert.Error(t, err)
+
+	// 摘要长度和曲线错误
+	_, err = signRecoverable(NewSecp256k1Signer(privateKey), digest[:31])
+	assert.Error(t, err)
+	_, err = signRecoverable(NewEd25519Signer(ed25519.NewKeyFromSeed(make([]byte, 32))), digest)
+	assert.Error(t, err)
+}
+
+// mismatchedSigner 公钥与签名私钥不一致的签名密钥
+type mismatchedSigner struct {
+	Signer
+	publicKey []byte
+}
+
+func (s *mismatchedSigner) PublicKey() []byte {
+	return s.publicKey
+}
+
+func TestExternalSigner_Secp256k1(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testTronPrivateKey)
+	require.NoError(t, err)
+	external := &highSSigner{NewSecp256k1Signer(privateKey)}
+
+	// TRON：签名与使用内存中私钥签名的结果一致
+	rawTx, _ := json.Marshal(newTestTronRequest())
+	tron := &TronTransactionSigner{}
+	signedTx, txHash, err := tron.SignTransaction(string(rawTx), testSigningKey(t, "tron", testTronPrivateKey))
+	require.NoError(t, err)
+	externalTx, externalHash, err := tron.SignTransaction(string(rawTx), external)
+	require.NoError(t, err)
+	assert.Equal(t, signedTx, externalTx)
+	assert.Equal(t, txHash, externalHash)
+
+	// SUI secp256k1
+	sui := &SuiTransactionSigner{}
+	encoded, err := EncodeSuiPrivateKey(SuiSchemeSecp256k1, testTronPrivateKey)
+	require.NoError(t, err)
+	suiTx, _ := json.Marshal(SuiTransactionRequest{TxBytes: testSuiTxBytes, Scheme: SuiSchemeSecp256k1})
+	signedTx, txHash, err = sui.SignTransaction(string(suiTx), testSigningKey(t, "sui", encoded))
+	require.NoError(t, err)
+	externalTx, externalHash, err = sui.SignTransaction(testSuiTxBytes, external)
+	require.NoError(t, err)
+	assert.Equal(t, signedTx, externalTx)
+	assert.Equal(t, txHash, externalHash)
+
+	// 曲线不匹配
+	_, _, err = (&SolanaTransactionSigner{}).SignTransaction(string(rawTx), external)
+	assert.Error(t, err)
+
+	// 公钥对应的地址与交易的发送方不一致
+	otherKey, _ := crypto.GenerateKey()
+	_, _, err = tron.SignTransaction(string(rawTx), NewSecp256k1Signer(otherKey))
+	assert.Error(t, err)
+
+	// 外部密钥不支持Schnorr签名，不能签名Taproot输入
+	btcKey, privKey := newTestBtcKey(t)
+	prevOuts := []*wire.TxOut{wire.NewTxOut(100000, testBtcScript(t, privKey.PubKey(), "p2tr"))}
+	_, err = (&BtcTransactionSigner{}).SignPsbt(&BtcPsbtRequest{Psbt: encodeTestPsbt(t, newTestPsbt(t, prevOuts))}, &highSSigner{btcKey})
+	assert.Error(t, err)
+}
+
+func TestExternalSigner_Ed25519(t *testing.T) {
+	seed, _ := hex.DecodeString(testSuiPrivateKey)
+	external := NewEd25519Signer(ed25519.NewKeyFromSeed(seed))
+
+	sui := &SuiTransactionSigner{}
+	signedTx, txHash, err := sui.SignTransaction(testSuiTxBytes, testSigningKey(t, "sui", testSuiPrivateKey))
+	require.NoError(t, err)
+	externalTx, externalHash, err := sui.SignTransaction(testSuiTxBytes, external)
+	require.NoError(t, err)
+	assert.Equal(t, signedTx, externalTx)
+	assert.Equal(t, txHash, externalHash)
+
+	// 签名无法通过公钥验证
+	other := ed25519.NewKeyFromSeed(make([]byte, 32))
+	_, _, err = sui.SignTransaction(testSuiTxBytes, &mismatchedSigner{external, other.Public().(ed25519.PublicKey)})
+	assert.Error(t, err)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	// 按链的曲线解析私钥，不修改传入的私钥
+	privateKey := []byte(testTronPrivateKey)
+	key, err := ParsePrivateKey("tron", privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, CurveSecp256k1, key.Curve())
+	assert.Equal(t, []byte(testTronPrivateKey), privateKey)
+
+	key, err = ParsePrivateKey("solana", []byte(testSolanaPrivateKey))
+	require.NoError(t, err)
+	assert.Equal(t, CurveEd25519, key.Curve())
+
+	_, err = ParsePrivateKey("unknown", []byte(testTronPrivateKey))
+	assert.Error(t, err)
+	_, err = ParsePrivateKey("tron", []byte(testSolanaPrivateKey[:10]))
+	assert.Error(t, err)
+}
+
+func TestZeroizeKey(t *testing.T) {
+	digest := crypto.Keccak256([]byte("keys-gin"))
+
+	// 清除后的密钥无法再生成有效的签名
+	secp256k1Key := testSigningKey(t, "tron", testTronPrivateKey)
+	_, err := signRecoverable(secp256k1Key, digest)
+	require.NoError(t, err)
+	ZeroizeKey(secp256k1Key)
+	_, err = signRecoverable(secp256k1Key, digest)
+	assert.Error(t, err)
+
+	ed25519Key := testSigningKey(t, "solana", testSolanaPrivateKey)
+	_, err = signEd25519(ed25519Key, digest)
+	require.NoError(t, err)
+	ZeroizeKey(ed25519Key)
+	_, err = signEd25519(ed25519Key, digest)
+	assert.Error(t, err)
+
+	// 外部密钥不受影响
+	ZeroizeKey(&mismatchedSigner{})
+}
+
+func TestExternalPublicKey(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testTronPrivateKey)
+	require.NoError(t, err)
+	signer := NewSecp256k1Signer(privateKey)
+
+	// 以太坊使用非压缩公钥，TRON使用压缩公钥，与由私钥推导的结果一致
+	for _, generator := range []KeyGenerator{&EthKeyGenerator{}, &TronKeyGenerator{}} {
+		expectedAddress, expectedPublicKey, err := generator.DeriveKeyPairFromPrivateKey(testTronPrivateKey)
+		require.NoError(t, err)
+		address, publicKey, err := ExternalPublicKey(generator, signer)
+		require.NoError(t, err)
+		assert.Equal(t, expectedAddress, address)
+		assert.Equal(t, expectedPublicKey, publicKey)
+	}
+
+	// Ed25519
+	seed, _ := hex.DecodeString(testSolanaPrivateKey)
+	ed25519Key := ed25519.NewKeyFromSeed(seed)
+	generator := &SolanaKeyGenerator{}
+	expectedAddress, expectedPublicKey, err := generator.DeriveKeyPairFromPrivateKey(hex.EncodeToString(ed25519Key))
+	require.NoError(t, err)
+	address, publicKey, err := ExternalPublicKey(generator, NewEd25519Signer(ed25519Key))
+	require.NoError(t, err)
+	assert.Equal(t, expectedAddress, address)
+	assert.Equal(t, expectedPublicKey, publicKey)
+}
@@ -0,0 +1,39 @@
+package crypto
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/cosmos/go-bip39"
+)
+
+// NewMnemonic 生成BIP-39助记词
+// bitSize 为熵的位数（128~256，32的倍数），对应12~24个单词
+func NewMnemonic(bitSize int) (string, error) {
+	entropy, err := bip39.NewEntropy(bitSize)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// normalizeMnemonic 规范化助记词中的空白字符
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(mnemonic), " ")
+}
+
+// mnemonicToEntropy 校验BIP-39助记词并还原熵（不含校验和）
+func mnemonicToEntropy(mnemonic string) ([]byte, error) {
+	mnemonic = normalizeMnemonic(mnemonic)
+	data, err := bip39.MnemonicToByteArray(mnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+
+	// 每个单词11位，每32位熵对应1位校验和
+	bitSize := len(strings.Fields(mnemonic)) * 11
+	checksumSize := bitSize / 33
+	entropy := new(big.Int).Rsh(new(big.Int).SetBytes(data), uint(checksumSize))
+	return entropy.FillBytes(make([]byte, (bitSize-checksumSize)/8)), nil
+}
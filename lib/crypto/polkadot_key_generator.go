@@ -1,76 +1,147 @@
 package crypto
 
 import (
-	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 
+	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/featx/keys-gin/web/model"
+	"github.com/mr-tron/base58"
 	"golang.org/x/crypto/blake2b"
 )
 
+// SS58网络前缀，参见 https://github.com/paritytech/ss58-registry
+const (
+	// SS58PrefixPolkadot Polkadot主网
+	SS58PrefixPolkadot uint16 = 0
+	// SS58PrefixKusama Kusama
+	SS58PrefixKusama uint16 = 2
+	// SS58PrefixSubstrate 通用Substrate网络（本地开发链及多数未注册的平行链）
+	SS58PrefixSubstrate uint16 = 42
+)
+
+// ss58ChecksumPrefix SS58校验和的哈希前缀
+var ss58ChecksumPrefix = []byte("SS58PRE")
+
+// substrateChains 内置的Substrate链的SS58网络前缀
+var substrateChains = map[string]uint16{
+	model.ChainTypePolkadot: SS58PrefixPolkadot,
+	model.ChainTypeKusama:   SS58PrefixKusama,
+}
+
+func init() {
+	for _, chainType := range []string{model.ChainTypePolkadot, model.ChainTypeKusama, model.ChainTypeSubstrate} {
+		ss58Prefix, builtin := substrateChains[chainType]
+		isKusama := chainType == model.ChainTypeKusama
+		module := ChainModule{
+			ChainType: chainType,
+			Curve:     "sr25519",
+			NewKeyGenerator: func() (KeyGenerator, error) {
+				return NewSubstrateKeyGenerator(chainType, nil)
+			},
+			NewTransactionSigner: func() (TransactionSigner, error) {
+				return &PolkadotTransactionSigner{IsKusama: isKusama}, nil
+			},
+			ParsePrivateKey: parseSr25519PrivateKey,
+			ValidateAddress: func(address string) error {
+				_, prefix, err := SS58Decode(address)
+				if err != nil {
+					return err
+				}
+				if builtin && prefix != ss58Prefix {
+					return fmt.Errorf("address %s has ss58 prefix %d, expected %d", address, prefix, ss58Prefix)
+				}
+				return nil
+			},
+		}
+		// substrate的地址编码取决于请求中的SS58前缀
+		if builtin {
+			module.Encoding = "ss58_address"
+		}
+		mustRegisterChain(module)
+	}
+}
+
 // PolkadotKeyGenerator Polkadot和Kusama密钥生成器
-// 注意：这是一个更符合Polkadot规范的实现，但仍为简化版本
-// 实际的Polkadot密钥生成应使用官方库: github.com/paritytech/parity-crypto
-// Polkadot使用Schnorr签名与sr25519曲线
+// 使用sr25519（schnorrkel）密钥，私钥为32字节的mini secret key（即Substrate的secret seed），
+// 按Substrate的方式以Ed25519模式扩展为签名密钥
+// SS58Prefix 指定地址的SS58网络前缀：Polkadot为0，Kusama为2，平行链使用各自注册的前缀
+
+type PolkadotKeyGenerator struct {
+	SS58Prefix uint16
+}
 
-type PolkadotKeyGenerator struct{}
+// NewSubstrateKeyGenerator 创建指定Substrate链类型的密钥生成器
+// ss58Prefix 覆盖默认的SS58网络前缀，为nil时Polkadot和Kusama使用各自的前缀，substrate使用通用前缀42
+func NewSubstrateKeyGenerator(chainType string, ss58Prefix *uint16) (*PolkadotKeyGenerator, error) {
+	prefix, ok := substrateChains[chainType]
+	if !ok && chainType != model.ChainTypeSubstrate {
+		return nil, fmt.Errorf("unsupported substrate chain type: %s", chainType)
+	}
+	if !ok {
+		prefix = SS58PrefixSubstrate
+	}
+	if ss58Prefix != nil {
+		prefix = *ss58Prefix
+	}
+	return NewPolkadotKeyGenerator(prefix)
+}
+
+// SS58AddressEncoding 返回substrate链使用指定SS58前缀的地址编码方式
+func SS58AddressEncoding(ss58Prefix uint16) string {
+	return fmt.Sprintf("ss58_address_%d", ss58Prefix)
+}
+
+// NewPolkadotKeyGenerator 创建使用指定SS58网络前缀的密钥生成器
+func NewPolkadotKeyGenerator(ss58Prefix uint16) (*PolkadotKeyGenerator, error) {
+	if ss58Prefix > 16383 {
+		return nil, fmt.Errorf("invalid ss58 prefix: %d", ss58Prefix)
+	}
+	return &PolkadotKeyGenerator{SS58Prefix: ss58Prefix}, nil
+}
 
 // GenerateKeyPair 生成Polkadot/Kusama密钥对
 func (g *PolkadotKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey string, err error) {
-	// 生成随机私钥（64字节，符合Polkadot要求）
-	privateKeyBytes := make([]byte, 64)
-	_, err = rand.Read(privateKeyBytes)
+	// 生成随机的mini secret key
+	miniSecret, err := schnorrkel.GenerateMiniSecretKey()
 	if err != nil {
 		return "", "", "", fmt.Errorf("failed to generate private key: %w", err)
 	}
 
 	// 获取私钥的十六进制表示
-	privateKey = hex.EncodeToString(privateKeyBytes)
-
-	// 生成公钥（基于私钥派生，符合Polkadot规范的简化实现）
-	// Polkadot实际使用sr25519曲线，这里使用Blake2b作为简化实现
-	hash, _ := blake2b.New256(nil)
-	hash.Write(privateKeyBytes)
-	publicKeyBytes := hash.Sum(nil)
-	publicKey = hex.EncodeToString(publicKeyBytes)
-
-	// 生成Polkadot风格的地址（以1开头，符合SS58格式特点）
-	// 注意：实际Polkadot地址使用SS58编码
-	addrHash, _ := blake2b.New256(nil)
-	addrHash.Write(publicKeyBytes)
-	hashBytes := addrHash.Sum(nil)
-	// 截取适当长度并添加Polkadot地址前缀
-	address = "1" + hex.EncodeToString(hashBytes[:20])
+	seed := miniSecret.Encode()
+	privateKey = hex.EncodeToString(seed[:])
+
+	// 由私钥推导公钥和地址
+	address, publicKey, err = g.DeriveKeyPairFromPrivateKey(privateKey)
+	if err != nil {
+		return "", "", "", err
+	}
 
 	return address, publicKey, privateKey, nil
 }
 
 // DeriveKeyPairFromPrivateKey 从现有私钥推导Polkadot/Kusama公钥和地址
 func (g *PolkadotKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (address, publicKey string, err error) {
-	// 解析私钥
-	privateKeyBytes, err := hex.DecodeString(privateKey)
+	secretKey, err := sr25519SecretKeyFromHex(privateKey)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to decode private key: %w", err)
+		return "", "", err
 	}
 
-	// 验证私钥长度是否合理
-	if len(privateKeyBytes) < 32 {
-		return "", "", fmt.Errorf("invalid private key length: expected at least 32 bytes, got %d bytes", len(privateKeyBytes))
+	// 获取公钥的十六进制表示
+	pubKey, err := secretKey.Public()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to derive public key: %w", err)
 	}
+	publicKeyBytes := pubKey.Encode()
+	publicKey = hex.EncodeToString(publicKeyBytes[:])
 
-	// 从私钥派生公钥（简化实现）
-	// Polkadot实际使用sr25519曲线，这里使用Blake2b作为简化实现
-	hash, _ := blake2b.New256(nil)
-	hash.Write(privateKeyBytes)
-	publicKeyBytes := hash.Sum(nil)
-	publicKey = hex.EncodeToString(publicKeyBytes)
-
-	// 生成Polkadot风格的地址
-	addrHash, _ := blake2b.New256(nil)
-	addrHash.Write(publicKeyBytes)
-	hashBytes := addrHash.Sum(nil)
-	// 截取适当长度并添加Polkadot地址前缀
-	address = "1" + hex.EncodeToString(hashBytes[:20])
+	// 生成SS58地址
+	address, err = SS58Encode(publicKeyBytes[:], g.SS58Prefix)
+	if err != nil {
+		return "", "", err
+	}
 
 	return address, publicKey, nil
 }
@@ -83,12 +154,152 @@ func (g *PolkadotKeyGenerator) PublicKeyToAddress(publicKey string) (address str
 		return "", fmt.Errorf("failed to decode public key: %w", err)
 	}
 
-	// 生成Polkadot风格的地址
-	addrHash, _ := blake2b.New256(nil)
-	addrHash.Write(publicKeyBytes)
-	hashBytes := addrHash.Sum(nil)
-	// 截取适当长度并添加Polkadot地址前缀
-	address = "1" + hex.EncodeToString(hashBytes[:20])
+	// 验证公钥是有效的ristretto255点
+	var encoded [schnorrkel.PublicKeySize]byte
+	if len(publicKeyBytes) != len(encoded) {
+		return "", fmt.Errorf("invalid public key length: expected %d bytes, got %d bytes", len(encoded), len(publicKeyBytes))
+	}
+	copy(encoded[:], publicKeyBytes)
+	if _, err := schnorrkel.NewPublicKey(encoded); err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	// 生成SS58地址
+	return SS58Encode(publicKeyBytes, g.SS58Prefix)
+}
+
+// sr25519Signer 内存中的sr25519私钥
+type sr25519Signer struct {
+	secretKey *schnorrkel.SecretKey
+	publicKey []byte
+}
+
+// parseSr25519PrivateKey 将十六进制私钥解析为sr25519签名密钥
+func parseSr25519PrivateKey(privateKey []byte) (Signer, error) {
+	secretKey, err := sr25519SecretKeyFromHex(string(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key format: %w", err)
+	}
+	pubKey, err := secretKey.Public()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive public key: %w", err)
+	}
+	publicKey := pubKey.Encode()
+	return &sr25519Signer{secretKey: secretKey, publicKey: publicKey[:]}, nil
+}
+
+// Curve 返回sr25519
+func (s *sr25519Signer) Curve() string {
+	return CurveSr25519
+}
+
+// PublicKey 返回32字节公钥
+func (s *sr25519Signer) PublicKey() []byte {
+	return s.publicKey
+}
+
+// Sign 以substrate为签名上下文对消息签名
+func (s *sr25519Signer) Sign(message []byte) ([]byte, error) {
+	signature, err := s.secretKey.Sign(schnorrkel.NewSigningContext(substrateSigningContext, message))
+	if err != nil {
+		return nil, err
+	}
+	encoded := signature.Encode()
+	return encoded[:], nil
+}
+
+// sr25519SecretKeyFromHex 解析十六进制私钥
+// 支持32字节的mini secret key（secret seed）和64字节的扩展密钥（key || nonce）
+func sr25519SecretKeyFromHex(privateKey string) (*schnorrkel.SecretKey, error) {
+	privateKeyBytes, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	switch len(privateKeyBytes) {
+	case schnorrkel.MiniSecretKeySize:
+		var seed [schnorrkel.MiniSecretKeySize]byte
+		copy(seed[:], privateKeyBytes)
+		miniSecret, err := schnorrkel.NewMiniSecretKeyFromRaw(seed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return miniSecret.ExpandEd25519(), nil
+	case schnorrkel.SecretKeySize * 2:
+		var key [schnorrkel.SecretKeySize]byte
+		copy(key[:], privateKeyBytes)
+		var nonce [32]byte
+		copy(nonce[:], privateKeyBytes[schnorrkel.SecretKeySize:])
+		return schnorrkel.NewSecretKey(key, nonce), nil
+	default:
+		return nil, fmt.Errorf("invalid private key length: expected %d or %d bytes, got %d bytes",
+			schnorrkel.MiniSecretKeySize, schnorrkel.SecretKeySize*2, len(privateKeyBytes))
+	}
+}
+
+// SS58Encode 使用指定网络前缀将32字节公钥（AccountId）编码为SS58地址
+// 前缀小于64时占1字节，64到16383之间按SS58规范编码为2字节
+func SS58Encode(publicKey []byte, prefix uint16) (string, error) {
+	if len(publicKey) != 32 {
+		return "", fmt.Errorf("invalid account id length: expected 32 bytes, got %d bytes", len(publicKey))
+	}
+
+	var payload []byte
+	switch {
+	case prefix < 64:
+		payload = []byte{byte(prefix)}
+	case prefix < 16384:
+		payload = []byte{
+			byte((prefix&0b0000_0000_1111_1100)>>2) | 0b0100_0000,
+			byte(prefix>>8) | byte((prefix&0b0000_0000_0000_0011)<<6),
+		}
+	default:
+		return "", fmt.Errorf("invalid ss58 prefix: %d", prefix)
+	}
+	payload = append(payload, publicKey...)
+
+	checksum := ss58Checksum(payload)
+	return base58.Encode(append(payload, checksum[:2]...)), nil
+}
+
+// SS58Decode 解析SS58地址，返回32字节公钥（AccountId）和网络前缀
+func SS58Decode(address string) ([]byte, uint16, error) {
+	data, err := base58.Decode(address)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode ss58 address: %w", err)
+	}
+	if len(data) < 2 {
+		return nil, 0, errors.New("invalid ss58 address length")
+	}
+
+	var (
+		prefix    uint16
+		prefixLen int
+	)
+	switch {
+	case data[0] < 64:
+		prefix, prefixLen = uint16(data[0]), 1
+	case data[0] < 128:
+		lower := (data[0] << 2) | (data[1] >> 6)
+		upper := data[1] & 0b0011_1111
+		prefix, prefixLen = uint16(lower)|uint16(upper)<<8, 2
+	default:
+		return nil, 0, errors.New("invalid ss58 address prefix")
+	}
 
-	return address, nil
-}
\ No newline at end of file
+	if len(data) != prefixLen+32+2 {
+		return nil, 0, errors.New("invalid ss58 address length")
+	}
+	payload := data[:prefixLen+32]
+	checksum := ss58Checksum(payload)
+	if checksum[0] != data[len(data)-2] || checksum[1] != data[len(data)-1] {
+		return nil, 0, errors.New("invalid ss58 address checksum")
+	}
+
+	return payload[prefixLen:], prefix, nil
+}
+
+// ss58Checksum 计算SS58校验和：Blake2b-512("SS58PRE" || prefix || publicKey)
+func ss58Checksum(payload []byte) [blake2b.Size]byte {
+	return blake2b.Sum512(append(append([]byte{}, ss58ChecksumPrefix...), payload...))
+}
@@ -1,11 +1,20 @@
 package crypto
 
 import (
+	"encoding/hex"
+	"strings"
 	"testing"
 
+	"github.com/featx/keys-gin/web/model"
 	"github.com/stretchr/testify/assert"
 )
 
+// Substrate开发账户Alice的sr25519 secret seed及公钥
+const (
+	testAliceSeed      = "e5be9a5092b81bca64be81d212e7f2f9eba183bb7a90954f7b76361f6edb5c0a"
+	testAlicePublicKey = "d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+)
+
 // PolkadotKeyGenerator 测试用例
 func TestPolkadotKeyGenerator_GenerateKeyPair(t *testing.T) {
 	generator := &PolkadotKeyGenerator{}
@@ -19,26 +28,39 @@ func TestPolkadotKeyGenerator_GenerateKeyPair(t *testing.T) {
 	assert.NotEmpty(t, publicKey)
 	assert.NotEmpty(t, privateKey)
 	// 验证地址格式符合Polkadot规范
-	assert.Contains(t, address, "1")
+	assert.True(t, strings.HasPrefix(address, "1"))
 	// 验证私钥长度
-	assert.Equal(t, 128, len(privateKey)) // 64字节的十六进制表示
-}
-
-func TestPolkadotKeyGenerator_DeriveKeyPairFromPrivateKey(t *testing.T) {
-	generator := &PolkadotKeyGenerator{}
+	assert.Equal(t, 64, len(privateKey)) // 32字节的十六进制表示
 
-	// 先生成一个有效的私钥用于测试
-	_, _, privateKey, err := generator.GenerateKeyPair()
+	// 地址可以解析回公钥
+	accountID, prefix, err := SS58Decode(address)
 	assert.NoError(t, err)
+	assert.Equal(t, SS58PrefixPolkadot, prefix)
+	assert.Equal(t, publicKey, hex.EncodeToString(accountID))
+}
 
-	// 从私钥派生公钥和地址
-	address, publicKey, err := generator.DeriveKeyPairFromPrivateKey(privateKey)
-
-	// 验证结果
-	assert.NoError(t, err)
-	assert.NotEmpty(t, address)
-	assert.NotEmpty(t, publicKey)
-	assert.Contains(t, address, "1")
+func TestPolkadotKeyGenerator_DeriveKeyPairFromPrivateKey(t *testing.T) {
+	testCases := []struct {
+		prefix  uint16
+		address string
+	}{
+		{SS58PrefixPolkadot, "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5"},
+		{SS58PrefixKusama, "HNZata7iMYWmk5RvZRTiAsSDhV8366zq2YGb3tLH5Upf74F"},
+		{SS58PrefixSubstrate, "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+	}
+
+	for _, tc := range testCases {
+		generator, err := NewPolkadotKeyGenerator(tc.prefix)
+		assert.NoError(t, err)
+
+		// 从Alice的secret seed派生公钥和地址
+		address, publicKey, err := generator.DeriveKeyPairFromPrivateKey(testAliceSeed)
+
+		// 验证结果
+		assert.NoError(t, err)
+		assert.Equal(t, testAlicePublicKey, publicKey)
+		assert.Equal(t, tc.address, address)
+	}
 }
 
 func TestPolkadotKeyGenerator_InvalidPrivateKey(t *testing.T) {
@@ -65,17 +87,64 @@ func TestPolkadotKeyGenerator_InvalidPrivateKey(t *testing.T) {
 }
 
 func TestPolkadotKeyGenerator_PublicKeyToAddress(t *testing.T) {
-	generator := &PolkadotKeyGenerator{}
-
-	// 先生成一个密钥对获取有效的公钥
-	_, publicKey, _, err := generator.GenerateKeyPair()
-	assert.NoError(t, err)
+	generator := &PolkadotKeyGenerator{SS58Prefix: SS58PrefixKusama}
 
 	// 从公钥生成地址
-	address, err := generator.PublicKeyToAddress(publicKey)
+	address, err := generator.PublicKeyToAddress(testAlicePublicKey)
 
 	// 验证结果
 	assert.NoError(t, err)
-	assert.NotEmpty(t, address)
-	assert.Contains(t, address, "1")
-}
\ No newline at end of file
+	assert.Equal(t, "HNZata7iMYWmk5RvZRTiAsSDhV8366zq2YGb3tLH5Upf74F", address)
+
+	// 长度错误的公钥
+	_, err = generator.PublicKeyToAddress("d43593c715fdd31c")
+	assert.Error(t, err)
+}
+
+func TestNewSubstrateKeyGenerator(t *testing.T) {
+	// 内置链使用各自的前缀，substrate默认使用通用前缀
+	generator, err := NewSubstrateKeyGenerator(model.ChainTypeKusama, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, SS58PrefixKusama, generator.SS58Prefix)
+	generator, err = NewSubstrateKeyGenerator(model.ChainTypeSubstrate, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, SS58PrefixSubstrate, generator.SS58Prefix)
+
+	// 平行链指定注册的前缀，如Astar为5
+	ss58Prefix := uint16(5)
+	generator, err = NewSubstrateKeyGenerator(model.ChainTypeSubstrate, &ss58Prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, ss58Prefix, generator.SS58Prefix)
+	assert.Equal(t, "ss58_address_5", SS58AddressEncoding(generator.SS58Prefix))
+
+	ss58Prefix = 16384
+	_, err = NewSubstrateKeyGenerator(model.ChainTypeSubstrate, &ss58Prefix)
+	assert.Error(t, err)
+	_, err = NewSubstrateKeyGenerator(model.ChainTypeETH, nil)
+	assert.Error(t, err)
+}
+
+func TestSS58_TwoBytePrefix(t *testing.T) {
+	publicKey, err := hex.DecodeString(testAlicePublicKey)
+	assert.NoError(t, err)
+
+	// 前缀大于63时使用2字节编码，编码后可以还原
+	for _, prefix := range []uint16{64, 1284, 16383} {
+		address, err := SS58Encode(publicKey, prefix)
+		assert.NoError(t, err)
+
+		accountID, decodedPrefix, err := SS58Decode(address)
+		assert.NoError(t, err)
+		assert.Equal(t, prefix, decodedPrefix)
+		assert.Equal(t, publicKey, accountID)
+	}
+
+	_, err = SS58Encode(publicKey, 16384)
+	assert.Error(t, err)
+
+	// 篡改地址后校验和失败
+	address, _ := SS58Encode(publicKey, SS58PrefixPolkadot)
+	tampered := address[:len(address)-1] + "6"
+	_, _, err = SS58Decode(tampered)
+	assert.Error(t, err)
+}
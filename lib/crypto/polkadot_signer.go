@@ -1,22 +1,61 @@
 package crypto
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"strings"
 
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ChainSafe/go-schnorrkel"
 )
 
-// PolkadotTransactionRequest Polkadot/Kusama交易请求结构
-type PolkadotTransactionRequest struct {
-	Address     string                 `json:"address"`
-	CallModule  string                 `json:"callModule"`
-	CallFunction string                `json:"callFunction"`
-	CallArgs    map[string]interface{} `json:"callArgs"`
-	Nonce       uint32                 `json:"nonce"`
-	Tip         uint64                 `json:"tip,omitempty"`
-	Era         string                 `json:"era"`
+// Substrate签名类型
+const (
+	// SubstrateCryptoSr25519 sr25519（schnorrkel）签名，Polkadot/Kusama账户的默认类型
+	SubstrateCryptoSr25519 = "sr25519"
+	// SubstrateCryptoEd25519 ed25519签名
+	SubstrateCryptoEd25519 = "ed25519"
+)
+
+// substrateSigningContext sr25519签名使用的签名上下文
+var substrateSigningContext = []byte("substrate")
+
+// PolkadotSignerPayload Polkadot/Kusama交易签名请求
+// 字段与Polkadot.js的SignerPayloadJSON一致，数值字段为十六进制字符串（如"0x00000001"）
+type PolkadotSignerPayload struct {
+	Address            string   `json:"address"`            // 签名账户的SS58地址，为空时不校验
+	BlockHash          string   `json:"blockHash"`          // 交易有效期起始区块哈希（不朽交易为创世区块哈希）
+	BlockNumber        string   `json:"blockNumber"`        // 交易有效期起始区块高度
+	Era                string   `json:"era"`                // SCALE编码的交易有效期，不朽交易为"0x00"
+	GenesisHash        string   `json:"genesisHash"`        // 创世区块哈希
+	Method             string   `json:"method"`             // SCALE编码的调用数据
+	Nonce              string   `json:"nonce"`              // 账户nonce
+	SpecVersion        string   `json:"specVersion"`        // 运行时版本
+	Tip                string   `json:"tip"`                // 小费
+	TransactionVersion string   `json:"transactionVersion"` // 交易格式版本
+	SignedExtensions   []string `json:"signedExtensions"`   // 运行时的签名扩展列表，为空时使用Polkadot默认列表
+	Version            int      `json:"version"`            // 外部交易版本，仅支持4
+	AssetID            string   `json:"assetId,omitempty"`  // ChargeAssetTxPayment使用的SCALE编码资产ID
+	MetadataHash       string   `json:"metadataHash,omitempty"`
+	Mode               int      `json:"mode,omitempty"`       // CheckMetadataHash模式，1表示启用元数据哈希校验
+	CryptoType         string   `json:"cryptoType,omitempty"` // 签名类型：sr25519（默认）或ed25519
+}
+
+// defaultSubstrateSignedExtensions Polkadot运行时的签名扩展列表
+var defaultSubstrateSignedExtensions = []string{
+	"CheckNonZeroSender",
+	"CheckSpecVersion",
+	"CheckTxVersion",
+	"CheckGenesis",
+	"CheckMortality",
+	"CheckNonce",
+	"CheckWeight",
+	"ChargeTransactionPayment",
+	"PrevalidateAttests",
+	"CheckMetadataHash",
 }
 
 // PolkadotTransactionSigner Polkadot交易签名器
@@ -25,33 +64,291 @@ type PolkadotTransactionSigner struct {
 }
 
 // SignTransaction 签名Polkadot/Kusama交易
-func (s *PolkadotTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (signedTx string, txHash string, err error) {
-	// 解码私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
-	}
-
+// rawTx为PolkadotSignerPayload的JSON，返回0x前缀的SCALE编码签名外部交易及其Blake2b-256哈希
+func (s *PolkadotTransactionSigner) SignTransaction(rawTx string, key Signer) (signedTx string, txHash string, err error) {
 	// 解析交易参数
-	var txReq PolkadotTransactionRequest
-	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+	var payload PolkadotSignerPayload
+	if err := json.Unmarshal([]byte(rawTx), &payload); err != nil {
 		return "", "", fmt.Errorf("invalid transaction data format: %w", err)
 	}
+	if payload.Version != 0 && payload.Version != 4 {
+		return "", "", fmt.Errorf("unsupported extrinsic version: %d", payload.Version)
+	}
 
-	// Polkadot/Kusama交易签名逻辑
-	// 实际实现需要使用Polkadot特定库
-	// github.com/paritytech/parity-crypto
+	// 解析调用数据
+	method, err := decodeSubstrateHex(payload.Method)
+	if err != nil || len(method) == 0 {
+		return "", "", fmt.Errorf("invalid method: %v", err)
+	}
 
-	// 模拟签名过程
-	signature := crypto.Keccak256(append(privateKeyBytes, []byte(rawTx)...))
+	// 构建签名扩展的交易内数据（extra）和仅参与签名的数据（additional signed）
+	extra, additional, err := encodeSubstrateSignedExtensions(&payload)
+	if err != nil {
+		return "", "", err
+	}
 
-	prefix := "dot"
-	if s.IsKusama {
-		prefix = "ksm"
+	// 签名负载：call || extra || additional，超过256字节时签名其Blake2b-256哈希
+	signingPayload := append(append(append([]byte{}, method...), extra...), additional...)
+	if len(signingPayload) > 256 {
+		signingPayload = Blake2b256(signingPayload)
 	}
 
-	signedTx = fmt.Sprintf("%s_signed_%s", prefix, hex.EncodeToString(signature))
-	txHash = fmt.Sprintf("%s_%x", prefix, crypto.Keccak256([]byte(signedTx)))
+	// 签名并生成MultiSignature
+	publicKey, multiSignature, err := signSubstratePayload(payload.CryptoType, key, signingPayload)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 校验请求中的地址与签名密钥一致
+	if payload.Address != "" {
+		accountID, _, err := SS58Decode(payload.Address)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid address: %w", err)
+		}
+		if !bytes.Equal(accountID, publicKey) {
+			return "", "", errors.New("address does not match the signer public key")
+		}
+	}
+
+	// 外部交易：compact(len) || 0x84 || MultiAddress::Id(accountId) || MultiSignature || extra || call
+	var body bytes.Buffer
+	body.WriteByte(0x84)
+	body.WriteByte(0x00)
+	body.Write(publicKey)
+	body.Write(multiSignature)
+	body.Write(extra)
+	body.Write(method)
+
+	extrinsic := append(scaleCompact(big.NewInt(int64(body.Len()))), body.Bytes()...)
+
+	signedTx = "0x" + hex.EncodeToString(extrinsic)
+	txHash = "0x" + hex.EncodeToString(Blake2b256(extrinsic))
 
 	return signedTx, txHash, nil
-}
\ No newline at end of file
+}
+
+// signSubstratePayload 使用签名密钥签名，返回32字节公钥和SCALE编码的MultiSignature
+// 签名类型由签名密钥的曲线决定，请求中指定cryptoType时必须与之一致
+func signSubstratePayload(cryptoType string, key Signer, message []byte) ([]byte, []byte, error) {
+	if key == nil {
+		return nil, nil, errors.New("signer is required")
+	}
+	if cryptoType != "" && cryptoType != key.Curve() {
+		return nil, nil, fmt.Errorf("crypto type %s does not match the key curve %s", cryptoType, key.Curve())
+	}
+
+	switch key.Curve() {
+	case SubstrateCryptoSr25519:
+		signature, err := signSr25519(key, message)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+		return key.PublicKey(), append([]byte{0x01}, signature...), nil
+
+	case SubstrateCryptoEd25519:
+		signature, err := signEd25519(key, message)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+		return key.PublicKey(), append([]byte{0x00}, signature...), nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported key curve: %s", key.Curve())
+	}
+}
+
+// signSr25519 用sr25519签名密钥以substrate为签名上下文签名，并用公钥验证签名
+func signSr25519(key Signer, message []byte) ([]byte, error) {
+	var publicKeyBytes [schnorrkel.PublicKeySize]byte
+	if len(key.PublicKey()) != len(publicKeyBytes) {
+		return nil, fmt.Errorf("invalid sr25519 public key length: %d", len(key.PublicKey()))
+	}
+	copy(publicKeyBytes[:], key.PublicKey())
+	publicKey, err := schnorrkel.NewPublicKey(publicKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sr25519 public key: %w", err)
+	}
+
+	signature, err := key.Sign(message)
+	if err != nil {
+		return nil, err
+	}
+	var signatureBytes [schnorrkel.SignatureSize]byte
+	if len(signature) != len(signatureBytes) {
+		return nil, fmt.Errorf("invalid sr25519 signature length: %d", len(signature))
+	}
+	copy(signatureBytes[:], signature)
+	var sig schnorrkel.Signature
+	if err := sig.Decode(signatureBytes); err != nil {
+		return nil, fmt.Errorf("invalid sr25519 signature: %w", err)
+	}
+	if ok, err := publicKey.Verify(&sig, schnorrkel.NewSigningContext(substrateSigningContext, message)); err != nil || !ok {
+		return nil, errors.New("signature does not match the signer public key")
+	}
+	return signature, nil
+}
+
+// encodeSubstrateSignedExtensions 按签名扩展的顺序编码extra和additional signed数据
+func encodeSubstrateSignedExtensions(payload *PolkadotSignerPayload) ([]byte, []byte, error) {
+	extensions := payload.SignedExtensions
+	if len(extensions) == 0 {
+		extensions = defaultSubstrateSignedExtensions
+	}
+
+	var extra, additional []byte
+	for _, extension := range extensions {
+		switch extension {
+		case "CheckNonZeroSender", "CheckWeight", "PrevalidateAttests", "CheckStorageAccess", "LockStakingStatus",
+			"ValidateEquivocationReport", "RestrictFunctionality", "StorageWeightReclaim":
+			// 不包含任何数据
+
+		case "CheckSpecVersion":
+			v, err := decodeSubstrateUint(payload.SpecVersion, 32)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid specVersion: %w", err)
+			}
+			additional = append(additional, scaleU32(uint32(v.Uint64()))...)
+
+		case "CheckTxVersion":
+			v, err := decodeSubstrateUint(payload.TransactionVersion, 32)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid transactionVersion: %w", err)
+			}
+			additional = append(additional, scaleU32(uint32(v.Uint64()))...)
+
+		case "CheckGenesis":
+			genesisHash, err := decodeSubstrateHash(payload.GenesisHash)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid genesisHash: %w", err)
+			}
+			additional = append(additional, genesisHash...)
+
+		case "CheckMortality", "CheckEra":
+			era, err := decodeSubstrateHex(payload.Era)
+			if err != nil || (len(era) != 1 && len(era) != 2) || (len(era) == 1 && era[0] != 0x00) {
+				return nil, nil, fmt.Errorf("invalid era: %s", payload.Era)
+			}
+			blockHash, err := decodeSubstrateHash(payload.BlockHash)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid blockHash: %w", err)
+			}
+			extra = append(extra, era...)
+			additional = append(additional, blockHash...)
+
+		case "CheckNonce":
+			nonce, err := decodeSubstrateUint(payload.Nonce, 32)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid nonce: %w", err)
+			}
+			extra = append(extra, scaleCompact(nonce)...)
+
+		case "ChargeTransactionPayment":
+			tip, err := decodeSubstrateUint(payload.Tip, 128)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid tip: %w", err)
+			}
+			extra = append(extra, scaleCompact(tip)...)
+
+		case "ChargeAssetTxPayment":
+			tip, err := decodeSubstrateUint(payload.Tip, 128)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid tip: %w", err)
+			}
+			extra = append(extra, scaleCompact(tip)...)
+			// Option<AssetId>
+			if payload.AssetID == "" {
+				extra = append(extra, 0x00)
+			} else {
+				assetID, err := decodeSubstrateHex(payload.AssetID)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid assetId: %w", err)
+				}
+				extra = append(append(extra, 0x01), assetID...)
+			}
+
+		case "CheckMetadataHash":
+			// extra为模式，additional为Option<[u8; 32]>
+			switch payload.Mode {
+			case 0:
+				extra = append(extra, 0x00)
+				additional = append(additional, 0x00)
+			case 1:
+				metadataHash, err := decodeSubstrateHash(payload.MetadataHash)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid metadataHash: %w", err)
+				}
+				extra = append(extra, 0x01)
+				additional = append(append(additional, 0x01), metadataHash...)
+			default:
+				return nil, nil, fmt.Errorf("invalid metadata hash mode: %d", payload.Mode)
+			}
+
+		default:
+			return nil, nil, fmt.Errorf("unsupported signed extension: %s", extension)
+		}
+	}
+
+	return extra, additional, nil
+}
+
+// decodeSubstrateHex 解析0x前缀（可选）的十六进制字符串
+func decodeSubstrateHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
+// decodeSubstrateHash 解析32字节的十六进制哈希
+func decodeSubstrateHash(s string) ([]byte, error) {
+	hash, err := decodeSubstrateHex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("expected 32 bytes, got %d bytes", len(hash))
+	}
+	return hash, nil
+}
+
+// decodeSubstrateUint 解析大端序十六进制表示的无符号整数，空字符串视为0
+func decodeSubstrateUint(s string, bits int) (*big.Int, error) {
+	digits := strings.TrimPrefix(s, "0x")
+	if digits == "" {
+		return new(big.Int), nil
+	}
+	v, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex number: %s", s)
+	}
+	if v.BitLen() > bits {
+		return nil, fmt.Errorf("value %s overflows u%d", s, bits)
+	}
+	return v, nil
+}
+
+// scaleU32 SCALE编码u32（小端序）
+func scaleU32(v uint32) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)}
+}
+
+// scaleCompact SCALE紧凑编码无符号整数
+//   - 0 ~ 2^6-1: 单字节模式
+//   - 2^6 ~ 2^14-1: 双字节模式
+//   - 2^14 ~ 2^30-1: 四字节模式
+//   - 更大的值: 大整数模式，首字节记录后续字节数
+func scaleCompact(v *big.Int) []byte {
+	switch {
+	case v.Cmp(big.NewInt(1<<6)) < 0:
+		return []byte{byte(v.Uint64() << 2)}
+	case v.Cmp(big.NewInt(1<<14)) < 0:
+		n := v.Uint64()<<2 | 0b01
+		return []byte{byte(n), byte(n >> 8)}
+	case v.Cmp(big.NewInt(1<<30)) < 0:
+		return scaleU32(uint32(v.Uint64()<<2 | 0b10))
+	default:
+		be := v.Bytes()
+		le := make([]byte, len(be))
+		for i := range be {
+			le[i] = be[len(be)-1-i]
+		}
+		return append([]byte{byte(len(le)-4)<<2 | 0b11}, le...)
+	}
+}
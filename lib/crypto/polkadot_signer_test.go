@@ -1,54 +1,179 @@
 package crypto
 
 import (
+	"crypto/ed25519"
+	"encoding/hex"
 	"encoding/json"
+	"math/big"
+	"strings"
 	"testing"
 
+	"github.com/ChainSafe/go-schnorrkel"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestPolkadotTransactionSigner_SignTransaction(t *testing.T) {
-	// 测试Polkadot
-	signer := &PolkadotTransactionSigner{IsKusama: false}
+// Polkadot创世区块哈希
+const testPolkadotGenesisHash = "0x91b171bb158e2d3848fa23a9f1c25182fb8e20313b2c1eb49219da7a70ce90c3"
+
+// newTestPolkadotPayload 构建向Bob转账的签名请求（balances.transferKeepAlive）
+func newTestPolkadotPayload(t *testing.T) PolkadotSignerPayload {
+	bob, _, err := SS58Decode("14E5nqKAp3oAJcmzgZhUD2RcptBeUBScxKHgJKU4HPNcKVf3")
+	require.NoError(t, err)
+	// pallet索引0x05，调用索引0x03，MultiAddress::Id(bob)，Compact<Balance>(10^12)
+	method := append([]byte{0x05, 0x03, 0x00}, bob...)
+	method = append(method, scaleCompact(big.NewInt(1000000000000))...)
+
+	return PolkadotSignerPayload{
+		Address:            "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5",
+		BlockHash:          testPolkadotGenesisHash,
+		BlockNumber:        "0x00000000",
+		Era:                "0x00",
+		GenesisHash:        testPolkadotGenesisHash,
+		Method:             "0x" + hex.EncodeToString(method),
+		Nonce:              "0x00000005",
+		SpecVersion:        "0x000f4dfc",
+		Tip:                "0x00000000000000000000000000000000",
+		TransactionVersion: "0x0000001a",
+		SignedExtensions:   defaultSubstrateSignedExtensions,
+		Version:            4,
+	}
+}
+
+// expectedSigningPayload 按Polkadot默认签名扩展手工构建签名负载
+func expectedSigningPayload(t *testing.T, payload PolkadotSignerPayload) []byte {
+	method, err := decodeSubstrateHex(payload.Method)
+	require.NoError(t, err)
+	genesisHash, _ := decodeSubstrateHex(payload.GenesisHash)
+	blockHash, _ := decodeSubstrateHex(payload.BlockHash)
 
-	// 测试用的私钥
-	privateKeyHex := "0000000000000000000000000000000000000000000000000000000000000001"
-
-	// 构建Polkadot交易请求
-	txReq := PolkadotTransactionRequest{
-		Address:      "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5",
-		CallModule:   "balances",
-		CallFunction: "transfer",
-		CallArgs: map[string]interface{}{
-			"dest":   "14E5nqKAp3oAJcmzgZhUD2RcptBeUBScxKHgJKU4HPNcKVf3",
-			"value":  1000000000000,
-		},
-		Nonce: 0,
-		Tip:   0,
-		Era:   "immortal",
+	var expected []byte
+	expected = append(expected, method...)
+	expected = append(expected, 0x00) // era: immortal
+	expected = append(expected, 0x14) // nonce: compact(5)
+	expected = append(expected, 0x00) // tip: compact(0)
+	expected = append(expected, 0x00) // CheckMetadataHash mode
+	expected = append(expected, scaleU32(1003004)...)
+	expected = append(expected, scaleU32(26)...)
+	expected = append(expected, genesisHash...)
+	expected = append(expected, blockHash...)
+	expected = append(expected, 0x00) // metadata hash: None
+	if len(expected) > 256 {
+		expected = Blake2b256(expected)
 	}
+	return expected
+}
 
-	rawTx, err := json.Marshal(txReq)
-	assert.NoError(t, err)
+func TestPolkadotTransactionSigner_SignTransaction(t *testing.T) {
+	signer := &PolkadotTransactionSigner{IsKusama: false}
+	payload := newTestPolkadotPayload(t)
+	rawTx, err := json.Marshal(payload)
+	require.NoError(t, err)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
-
-	// 验证结果
-	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	assert.Contains(t, signedTx, "dot_signed_")
-	assert.Contains(t, txHash, "dot_")
-
-	// 测试Kusama
-	signer = &PolkadotTransactionSigner{IsKusama: true}
-	signedTx, txHash, err = signer.SignTransaction(string(rawTx), privateKeyHex)
-
-	// 验证结果
-	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	assert.Contains(t, signedTx, "ksm_signed_")
-	assert.Contains(t, txHash, "ksm_")
-}
\ No newline at end of file
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "polkadot", testAliceSeed))
+	require.NoError(t, err)
+
+	extrinsic, err := decodeSubstrateHex(signedTx)
+	require.NoError(t, err)
+	assert.Equal(t, "0x"+hex.EncodeToString(Blake2b256(extrinsic)), txHash)
+
+	// 长度前缀 || 版本 || MultiAddress::Id || MultiSignature::Sr25519 || extra || call
+	method, _ := decodeSubstrateHex(payload.Method)
+	body := extrinsic[2:]
+	assert.Equal(t, scaleCompact(big.NewInt(int64(len(body)))), extrinsic[:2])
+	assert.Equal(t, byte(0x84), body[0])
+	assert.Equal(t, byte(0x00), body[1])
+	assert.Equal(t, testAlicePublicKey, hex.EncodeToString(body[2:34]))
+	assert.Equal(t, byte(0x01), body[34])
+	assert.Equal(t, []byte{0x00, 0x14, 0x00, 0x00}, body[99:103])
+	assert.Equal(t, method, body[103:])
+
+	// 使用公钥验证sr25519签名
+	var sigBytes [schnorrkel.SignatureSize]byte
+	copy(sigBytes[:], body[35:99])
+	signature := &schnorrkel.Signature{}
+	require.NoError(t, signature.Decode(sigBytes))
+	var pubBytes [schnorrkel.PublicKeySize]byte
+	copy(pubBytes[:], body[2:34])
+	pubKey, err := schnorrkel.NewPublicKey(pubBytes)
+	require.NoError(t, err)
+	ok, err := pubKey.Verify(signature, schnorrkel.NewSigningContext(substrateSigningContext, expectedSigningPayload(t, payload)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestPolkadotTransactionSigner_Ed25519(t *testing.T) {
+	signer := &PolkadotTransactionSigner{IsKusama: true}
+	payload := newTestPolkadotPayload(t)
+	payload.Address = ""
+	payload.CryptoType = SubstrateCryptoEd25519
+	// 调用数据较长时签名其Blake2b-256哈希
+	payload.Method += strings.Repeat("00", 300)
+	rawTx, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	// sr25519密钥不能签名ed25519交易
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "polkadot", testAliceSeed))
+	assert.Error(t, err)
+
+	seed, _ := hex.DecodeString(testAliceSeed)
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	signedTx, _, err := signer.SignTransaction(string(rawTx), NewEd25519Signer(privateKey))
+	require.NoError(t, err)
+
+	extrinsic, err := decodeSubstrateHex(signedTx)
+	require.NoError(t, err)
+	body := extrinsic[2:]
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	assert.Equal(t, []byte(publicKey), body[2:34])
+	assert.Equal(t, byte(0x00), body[34])
+	assert.True(t, ed25519.Verify(publicKey, expectedSigningPayload(t, payload), body[35:99]))
+}
+
+func TestPolkadotTransactionSigner_InvalidRequest(t *testing.T) {
+	signer := &PolkadotTransactionSigner{}
+
+	// 地址与私钥不匹配
+	payload := newTestPolkadotPayload(t)
+	payload.Address = "14E5nqKAp3oAJcmzgZhUD2RcptBeUBScxKHgJKU4HPNcKVf3"
+	rawTx, _ := json.Marshal(payload)
+	_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "polkadot", testAliceSeed))
+	assert.Error(t, err)
+
+	// 不支持的签名扩展
+	payload = newTestPolkadotPayload(t)
+	payload.SignedExtensions = append(payload.SignedExtensions, "UnknownExtension")
+	rawTx, _ = json.Marshal(payload)
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "polkadot", testAliceSeed))
+	assert.Error(t, err)
+
+	// 无效的私钥
+	_, err = ParsePrivateKey("polkadot", []byte("invalid_private_key"))
+	assert.Error(t, err)
+
+	// 非JSON数据
+	_, _, err = signer.SignTransaction("not json", testSigningKey(t, "polkadot", testAliceSeed))
+	assert.Error(t, err)
+}
+
+func TestScaleCompact(t *testing.T) {
+	testCases := []struct {
+		value    int64
+		expected string
+	}{
+		{0, "00"},
+		{1, "04"},
+		{63, "fc"},
+		{64, "0101"},
+		{16383, "fdff"},
+		{16384, "02000100"},
+		{1073741823, "feffffff"},
+		{1073741824, "0300000040"},
+		{1000000000000, "070010a5d4e8"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, hex.EncodeToString(scaleCompact(big.NewInt(tc.value))), tc.value)
+	}
+}
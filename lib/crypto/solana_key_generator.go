@@ -6,9 +6,25 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"github.com/featx/keys-gin/web/model"
 	"github.com/mr-tron/base58"
 )
 
+func init() {
+	mustRegisterChain(ChainModule{
+		ChainType: model.ChainTypeSolana,
+		Curve:     "ed25519",
+		Encoding:  "solana_address",
+		NewKeyGenerator: func() (KeyGenerator, error) {
+			return &SolanaKeyGenerator{}, nil
+		},
+		NewTransactionSigner: func() (TransactionSigner, error) {
+			return &SolanaTransactionSigner{}, nil
+		},
+		ValidateAddress: validateSolanaAddress,
+	})
+}
+
 // SolanaKeyGenerator Solana密钥生成器
 // 实现了使用标准库crypto/ed25519的真实Solana密钥生成
 // Solana使用Edwards-curve Digital Signature Algorithm (EdDSA)与Curve25519
@@ -82,6 +98,16 @@ func (g *SolanaKeyGenerator) PublicKeyToAddress(publicKey string) (address strin
 	return address, nil
 }
 
+// DerivationPath 返回SLIP-0010派生路径 m/44'/501'/account'/index'，与Phantom、Solflare等钱包一致
+func (g *SolanaKeyGenerator) DerivationPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/%d'", CoinTypeSolana, account, index)
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按SLIP-0010派生Solana密钥对
+func (g *SolanaKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	return deriveEd25519HDKeyPair(g, mnemonic, passphrase, path)
+}
+
 // AddressToPublicKey 将Solana地址转换回公钥
 func (g *SolanaKeyGenerator) AddressToPublicKey(address string) (publicKey string, err error) {
 	// 检查地址格式
@@ -104,4 +130,13 @@ func (g *SolanaKeyGenerator) AddressToPublicKey(address string) (publicKey strin
 	publicKey = hex.EncodeToString(publicKeyBytes)
 
 	return publicKey, nil
-}
\ No newline at end of file
+}
+
+// validateSolanaAddress 校验Solana地址：Base58编码的32字节公钥
+func validateSolanaAddress(address string) error {
+	decoded, err := base58.Decode(address)
+	if err != nil || len(decoded) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid solana address: %s", address)
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+// Solana交易格式的常量
+const (
+	// solanaVersionPrefix 版本化消息首字节的最高位
+	solanaVersionPrefix = 0x80
+	// solanaSignatureSize Ed25519签名长度
+	solanaSignatureSize = 64
+	// solanaPacketDataSize 交易序列化后的最大长度（IPv6 MTU减去报头）
+	solanaPacketDataSize = 1232
+)
+
+// Solana消息版本
+const (
+	// SolanaMessageLegacy 旧版消息
+	SolanaMessageLegacy = "legacy"
+	// SolanaMessageV0 v0版本消息，支持地址查找表
+	SolanaMessageV0 = "0"
+)
+
+// SolanaAccountMeta 指令引用的账户
+type SolanaAccountMeta struct {
+	Pubkey     string `json:"pubkey"`
+	IsSigner   bool   `json:"isSigner"`
+	IsWritable bool   `json:"isWritable"`
+}
+
+// SolanaAddressLookupTable 地址查找表，Addresses为链上查找表的完整内容（按索引排列）
+type SolanaAddressLookupTable struct {
+	Key       string   `json:"key"`
+	Addresses []string `json:"addresses"`
+}
+
+// solanaCompiledKey 编译过程中的账户及其权限
+type solanaCompiledKey struct {
+	pubkey     [32]byte
+	isSigner   bool
+	isWritable bool
+	isInvoked  bool
+}
+
+// solanaLookup 编译后的地址查找表引用
+type solanaLookup struct {
+	key             [32]byte
+	writableIndexes []byte
+	readonlyIndexes []byte
+}
+
+// solanaMessage 编译后的Solana消息
+type solanaMessage struct {
+	version               string
+	numRequiredSignatures byte
+	numReadonlySigned     byte
+	numReadonlyUnsigned   byte
+	accountKeys           [][32]byte
+	recentBlockhash       [32]byte
+	instructions          []solanaCompiledInstruction
+	lookups               []solanaLookup
+}
+
+// solanaCompiledInstruction 编译后的指令，账户以索引表示
+type solanaCompiledInstruction struct {
+	programIDIndex byte
+	accounts       []byte
+	data           []byte
+}
+
+// compileSolanaMessage 将交易请求编译为消息
+// 账户按可写签名者（手续费支付者在首位）、只读签名者、可写非签名者、只读非签名者排序，
+// v0消息中未被调用为程序的非签名账户如存在于查找表中，则改为通过查找表加载
+func compileSolanaMessage(txReq *SolanaTransactionRequest, feePayer [32]byte) (*solanaMessage, error) {
+	version := txReq.Version
+	if version == "" {
+		version = SolanaMessageLegacy
+	}
+	if version != SolanaMessageLegacy && version != SolanaMessageV0 {
+		return nil, fmt.Errorf("unsupported solana message version: %s", txReq.Version)
+	}
+	if version == SolanaMessageLegacy && len(txReq.AddressLookupTables) > 0 {
+		return nil, errors.New("address lookup tables require a v0 message")
+	}
+
+	blockhash, err := decodeSolanaPubkey(txReq.RecentBlockhash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recent blockhash: %w", err)
+	}
+	if len(txReq.Instructions) == 0 {
+		return nil, errors.New("transaction has no instructions")
+	}
+
+	// 按出现顺序收集账户并合并权限
+	var keys []*solanaCompiledKey
+	keyIndex := map[[32]byte]*solanaCompiledKey{}
+	getOrInsert := func(pubkey [32]byte) *solanaCompiledKey {
+		if key, ok := keyIndex[pubkey]; ok {
+			return key
+		}
+		key := &solanaCompiledKey{pubkey: pubkey}
+		keyIndex[pubkey] = key
+		keys = append(keys, key)
+		return key
+	}
+
+	payer := getOrInsert(feePayer)
+	payer.isSigner, payer.isWritable = true, true
+
+	type decodedInstruction struct {
+		programID [32]byte
+		accounts  [][32]byte
+		data      []byte
+	}
+	instructions := make([]decodedInstruction, 0, len(txReq.Instructions))
+	for i, ix := range txReq.Instructions {
+		programID, err := decodeSolanaPubkey(ix.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid program id in instruction %d: %w", i, err)
+		}
+		data, err := base64.StdEncoding.DecodeString(ix.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data in instruction %d: %w", i, err)
+		}
+		getOrInsert(programID).isInvoked = true
+
+		decoded := decodedInstruction{programID: programID, data: data}
+		for _, meta := range ix.Accounts {
+			pubkey, err := decodeSolanaPubkey(meta.Pubkey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid account in instruction %d: %w", i, err)
+			}
+			key := getOrInsert(pubkey)
+			key.isSigner = key.isSigner || meta.IsSigner
+			key.isWritable = key.isWritable || meta.IsWritable
+			decoded.accounts = append(decoded.accounts, pubkey)
+		}
+		instructions = append(instructions, decoded)
+	}
+
+	// 从查找表中提取可以动态加载的账户
+	msg := &solanaMessage{version: version, recentBlockhash: blockhash}
+	var loadedWritable, loadedReadonly [][32]byte
+	for _, table := range txReq.AddressLookupTables {
+		tableKey, err := decodeSolanaPubkey(table.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lookup table key: %w", err)
+		}
+		lookup := solanaLookup{key: tableKey}
+		for i, address := range table.Addresses {
+			if i > 255 {
+				return nil, fmt.Errorf("lookup table %s has more than 256 addresses", table.Key)
+			}
+			pubkey, err := decodeSolanaPubkey(address)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address in lookup table %s: %w", table.Key, err)
+			}
+			key, ok := keyIndex[pubkey]
+			if !ok || key.isSigner || key.isInvoked {
+				continue
+			}
+			delete(keyIndex, pubkey)
+			if key.isWritable {
+				lookup.writableIndexes = append(lookup.writableIndexes, byte(i))
+				loadedWritable = append(loadedWritable, pubkey)
+			} else {
+				lookup.readonlyIndexes = append(lookup.readonlyIndexes, byte(i))
+				loadedReadonly = append(loadedReadonly, pubkey)
+			}
+		}
+		if len(lookup.writableIndexes) > 0 || len(lookup.readonlyIndexes) > 0 {
+			msg.lookups = append(msg.lookups, lookup)
+		}
+	}
+
+	// 静态账户分组排序
+	groups := [4][][32]byte{}
+	for _, key := range keys {
+		if _, ok := keyIndex[key.pubkey]; !ok {
+			continue
+		}
+		switch {
+		case key.isSigner && key.isWritable:
+			groups[0] = append(groups[0], key.pubkey)
+		case key.isSigner:
+			groups[1] = append(groups[1], key.pubkey)
+		case key.isWritable:
+			groups[2] = append(groups[2], key.pubkey)
+		default:
+			groups[3] = append(groups[3], key.pubkey)
+		}
+	}
+	for _, group := range groups {
+		msg.accountKeys = append(msg.accountKeys, group...)
+	}
+	if len(groups[0])+len(groups[1]) > 255 || len(msg.accountKeys)+len(loadedWritable)+len(loadedReadonly) > 256 {
+		return nil, errors.New("too many accounts in transaction")
+	}
+	msg.numRequiredSignatures = byte(len(groups[0]) + len(groups[1]))
+	msg.numReadonlySigned = byte(len(groups[1]))
+	msg.numReadonlyUnsigned = byte(len(groups[3]))
+
+	// 指令中的账户索引依次指向静态账户、查找表加载的可写账户、查找表加载的只读账户
+	indexes := map[[32]byte]byte{}
+	for i, pubkey := range append(append(append([][32]byte{}, msg.accountKeys...), loadedWritable...), loadedReadonly...) {
+		indexes[pubkey] = byte(i)
+	}
+	for _, ix := range instructions {
+		compiled := solanaCompiledInstruction{programIDIndex: indexes[ix.programID], data: ix.data}
+		for _, account := range ix.accounts {
+			compiled.accounts = append(compiled.accounts, indexes[account])
+		}
+		msg.instructions = append(msg.instructions, compiled)
+	}
+
+	return msg, nil
+}
+
+// serialize 序列化消息，即需要签名的数据
+func (m *solanaMessage) serialize() []byte {
+	var buf bytes.Buffer
+	if m.version == SolanaMessageV0 {
+		buf.WriteByte(solanaVersionPrefix)
+	}
+	buf.Write([]byte{m.numRequiredSignatures, m.numReadonlySigned, m.numReadonlyUnsigned})
+
+	buf.Write(solanaShortVec(len(m.accountKeys)))
+	for _, key := range m.accountKeys {
+		buf.Write(key[:])
+	}
+	buf.Write(m.recentBlockhash[:])
+
+	buf.Write(solanaShortVec(len(m.instructions)))
+	for _, ix := range m.instructions {
+		buf.WriteByte(ix.programIDIndex)
+		buf.Write(solanaShortVec(len(ix.accounts)))
+		buf.Write(ix.accounts)
+		buf.Write(solanaShortVec(len(ix.data)))
+		buf.Write(ix.data)
+	}
+
+	if m.version == SolanaMessageV0 {
+		buf.Write(solanaShortVec(len(m.lookups)))
+		for _, lookup := range m.lookups {
+			buf.Write(lookup.key[:])
+			buf.Write(solanaShortVec(len(lookup.writableIndexes)))
+			buf.Write(lookup.writableIndexes)
+			buf.Write(solanaShortVec(len(lookup.readonlyIndexes)))
+			buf.Write(lookup.readonlyIndexes)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// solanaTransaction 序列化的交易：签名列表和消息
+type solanaTransaction struct {
+	signatures [][]byte
+	message    []byte
+	// signers 需要签名的账户，即消息中前numRequiredSignatures个静态账户
+	signers [][32]byte
+}
+
+// parseSolanaTransaction 解析序列化的交易（签名列表可以为空或占位）
+func parseSolanaTransaction(data []byte) (*solanaTransaction, error) {
+	numSignatures, n, err := readSolanaShortVec(data)
+	if err != nil {
+		return nil, err
+	}
+	data = data[n:]
+	if len(data) < numSignatures*solanaSignatureSize {
+		return nil, errors.New("unexpected end of transaction")
+	}
+
+	tx := &solanaTransaction{}
+	for i := 0; i < numSignatures; i++ {
+		tx.signatures = append(tx.signatures, append([]byte{}, data[:solanaSignatureSize]...))
+		data = data[solanaSignatureSize:]
+	}
+	tx.message = data
+
+	// 解析消息头和静态账户
+	if len(data) > 0 && data[0]&solanaVersionPrefix != 0 {
+		if version := data[0] &^ solanaVersionPrefix; version != 0 {
+			return nil, fmt.Errorf("unsupported solana message version: %d", version)
+		}
+		data = data[1:]
+	}
+	if len(data) < 3 {
+		return nil, errors.New("unexpected end of message header")
+	}
+	numRequiredSignatures := int(data[0])
+	numKeys, n, err := readSolanaShortVec(data[3:])
+	if err != nil {
+		return nil, err
+	}
+	data = data[3+n:]
+	if numKeys < numRequiredSignatures || len(data) < numKeys*32 {
+		return nil, errors.New("invalid message account keys")
+	}
+	for i := 0; i < numRequiredSignatures; i++ {
+		var key [32]byte
+		copy(key[:], data[i*32:])
+		tx.signers = append(tx.signers, key)
+	}
+
+	// 签名列表为空时按需要的签名数量补齐占位签名
+	switch len(tx.signatures) {
+	case numRequiredSignatures:
+	case 0:
+		for i := 0; i < numRequiredSignatures; i++ {
+			tx.signatures = append(tx.signatures, make([]byte, solanaSignatureSize))
+		}
+	default:
+		return nil, fmt.Errorf("transaction has %d signatures, message requires %d", len(tx.signatures), numRequiredSignatures)
+	}
+
+	return tx, nil
+}
+
+// serialize 序列化交易
+func (tx *solanaTransaction) serialize() []byte {
+	var buf bytes.Buffer
+	buf.Write(solanaShortVec(len(tx.signatures)))
+	for _, signature := range tx.signatures {
+		buf.Write(signature)
+	}
+	buf.Write(tx.message)
+	return buf.Bytes()
+}
+
+// signerIndex 返回公钥在签名者中的位置
+func (tx *solanaTransaction) signerIndex(pubkey [32]byte) (int, bool) {
+	for i, signer := range tx.signers {
+		if signer == pubkey {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// solanaShortVec 编码compact-u16长度前缀
+func solanaShortVec(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// readSolanaShortVec 解码compact-u16长度前缀，返回数值和占用的字节数
+func readSolanaShortVec(data []byte) (int, int, error) {
+	var n int
+	for i := 0; i < 3; i++ {
+		if i >= len(data) {
+			return 0, 0, errors.New("unexpected end of compact-u16")
+		}
+		n |= int(data[i]&0x7f) << (7 * i)
+		if data[i]&0x80 == 0 {
+			return n, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("invalid compact-u16")
+}
+
+// decodeSolanaPubkey 解码Base58编码的32字节公钥或哈希
+func decodeSolanaPubkey(s string) ([32]byte, error) {
+	var key [32]byte
+	data, err := base58.Decode(s)
+	if err != nil {
+		return key, err
+	}
+	if len(data) != len(key) {
+		return key, fmt.Errorf("invalid length: expected 32 bytes, got %d bytes", len(data))
+	}
+	copy(key[:], data)
+	return key, nil
+}
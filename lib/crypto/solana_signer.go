@@ -2,75 +2,112 @@ package crypto
 
 import (
 	"crypto/ed25519"
-	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+// Solana交易的序列化编码
+const (
+	// SolanaEncodingBase64 Base64编码（默认），RPC sendTransaction推荐的编码
+	SolanaEncodingBase64 = "base64"
+	// SolanaEncodingBase58 Base58编码
+	SolanaEncodingBase58 = "base58"
 )
 
 // SolanaTransactionRequest Solana交易请求结构
+// 传入Transaction时直接为已序列化的未签名交易添加签名，忽略其余的交易字段
 type SolanaTransactionRequest struct {
-	RecentBlockhash string             `json:"recentBlockhash"`
-	Signatures      []string           `json:"signatures"`
-	Instructions    []SolanaInstruction `json:"instructions"`
+	FeePayer            string                     `json:"feePayer,omitempty"` // 手续费支付者，默认为签名账户
+	RecentBlockhash     string                     `json:"recentBlockhash"`
+	Instructions        []SolanaInstruction        `json:"instructions"`
+	Version             string                     `json:"version,omitempty"`             // 消息版本：legacy（默认）或0
+	AddressLookupTables []SolanaAddressLookupTable `json:"addressLookupTables,omitempty"` // v0消息使用的地址查找表
+	Transaction         string                     `json:"transaction,omitempty"`         // 已序列化的交易
+	Encoding            string                     `json:"encoding,omitempty"`            // Transaction及返回交易的编码：base64（默认）或base58
 }
 
 // SolanaInstruction Solana交易指令
 type SolanaInstruction struct {
-	ProgramID string   `json:"programId"`
-	Accounts  []string `json:"accounts"`
-	Data      string   `json:"data"` // Base64编码的指令数据
+	ProgramID string              `json:"programId"`
+	Accounts  []SolanaAccountMeta `json:"accounts"`
+	Data      string              `json:"data"` // Base64编码的指令数据
 }
 
 // SolanaTransactionSigner Solana交易签名器
 type SolanaTransactionSigner struct{}
 
 // SignTransaction 签名Solana交易
-// 使用Ed25519算法进行签名，符合Solana的要求
-func (s *SolanaTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (string, string, error) {
-	// 解码私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
-	}
-
-	var privateKey ed25519.PrivateKey
-	// 处理不同长度的私钥
-	if len(privateKeyBytes) == 32 {
-		// 如果是32字节的私钥种子，生成完整的64字节私钥
-		publicKey := ed25519.PublicKey(privateKeyBytes)
-		privateKey = make([]byte, ed25519.PrivateKeySize)
-		copy(privateKey, privateKeyBytes)
-		copy(privateKey[ed25519.PrivateKeySize-ed25519.PublicKeySize:], publicKey)
-	} else if len(privateKeyBytes) == ed25519.PrivateKeySize {
-		// 如果已经是完整的64字节私钥，直接使用
-		privateKey = privateKeyBytes
-	} else {
-		return "", "", fmt.Errorf("invalid private key length: expected 32 or %d bytes, got %d bytes", ed25519.PrivateKeySize, len(privateKeyBytes))
+// 使用Ed25519算法对消息进行签名，返回序列化的交易，交易哈希为第一个签名（Base58编码）
+func (s *SolanaTransactionSigner) SignTransaction(rawTx string, signer Signer) (string, string, error) {
+	if err := requireCurve(signer, CurveEd25519); err != nil {
+		return "", "", err
 	}
+	var pubkey [32]byte
+	copy(pubkey[:], signer.PublicKey())
 
 	// 解析交易参数
 	var txReq SolanaTransactionRequest
-	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+	var err error
+	if err = json.Unmarshal([]byte(rawTx), &txReq); err != nil {
 		return "", "", fmt.Errorf("invalid transaction data format: %w", err)
 	}
 
-	// 准备要签名的数据
-	txDataHash := sha256.Sum256([]byte(rawTx))
+	// 获取待签名的交易：解析调用方序列化的交易，或由交易请求编译消息
+	var tx *solanaTransaction
+	if txReq.Transaction != "" {
+		data, err := decodeSolanaData(txReq.Transaction, txReq.Encoding)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid transaction encoding: %w", err)
+		}
+		if tx, err = parseSolanaTransaction(data); err != nil {
+			return "", "", fmt.Errorf("invalid transaction: %w", err)
+		}
+	} else {
+		feePayer := pubkey
+		if txReq.FeePayer != "" {
+			if feePayer, err = decodeSolanaPubkey(txReq.FeePayer); err != nil {
+				return "", "", fmt.Errorf("invalid fee payer: %w", err)
+			}
+		}
+		msg, err := compileSolanaMessage(&txReq, feePayer)
+		if err != nil {
+			return "", "", err
+		}
+		if tx, err = parseSolanaTransaction(append(solanaShortVec(0), msg.serialize()...)); err != nil {
+			return "", "", err
+		}
+	}
 
-	// 使用Ed25519私钥对数据进行签名
-	signature := ed25519.Sign(privateKey, txDataHash[:])
+	// 在签名者对应的位置填入签名
+	index, ok := tx.signerIndex(pubkey)
+	if !ok {
+		return "", "", fmt.Errorf("account %s is not a required signer of the transaction", base58.Encode(pubkey[:]))
+	}
+	signature, err := signEd25519(signer, tx.message)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	tx.signatures[index] = signature
 
-	// 构建带前缀的签名交易和交易哈希
-	signedTx := "sol_signed_" + hex.EncodeToString(signature)
-	txHash := "sol_" + hex.EncodeToString(txDataHash[:])
+	serialized := tx.serialize()
+	if len(serialized) > solanaPacketDataSize {
+		return "", "", fmt.Errorf("transaction too large: %d bytes, max %d bytes", len(serialized), solanaPacketDataSize)
+	}
+	signedTx, err := encodeSolanaData(serialized, txReq.Encoding)
+	if err != nil {
+		return "", "", err
+	}
 
-	return signedTx, txHash, nil
+	return signedTx, base58.Encode(tx.signatures[0]), nil
 }
 
 // VerifyTransaction 验证Solana交易签名
-// 这个方法用于验证交易签名是否有效
-func (s *SolanaTransactionSigner) VerifyTransaction(rawTx, signatureHex, publicKeyHex string) (bool, error) {
+// signedTx为SignTransaction返回的序列化交易，rawTx用于确定交易的编码
+func (s *SolanaTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex string) (bool, error) {
 	// 解码公钥
 	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
 	if err != nil {
@@ -82,20 +119,31 @@ func (s *SolanaTransactionSigner) VerifyTransaction(rawTx, signatureHex, publicK
 		return false, fmt.Errorf("invalid public key length: expected 32 bytes, got %d bytes", len(publicKeyBytes))
 	}
 
-	// 将字节切片转换为ed25519.PublicKey类型
-	publicKey := ed25519.PublicKey(publicKeyBytes)
+	var txReq SolanaTransactionRequest
+	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+		return false, fmt.Errorf("invalid transaction data format: %w", err)
+	}
 
-	// 解码签名
-	signature, err := hex.DecodeString(signatureHex)
+	// 解析签名后的交易
+	data, err := decodeSolanaData(signedTx, txReq.Encoding)
 	if err != nil {
-		return false, fmt.Errorf("invalid signature format: %w", err)
+		return false, fmt.Errorf("invalid signed transaction encoding: %w", err)
+	}
+	tx, err := parseSolanaTransaction(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid signed transaction: %w", err)
 	}
 
-	// 准备要验证的数据（与签名时相同）
-	txDataHash := sha256.Sum256([]byte(rawTx))
+	// 公钥必须是交易的签名者
+	var pubkey [32]byte
+	copy(pubkey[:], publicKeyBytes)
+	index, ok := tx.signerIndex(pubkey)
+	if !ok {
+		return false, nil
+	}
 
 	// 使用Ed25519公钥验证签名
-	valid := ed25519.Verify(publicKey, txDataHash[:], signature)
+	valid := ed25519.Verify(publicKeyBytes, tx.message, tx.signatures[index])
 
 	return valid, nil
 }
@@ -109,7 +157,6 @@ func (s *SolanaTransactionSigner) CreateSolanaTransaction(
 	// 创建交易请求
 	txReq := SolanaTransactionRequest{
 		RecentBlockhash: recentBlockhash,
-		Signatures:      []string{}, // 签名将在之后添加
 		Instructions:    instructions,
 	}
 
@@ -120,4 +167,28 @@ func (s *SolanaTransactionSigner) CreateSolanaTransaction(
 	}
 
 	return string(txJson), nil
-}
\ No newline at end of file
+}
+
+// decodeSolanaData 按指定编码解码序列化的交易
+func decodeSolanaData(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", SolanaEncodingBase64:
+		return base64.StdEncoding.DecodeString(s)
+	case SolanaEncodingBase58:
+		return base58.Decode(s)
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+}
+
+// encodeSolanaData 按指定编码编码序列化的交易
+func encodeSolanaData(data []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", SolanaEncodingBase64:
+		return base64.StdEncoding.EncodeToString(data), nil
+	case SolanaEncodingBase58:
+		return base58.Encode(data), nil
+	default:
+		return "", fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+}
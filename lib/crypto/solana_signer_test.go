@@ -1,43 +1,246 @@
 package crypto
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"testing"
 
+	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+const (
+	// 测试用的私钥
+	testSolanaPrivateKey = "0000000000000000000000000000000000000000000000000000000000000001"
+	testSolanaBlockhash  = "EETubP5AKHgjPAhzPAFcb8BAY1hMHc4py8gRqsAKSKiW"
+	testSolanaRecipient  = "2vJhN51FwR9pLVfFzGkXgW9xNCMdYQyH84ZtMvVwXQ9s"
+	solanaSystemProgram  = "11111111111111111111111111111111"
+)
+
+// testSolanaAccount 由32字节种子得到的Solana账户
+func testSolanaAccount(t *testing.T, seedHex string) (ed25519.PublicKey, string) {
+	seed, err := hex.DecodeString(seedHex)
+	require.NoError(t, err)
+	publicKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	return publicKey, base58.Encode(publicKey)
+}
+
+// systemTransfer System Program的转账指令
+func systemTransfer(from, to string, lamports uint64) SolanaInstruction {
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data, 2)
+	binary.LittleEndian.PutUint64(data[4:], lamports)
+	return SolanaInstruction{
+		ProgramID: solanaSystemProgram,
+		Accounts: []SolanaAccountMeta{
+			{Pubkey: from, IsSigner: true, IsWritable: true},
+			{Pubkey: to, IsWritable: true},
+		},
+		Data: base64.StdEncoding.EncodeToString(data),
+	}
+}
+
 func TestSolanaTransactionSigner_SignTransaction(t *testing.T) {
 	signer := &SolanaTransactionSigner{}
-
-	// 测试用的私钥
-	privateKeyHex := "0000000000000000000000000000000000000000000000000000000000000001"
+	publicKey, address := testSolanaAccount(t, testSolanaPrivateKey)
 
 	// 构建Solana交易请求
 	txReq := SolanaTransactionRequest{
-		RecentBlockhash: "EETubP5AKHgjPAhzPAFcb8BAY1hMHc4py8gRqsAKSKiW",
-		Signatures:      []string{""}, // 空签名，等待填充
-		Instructions: []SolanaInstruction{
-			{
-				ProgramID: "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
-				Accounts:  []string{"2vJhN51FwR9pLVfFzGkXgW9xNCMdYQyH84ZtMvVwXQ9s", "11111111111111111111111111111111"},
-				Data:      "AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
-			},
-		},
+		RecentBlockhash: testSolanaBlockhash,
+		Instructions:    []SolanaInstruction{systemTransfer(address, testSolanaRecipient, 1000000)},
 	}
 
 	rawTx, err := json.Marshal(txReq)
 	assert.NoError(t, err)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "solana", testSolanaPrivateKey))
 
 	// 验证结果
+	require.NoError(t, err)
+	data, err := base64.StdEncoding.DecodeString(signedTx)
+	require.NoError(t, err)
+
+	// 旧版消息：消息头 || 账户 || 最近区块哈希 || 指令
+	recipient, _ := base58.Decode(testSolanaRecipient)
+	system, _ := base58.Decode(solanaSystemProgram)
+	blockhash, _ := base58.Decode(testSolanaBlockhash)
+	ixData, _ := base64.StdEncoding.DecodeString(txReq.Instructions[0].Data)
+	var expected bytes.Buffer
+	expected.Write([]byte{1, 0, 1, 3})
+	expected.Write(publicKey)
+	expected.Write(recipient)
+	expected.Write(system)
+	expected.Write(blockhash)
+	expected.Write([]byte{1, 2, 2, 0, 1, 12})
+	expected.Write(ixData)
+
+	assert.Equal(t, byte(1), data[0])
+	assert.Equal(t, expected.Bytes(), data[1+64:])
+	assert.True(t, ed25519.Verify(publicKey, expected.Bytes(), data[1:65]))
+	assert.Equal(t, base58.Encode(data[1:65]), txHash)
+
+	// 通过VerifyTransaction验证签名
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, hex.EncodeToString(publicKey))
 	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	// 简化断言，只要签名和哈希不为空即可
-	// 注释掉可能导致测试失败的特定前缀检查
-	// assert.Contains(t, signedTx, "sol_signed_")
-	// assert.Contains(t, txHash, "sol_")
-}
\ No newline at end of file
+	assert.True(t, valid)
+
+	// Base58编码
+	txReq.Encoding = SolanaEncodingBase58
+	rawTx, _ = json.Marshal(txReq)
+	signedTx58, txHash58, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "solana", testSolanaPrivateKey))
+	require.NoError(t, err)
+	assert.Equal(t, base58.Encode(data), signedTx58)
+	assert.Equal(t, txHash, txHash58)
+}
+
+func TestSolanaTransactionSigner_V0LookupTable(t *testing.T) {
+	signer := &SolanaTransactionSigner{}
+	publicKey, address := testSolanaAccount(t, testSolanaPrivateKey)
+	_, readonly := testSolanaAccount(t, "0000000000000000000000000000000000000000000000000000000000000002")
+
+	// 转账并引用一个只读账户，接收方和只读账户都在查找表中
+	ix := systemTransfer(address, testSolanaRecipient, 1)
+	ix.Accounts = append(ix.Accounts, SolanaAccountMeta{Pubkey: readonly})
+	_, tableKey := testSolanaAccount(t, "0000000000000000000000000000000000000000000000000000000000000003")
+	txReq := SolanaTransactionRequest{
+		RecentBlockhash: testSolanaBlockhash,
+		Instructions:    []SolanaInstruction{ix},
+		Version:         SolanaMessageV0,
+		AddressLookupTables: []SolanaAddressLookupTable{
+			{Key: tableKey, Addresses: []string{readonly, solanaSystemProgram, testSolanaRecipient}},
+		},
+	}
+	rawTx, _ := json.Marshal(txReq)
+
+	signedTx, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "solana", testSolanaPrivateKey))
+	require.NoError(t, err)
+	data, _ := base64.StdEncoding.DecodeString(signedTx)
+	message := data[1+64:]
+
+	// 版本前缀 || 消息头 || 静态账户（签名者和被调用的程序）
+	assert.Equal(t, []byte{0x80, 1, 0, 1, 2}, message[:5])
+	assert.Equal(t, []byte(publicKey), message[5:37])
+	system, _ := base58.Decode(solanaSystemProgram)
+	assert.Equal(t, system, message[37:69])
+
+	// 指令：程序索引1，账户依次为签名者、查找表可写账户（索引2）、查找表只读账户（索引3）
+	rest := message[69+32:]
+	assert.Equal(t, []byte{1, 1, 3, 0, 2, 3, 12}, rest[:7])
+	rest = rest[7+12:]
+
+	// 查找表：可写索引[2]，只读索引[0]
+	table, _ := base58.Decode(tableKey)
+	assert.Equal(t, byte(1), rest[0])
+	assert.Equal(t, table, rest[1:33])
+	assert.Equal(t, []byte{1, 2, 1, 0}, rest[33:])
+
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, hex.EncodeToString(publicKey))
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// 旧版消息不支持查找表
+	txReq.Version = SolanaMessageLegacy
+	rawTx, _ = json.Marshal(txReq)
+	_, _, err = signer.SignTransaction(string(rawTx), testSigningKey(t, "solana", testSolanaPrivateKey))
+	assert.Error(t, err)
+}
+
+func TestSolanaTransactionSigner_SerializedTransaction(t *testing.T) {
+	signer := &SolanaTransactionSigner{}
+	const payerKey = "0000000000000000000000000000000000000000000000000000000000000004"
+	payerPublicKey, payer := testSolanaAccount(t, payerKey)
+	publicKey, address := testSolanaAccount(t, testSolanaPrivateKey)
+
+	// 由其他账户支付手续费的转账，先由本账户签名
+	txReq := SolanaTransactionRequest{
+		FeePayer:        payer,
+		RecentBlockhash: testSolanaBlockhash,
+		Instructions:    []SolanaInstruction{systemTransfer(address, testSolanaRecipient, 1)},
+	}
+	rawTx, _ := json.Marshal(txReq)
+	partiallySigned, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "solana", testSolanaPrivateKey))
+	require.NoError(t, err)
+	// 手续费支付者尚未签名，第一个签名为空
+	assert.Equal(t, base58.Encode(make([]byte, 64)), txHash)
+
+	// 手续费支付者对序列化的交易追加签名
+	serializedReq, _ := json.Marshal(SolanaTransactionRequest{Transaction: partiallySigned})
+	signedTx, txHash, err := signer.SignTransaction(string(serializedReq), testSigningKey(t, "solana", payerKey))
+	require.NoError(t, err)
+
+	data, _ := base64.StdEncoding.DecodeString(signedTx)
+	assert.Equal(t, byte(2), data[0])
+	assert.Equal(t, base58.Encode(data[1:65]), txHash)
+
+	for _, key := range []ed25519.PublicKey{payerPublicKey, publicKey} {
+		valid, err := signer.VerifyTransaction(string(serializedReq), signedTx, hex.EncodeToString(key))
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	// 不在签名者中的账户无法签名
+	_, _, err = signer.SignTransaction(string(serializedReq), testSigningKey(t, "solana", "0000000000000000000000000000000000000000000000000000000000000009"))
+	assert.Error(t, err)
+}
+
+func TestSolanaTransactionSigner_InvalidRequest(t *testing.T) {
+	signer := &SolanaTransactionSigner{}
+	_, address := testSolanaAccount(t, testSolanaPrivateKey)
+	valid := SolanaTransactionRequest{
+		RecentBlockhash: testSolanaBlockhash,
+		Instructions:    []SolanaInstruction{systemTransfer(address, testSolanaRecipient, 1)},
+	}
+
+	testCases := []func(req *SolanaTransactionRequest){
+		// 无效的区块哈希
+		func(req *SolanaTransactionRequest) { req.RecentBlockhash = "invalid" },
+		// 没有指令
+		func(req *SolanaTransactionRequest) { req.Instructions = nil },
+		// 不支持的消息版本
+		func(req *SolanaTransactionRequest) { req.Version = "1" },
+		// 不支持的编码
+		func(req *SolanaTransactionRequest) { req.Encoding = "hex" },
+		// 无效的序列化交易
+		func(req *SolanaTransactionRequest) { req.Transaction = "AQ==" },
+	}
+
+	for i, modify := range testCases {
+		req := valid
+		modify(&req)
+		rawTx, _ := json.Marshal(req)
+		_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "solana", testSolanaPrivateKey))
+		assert.Error(t, err, i)
+	}
+
+	// 无效的私钥
+	_, err := ParsePrivateKey("solana", []byte("invalid_private_key"))
+	assert.Error(t, err)
+}
+
+func TestSolanaShortVec(t *testing.T) {
+	testCases := []struct {
+		value    int
+		expected string
+	}{
+		{0, "00"},
+		{127, "7f"},
+		{128, "8001"},
+		{16383, "ff7f"},
+		{16384, "808001"},
+	}
+
+	for _, tc := range testCases {
+		encoded := solanaShortVec(tc.value)
+		assert.Equal(t, tc.expected, hex.EncodeToString(encoded))
+		decoded, n, err := readSolanaShortVec(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.value, decoded)
+		assert.Equal(t, len(encoded), n)
+	}
+}
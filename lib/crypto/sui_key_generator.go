@@ -1,108 +1,329 @@
 package crypto
 
 import (
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
+	"strings"
 
-	"github.com/mr-tron/base58"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/featx/keys-gin/web/model"
 )
 
+// SUI签名方案
+const (
+	// SuiSchemeEd25519 Ed25519（默认）
+	SuiSchemeEd25519 = "ed25519"
+	// SuiSchemeSecp256k1 ECDSA secp256k1
+	SuiSchemeSecp256k1 = "secp256k1"
+	// SuiSchemeSecp256r1 ECDSA secp256r1（P-256）
+	SuiSchemeSecp256r1 = "secp256r1"
+)
+
+// suiSchemeFlags 签名方案对应的标志字节，用于地址、序列化签名和suiprivkey
+var suiSchemeFlags = map[string]byte{
+	SuiSchemeEd25519:   0x00,
+	SuiSchemeSecp256k1: 0x01,
+	SuiSchemeSecp256r1: 0x02,
+}
+
+// suiPrivateKeyPrefix Bech32编码私钥的HRP
+const suiPrivateKeyPrefix = "suiprivkey"
+
+func init() {
+	mustRegisterChain(ChainModule{
+		ChainType: model.ChainTypeSUI,
+		Curve:     "ed25519",
+		Encoding:  "sui_address",
+		NewKeyGenerator: func() (KeyGenerator, error) {
+			return &SuiKeyGenerator{}, nil
+		},
+		NewTransactionSigner: func() (TransactionSigner, error) {
+			return &SuiTransactionSigner{}, nil
+		},
+		ParsePrivateKey: parseSuiSigningKey,
+		ValidateAddress: validateSuiAddress,
+	})
+}
+
 // SuiKeyGenerator SUI密钥生成器
-// 实现了使用标准库crypto/ed25519的真实SUI密钥生成
-// SUI使用Edwards-curve Digital Signature Algorithm (EdDSA)与Curve25519
-type SuiKeyGenerator struct{}
+// Scheme 指定签名方案：ed25519（默认）、secp256k1或secp256r1
+// 地址为 0x || 十六进制(Blake2b-256(flag || 公钥))
+// DeriveKeyPairFromPrivateKey 同时接受十六进制私钥和suiprivkey格式（Bech32）的私钥，后者的方案由其标志字节决定
+type SuiKeyGenerator struct {
+	Scheme string
+}
 
 // GenerateKeyPair 生成SUI密钥对
 func (g *SuiKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey string, err error) {
-	// 生成随机私钥（符合Ed25519要求）
-	publicKeyBytes, privateKeyBytes, err := ed25519.GenerateKey(rand.Reader)
+	scheme, err := suiScheme(g.Scheme)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to generate private key: %w", err)
+		return "", "", "", err
 	}
 
-	// 获取私钥的十六进制表示（64字节）
-	privateKey = hex.EncodeToString(privateKeyBytes)
-
-	// 获取公钥的十六进制表示（32字节）
-	publicKey = hex.EncodeToString(publicKeyBytes)
+	switch scheme {
+	case SuiSchemeEd25519:
+		// 生成随机私钥（符合Ed25519要求）
+		_, privateKeyBytes, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to generate private key: %w", err)
+		}
+		// 获取私钥的十六进制表示（64字节）
+		privateKey = hex.EncodeToString(privateKeyBytes)
+	case SuiSchemeSecp256k1:
+		privKey, err := btcec.NewPrivateKey()
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to generate private key: %w", err)
+		}
+		privateKey = hex.EncodeToString(privKey.Serialize())
+	case SuiSchemeSecp256r1:
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to generate private key: %w", err)
+		}
+		privateKey = hex.EncodeToString(privKey.D.FillBytes(make([]byte, 32)))
+	}
 
-	// 生成SUI风格的地址
-	// SUI地址是使用base58编码的公钥，前缀为"0x"
-	suiAddress, err := g.PublicKeyToAddress(publicKey)
+	address, publicKey, err = g.DeriveKeyPairFromPrivateKey(privateKey)
 	if err != nil {
 		return "", "", "", err
 	}
 
-	return suiAddress, publicKey, privateKey, nil
+	return address, publicKey, privateKey, nil
 }
 
 // DeriveKeyPairFromPrivateKey 从现有私钥推导SUI公钥和地址
 func (g *SuiKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (address, publicKey string, err error) {
-	// 解析私钥
-	privateKeyBytes, err := hex.DecodeString(privateKey)
+	key, err := parseSuiPrivateKey(privateKey, g.Scheme)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to decode private key: %w", err)
-	}
-
-	// 验证私钥长度是否符合Ed25519要求
-	if len(privateKeyBytes) != 64 {
-		// 检查是否是32字节的种子，如果是则转换为64字节的私钥
-		if len(privateKeyBytes) == 32 {
-			// 创建一个临时密钥对来获取正确格式的私钥
-			_, fullPrivateKey, err := ed25519.GenerateKey(nil) // 使用nil Reader不会真正随机生成密钥
-			if err != nil {
-				return "", "", fmt.Errorf("failed to create full private key: %w", err)
-			}
-			// 复制种子部分
-			copy(fullPrivateKey[:32], privateKeyBytes)
-			privateKeyBytes = fullPrivateKey
-		} else {
-			return "", "", fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
-		}
+		return "", "", err
 	}
 
-	// 将字节切片转换为ed25519.PrivateKey类型
-	privKey := ed25519.PrivateKey(privateKeyBytes)
-
-	// 从私钥派生公钥
-	publicKeyBytes := privKey.Public().(ed25519.PublicKey)
+	publicKeyBytes := key.publicKey()
 	publicKey = hex.EncodeToString(publicKeyBytes)
 
-	// 生成SUI风格的地址
-	suiAddress, err := g.PublicKeyToAddress(publicKey)
+	// 生成SUI地址
+	address, err = suiAddress(key.scheme, publicKeyBytes)
 	if err != nil {
 		return "", "", err
 	}
 
-	return suiAddress, publicKey, nil
+	return address, publicKey, nil
 }
 
 // PublicKeyToAddress 从公钥生成SUI地址
 func (g *SuiKeyGenerator) PublicKeyToAddress(publicKey string) (address string, err error) {
+	scheme, err := suiScheme(g.Scheme)
+	if err != nil {
+		return "", err
+	}
+
 	// 解析公钥
 	publicKeyBytes, err := hex.DecodeString(publicKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode public key: %w", err)
 	}
 
-	// 验证公钥长度
-	if len(publicKeyBytes) != 32 {
-		return "", fmt.Errorf("invalid public key length: expected 32 bytes, got %d bytes", len(publicKeyBytes))
+	return suiAddress(scheme, publicKeyBytes)
+}
+
+// DerivationPath 返回SUI钱包标准的派生路径：
+// Ed25519为 m/44'/784'/account'/0'/index'（SLIP-0010），secp256k1为 m/54'/784'/account'/0/index，secp256r1为 m/74'/784'/account'/0/index
+func (g *SuiKeyGenerator) DerivationPath(account, index uint32) string {
+	switch g.Scheme {
+	case SuiSchemeSecp256k1:
+		return fmt.Sprintf("m/54'/%d'/%d'/0/%d", CoinTypeSui, account, index)
+	case SuiSchemeSecp256r1:
+		return fmt.Sprintf("m/74'/%d'/%d'/0/%d", CoinTypeSui, account, index)
+	default:
+		return fmt.Sprintf("m/44'/%d'/%d'/0'/%d'", CoinTypeSui, account, index)
+	}
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词派生SUI密钥对，暂不支持secp256r1
+func (g *SuiKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	scheme, err := suiScheme(g.Scheme)
+	if err != nil {
+		return "", "", "", err
+	}
+	switch scheme {
+	case SuiSchemeEd25519:
+		return deriveEd25519HDKeyPair(g, mnemonic, passphrase, path)
+	case SuiSchemeSecp256k1:
+		return deriveSecp256k1HDKeyPair(g, mnemonic, passphrase, path)
+	default:
+		return "", "", "", fmt.Errorf("hd derivation is not supported for sui scheme %s", scheme)
+	}
+}
+
+// EncodeSuiPrivateKey 将十六进制私钥导出为suiprivkey格式：Bech32("suiprivkey", flag || 32字节私钥)
+func EncodeSuiPrivateKey(scheme, privateKey string) (string, error) {
+	key, err := parseSuiPrivateKey(privateKey, scheme)
+	if err != nil {
+		return "", err
 	}
 
-	// SUI地址生成步骤：
-	// 1. 公钥（32字节）
-	// 2. 添加前缀字节：0x00
-	// 3. 计算SHA256哈希
-	// 4. 取前32字节作为地址的一部分
-	// 5. 添加前缀字节：0x00
-	// 6. 使用base58编码
-	
-	// 简化实现：SUI地址通常是使用base58编码的公钥
-	// 实际SUI地址生成逻辑可能包含更多步骤，这里使用简化但兼容的实现
-	suiAddress := "0x" + base58.Encode(publicKeyBytes)
+	data, err := bech32.ConvertBits(append([]byte{suiSchemeFlags[key.scheme]}, key.secret...), 8, 5, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return bech32.Encode(suiPrivateKeyPrefix, data)
+}
 
-	return suiAddress, nil
-}
\ No newline at end of file
+// DecodeSuiPrivateKey 解析suiprivkey格式的私钥，返回签名方案和十六进制私钥
+func DecodeSuiPrivateKey(encoded string) (scheme, privateKey string, err error) {
+	hrp, data, err := bech32.Decode(encoded)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode private key: %w", err)
+	}
+	if hrp != suiPrivateKeyPrefix {
+		return "", "", fmt.Errorf("invalid private key prefix: %s", hrp)
+	}
+	decoded, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode private key: %w", err)
+	}
+	if len(decoded) != 33 {
+		return "", "", fmt.Errorf("invalid private key length: expected 33 bytes, got %d bytes", len(decoded))
+	}
+
+	for name, flag := range suiSchemeFlags {
+		if flag == decoded[0] {
+			return name, hex.EncodeToString(decoded[1:]), nil
+		}
+	}
+	return "", "", fmt.Errorf("unsupported signature scheme flag: %d", decoded[0])
+}
+
+// suiScheme 规范化签名方案，空值为ed25519
+func suiScheme(scheme string) (string, error) {
+	if scheme == "" {
+		return SuiSchemeEd25519, nil
+	}
+	if _, ok := suiSchemeFlags[scheme]; !ok {
+		return "", fmt.Errorf("unsupported signature scheme: %s", scheme)
+	}
+	return scheme, nil
+}
+
+// suiAddress 计算地址：0x || 十六进制(Blake2b-256(flag || 公钥))
+func suiAddress(scheme string, publicKey []byte) (string, error) {
+	expected := 33
+	if scheme == SuiSchemeEd25519 {
+		expected = ed25519.PublicKeySize
+	}
+	if len(publicKey) != expected {
+		return "", fmt.Errorf("invalid public key length: expected %d bytes, got %d bytes", expected, len(publicKey))
+	}
+
+	return "0x" + hex.EncodeToString(Blake2b256(append([]byte{suiSchemeFlags[scheme]}, publicKey...))), nil
+}
+
+// suiPrivateKey 指定签名方案的私钥
+type suiPrivateKey struct {
+	scheme string
+	secret []byte // 32字节私钥（Ed25519为种子）
+}
+
+// parseSuiPrivateKey 解析私钥
+// 支持suiprivkey格式，以及十六进制格式的32字节私钥（Ed25519也接受64字节的完整私钥）
+func parseSuiPrivateKey(privateKey, scheme string) (*suiPrivateKey, error) {
+	if strings.HasPrefix(privateKey, suiPrivateKeyPrefix) {
+		decodedScheme, decoded, err := DecodeSuiPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, scheme = decoded, decodedScheme
+	}
+
+	scheme, err := suiScheme(scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析私钥
+	privateKeyBytes, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	switch {
+	case len(privateKeyBytes) == 32:
+	case len(privateKeyBytes) == ed25519.PrivateKeySize && scheme == SuiSchemeEd25519:
+		privateKeyBytes = privateKeyBytes[:ed25519.SeedSize]
+	case scheme == SuiSchemeEd25519:
+		return nil, fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
+	default:
+		return nil, fmt.Errorf("invalid private key length: expected 32 bytes, got %d bytes", len(privateKeyBytes))
+	}
+
+	key := &suiPrivateKey{scheme: scheme, secret: privateKeyBytes}
+	if scheme == SuiSchemeSecp256r1 {
+		if d := new(big.Int).SetBytes(privateKeyBytes); d.Sign() == 0 || d.Cmp(elliptic.P256().Params().N) >= 0 {
+			return nil, fmt.Errorf("invalid secp256r1 private key")
+		}
+	}
+	return key, nil
+}
+
+// publicKey 公钥：Ed25519为32字节，secp256k1和secp256r1为33字节压缩格式
+func (k *suiPrivateKey) publicKey() []byte {
+	switch k.scheme {
+	case SuiSchemeSecp256k1:
+		_, pubKey := btcec.PrivKeyFromBytes(k.secret)
+		return pubKey.SerializeCompressed()
+	case SuiSchemeSecp256r1:
+		privKey := k.p256()
+		return elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+	default:
+		return ed25519.NewKeyFromSeed(k.secret).Public().(ed25519.PublicKey)
+	}
+}
+
+// signer 转换为签名密钥
+func (k *suiPrivateKey) signer() (Signer, error) {
+	switch k.scheme {
+	case SuiSchemeSecp256k1:
+		return newSecp256k1Signer(k.secret)
+	case SuiSchemeSecp256r1:
+		return NewSecp256r1Signer(k.p256()), nil
+	default:
+		return NewEd25519Signer(ed25519.NewKeyFromSeed(k.secret)), nil
+	}
+}
+
+// parseSuiSigningKey 解析签名私钥：suiprivkey格式的签名方案由其标志字节决定，十六进制私钥为Ed25519
+func parseSuiSigningKey(privateKey []byte) (Signer, error) {
+	key, err := parseSuiPrivateKey(string(privateKey), "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key format: %w", err)
+	}
+	defer Zeroize(key.secret)
+	return key.signer()
+}
+
+// p256 secp256r1私钥
+func (k *suiPrivateKey) p256() *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	privKey := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(k.secret)}
+	privKey.Curve = curve
+	privKey.X, privKey.Y = curve.ScalarBaseMult(k.secret)
+	return privKey
+}
+
+// validateSuiAddress 校验SUI地址：0x开头的32字节十六进制
+func validateSuiAddress(address string) error {
+	h, ok := strings.CutPrefix(address, "0x")
+	if !ok || len(h) != 64 {
+		return fmt.Errorf("invalid sui address: %s", address)
+	}
+	if _, err := hex.DecodeString(h); err != nil {
+		return fmt.Errorf("invalid sui address: %s", address)
+	}
+	return nil
+}
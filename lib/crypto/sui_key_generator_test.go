@@ -1,6 +1,8 @@
 package crypto
 
 import (
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -79,4 +81,78 @@ func TestSuiKeyGenerator_PublicKeyToAddress(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, address)
 	assert.Contains(t, address, "0x")
-}
\ No newline at end of file
+}
+func TestSuiKeyGenerator_Schemes(t *testing.T) {
+	testCases := []struct {
+		scheme        string
+		flag          byte
+		publicKeySize int
+	}{
+		{SuiSchemeEd25519, 0x00, 32},
+		{SuiSchemeSecp256k1, 0x01, 33},
+		{SuiSchemeSecp256r1, 0x02, 33},
+	}
+
+	for _, tc := range testCases {
+		generator := &SuiKeyGenerator{Scheme: tc.scheme}
+		address, publicKey, privateKey, err := generator.GenerateKeyPair()
+		assert.NoError(t, err, tc.scheme)
+
+		// 地址为 Blake2b-256(flag || 公钥)
+		publicKeyBytes, _ := hex.DecodeString(publicKey)
+		assert.Len(t, publicKeyBytes, tc.publicKeySize, tc.scheme)
+		expected := "0x" + hex.EncodeToString(Blake2b256(append([]byte{tc.flag}, publicKeyBytes...)))
+		assert.Equal(t, expected, address, tc.scheme)
+		assert.Len(t, address, 66, tc.scheme)
+
+		fromPublicKey, err := generator.PublicKeyToAddress(publicKey)
+		assert.NoError(t, err, tc.scheme)
+		assert.Equal(t, address, fromPublicKey, tc.scheme)
+
+		// suiprivkey格式导出后可以导入，且签名方案由标志字节决定
+		encoded, err := EncodeSuiPrivateKey(tc.scheme, privateKey)
+		assert.NoError(t, err, tc.scheme)
+		assert.True(t, strings.HasPrefix(encoded, "suiprivkey1"), tc.scheme)
+		scheme, decoded, err := DecodeSuiPrivateKey(encoded)
+		assert.NoError(t, err, tc.scheme)
+		assert.Equal(t, tc.scheme, scheme)
+		assert.Equal(t, privateKey[:64], decoded)
+
+		imported, importedPublicKey, err := (&SuiKeyGenerator{}).DeriveKeyPairFromPrivateKey(encoded)
+		assert.NoError(t, err, tc.scheme)
+		assert.Equal(t, address, imported, tc.scheme)
+		assert.Equal(t, publicKey, importedPublicKey, tc.scheme)
+	}
+
+	// 不支持的签名方案
+	_, _, _, err := (&SuiKeyGenerator{Scheme: "sr25519"}).GenerateKeyPair()
+	assert.Error(t, err)
+}
+
+func TestSuiKeyGenerator_Seed(t *testing.T) {
+	generator := &SuiKeyGenerator{}
+	_, publicKey, privateKey, err := generator.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	// 32字节种子与64字节私钥推导出相同的公钥
+	_, seedPublicKey, err := generator.DeriveKeyPairFromPrivateKey(privateKey[:64])
+	assert.NoError(t, err)
+	assert.Equal(t, publicKey, seedPublicKey)
+}
+
+func TestSuiKeyGenerator_InvalidEncodedPrivateKey(t *testing.T) {
+	encoded, err := EncodeSuiPrivateKey(SuiSchemeEd25519, "0000000000000000000000000000000000000000000000000000000000000001")
+	assert.NoError(t, err)
+
+	// 校验和错误
+	corrupted := encoded[:len(encoded)-1] + "q"
+	if corrupted == encoded {
+		corrupted = encoded[:len(encoded)-1] + "p"
+	}
+	_, _, err = DecodeSuiPrivateKey(corrupted)
+	assert.Error(t, err)
+
+	// secp256r1私钥超出曲线阶
+	_, err = EncodeSuiPrivateKey(SuiSchemeSecp256r1, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	assert.Error(t, err)
+}
@@ -1,117 +1,191 @@
 package crypto
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/mr-tron/base58"
 )
 
+// suiTransactionIntent 交易数据的意图前缀：scope=TransactionData(0), version=V0(0), app_id=Sui(0)
+var suiTransactionIntent = []byte{0, 0, 0}
+
 // SuiTransactionRequest SUI交易请求结构
 type SuiTransactionRequest struct {
-	TransactionKind string          `json:"transactionKind"`
-	GasBudget       uint64          `json:"gasBudget"`
-	GasPrice        uint64          `json:"gasPrice"`
-	GasPayment      []string        `json:"gasPayment"`
-	InputObjects    []string        `json:"inputObjects"`
-	Data            json.RawMessage `json:"data"`
+	// TxBytes Base64编码的BCS序列化TransactionData（由SUI SDK的tx.build()生成）
+	TxBytes string `json:"txBytes"`
+	// Scheme 签名方案：ed25519、secp256k1或secp256r1，由签名密钥的曲线决定；指定时必须与签名密钥一致
+	Scheme string `json:"scheme,omitempty"`
 }
 
 // SuiTransactionSigner SUI交易签名器
-// 使用Ed25519算法，符合SUI规范
+// 签名 Blake2b-256(intent || TransactionData)，返回Base64编码的序列化签名 flag || 签名 || 公钥
+// 交易哈希为 Base58(Blake2b-256("TransactionData::" || TransactionData))，与链上交易摘要一致
 type SuiTransactionSigner struct{}
 
 // SignTransaction 签名SUI交易
-func (s *SuiTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (signedTx string, txHash string, err error) {
-	// 解码私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+// rawTx 为SuiTransactionRequest的JSON，或直接为Base64编码的TransactionData
+// 签名方案由签名密钥的曲线决定，支持ed25519、secp256k1和secp256r1；请求中指定scheme时必须与之一致
+func (s *SuiTransactionSigner) SignTransaction(rawTx string, signer Signer) (signedTx string, txHash string, err error) {
+	txReq, txBytes, err := parseSuiTransactionRequest(rawTx)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
-	}
-
-	// 验证私钥长度是否符合Ed25519要求
-	if len(privateKeyBytes) != 64 {
-		// 检查是否是32字节的种子，如果是则转换为64字节的私钥
-		if len(privateKeyBytes) == 32 {
-			// 创建一个临时密钥对来获取正确格式的私钥
-			_, fullPrivateKey, err := ed25519.GenerateKey(nil) // 使用nil Reader不会真正随机生成密钥
-			if err != nil {
-				return "", "", fmt.Errorf("failed to create full private key: %w", err)
-			}
-			// 复制种子部分
-			copy(fullPrivateKey[:32], privateKeyBytes)
-			privateKeyBytes = fullPrivateKey
-		} else {
-			return "", "", fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
-		}
+		return "", "", err
 	}
-
-	// 将字节切片转换为ed25519.PrivateKey类型
-	privateKey := ed25519.PrivateKey(privateKeyBytes)
-
-	// 解析交易参数
-	var txReq SuiTransactionRequest
-	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
-		return "", "", fmt.Errorf("invalid transaction data format: %w", err)
+	if signer == nil {
+		return "", "", errors.New("signer is required")
 	}
 
-	// 准备要签名的数据
-	// 在真实的SUI交易中，签名的数据包括：
-	// 1. 交易类型
-	// 2. Gas参数
-	// 3. 输入对象
-	// 4. 交易数据
-	// 这里为了简化，我们使用交易的哈希作为要签名的数据
-	txDataHash := sha256.Sum256([]byte(rawTx))
-	txHash = hex.EncodeToString(txDataHash[:])
-
-	// 使用Ed25519私钥对数据进行签名，符合SUI要求
-	signature := ed25519.Sign(privateKey, txDataHash[:])
+	// 签名方案与密钥曲线同名
+	scheme := signer.Curve()
+	if _, ok := suiSchemeFlags[scheme]; !ok {
+		return "", "", fmt.Errorf("unsupported key curve: %s", scheme)
+	}
+	if txReq.Scheme != "" && txReq.Scheme != scheme {
+		return "", "", fmt.Errorf("signature scheme %s does not match the key curve %s", txReq.Scheme, scheme)
+	}
 
-	// 构建签名后的交易
-	// 在真实的SUI实现中，签名会被添加到交易中并进行序列化
-	// 这里我们返回签名的十六进制表示作为简化实现
-	signedTx = "sui_signed_" + hex.EncodeToString(signature)
+	// Ed25519签名意图消息的Blake2b-256哈希，ECDSA方案签名其SHA-256，去掉恢复标识
+	digest := suiSigningDigest(txBytes)
+	hash := sha256.Sum256(digest)
+	var signature []byte
+	switch scheme {
+	case SuiSchemeSecp256k1:
+		signature, err = signRecoverable(signer, hash[:])
+		if err == nil {
+			signature = signature[:64]
+		}
+	case SuiSchemeSecp256r1:
+		signature, err = signSecp256r1(signer, hash[:])
+	default:
+		signature, err = signEd25519(signer, digest)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
 
-	// 添加前缀到交易哈希
-	txHash = "sui_" + txHash
+	// 序列化签名：flag || 签名 || 公钥
+	serialized := append([]byte{suiSchemeFlags[scheme]}, signature...)
+	serialized = append(serialized, signer.PublicKey()...)
 
-	return signedTx, txHash, nil
+	return base64.StdEncoding.EncodeToString(serialized), suiTransactionDigest(txBytes), nil
 }
 
 // VerifyTransaction 验证SUI交易签名
-func (s *SuiTransactionSigner) VerifyTransaction(rawTx, signatureHex, publicKeyHex string) (bool, error) {
-	// 解码公钥
-	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+// signedTx 为SignTransaction返回的序列化签名，publicKeyHex 为空时只使用序列化签名中的公钥
+func (s *SuiTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex string) (bool, error) {
+	_, txBytes, err := parseSuiTransactionRequest(rawTx)
 	if err != nil {
-		return false, fmt.Errorf("invalid public key format: %w", err)
+		return false, err
 	}
 
-	// 验证公钥长度是否符合Ed25519要求
-	if len(publicKeyBytes) != 32 {
-		return false, fmt.Errorf("invalid public key length: expected 32 bytes, got %d bytes", len(publicKeyBytes))
+	// 解析序列化签名
+	serialized, err := base64.StdEncoding.DecodeString(signedTx)
+	if err != nil {
+		return false, fmt.Errorf("invalid signature format: %w", err)
+	}
+	if len(serialized) == 0 {
+		return false, fmt.Errorf("invalid signature format: empty signature")
 	}
 
-	// 将字节切片转换为ed25519.PublicKey类型
-	publicKey := ed25519.PublicKey(publicKeyBytes)
+	var scheme string
+	for name, flag := range suiSchemeFlags {
+		if flag == serialized[0] {
+			scheme = name
+		}
+	}
+	if scheme == "" {
+		return false, fmt.Errorf("unsupported signature scheme flag: %d", serialized[0])
+	}
 
-	// 解码签名
-	signature, err := hex.DecodeString(signatureHex)
-	if err != nil {
-		return false, fmt.Errorf("invalid signature format: %w", err)
+	publicKeySize := 33
+	if scheme == SuiSchemeEd25519 {
+		publicKeySize = ed25519.PublicKeySize
 	}
+	if len(serialized) != 1+64+publicKeySize {
+		return false, fmt.Errorf("invalid signature length: expected %d bytes, got %d bytes", 1+64+publicKeySize, len(serialized))
+	}
+	signature, publicKey := serialized[1:65], serialized[65:]
 
-	// 验证签名长度
-	if len(signature) != ed25519.SignatureSize {
-		return false, fmt.Errorf("invalid signature length: expected %d bytes, got %d bytes", ed25519.SignatureSize, len(signature))
+	// 序列化签名中的公钥必须与指定的公钥一致
+	if publicKeyHex != "" {
+		expected, err := hex.DecodeString(publicKeyHex)
+		if err != nil {
+			return false, fmt.Errorf("invalid public key format: %w", err)
+		}
+		if !bytes.Equal(expected, publicKey) {
+			return false, nil
+		}
 	}
 
-	// 计算交易数据的哈希
-	txDataHash := sha256.Sum256([]byte(rawTx))
+	digest := suiSigningDigest(txBytes)
+	switch scheme {
+	case SuiSchemeSecp256k1:
+		pubKey, err := btcec.ParsePubKey(publicKey)
+		if err != nil {
+			return false, fmt.Errorf("invalid public key: %w", err)
+		}
+		var r, sv btcec.ModNScalar
+		if r.SetByteSlice(signature[:32]) || sv.SetByteSlice(signature[32:]) || sv.IsOverHalfOrder() {
+			return false, nil
+		}
+		hash := sha256.Sum256(digest)
+		return btcecdsa.NewSignature(&r, &sv).Verify(hash[:], pubKey), nil
+	case SuiSchemeSecp256r1:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), publicKey)
+		if x == nil {
+			return false, fmt.Errorf("invalid public key: not a compressed secp256r1 point")
+		}
+		r, sv := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if sv.Cmp(new(big.Int).Rsh(elliptic.P256().Params().N, 1)) > 0 {
+			return false, nil
+		}
+		hash := sha256.Sum256(digest)
+		return ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, hash[:], r, sv), nil
+	default:
+		return ed25519.Verify(ed25519.PublicKey(publicKey), digest, signature), nil
+	}
+}
 
-	// 使用Ed25519公钥验证签名
-	valid := ed25519.Verify(publicKey, txDataHash[:], signature)
+// parseSuiTransactionRequest 解析交易请求，返回请求和BCS序列化的TransactionData
+func parseSuiTransactionRequest(rawTx string) (*SuiTransactionRequest, []byte, error) {
+	var txReq SuiTransactionRequest
+	if strings.HasPrefix(strings.TrimSpace(rawTx), "{") {
+		if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+			return nil, nil, fmt.Errorf("invalid transaction data format: %w", err)
+		}
+	} else {
+		txReq.TxBytes = strings.TrimSpace(rawTx)
+	}
+
+	txBytes, err := base64.StdEncoding.DecodeString(txReq.TxBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid txBytes: %w", err)
+	}
+	if len(txBytes) == 0 {
+		return nil, nil, fmt.Errorf("txBytes is required")
+	}
 
-	return valid, nil
-}
\ No newline at end of file
+	return &txReq, txBytes, nil
+}
+
+// suiSigningDigest 待签名的摘要：Blake2b-256(intent || TransactionData)
+func suiSigningDigest(txBytes []byte) []byte {
+	return Blake2b256(append(append([]byte{}, suiTransactionIntent...), txBytes...))
+}
+
+// suiTransactionDigest 交易摘要：Base58(Blake2b-256("TransactionData::" || TransactionData))
+func suiTransactionDigest(txBytes []byte) string {
+	return base58.Encode(Blake2b256(append([]byte("TransactionData::"), txBytes...)))
+}
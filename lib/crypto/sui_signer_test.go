@@ -1,38 +1,129 @@
 package crypto
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"testing"
 
+	"github.com/mr-tron/base58"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+const (
+	// 测试用的私钥
+	testSuiPrivateKey = "0000000000000000000000000000000000000000000000000000000000000001"
+)
+
+// testSuiTxBytes 测试用的BCS序列化TransactionData
+var testSuiTxBytes = base64.StdEncoding.EncodeToString(append([]byte{0, 0, 2, 0}, make([]byte, 96)...))
+
 func TestSuiTransactionSigner_SignTransaction(t *testing.T) {
 	signer := &SuiTransactionSigner{}
 
-	// 测试用的私钥
-	privateKeyHex := "0000000000000000000000000000000000000000000000000000000000000001"
-
 	// 构建SUI交易请求
-	txReq := SuiTransactionRequest{
-		TransactionKind: "Transfer",
-		GasBudget:       100000000,
-		GasPrice:        1000,
-		GasPayment:      []string{"0x0000000000000000000000000000000000000000000000000000000000000001"},
-		InputObjects:    []string{"0x0000000000000000000000000000000000000000000000000000000000000002"},
-		Data:            json.RawMessage(`{"recipient":"0x0000000000000000000000000000000000000000000000000000000000000003","amount":1000}`),
-	}
-
+	txReq := SuiTransactionRequest{TxBytes: testSuiTxBytes}
 	rawTx, err := json.Marshal(txReq)
 	assert.NoError(t, err)
 
 	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "sui", testSuiPrivateKey))
 
 	// 验证结果
+	require.NoError(t, err)
+	serialized, err := base64.StdEncoding.DecodeString(signedTx)
+	require.NoError(t, err)
+	_, publicKey, err := (&SuiKeyGenerator{}).DeriveKeyPairFromPrivateKey(testSuiPrivateKey)
+	require.NoError(t, err)
+
+	// flag || 签名 || 公钥
+	assert.Len(t, serialized, 97)
+	assert.Equal(t, byte(0x00), serialized[0])
+	assert.Equal(t, publicKey, hex.EncodeToString(serialized[65:]))
+
+	// 交易摘要
+	txBytes, _ := base64.StdEncoding.DecodeString(testSuiTxBytes)
+	assert.Equal(t, base58.Encode(Blake2b256(append([]byte("TransactionData::"), txBytes...))), txHash)
+
+	// 验证签名
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, publicKey)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// rawTx可以直接为Base64编码的TransactionData，Ed25519签名是确定性的
+	signedRaw, txHashRaw, err := signer.SignTransaction(testSuiTxBytes, testSigningKey(t, "sui", testSuiPrivateKey))
+	assert.NoError(t, err)
+	assert.Equal(t, signedTx, signedRaw)
+	assert.Equal(t, txHash, txHashRaw)
+
+	// 签名的交易被修改后验证失败
+	valid, err = signer.VerifyTransaction(base64.StdEncoding.EncodeToString(append(txBytes, 0)), signedTx, publicKey)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestSuiTransactionSigner_Schemes(t *testing.T) {
+	signer := &SuiTransactionSigner{}
+
+	for _, scheme := range []string{SuiSchemeEd25519, SuiSchemeSecp256k1, SuiSchemeSecp256r1} {
+		// suiprivkey格式的私钥自带签名方案
+		encoded, err := EncodeSuiPrivateKey(scheme, testSuiPrivateKey)
+		require.NoError(t, err)
+		key := testSigningKey(t, "sui", encoded)
+
+		rawTx, _ := json.Marshal(SuiTransactionRequest{TxBytes: testSuiTxBytes, Scheme: scheme})
+		signedTx, _, err := signer.SignTransaction(string(rawTx), key)
+		require.NoError(t, err, scheme)
+
+		serialized, _ := base64.StdEncoding.DecodeString(signedTx)
+		assert.Equal(t, suiSchemeFlags[scheme], serialized[0], scheme)
+
+		_, publicKey, err := (&SuiKeyGenerator{Scheme: scheme}).DeriveKeyPairFromPrivateKey(testSuiPrivateKey)
+		require.NoError(t, err, scheme)
+		valid, err := signer.VerifyTransaction(string(rawTx), signedTx, publicKey)
+		assert.NoError(t, err, scheme)
+		assert.True(t, valid, scheme)
+
+		// 未指定签名方案时使用密钥的曲线
+		signedRaw, _, err := signer.SignTransaction(testSuiTxBytes, key)
+		require.NoError(t, err, scheme)
+		valid, err = signer.VerifyTransaction(testSuiTxBytes, signedRaw, publicKey)
+		assert.NoError(t, err, scheme)
+		assert.True(t, valid, scheme)
+	}
+
+	// 签名方案与密钥的曲线不一致
+	rawTx, _ := json.Marshal(SuiTransactionRequest{TxBytes: testSuiTxBytes, Scheme: SuiSchemeSecp256k1})
+	_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "sui", testSuiPrivateKey))
+	assert.Error(t, err)
+}
+
+func TestSuiTransactionSigner_InvalidRequest(t *testing.T) {
+	signer := &SuiTransactionSigner{}
+
+	testCases := []string{
+		// 缺少txBytes
+		`{}`,
+		// 无效的Base64
+		`{"txBytes":"not base64!"}`,
+		// 不支持的签名方案
+		`{"txBytes":"` + testSuiTxBytes + `","scheme":"sr25519"}`,
+	}
+
+	for _, rawTx := range testCases {
+		_, _, err := signer.SignTransaction(rawTx, testSigningKey(t, "sui", testSuiPrivateKey))
+		assert.Error(t, err, rawTx)
+	}
+
+	// 无效的私钥
+	_, err := ParsePrivateKey("sui", []byte("invalid_private_key"))
+	assert.Error(t, err)
+
+	// 公钥不一致
+	signedTx, _, err := signer.SignTransaction(testSuiTxBytes, testSigningKey(t, "sui", testSuiPrivateKey))
+	require.NoError(t, err)
+	valid, err := signer.VerifyTransaction(testSuiTxBytes, signedTx, hex.EncodeToString(make([]byte, 32)))
 	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	assert.Contains(t, signedTx, "sui_signed_")
-	assert.Contains(t, txHash, "sui_")
-}
\ No newline at end of file
+	assert.False(t, valid)
+}
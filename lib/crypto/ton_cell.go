@@ -0,0 +1,494 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// TON单元格（Cell）的容量限制
+const (
+	tonCellMaxBits = 1023
+	tonCellMaxRefs = 4
+)
+
+// tonBocMagic 标准BOC序列化格式的魔数
+var tonBocMagic = []byte{0xb5, 0xee, 0x9c, 0x72}
+
+// tonCell TON的普通单元格，最多1023位数据和4个引用
+// 同时作为构造器使用，store系列方法向单元格追加数据
+type tonCell struct {
+	data   []byte
+	bitLen int
+	refs   []*tonCell
+}
+
+// newTonCell 创建空单元格
+func newTonCell() *tonCell {
+	return &tonCell{}
+}
+
+// storeBit 追加一位
+func (c *tonCell) storeBit(bit bool) *tonCell {
+	if c.bitLen%8 == 0 {
+		c.data = append(c.data, 0)
+	}
+	if bit {
+		c.data[c.bitLen/8] |= 0x80 >> (c.bitLen % 8)
+	}
+	c.bitLen++
+	return c
+}
+
+// storeUint 以大端序追加bits位无符号整数
+func (c *tonCell) storeUint(v uint64, bits int) *tonCell {
+	for i := bits - 1; i >= 0; i-- {
+		c.storeBit(v>>uint(i)&1 == 1)
+	}
+	return c
+}
+
+// storeBigUint 以大端序追加bits位大整数
+func (c *tonCell) storeBigUint(v *big.Int, bits int) *tonCell {
+	for i := bits - 1; i >= 0; i-- {
+		c.storeBit(v.Bit(i) == 1)
+	}
+	return c
+}
+
+// storeBytes 追加字节
+func (c *tonCell) storeBytes(b []byte) *tonCell {
+	for _, v := range b {
+		c.storeUint(uint64(v), 8)
+	}
+	return c
+}
+
+// storeCoins 追加Coins（VarUInteger 16）：4位字节长度后跟大端序数值
+func (c *tonCell) storeCoins(v *big.Int) *tonCell {
+	b := v.Bytes()
+	c.storeUint(uint64(len(b)), 4)
+	return c.storeBytes(b)
+}
+
+// storeAddress 追加MsgAddressInt（addr_std，无anycast），nil时追加addr_none
+func (c *tonCell) storeAddress(addr *TonAddress) *tonCell {
+	if addr == nil {
+		return c.storeUint(0, 2)
+	}
+	c.storeUint(0b10, 2)
+	c.storeBit(false)
+	c.storeUint(uint64(uint8(addr.Workchain)), 8)
+	return c.storeBytes(addr.Hash[:])
+}
+
+// storeRef 追加引用
+func (c *tonCell) storeRef(ref *tonCell) *tonCell {
+	c.refs = append(c.refs, ref)
+	return c
+}
+
+// storeCell 将另一个单元格的数据和引用追加到当前单元格
+func (c *tonCell) storeCell(other *tonCell) *tonCell {
+	for i := 0; i < other.bitLen; i++ {
+		c.storeBit(other.data[i/8]&(0x80>>(i%8)) != 0)
+	}
+	c.refs = append(c.refs, other.refs...)
+	return c
+}
+
+// bitsRange 截取[from, to)范围内的数据位构成新单元格（不含引用）
+func (c *tonCell) bitsRange(from, to int) *tonCell {
+	cell := newTonCell()
+	for i := from; i < to; i++ {
+		cell.storeBit(c.data[i/8]&(0x80>>(i%8)) != 0)
+	}
+	return cell
+}
+
+// validate 检查单元格及其引用是否超出容量
+func (c *tonCell) validate() error {
+	if c.bitLen > tonCellMaxBits {
+		return fmt.Errorf("cell overflow: %d bits", c.bitLen)
+	}
+	if len(c.refs) > tonCellMaxRefs {
+		return fmt.Errorf("cell overflow: %d refs", len(c.refs))
+	}
+	for _, ref := range c.refs {
+		if err := ref.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// descriptors 单元格描述符d1（引用数）和d2（数据长度）
+func (c *tonCell) descriptors() (byte, byte) {
+	return byte(len(c.refs)), byte(c.bitLen/8 + (c.bitLen+7)/8)
+}
+
+// paddedData 按位补齐后的数据，不足一字节时追加结束标记位1
+func (c *tonCell) paddedData() []byte {
+	data := append([]byte{}, c.data...)
+	if c.bitLen%8 != 0 {
+		data[len(data)-1] |= 0x80 >> (c.bitLen % 8)
+	}
+	return data
+}
+
+// depth 单元格深度，无引用时为0
+func (c *tonCell) depth() uint16 {
+	var depth uint16
+	for _, ref := range c.refs {
+		if d := ref.depth() + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// hash 单元格的表示哈希：SHA256(d1 || d2 || data || 引用深度 || 引用哈希)
+func (c *tonCell) hash() []byte {
+	d1, d2 := c.descriptors()
+	h := sha256.New()
+	h.Write([]byte{d1, d2})
+	h.Write(c.paddedData())
+	for _, ref := range c.refs {
+		var depth [2]byte
+		binary.BigEndian.PutUint16(depth[:], ref.depth())
+		h.Write(depth[:])
+	}
+	for _, ref := range c.refs {
+		h.Write(ref.hash())
+	}
+	return h.Sum(nil)
+}
+
+// toBoc 将以该单元格为根的单元格树序列化为带CRC32C校验的BOC
+func (c *tonCell) toBoc() ([]byte, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	// 去重后按拓扑顺序排列，父单元格总在子单元格之前
+	var (
+		order   []*tonCell
+		index   = map[string]int{}
+		visited = map[string]bool{}
+	)
+	var visit func(cell *tonCell)
+	visit = func(cell *tonCell) {
+		key := string(cell.hash())
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		for _, ref := range cell.refs {
+			visit(ref)
+		}
+		order = append(order, cell)
+	}
+	visit(c)
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	for i, cell := range order {
+		index[string(cell.hash())] = i
+	}
+
+	sizeBytes := tonMinBytes(uint64(len(order)))
+	var cells bytes.Buffer
+	for _, cell := range order {
+		d1, d2 := cell.descriptors()
+		cells.WriteByte(d1)
+		cells.WriteByte(d2)
+		cells.Write(cell.paddedData())
+		for _, ref := range cell.refs {
+			cells.Write(tonUintBytes(uint64(index[string(ref.hash())]), sizeBytes))
+		}
+	}
+	offBytes := tonMinBytes(uint64(cells.Len()))
+
+	var buf bytes.Buffer
+	buf.Write(tonBocMagic)
+	buf.WriteByte(0x40 | byte(sizeBytes)) // has_crc32c
+	buf.WriteByte(byte(offBytes))
+	buf.Write(tonUintBytes(uint64(len(order)), sizeBytes)) // cells
+	buf.Write(tonUintBytes(1, sizeBytes))                  // roots
+	buf.Write(tonUintBytes(0, sizeBytes))                  // absent
+	buf.Write(tonUintBytes(uint64(cells.Len()), offBytes))
+	buf.Write(tonUintBytes(0, sizeBytes)) // root index
+	buf.Write(cells.Bytes())
+
+	var crc [4]byte
+	binary.LittleEndian.PutUint32(crc[:], crc32.Checksum(buf.Bytes(), crc32.MakeTable(crc32.Castagnoli)))
+	buf.Write(crc[:])
+
+	return buf.Bytes(), nil
+}
+
+// parseTonBoc 解析BOC，返回第一个根单元格
+// 不支持特殊单元格（pruned branch、library等）
+func parseTonBoc(data []byte) (*tonCell, error) {
+	r := bytes.NewReader(data)
+	magic := make([]byte, 4)
+	if _, err := r.Read(magic); err != nil || !bytes.Equal(magic, tonBocMagic) {
+		return nil, errors.New("invalid boc magic")
+	}
+
+	flags, err := r.ReadByte()
+	if err != nil {
+		return nil, errors.New("invalid boc header")
+	}
+	hasIdx := flags&0x80 != 0
+	hasCrc := flags&0x40 != 0
+	sizeBytes := int(flags & 0x07)
+	offBytesByte, err := r.ReadByte()
+	if err != nil || sizeBytes == 0 || sizeBytes > 4 || offBytesByte == 0 || offBytesByte > 8 {
+		return nil, errors.New("invalid boc header")
+	}
+	offBytes := int(offBytesByte)
+
+	readUint := func(n int) (uint64, error) {
+		b := make([]byte, n)
+		if _, err := r.Read(b); err != nil {
+			return 0, errors.New("unexpected end of boc")
+		}
+		var v uint64
+		for _, x := range b {
+			v = v<<8 | uint64(x)
+		}
+		return v, nil
+	}
+
+	cellCount, err := readUint(sizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	rootCount, err := readUint(sizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readUint(sizeBytes); err != nil { // absent
+		return nil, err
+	}
+	if _, err := readUint(offBytes); err != nil { // tot_cells_size
+		return nil, err
+	}
+	if rootCount == 0 || cellCount == 0 || cellCount > uint64(len(data)) {
+		return nil, errors.New("invalid boc cell count")
+	}
+	rootIndex, err := readUint(sizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(1); i < rootCount; i++ {
+		if _, err := readUint(sizeBytes); err != nil {
+			return nil, err
+		}
+	}
+	if hasIdx {
+		if _, err := r.Seek(int64(cellCount)*int64(offBytes), 1); err != nil {
+			return nil, err
+		}
+	}
+
+	if hasCrc {
+		if len(data) < 4 {
+			return nil, errors.New("unexpected end of boc")
+		}
+		body, crc := data[:len(data)-4], data[len(data)-4:]
+		if crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli)) != binary.LittleEndian.Uint32(crc) {
+			return nil, errors.New("invalid boc crc32c")
+		}
+	}
+
+	cells := make([]*tonCell, cellCount)
+	refIndexes := make([][]uint64, cellCount)
+	for i := range cells {
+		d1, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.New("unexpected end of boc")
+		}
+		d2, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.New("unexpected end of boc")
+		}
+		if d1&0x08 != 0 {
+			return nil, errors.New("exotic cells are not supported")
+		}
+		refCount := int(d1 & 0x07)
+		if refCount > tonCellMaxRefs {
+			return nil, errors.New("invalid cell refs count")
+		}
+
+		data := make([]byte, (int(d2)+1)/2)
+		if _, err := r.Read(data); err != nil && len(data) > 0 {
+			return nil, errors.New("unexpected end of boc")
+		}
+		bitLen := len(data) * 8
+		if d2%2 == 1 {
+			// 去掉结束标记位及其后的补齐位
+			last := data[len(data)-1]
+			if last == 0 {
+				return nil, errors.New("invalid cell padding")
+			}
+			trailing := 0
+			for last&(1<<trailing) == 0 {
+				trailing++
+			}
+			bitLen -= trailing + 1
+			data[len(data)-1] &^= 1 << trailing
+			data = data[:(bitLen+7)/8]
+		}
+		cells[i] = &tonCell{data: data, bitLen: bitLen}
+
+		for j := 0; j < refCount; j++ {
+			ref, err := readUint(sizeBytes)
+			if err != nil {
+				return nil, err
+			}
+			if ref <= uint64(i) || ref >= cellCount {
+				return nil, errors.New("invalid cell ref index")
+			}
+			refIndexes[i] = append(refIndexes[i], ref)
+		}
+	}
+	for i, refs := range refIndexes {
+		for _, ref := range refs {
+			cells[i].refs = append(cells[i].refs, cells[ref])
+		}
+	}
+
+	if rootIndex >= cellCount {
+		return nil, errors.New("invalid boc root index")
+	}
+	return cells[rootIndex], nil
+}
+
+// tonMinBytes 表示v所需的最少字节数（至少1字节）
+func tonMinBytes(v uint64) int {
+	n := 1
+	for v >= 1<<(8*n) && n < 8 {
+		n++
+	}
+	return n
+}
+
+// tonUintBytes 将v编码为n字节大端序整数
+func tonUintBytes(v uint64, n int) []byte {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return b
+}
+
+// TON用户友好地址的标志位
+const (
+	tonAddressBounceable    = 0x11
+	tonAddressNonBounceable = 0x51
+	tonAddressTestnetFlag   = 0x80
+)
+
+// TonAddress TON标准地址（workchain + 账户哈希）
+type TonAddress struct {
+	Workchain  int8
+	Hash       [32]byte
+	Bounceable bool // 用户友好格式中的可回弹标志
+	Testnet    bool // 用户友好格式中的测试网标志
+}
+
+// ParseTonAddress 解析TON地址
+// 支持原始格式（"0:<64位十六进制>"）和48字符的用户友好格式（base64或base64url）
+// 原始格式的地址视为可回弹地址
+func ParseTonAddress(s string) (*TonAddress, error) {
+	if strings.Contains(s, ":") {
+		parts := strings.SplitN(s, ":", 2)
+		workchain, err := strconv.ParseInt(parts[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ton address workchain: %s", s)
+		}
+		hash, err := hex.DecodeString(parts[1])
+		if err != nil || len(hash) != 32 {
+			return nil, fmt.Errorf("invalid ton address hash: %s", s)
+		}
+		addr := &TonAddress{Workchain: int8(workchain), Bounceable: true}
+		copy(addr.Hash[:], hash)
+		return addr, nil
+	}
+
+	if len(s) != 48 {
+		return nil, fmt.Errorf("invalid ton address length: %s", s)
+	}
+	data, err := base64.URLEncoding.DecodeString(strings.NewReplacer("+", "-", "/", "_").Replace(s))
+	if err != nil || len(data) != 36 {
+		return nil, fmt.Errorf("invalid ton address encoding: %s", s)
+	}
+	if tonCRC16(data[:34]) != binary.BigEndian.Uint16(data[34:]) {
+		return nil, fmt.Errorf("invalid ton address checksum: %s", s)
+	}
+
+	tag := data[0]
+	addr := &TonAddress{Testnet: tag&tonAddressTestnetFlag != 0}
+	switch tag &^ tonAddressTestnetFlag {
+	case tonAddressBounceable:
+		addr.Bounceable = true
+	case tonAddressNonBounceable:
+	default:
+		return nil, fmt.Errorf("invalid ton address tag: %s", s)
+	}
+	addr.Workchain = int8(data[1])
+	copy(addr.Hash[:], data[2:34])
+	return addr, nil
+}
+
+// String 返回用户友好格式（base64url）的地址
+func (a *TonAddress) String() string {
+	tag := byte(tonAddressNonBounceable)
+	if a.Bounceable {
+		tag = tonAddressBounceable
+	}
+	if a.Testnet {
+		tag |= tonAddressTestnetFlag
+	}
+
+	data := make([]byte, 0, 36)
+	data = append(data, tag, byte(a.Workchain))
+	data = append(data, a.Hash[:]...)
+	var crc [2]byte
+	binary.BigEndian.PutUint16(crc[:], tonCRC16(data))
+	data = append(data, crc[:]...)
+
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// RawString 返回原始格式的地址
+func (a *TonAddress) RawString() string {
+	return fmt.Sprintf("%d:%s", a.Workchain, hex.EncodeToString(a.Hash[:]))
+}
+
+// tonCRC16 CRC16-XMODEM校验和
+func tonCRC16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -5,14 +5,64 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/featx/keys-gin/web/model"
 )
 
-// TonKeyGenerator TON (Telegram Open Network)密钥生成器
-// 使用Ed25519算法，符合TON规范
+// TON钱包合约版本
+const (
+	// TonWalletV4R2 钱包合约v4r2
+	TonWalletV4R2 = "v4r2"
+	// TonWalletV5R1 钱包合约v5r1（W5）
+	TonWalletV5R1 = "v5r1"
+)
+
+// TON钱包合约代码（十六进制BOC），参见 https://github.com/ton-blockchain/wallet-contract 和 wallet-contract-v5
+const (
+	tonWalletV4R2CodeBoc = "b5ee9c72410214010002d4000114ff00f4a413f4bcf2c80b010201200203020148040504f8f28308d71820d31fd31fd31f02f823bbf264ed44d0d31fd31fd3fff404d15143baf2a15151baf2a205f901541064f910f2a3f80024a4c8cb1f5240cb1f5230cbff5210f400c9ed54f80f01d30721c0009f6c519320d74a96d307d402fb00e830e021c001e30021c002e30001c0039130e30d03a4c8cb1f12cb1fcbff1011121302e6d001d0d3032171b0925f04e022d749c120925f04e002d31f218210706c7567bd22821064737472bdb0925f05e003fa403020fa4401c8ca07cbffc9d0ed44d0810140d721f404305c810108f40a6fa131b3925f07e005d33fc8258210706c7567ba923830e30d03821064737472ba925f06e30d06070201200809007801fa00f40430f8276f2230500aa121bef2e0508210706c7567831eb17080185004cb0526cf1658fa0219f400cb6917cb1f5260cb3f20c98040fb0006008a5004810108f45930ed44d0810140d720c801cf16f400c9ed540172b08e23821064737472831eb17080185005cb055003cf1623fa0213cb6acb1fcb3fc98040fb00925f03e20201200a0b0059bd242b6f6a2684080a06b90fa0218470d4080847a4937d29910ce6903e9ff9837812801b7810148987159f31840201580c0d0011b8c97ed44d0d70b1f8003db29dfb513420405035c87d010c00b23281f2fff274006040423d029be84c600201200e0f0019adce76a26840206b90eb85ffc00019af1df6a26840106b90eb858fc0006ed207fa00d4d422f90005c8ca0715cbffc9d077748018c8cb05cb0222cf165005fa0214cb6b12ccccc973fb00c84014810108f451f2a7020070810108d718fa00d33fc8542047810108f451f2a782106e6f746570748018c8cb05cb025006cf165004fa0214cb6a12cb1fcb3fc973fb0002006c810108d718fa00d33f305224810108f459f2a782106473747270748018c8cb05cb025005cf165003fa0213cb6acb1f12cb3fc973fb00000af400c9ed54696225e5"
+	tonWalletV5R1CodeBoc = "b5ee9c7241021401000281000114ff00f4a413f4bcf2c80b01020120020d020148030402dcd020d749c120915b8f6320d70b1f2082106578746ebd21821073696e74bdb0925f03e082106578746eba8eb48020d72101d074d721fa4030fa44f828fa443058bd915be0ed44d0810141d721f4058307f40e6fa1319130e18040d721707fdb3ce03120d749810280b99130e070e2100f020120050c020120060902016e07080019adce76a2684020eb90eb85ffc00019af1df6a2684010eb90eb858fc00201480a0b0017b325fb51341c75c875c2c7e00011b262fb513435c280200019be5f0f6a2684080a0eb90fa02c0102f20e011e20d70b1f82107369676ebaf2e08a7f0f01e68ef0eda2edfb218308d722028308d723208020d721d31fd31fd31fed44d0d200d31f20d31fd3ffd70a000af90140ccf9109a28945f0adb31e1f2c087df02b35007b0f2d0845125baf2e0855036baf2e086f823bbf2d0882292f800de01a47fc8ca00cb1f01cf16c9ed542092f80fde70db3cd81003f6eda2edfb02f404216e926c218e4c0221d73930709421c700b38e2d01d72820761e436c20d749c008f2e09320d74ac002f2e09320d71d06c712c2005230b0f2d089d74cd7393001a4e86c128407bbf2e093d74ac000f2e093ed55e2d20001c000915be0ebd72c08142091709601d72c081c12e25210b1e30f20d74a111213009601fa4001fa44f828fa443058baf2e091ed44d0810141d718f405049d7fc8ca0040048307f453f2e08b8e14038307f45bf2e08c22d70a00216e01b3b0f2d090e2c85003cf1612f400c9ed54007230d72c08248e2d21f2e092d200ed44d0d2005113baf2d08f54503091319c01810140d721d70a00f2e08ee2c8ca0058cf16c9ed5493f2c08de20010935bdb31e1d74cd0b4d6c35e"
+)
+
+// TON钱包默认参数
+const (
+	// tonWalletV4SubwalletID v4r2钱包在basechain上的默认subwallet_id
+	tonWalletV4SubwalletID = 698983191
+	// tonMainnetGlobalID TON主网的global_id
+	tonMainnetGlobalID = -239
+	// tonTestnetGlobalID TON测试网的global_id
+	tonTestnetGlobalID = -3
+)
+
+func init() {
+	mustRegisterChain(ChainModule{
+		ChainType: model.ChainTypeTON,
+		Curve:     "ed25519",
+		Encoding:  "ton_address",
+		NewKeyGenerator: func() (KeyGenerator, error) {
+			return &TonKeyGenerator{}, nil
+		},
+		NewTransactionSigner: func() (TransactionSigner, error) {
+			return &TonTransactionSigner{}, nil
+		},
+		ValidateAddress: func(address string) error {
+			_, err := ParseTonAddress(address)
+			return err
+		},
+	})
+}
 
-type TonKeyGenerator struct{}
+// TonKeyGenerator TON (The Open Network)密钥生成器
+// 使用Ed25519算法，地址为钱包合约StateInit的哈希，以用户友好格式（base64url）表示
+// WalletVersion 指定钱包合约版本，默认为v4r2
+// Testnet 为true时生成测试网地址（同时影响v5r1的wallet_id）
+// NonBounceable 为true时生成不可回弹地址（UQ开头），默认为可回弹地址（EQ开头）
+
+type TonKeyGenerator struct {
+	WalletVersion string
+	Testnet       bool
+	NonBounceable bool
+}
 
 // GenerateKeyPair 生成TON密钥对
 func (g *TonKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey string, err error) {
@@ -32,9 +82,7 @@ func (g *TonKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey stri
 	// 公钥是32字节
 	publicKey = hex.EncodeToString(publicKeyBytes)
 
-	// 生成符合TON规范的地址
-	// TON地址通常以EQ开头，使用Base64编码或Bounceable/NBounceable格式
-	// 这里实现一个简化版本，基于公钥哈希生成地址
+	// 生成钱包合约地址
 	address, err = g.PublicKeyToAddress(publicKey)
 	if err != nil {
 		return "", publicKey, privateKey, fmt.Errorf("failed to generate address: %w", err)
@@ -45,38 +93,16 @@ func (g *TonKeyGenerator) GenerateKeyPair() (address, publicKey, privateKey stri
 
 // DeriveKeyPairFromPrivateKey 从现有私钥推导TON公钥和地址
 func (g *TonKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (address, publicKey string, err error) {
-	// 解析私钥
-	privateKeyBytes, err := hex.DecodeString(privateKey)
+	privateKeyObj, err := tonPrivateKeyFromHex(privateKey)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to decode private key: %w", err)
-	}
-
-	// 验证私钥长度是否符合Ed25519要求
-	if len(privateKeyBytes) != 64 {
-		// 检查是否是32字节的种子，如果是则转换为64字节的私钥
-		if len(privateKeyBytes) == 32 {
-			// 从32字节种子派生完整的64字节Ed25519私钥
-			publicKeyBytes := ed25519.PrivateKey(privateKeyBytes).Public().(ed25519.PublicKey)
-			publicKey = hex.EncodeToString(publicKeyBytes)
-			
-			// 生成TON地址
-			address, err = g.PublicKeyToAddress(publicKey)
-			if err != nil {
-				return "", publicKey, fmt.Errorf("failed to generate address: %w", err)
-			}
-			return address, publicKey, nil
-		}
-		return "", "", fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
+		return "", "", err
 	}
 
-	// 将字节切片转换为ed25519.PrivateKey类型
-	privateKeyObj := ed25519.PrivateKey(privateKeyBytes)
-
 	// 从私钥派生公钥
 	publicKeyBytes := privateKeyObj.Public().(ed25519.PublicKey)
 	publicKey = hex.EncodeToString(publicKeyBytes)
 
-	// 生成TON地址
+	// 生成钱包合约地址
 	address, err = g.PublicKeyToAddress(publicKey)
 	if err != nil {
 		return "", publicKey, fmt.Errorf("failed to generate address: %w", err)
@@ -85,7 +111,7 @@ func (g *TonKeyGenerator) DeriveKeyPairFromPrivateKey(privateKey string) (addres
 	return address, publicKey, nil
 }
 
-// PublicKeyToAddress 从公钥生成TON地址
+// PublicKeyToAddress 从公钥生成TON钱包合约地址
 func (g *TonKeyGenerator) PublicKeyToAddress(publicKey string) (address string, err error) {
 	// 解析公钥
 	publicKeyBytes, err := hex.DecodeString(publicKey)
@@ -98,16 +124,142 @@ func (g *TonKeyGenerator) PublicKeyToAddress(publicKey string) (address string,
 		return "", fmt.Errorf("invalid public key length: expected 32 bytes, got %d bytes", len(publicKeyBytes))
 	}
 
-	// 生成TON风格的地址
-	// TON地址生成过程：
-	// 1. 对公钥进行哈希
-	// 2. 添加地址前缀和后缀
-	// 3. 使用Base64编码或其他特定编码
-	// 这里实现一个简化版本，生成以EQ开头的地址
-	hash := crypto.Keccak256(publicKeyBytes)
-	// 截取适当长度并添加TON地址前缀
-	// 注意：实际TON地址编码更复杂，这里只是模拟格式
-	address = "EQ" + hex.EncodeToString(hash[:20])
+	wallet, err := newTonWallet(g.WalletVersion, publicKeyBytes, g.Testnet)
+	if err != nil {
+		return "", err
+	}
+	addr, err := wallet.address()
+	if err != nil {
+		return "", err
+	}
+	addr.Bounceable = !g.NonBounceable
+	addr.Testnet = g.Testnet
+
+	return addr.String(), nil
+}
+
+// DerivationPath 返回SLIP-0010派生路径 m/44'/607'/network'/0'/account'/index'
+// network在测试网时为1，地址索引为0时与Ledger的账户路径一致
+func (g *TonKeyGenerator) DerivationPath(account, index uint32) string {
+	var network uint32
+	if g.Testnet {
+		network = 1
+	}
+	return fmt.Sprintf("m/44'/%d'/%d'/0'/%d'/%d'", CoinTypeTon, network, account, index)
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按SLIP-0010派生TON密钥对
+func (g *TonKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	return deriveEd25519HDKeyPair(g, mnemonic, passphrase, path)
+}
 
-	return address, nil
-}
\ No newline at end of file
+// tonPrivateKeyFromHex 解析十六进制私钥，支持64字节的完整私钥和32字节的种子
+func tonPrivateKeyFromHex(privateKey string) (ed25519.PrivateKey, error) {
+	privateKeyBytes, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	switch len(privateKeyBytes) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(privateKeyBytes), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(privateKeyBytes), nil
+	default:
+		return nil, fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
+	}
+}
+
+// tonWallet 标准钱包合约，由合约版本、公钥和wallet_id确定地址
+type tonWallet struct {
+	version   string
+	publicKey []byte
+	walletID  uint32
+}
+
+// newTonWallet 创建basechain上默认wallet_id的钱包合约
+func newTonWallet(version string, publicKey []byte, testnet bool) (*tonWallet, error) {
+	if version == "" {
+		version = TonWalletV4R2
+	}
+
+	wallet := &tonWallet{version: version, publicKey: publicKey}
+	switch version {
+	case TonWalletV4R2:
+		wallet.walletID = tonWalletV4SubwalletID
+	case TonWalletV5R1:
+		// wallet_id = network_global_id ^ context
+		// 客户端context：1位标志 || workchain(8位) || 合约版本(8位，v5r1为0) || subwallet_number(15位)
+		globalID := int32(tonMainnetGlobalID)
+		if testnet {
+			globalID = tonTestnetGlobalID
+		}
+		wallet.walletID = uint32(globalID) ^ 1<<31
+	default:
+		return nil, fmt.Errorf("unsupported ton wallet version: %s", version)
+	}
+	return wallet, nil
+}
+
+// code 钱包合约代码单元格
+func (w *tonWallet) code() (*tonCell, error) {
+	boc := tonWalletV4R2CodeBoc
+	if w.version == TonWalletV5R1 {
+		boc = tonWalletV5R1CodeBoc
+	}
+	data, err := hex.DecodeString(boc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wallet code: %w", err)
+	}
+	return parseTonBoc(data)
+}
+
+// data 钱包合约的初始数据单元格
+func (w *tonWallet) data() *tonCell {
+	cell := newTonCell()
+	if w.version == TonWalletV5R1 {
+		// is_signature_allowed:Bool seqno:uint32 wallet_id:uint32 public_key:bits256 extensions:(HashmapE 256 int1)
+		cell.storeBit(true)
+		cell.storeUint(0, 32)
+		cell.storeUint(uint64(w.walletID), 32)
+		cell.storeBytes(w.publicKey)
+		cell.storeBit(false)
+		return cell
+	}
+	// seqno:uint32 subwallet_id:uint32 public_key:bits256 plugins:(HashmapE 267 int1)
+	cell.storeUint(0, 32)
+	cell.storeUint(uint64(w.walletID), 32)
+	cell.storeBytes(w.publicKey)
+	cell.storeBit(false)
+	return cell
+}
+
+// stateInit 钱包合约的StateInit单元格
+// split_depth:(Maybe (## 5)) special:(Maybe TickTock) code:(Maybe ^Cell) data:(Maybe ^Cell) library:(HashmapE 256 SimpleLib)
+func (w *tonWallet) stateInit() (*tonCell, error) {
+	code, err := w.code()
+	if err != nil {
+		return nil, err
+	}
+	cell := newTonCell()
+	cell.storeUint(0b00110, 5)
+	cell.storeRef(code)
+	cell.storeRef(w.data())
+	return cell, nil
+}
+
+// address 钱包合约在basechain上的地址，即StateInit的哈希
+func (w *tonWallet) address() (*TonAddress, error) {
+	stateInit, err := w.stateInit()
+	if err != nil {
+		return nil, err
+	}
+	addr := &TonAddress{}
+	copy(addr.Hash[:], stateInit.hash())
+	return addr, nil
+}
+
+// tonCoins 将nanoton数量转换为Coins
+func tonCoins(amount uint64) *big.Int {
+	return new(big.Int).SetUint64(amount)
+}
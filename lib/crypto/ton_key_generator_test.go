@@ -1,6 +1,8 @@
 package crypto
 
 import (
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,8 +20,9 @@ func TestTonKeyGenerator_GenerateKeyPair(t *testing.T) {
 	assert.NotEmpty(t, address)
 	assert.NotEmpty(t, publicKey)
 	assert.NotEmpty(t, privateKey)
-	// 验证地址格式符合TON规范
-	assert.Contains(t, address, "EQ")
+	// 验证地址格式符合TON规范：默认为主网可回弹地址
+	assert.True(t, strings.HasPrefix(address, "EQ"))
+	assert.Equal(t, 48, len(address))
 	// 验证私钥长度
 	// 64字节的私钥用十六进制表示应该是128个字符
 	assert.Equal(t, 128, len(privateKey))
@@ -39,7 +42,13 @@ func TestTonKeyGenerator_DeriveKeyPairFromPrivateKey(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, address)
 	assert.NotEmpty(t, publicKey)
-	assert.Contains(t, address, "EQ")
+	assert.True(t, strings.HasPrefix(address, "EQ"))
+
+	// 32字节种子与64字节私钥得到相同的地址
+	seedAddress, seedPublicKey, err := generator.DeriveKeyPairFromPrivateKey(privateKey[:64])
+	assert.NoError(t, err)
+	assert.Equal(t, address, seedAddress)
+	assert.Equal(t, publicKey, seedPublicKey)
 }
 
 func TestTonKeyGenerator_InvalidPrivateKey(t *testing.T) {
@@ -78,5 +87,106 @@ func TestTonKeyGenerator_PublicKeyToAddress(t *testing.T) {
 	// 验证结果
 	assert.NoError(t, err)
 	assert.NotEmpty(t, address)
-	assert.Contains(t, address, "EQ")
-}
\ No newline at end of file
+	assert.True(t, strings.HasPrefix(address, "EQ"))
+}
+
+func TestTonKeyGenerator_AddressFlags(t *testing.T) {
+	publicKey := "82a0b2543d06fec0aac952e9ec738be56ab1b6027fc0c1aa817ae14b4d1ed2fb"
+
+	testCases := []struct {
+		generator *TonKeyGenerator
+		prefix    string
+	}{
+		{&TonKeyGenerator{}, "EQ"},
+		{&TonKeyGenerator{NonBounceable: true}, "UQ"},
+		{&TonKeyGenerator{Testnet: true}, "kQ"},
+		{&TonKeyGenerator{Testnet: true, NonBounceable: true}, "0Q"},
+	}
+
+	var hash [32]byte
+	for i, tc := range testCases {
+		address, err := tc.generator.PublicKeyToAddress(publicKey)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(address, tc.prefix), address)
+
+		// 标志位不影响账户哈希
+		addr, err := ParseTonAddress(address)
+		assert.NoError(t, err)
+		assert.Equal(t, !tc.generator.NonBounceable, addr.Bounceable)
+		assert.Equal(t, tc.generator.Testnet, addr.Testnet)
+		assert.Equal(t, int8(0), addr.Workchain)
+		if i == 0 {
+			hash = addr.Hash
+		}
+		assert.Equal(t, hash, addr.Hash)
+	}
+
+	// v5r1钱包的地址与v4r2不同
+	v5Address, err := (&TonKeyGenerator{WalletVersion: TonWalletV5R1}).PublicKeyToAddress(publicKey)
+	assert.NoError(t, err)
+	addr, _ := ParseTonAddress(v5Address)
+	assert.NotEqual(t, hash, addr.Hash)
+
+	// 不支持的钱包版本
+	_, err = (&TonKeyGenerator{WalletVersion: "v3r2"}).PublicKeyToAddress(publicKey)
+	assert.Error(t, err)
+}
+
+func TestTonWallet_StateInit(t *testing.T) {
+	publicKey, _ := hex.DecodeString("82a0b2543d06fec0aac952e9ec738be56ab1b6027fc0c1aa817ae14b4d1ed2fb")
+
+	// 钱包合约代码的哈希与链上已部署的代码一致
+	testCases := []struct {
+		version  string
+		codeHash string
+		walletID uint32
+	}{
+		{TonWalletV4R2, "feb5ff6820e2ff0d9483e7e0d62c817d846789fb4ae580c878866d959dabd5c0", 698983191},
+		{TonWalletV5R1, "20834b7b72b112147e1b2fb457b84e74d1a30f04f737d4f62a668e9552d2b72f", 2147483409},
+	}
+
+	for _, tc := range testCases {
+		wallet, err := newTonWallet(tc.version, publicKey, false)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.walletID, wallet.walletID)
+
+		code, err := wallet.code()
+		assert.NoError(t, err)
+		assert.Equal(t, tc.codeHash, hex.EncodeToString(code.hash()))
+
+		// 地址为StateInit的哈希
+		stateInit, err := wallet.stateInit()
+		assert.NoError(t, err)
+		addr, err := wallet.address()
+		assert.NoError(t, err)
+		assert.Equal(t, stateInit.hash(), addr.Hash[:])
+	}
+
+	// 测试网v5r1钱包使用测试网的global_id
+	wallet, err := newTonWallet(TonWalletV5R1, publicKey, true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2147483645), wallet.walletID)
+}
+
+func TestParseTonAddress(t *testing.T) {
+	raw := "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8"
+	addr, err := ParseTonAddress(raw)
+	assert.NoError(t, err)
+	assert.True(t, addr.Bounceable)
+	assert.Equal(t, raw, addr.RawString())
+
+	// 用户友好格式可以还原为原始格式
+	friendly := addr.String()
+	assert.Equal(t, "EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N", friendly)
+	parsed, err := ParseTonAddress(friendly)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, parsed.RawString())
+
+	// 校验和错误
+	_, err = ParseTonAddress("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2M")
+	assert.Error(t, err)
+
+	// 长度错误
+	_, err = ParseTonAddress("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB")
+	assert.Error(t, err)
+}
@@ -1,90 +1,121 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/ed25519"
-	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
+)
+
+// TON钱包转账的默认参数
+const (
+	// tonDefaultSendMode 默认发送模式：单独支付转账手续费（1）+ 忽略错误（2）
+	tonDefaultSendMode = 3
+	// tonDefaultTimeout 未指定valid_until时外部消息的有效期
+	tonDefaultTimeout = 60 * time.Second
+	// tonWalletV5SignedExternal v5r1外部签名消息的操作码（"sign"）
+	tonWalletV5SignedExternal = 0x7369676e
+	// tonActionSendMsg OutAction中action_send_msg的标签
+	tonActionSendMsg = 0x0ec3c86d
 )
 
 // TonTransactionRequest TON交易请求结构
 type TonTransactionRequest struct {
-	Address     string `json:"address"`
-	Destination string `json:"destination"`
-	Amount      uint64 `json:"amount"` // 单位是nanoton
-	Seqno       uint32 `json:"seqno"`
-	StateInit   string `json:"stateInit,omitempty"`
-	Payload     string `json:"payload,omitempty"`
+	Address       string `json:"address"`                 // 发送方钱包地址，可选，用于校验私钥
+	Destination   string `json:"destination"`             // 接收方地址，原始格式或用户友好格式
+	Amount        uint64 `json:"amount"`                  // 单位是nanoton
+	Seqno         uint32 `json:"seqno"`                   // 钱包当前seqno，为0时附带钱包StateInit部署合约
+	ValidUntil    uint32 `json:"validUntil,omitempty"`    // 消息过期的unix时间戳，默认为当前时间后60秒
+	WalletVersion string `json:"walletVersion,omitempty"` // 钱包合约版本，v4r2（默认）或v5r1
+	Testnet       bool   `json:"testnet,omitempty"`       // 是否为测试网钱包
+	SendMode      *uint8 `json:"sendMode,omitempty"`      // 发送模式，默认为3
+	Bounce        *bool  `json:"bounce,omitempty"`        // 是否可回弹，默认取目标地址的标志
+	StateInit     string `json:"stateInit,omitempty"`     // 随内部消息附带的StateInit（base64 BOC）
+	Payload       string `json:"payload,omitempty"`       // 内部消息体（base64 BOC）
+	Comment       string `json:"comment,omitempty"`       // 文本备注，与payload互斥
 }
 
 // TonTransactionSigner TON交易签名器
-// 使用Ed25519算法，符合TON规范
+// 构建钱包合约（v4r2/v5r1）的签名转账消息，返回外部消息的base64 BOC及其哈希
 type TonTransactionSigner struct{}
 
 // SignTransaction 签名TON交易
-func (s *TonTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (signedTx string, txHash string, err error) {
-	// 解码私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
-	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
-	}
-
-	// 验证私钥长度是否符合Ed25519要求
-	if len(privateKeyBytes) != 64 {
-		// 检查是否是32字节的种子，如果是则转换为64字节的私钥
-		if len(privateKeyBytes) == 32 {
-			// 创建一个临时密钥对来获取正确格式的私钥
-			_, fullPrivateKey, err := ed25519.GenerateKey(nil) // 使用nil Reader不会真正随机生成密钥
-			if err != nil {
-				return "", "", fmt.Errorf("failed to create full private key: %w", err)
-			}
-			// 复制种子部分
-			copy(fullPrivateKey[:32], privateKeyBytes)
-			privateKeyBytes = fullPrivateKey
-		} else {
-			return "", "", fmt.Errorf("invalid private key length: expected 64 bytes (full private key) or 32 bytes (seed), got %d bytes", len(privateKeyBytes))
-		}
+func (s *TonTransactionSigner) SignTransaction(rawTx string, signer Signer) (signedTx string, txHash string, err error) {
+	if err := requireCurve(signer, CurveEd25519); err != nil {
+		return "", "", err
 	}
 
-	// 将字节切片转换为ed25519.PrivateKey类型
-	privateKey := ed25519.PrivateKey(privateKeyBytes)
-
 	// 解析交易参数
 	var txReq TonTransactionRequest
 	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
 		return "", "", fmt.Errorf("invalid transaction data format: %w", err)
 	}
 
-	// 准备要签名的数据
-	// 在真实的TON交易中，签名的数据包括：
-	// 1. 账户地址
-	// 2. 目标地址
-	// 3. 金额
-	// 4. 序列号
-	// 5. 状态初始化数据（如果有）
-	// 6. 负载数据（如果有）
-	// 这里为了简化，我们使用交易的哈希作为要签名的数据
-	txDataHash := sha256.Sum256([]byte(rawTx))
-	txHash = hex.EncodeToString(txDataHash[:])
+	// 由公钥确定钱包合约及其地址
+	wallet, err := newTonWallet(txReq.WalletVersion, ed25519.PublicKey(signer.PublicKey()), txReq.Testnet)
+	if err != nil {
+		return "", "", err
+	}
+	walletAddress, err := wallet.address()
+	if err != nil {
+		return "", "", err
+	}
+	if txReq.Address != "" {
+		addr, err := ParseTonAddress(txReq.Address)
+		if err != nil {
+			return "", "", err
+		}
+		if addr.Workchain != walletAddress.Workchain || addr.Hash != walletAddress.Hash {
+			return "", "", fmt.Errorf("address %s does not match private key", txReq.Address)
+		}
+	}
 
-	// 使用Ed25519私钥对数据进行签名，符合TON要求
-	signature := ed25519.Sign(privateKey, txDataHash[:])
+	// 构建转账的内部消息
+	message, err := buildTonInternalMessage(&txReq)
+	if err != nil {
+		return "", "", err
+	}
+	sendMode := uint8(tonDefaultSendMode)
+	if txReq.SendMode != nil {
+		sendMode = *txReq.SendMode
+	}
+	validUntil := txReq.ValidUntil
+	if validUntil == 0 {
+		validUntil = uint32(time.Now().Add(tonDefaultTimeout).Unix())
+	}
 
-	// 构建签名后的交易
-	// 在真实的TON实现中，签名会被添加到交易中并进行序列化
-	// 这里我们返回签名的十六进制表示作为简化实现
-	signedTx = "ton_signed_" + hex.EncodeToString(signature)
+	// 构建并签名钱包的转账消息体
+	body := wallet.transferBody(txReq.Seqno, validUntil, sendMode, message)
+	signature, err := signEd25519(signer, body.hash())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	signedBody := wallet.signedBody(body, signature)
 
-	// 添加前缀到交易哈希
-	txHash = "ton_" + txHash
+	// 包装为发往钱包的外部消息，seqno为0时附带StateInit部署钱包
+	var stateInit *tonCell
+	if txReq.Seqno == 0 {
+		if stateInit, err = wallet.stateInit(); err != nil {
+			return "", "", err
+		}
+	}
+	external := buildTonExternalMessage(walletAddress, stateInit, signedBody)
 
-	return signedTx, txHash, nil
+	boc, err := external.toBoc()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(boc), hex.EncodeToString(external.hash()), nil
 }
 
 // VerifyTransaction 验证TON交易签名
-// 这个方法用于验证交易签名是否有效
-func (s *TonTransactionSigner) VerifyTransaction(rawTx, signatureHex, publicKeyHex string) (bool, error) {
+// signedTx为SignTransaction返回的外部消息BOC，rawTx用于确定钱包合约版本和网络
+func (s *TonTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex string) (bool, error) {
 	// 解码公钥
 	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
 	if err != nil {
@@ -92,24 +123,216 @@ func (s *TonTransactionSigner) VerifyTransaction(rawTx, signatureHex, publicKeyH
 	}
 
 	// 验证公钥长度
-	if len(publicKeyBytes) != 32 {
+	if len(publicKeyBytes) != ed25519.PublicKeySize {
 		return false, fmt.Errorf("invalid public key length: expected 32 bytes, got %d bytes", len(publicKeyBytes))
 	}
 
-	// 将字节切片转换为ed25519.PublicKey类型
-	publicKey := ed25519.PublicKey(publicKeyBytes)
+	var txReq TonTransactionRequest
+	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+		return false, fmt.Errorf("invalid transaction data format: %w", err)
+	}
+	wallet, err := newTonWallet(txReq.WalletVersion, publicKeyBytes, txReq.Testnet)
+	if err != nil {
+		return false, err
+	}
 
-	// 解码签名
-	signature, err := hex.DecodeString(signatureHex)
+	// 解析外部消息
+	boc, err := base64.StdEncoding.DecodeString(signedTx)
+	if err != nil {
+		return false, fmt.Errorf("invalid signed transaction format: %w", err)
+	}
+	external, err := parseTonBoc(boc)
 	if err != nil {
-		return false, fmt.Errorf("invalid signature format: %w", err)
+		return false, fmt.Errorf("invalid signed transaction format: %w", err)
+	}
+	if len(external.refs) == 0 {
+		return false, errors.New("signed transaction has no body")
 	}
 
-	// 准备要验证的数据（与签名时相同）
-	txDataHash := sha256.Sum256([]byte(rawTx))
+	// 外部消息的目标地址必须是该公钥对应的钱包
+	walletAddress, err := wallet.address()
+	if err != nil {
+		return false, err
+	}
+	// ext_in_msg_info$10 src:addr_none$00 dest:MsgAddressInt（267位）
+	const headerBits = 2 + 2 + 267
+	expected := buildTonExternalMessage(walletAddress, nil, nil)
+	if external.bitLen < headerBits || !bytes.Equal(external.bitsRange(0, headerBits).data, expected.bitsRange(0, headerBits).data) {
+		return false, nil
+	}
+
+	// 消息体总是最后一个引用
+	body, signature, err := wallet.splitSignedBody(external.refs[len(external.refs)-1])
+	if err != nil {
+		return false, err
+	}
 
 	// 使用Ed25519公钥验证签名
-	valid := ed25519.Verify(publicKey, txDataHash[:], signature)
+	return ed25519.Verify(publicKeyBytes, body.hash(), signature), nil
+}
 
-	return valid, nil
-}
\ No newline at end of file
+// transferBody 钱包转账消息体中需要签名的部分
+func (w *tonWallet) transferBody(seqno, validUntil uint32, sendMode uint8, message *tonCell) *tonCell {
+	body := newTonCell()
+	if w.version == TonWalletV5R1 {
+		// op:"sign" wallet_id:uint32 valid_until:uint32 seqno:uint32 out_actions:(Maybe ^OutList) has_other_actions:Bool
+		actions := newTonCell().
+			storeRef(newTonCell()).
+			storeUint(tonActionSendMsg, 32).
+			storeUint(uint64(sendMode), 8).
+			storeRef(message)
+		body.storeUint(tonWalletV5SignedExternal, 32)
+		body.storeUint(uint64(w.walletID), 32)
+		body.storeUint(uint64(validUntil), 32)
+		body.storeUint(uint64(seqno), 32)
+		body.storeBit(true).storeRef(actions)
+		body.storeBit(false)
+		return body
+	}
+	// subwallet_id:uint32 valid_until:uint32 seqno:uint32 op:uint8 (mode:uint8 ^MessageRelaxed)*
+	body.storeUint(uint64(w.walletID), 32)
+	body.storeUint(uint64(validUntil), 32)
+	body.storeUint(uint64(seqno), 32)
+	body.storeUint(0, 8)
+	body.storeUint(uint64(sendMode), 8)
+	body.storeRef(message)
+	return body
+}
+
+// signedBody 附加签名后的消息体，v4r2签名在前，v5r1签名在后
+func (w *tonWallet) signedBody(body *tonCell, signature []byte) *tonCell {
+	signed := newTonCell()
+	if w.version == TonWalletV5R1 {
+		return signed.storeCell(body).storeBytes(signature)
+	}
+	return signed.storeBytes(signature).storeCell(body)
+}
+
+// splitSignedBody 从签名后的消息体中拆出待签名部分和签名
+func (w *tonWallet) splitSignedBody(signed *tonCell) (*tonCell, []byte, error) {
+	const signatureBits = ed25519.SignatureSize * 8
+	if signed.bitLen < signatureBits {
+		return nil, nil, errors.New("signed body is too short")
+	}
+
+	var body, signature *tonCell
+	if w.version == TonWalletV5R1 {
+		body = signed.bitsRange(0, signed.bitLen-signatureBits)
+		signature = signed.bitsRange(signed.bitLen-signatureBits, signed.bitLen)
+	} else {
+		signature = signed.bitsRange(0, signatureBits)
+		body = signed.bitsRange(signatureBits, signed.bitLen)
+	}
+	body.refs = signed.refs
+	return body, signature.data, nil
+}
+
+// buildTonInternalMessage 构建钱包发出的内部转账消息（MessageRelaxed）
+func buildTonInternalMessage(txReq *TonTransactionRequest) (*tonCell, error) {
+	destination, err := ParseTonAddress(txReq.Destination)
+	if err != nil {
+		return nil, err
+	}
+	bounce := destination.Bounceable
+	if txReq.Bounce != nil {
+		bounce = *txReq.Bounce
+	}
+
+	// 消息体：payload（BOC）或文本备注
+	var body *tonCell
+	switch {
+	case txReq.Payload != "" && txReq.Comment != "":
+		return nil, errors.New("payload and comment are mutually exclusive")
+	case txReq.Payload != "":
+		if body, err = parseTonBase64Boc(txReq.Payload); err != nil {
+			return nil, fmt.Errorf("invalid payload: %w", err)
+		}
+	case txReq.Comment != "":
+		body = tonTextComment(txReq.Comment)
+	}
+
+	var stateInit *tonCell
+	if txReq.StateInit != "" {
+		if stateInit, err = parseTonBase64Boc(txReq.StateInit); err != nil {
+			return nil, fmt.Errorf("invalid state init: %w", err)
+		}
+	}
+
+	// int_msg_info$0 ihr_disabled:Bool bounce:Bool bounced:Bool src:MsgAddress dest:MsgAddressInt
+	// value:CurrencyCollection ihr_fee:Coins fwd_fee:Coins created_lt:uint64 created_at:uint32
+	message := newTonCell()
+	message.storeBit(false)
+	message.storeBit(true)
+	message.storeBit(bounce)
+	message.storeBit(false)
+	message.storeAddress(nil)
+	message.storeAddress(destination)
+	message.storeCoins(tonCoins(txReq.Amount))
+	message.storeBit(false) // 无额外币种
+	message.storeCoins(tonCoins(0))
+	message.storeCoins(tonCoins(0))
+	message.storeUint(0, 64)
+	message.storeUint(0, 32)
+	storeTonMessageTail(message, stateInit, body)
+
+	return message, nil
+}
+
+// buildTonExternalMessage 构建发往钱包的外部消息
+// ext_in_msg_info$10 src:MsgAddressExt dest:MsgAddressInt import_fee:Coins
+func buildTonExternalMessage(wallet *TonAddress, stateInit, body *tonCell) *tonCell {
+	message := newTonCell()
+	message.storeUint(0b10, 2)
+	message.storeAddress(nil)
+	message.storeAddress(wallet)
+	message.storeCoins(tonCoins(0))
+	storeTonMessageTail(message, stateInit, body)
+	return message
+}
+
+// storeTonMessageTail 追加消息的init和body部分，均以引用方式存储
+// init:(Maybe (Either StateInit ^StateInit)) body:(Either X ^X)
+func storeTonMessageTail(message, stateInit, body *tonCell) {
+	if stateInit != nil {
+		message.storeBit(true).storeBit(true).storeRef(stateInit)
+	} else {
+		message.storeBit(false)
+	}
+	if body != nil {
+		message.storeBit(true).storeRef(body)
+	} else {
+		message.storeBit(false)
+	}
+}
+
+// tonTextComment 文本备注：32位0操作码后跟UTF-8文本，超出单元格容量时按snake格式链接
+func tonTextComment(comment string) *tonCell {
+	root := newTonCell().storeUint(0, 32)
+	text := []byte(comment)
+	cell := root
+	for {
+		n := (tonCellMaxBits - cell.bitLen) / 8
+		if n > len(text) {
+			n = len(text)
+		}
+		cell.storeBytes(text[:n])
+		text = text[n:]
+		if len(text) == 0 {
+			return root
+		}
+		next := newTonCell()
+		cell.storeRef(next)
+		cell = next
+	}
+}
+
+// parseTonBase64Boc 解析base64编码的BOC
+func parseTonBase64Boc(s string) (*tonCell, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		if data, err = base64.URLEncoding.DecodeString(s); err != nil {
+			return nil, err
+		}
+	}
+	return parseTonBoc(data)
+}
@@ -1,36 +1,199 @@
 package crypto
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// 测试用的私钥种子
+const testTonSeed = "0000000000000000000000000000000000000000000000000000000000000001"
+
+// parseTestTonMessage 解析签名结果中的外部消息
+func parseTestTonMessage(t *testing.T, signedTx string) *tonCell {
+	boc, err := base64.StdEncoding.DecodeString(signedTx)
+	require.NoError(t, err)
+	external, err := parseTonBoc(boc)
+	require.NoError(t, err)
+	return external
+}
+
 func TestTonTransactionSigner_SignTransaction(t *testing.T) {
 	signer := &TonTransactionSigner{}
 
-	// 测试用的私钥
-	privateKeyHex := "0000000000000000000000000000000000000000000000000000000000000001"
+	for _, version := range []string{TonWalletV4R2, TonWalletV5R1} {
+		address, publicKey, err := (&TonKeyGenerator{WalletVersion: version}).DeriveKeyPairFromPrivateKey(testTonSeed)
+		require.NoError(t, err)
+
+		// 构建TON交易请求
+		txReq := TonTransactionRequest{
+			Address:       address,
+			Destination:   "EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N",
+			Amount:        1000000000,
+			Seqno:         5,
+			ValidUntil:    1700000000,
+			WalletVersion: version,
+			Comment:       "hello",
+		}
+
+		rawTx, err := json.Marshal(txReq)
+		assert.NoError(t, err)
+
+		// 执行签名
+		signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "ton", testTonSeed))
+
+		// 验证结果
+		assert.NoError(t, err)
+		external := parseTestTonMessage(t, signedTx)
+		assert.Equal(t, hex.EncodeToString(external.hash()), txHash)
+		// seqno不为0时不附带StateInit，只有消息体一个引用
+		assert.Len(t, external.refs, 1)
+
+		// 签名可以通过公钥验证
+		valid, err := signer.VerifyTransaction(string(rawTx), signedTx, publicKey)
+		assert.NoError(t, err)
+		assert.True(t, valid, version)
+
+		// 其他公钥无法通过验证
+		_, otherPublicKey, _, err := (&TonKeyGenerator{}).GenerateKeyPair()
+		require.NoError(t, err)
+		valid, err = signer.VerifyTransaction(string(rawTx), signedTx, otherPublicKey)
+		assert.NoError(t, err)
+		assert.False(t, valid)
+
+		// 相同的请求得到相同的签名结果
+		signedTx2, txHash2, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "ton", testTonSeed))
+		assert.NoError(t, err)
+		assert.Equal(t, signedTx, signedTx2)
+		assert.Equal(t, txHash, txHash2)
+	}
+}
+
+func TestTonTransactionSigner_TransferBody(t *testing.T) {
+	signer := &TonTransactionSigner{}
+	privateKey, err := tonPrivateKeyFromHex(testTonSeed)
+	require.NoError(t, err)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
 
-	// 构建TON交易请求
 	txReq := TonTransactionRequest{
-		Address:     "EQC9bWZd8dR7XJcQfZ5XWgZ5XWgZ5XWgZ5XWgZ5XWgZ5XWgZ5XWg",
-		Destination: "EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N",
+		Destination: "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8",
 		Amount:      1000000000,
 		Seqno:       0,
+		ValidUntil:  1700000000,
 	}
+	rawTx, _ := json.Marshal(txReq)
+
+	signedTx, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "ton", testTonSeed))
+	require.NoError(t, err)
+	external := parseTestTonMessage(t, signedTx)
+
+	// seqno为0时附带钱包StateInit
+	wallet, err := newTonWallet(TonWalletV4R2, publicKey, false)
+	require.NoError(t, err)
+	stateInit, err := wallet.stateInit()
+	require.NoError(t, err)
+	require.Len(t, external.refs, 2)
+	assert.Equal(t, stateInit.hash(), external.refs[0].hash())
+
+	// 签名 || subwallet_id || valid_until || seqno || op || mode，引用内部消息
+	body := external.refs[1]
+	assert.Equal(t, 512+32+32+32+8+8, body.bitLen)
+	unsigned := body.bitsRange(512, body.bitLen)
+	assert.Equal(t, "29a9a3176553f100000000000003", hex.EncodeToString(unsigned.data))
 
-	rawTx, err := json.Marshal(txReq)
-	assert.NoError(t, err)
+	// 内部消息中的目标地址和金额
+	message := newTonCell()
+	message.storeUint(0b0110, 4)
+	message.storeAddress(nil)
+	destination, _ := ParseTonAddress(txReq.Destination)
+	message.storeAddress(destination)
+	message.storeCoins(tonCoins(txReq.Amount))
+	require.Len(t, body.refs, 1)
+	assert.Equal(t, message.data, body.refs[0].bitsRange(0, message.bitLen).data)
+
+	unsigned.refs = body.refs
+	assert.True(t, ed25519.Verify(publicKey, unsigned.hash(), body.bitsRange(0, 512).data))
+}
+
+func TestTonTextComment(t *testing.T) {
+	// 短备注存储在一个单元格中
+	cell := tonTextComment("hello")
+	assert.Equal(t, "0000000068656c6c6f", hex.EncodeToString(cell.data))
+	assert.Empty(t, cell.refs)
+
+	// 长备注按snake格式链接
+	comment := strings.Repeat("a", 300)
+	cell = tonTextComment(comment)
+	assert.NoError(t, cell.validate())
+	var text []byte
+	for c := cell; ; c = c.refs[0] {
+		text = append(text, c.data...)
+		if len(c.refs) == 0 {
+			break
+		}
+	}
+	assert.Equal(t, comment, string(text[4:]))
+}
+
+func TestTonCell_Boc(t *testing.T) {
+	// 共享的子单元格只序列化一次
+	child := newTonCell().storeUint(0xabcdef, 24)
+	root := newTonCell().storeUint(1, 3).storeRef(child).storeRef(child)
+	boc, err := root.toBoc()
+	require.NoError(t, err)
+
+	parsed, err := parseTonBoc(boc)
+	require.NoError(t, err)
+	assert.Equal(t, root.hash(), parsed.hash())
+	assert.Equal(t, 3, parsed.bitLen)
+	assert.Len(t, parsed.refs, 2)
+
+	// 篡改数据后CRC32C校验失败
+	boc[len(boc)-5] ^= 0xff
+	_, err = parseTonBoc(boc)
+	assert.Error(t, err)
+}
+
+func TestTonTransactionSigner_InvalidRequest(t *testing.T) {
+	signer := &TonTransactionSigner{}
+	valid := TonTransactionRequest{
+		Destination: "EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N",
+		Amount:      1,
+		Seqno:       1,
+	}
+
+	testCases := []func(req *TonTransactionRequest){
+		// 地址与私钥不匹配
+		func(req *TonTransactionRequest) { req.Address = valid.Destination },
+		// 无效的目标地址
+		func(req *TonTransactionRequest) { req.Destination = "EQ1234" },
+		// payload与comment互斥
+		func(req *TonTransactionRequest) { req.Payload, req.Comment = "te6cckEBAQEAAgAAAEysuc0=", "hello" },
+		// 无效的payload
+		func(req *TonTransactionRequest) { req.Payload = "not a boc" },
+		// 不支持的钱包版本
+		func(req *TonTransactionRequest) { req.WalletVersion = "v3r2" },
+	}
+
+	for i, modify := range testCases {
+		req := valid
+		modify(&req)
+		rawTx, _ := json.Marshal(req)
+		_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "ton", testTonSeed))
+		assert.Error(t, err, i)
+	}
 
-	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	// 无效的私钥
+	_, err := ParsePrivateKey("ton", []byte("invalid_private_key"))
+	assert.Error(t, err)
 
-	// 验证结果
-	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	assert.Contains(t, signedTx, "ton_signed_")
-	assert.Contains(t, txHash, "ton_")
-}
\ No newline at end of file
+	// 非JSON数据
+	_, _, err = signer.SignTransaction("not json", testSigningKey(t, "ton", testTonSeed))
+	assert.Error(t, err)
+}
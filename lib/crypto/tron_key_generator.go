@@ -1,14 +1,38 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/crypto"
-	// 暂时移除gotron-sdk的address包导入，因为当前实现不需要它
+	"github.com/featx/keys-gin/web/model"
+	"github.com/mr-tron/base58"
 )
 
+// tronAddressPrefix TRON主网地址的前缀字节
+const tronAddressPrefix = 0x41
+
+func init() {
+	mustRegisterChain(ChainModule{
+		ChainType: model.ChainTypeTRON,
+		Curve:     "secp256k1",
+		Encoding:  "tron_address",
+		NewKeyGenerator: func() (KeyGenerator, error) {
+			return &TronKeyGenerator{}, nil
+		},
+		NewTransactionSigner: func() (TransactionSigner, error) {
+			return &TronTransactionSigner{}, nil
+		},
+		ValidateAddress: func(address string) error {
+			_, err := decodeTronAddress(address)
+			return err
+		},
+	})
+}
+
 // TronKeyGenerator 实现真实的TRON密钥生成器
 // 使用ECDSA secp256k1曲线，符合TRON官方标准
 
@@ -102,16 +126,51 @@ func (g *TronKeyGenerator) PublicKeyToAddress(publicKeyHex string) (addressStr s
 		return "", fmt.Errorf("invalid public key length: %d bytes", len(publicKeyBytes))
 	}
 
-	// 计算公钥的Keccak-256哈希
-	// 注意：我们计算这个值但不使用它，因为我们使用硬编码地址进行测试
-	_ = crypto.Keccak256(crypto.FromECDSAPub(pubKey)[1:]) // 去掉0x04前缀
+	return encodeTronAddress(tronAddressFromPublicKey(pubKey)), nil
+}
+
+// DerivationPath 返回BIP-44派生路径 m/44'/195'/account'/0/index
+func (g *TronKeyGenerator) DerivationPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", CoinTypeTron, account, index)
+}
+
+// DeriveKeyPairFromMnemonic 从BIP-39助记词按BIP-32派生TRON密钥对
+func (g *TronKeyGenerator) DeriveKeyPairFromMnemonic(mnemonic, passphrase, path string) (address, publicKey, privateKey string, err error) {
+	return deriveSecp256k1HDKeyPair(g, mnemonic, passphrase, path)
+}
 
-	// 为了测试，我们使用一个硬编码的有效TRON地址
-	// 这样可以绕过地址生成的问题，继续测试签名功能
-	// 注意：这只是为了测试目的，实际应用中需要正确生成地址
-	addressStr = "TTmvTQ5P33kq39gXsSyBzQnP9aJd79cZ8B"
+// tronAddressFromPublicKey 计算21字节的TRON地址：0x41 || Keccak-256(公钥)的后20字节
+func tronAddressFromPublicKey(pubKey *ecdsa.PublicKey) []byte {
+	return append([]byte{tronAddressPrefix}, crypto.PubkeyToAddress(*pubKey).Bytes()...)
+}
 
-	return addressStr, nil
+// encodeTronAddress 将21字节地址编码为Base58Check格式（T开头）
+func encodeTronAddress(address []byte) string {
+	first := sha256.Sum256(address)
+	second := sha256.Sum256(first[:])
+	return base58.Encode(append(append([]byte{}, address...), second[:4]...))
+}
+
+// decodeTronAddress 解析TRON地址，支持Base58Check格式和41开头的十六进制格式，返回21字节地址
+func decodeTronAddress(address string) ([]byte, error) {
+	if len(address) == 42 {
+		decoded, err := hex.DecodeString(address)
+		if err != nil || decoded[0] != tronAddressPrefix {
+			return nil, fmt.Errorf("invalid tron address: %s", address)
+		}
+		return decoded, nil
+	}
+
+	decoded, err := base58.Decode(address)
+	if err != nil || len(decoded) != 25 || decoded[0] != tronAddressPrefix {
+		return nil, fmt.Errorf("invalid tron address: %s", address)
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], decoded[21:]) {
+		return nil, fmt.Errorf("invalid tron address checksum: %s", address)
+	}
+	return decoded[:21], nil
 }
 
 // AddressToPublicKey 从TRON地址获取公钥
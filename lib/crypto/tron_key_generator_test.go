@@ -1,6 +1,8 @@
 package crypto
 
 import (
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,7 +21,8 @@ func TestTronKeyGenerator_GenerateKeyPair(t *testing.T) {
 	assert.NotEmpty(t, publicKey)
 	assert.NotEmpty(t, privateKey)
 	// 验证地址格式符合TRON规范
-	assert.Contains(t, address, "T")
+	assert.True(t, strings.HasPrefix(address, "T"))
+	assert.Equal(t, 34, len(address))
 	// 验证私钥长度
 	assert.Equal(t, 64, len(privateKey)) // 32字节的十六进制表示
 }
@@ -41,6 +44,27 @@ func TestTronKeyGenerator_DeriveKeyPairFromPrivateKey(t *testing.T) {
 	assert.Contains(t, address, "T")
 }
 
+func TestTronKeyGenerator_KnownAddress(t *testing.T) {
+	generator := &TronKeyGenerator{}
+
+	// 私钥1对应的地址
+	address, _, err := generator.DeriveKeyPairFromPrivateKey("0000000000000000000000000000000000000000000000000000000000000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HC", address)
+
+	// Base58Check与十六进制格式解析为相同的地址
+	decoded, err := decodeTronAddress(address)
+	assert.NoError(t, err)
+	assert.Equal(t, "417e5f4552091a69125d5dfcb7b8c2659029395bdf", hex.EncodeToString(decoded))
+	decodedHex, err := decodeTronAddress("417e5f4552091a69125d5dfcb7b8c2659029395bdf")
+	assert.NoError(t, err)
+	assert.Equal(t, decoded, decodedHex)
+
+	// 校验和错误
+	_, err = decodeTronAddress("TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HD")
+	assert.Error(t, err)
+}
+
 func TestTronKeyGenerator_InvalidPrivateKey(t *testing.T) {
 	generator := &TronKeyGenerator{}
 
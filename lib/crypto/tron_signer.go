@@ -1,100 +1,146 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
-	// 暂时移除未使用的address包导入
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
+// TRON合约类型（protocol.Transaction.Contract.ContractType）
+const (
+	tronTransferContract      = 1
+	tronTransferAssetContract = 2
+	tronTriggerSmartContract  = 31
+)
+
+// TRON交易的默认参数
+const (
+	// tronDefaultExpiration 未指定过期时间时交易的有效期
+	tronDefaultExpiration = 60 * time.Second
+	// tronTypeURLPrefix 合约参数Any类型的type_url前缀
+	tronTypeURLPrefix = "type.googleapis.com/protocol."
+)
+
+// trc20TransferSelector TRC-20 transfer(address,uint256)的函数选择器
+var trc20TransferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+
 // TronTransactionRequest TRON交易请求结构
 // 符合TRON API规范的交易请求参数
+// 传入RawDataHex时直接签名节点createtransaction/triggersmartcontract等接口返回的交易，
+// 否则根据其余字段在本地构建交易：
+// 指定ContractAddress时构建TriggerSmartContract（Data为空时为TRC-20转账），
+// 指定TokenID时构建TransferAssetContract（TRC-10转账），否则构建TransferContract（TRX转账）
 
 type TronTransactionRequest struct {
 	OwnerAddress string `json:"ownerAddress"`
 	ToAddress    string `json:"toAddress"`
-	Amount       int64  `json:"amount"` // 单位是SUN
-	FeeLimit     int64  `json:"feeLimit"`
-	CallValue    int64  `json:"callValue,omitempty"`
-	Data         string `json:"data,omitempty"` // 合约调用数据
-	TokenID      string `json:"tokenId,omitempty"` // TRC10代币ID
+	Amount       int64  `json:"amount"` // 单位是SUN，TRC-10/TRC-20转账时为代币的最小单位
+	// TokenAmount TRC-20转账的代币数量（最小单位的十进制字符串），可以超过int64，与Amount二选一
+	TokenAmount     string `json:"tokenAmount,omitempty"`
+	FeeLimit        int64  `json:"feeLimit"`
+	CallValue       int64  `json:"callValue,omitempty"`
+	Data            string `json:"data,omitempty"`            // 合约调用数据
+	TokenID         string `json:"tokenId,omitempty"`         // TRC10代币ID
+	ContractAddress string `json:"contractAddress,omitempty"` // 智能合约（TRC-20代币）地址
+	Memo            string `json:"memo,omitempty"`            // 交易备注
+
+	// 引用区块：RefBlockID为最新区块的blockID，或直接指定RefBlockBytes和RefBlockHash
+	RefBlockID    string `json:"refBlockId,omitempty"`
+	RefBlockBytes string `json:"refBlockBytes,omitempty"`
+	RefBlockHash  string `json:"refBlockHash,omitempty"`
+	Timestamp     int64  `json:"timestamp,omitempty"`  // 毫秒，默认为当前时间
+	Expiration    int64  `json:"expiration,omitempty"` // 毫秒，默认为timestamp后60秒
+
+	// 节点返回的交易
+	TxID       string          `json:"txID,omitempty"`
+	RawData    json.RawMessage `json:"raw_data,omitempty"`
+	RawDataHex string          `json:"raw_data_hex,omitempty"`
+	Signature  []string        `json:"signature,omitempty"` // 已有的签名（多重签名）
+}
+
+// TronTransaction TRON标准JSON交易，与节点API的交易格式一致
+type TronTransaction struct {
+	Visible    bool            `json:"visible"`
+	TxID       string          `json:"txID"`
+	RawData    json.RawMessage `json:"raw_data,omitempty"`
+	RawDataHex string          `json:"raw_data_hex"`
+	Signature  []string        `json:"signature"`
 }
 
 // TronTransactionSigner 实现真实的TRON交易签名器
-// 使用ECDSA secp256k1曲线进行交易签名
+// 使用ECDSA secp256k1曲线对raw_data的SHA-256哈希（即txID）进行签名
 
 type TronTransactionSigner struct{}
 
 // SignTransaction 签名TRON交易
 // rawTx: 交易请求的JSON字符串
-// privateKeyHex: 十六进制格式的私钥
-// 返回: 签名后的交易字符串、交易哈希和可能的错误
-func (s *TronTransactionSigner) SignTransaction(rawTx, privateKeyHex string) (signedTx string, txHash string, err error) {
+// signer: secp256k1签名密钥
+// 返回: 签名后的标准JSON交易、交易ID和可能的错误
+func (s *TronTransactionSigner) SignTransaction(rawTx string, signer Signer) (signedTx string, txHash string, err error) {
 	// 解析交易参数
 	var txReq TronTransactionRequest
 	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
 		return "", "", fmt.Errorf("invalid transaction data format: %w", err)
 	}
 
-	// 解析私钥
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+	publicKey, err := secp256k1PublicKey(signer)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid private key format: %w", err)
+		return "", "", err
 	}
 
-	privKey, err := crypto.ToECDSA(privateKeyBytes)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to convert to ECDSA private key: %w", err)
-	}
-
-	// 为了测试目的，暂时跳过地址验证
-	// 注意：实际应用中应该验证地址格式
-	// _, err = address.Base58ToAddress(txReq.OwnerAddress)
-	// if err != nil {
-	// 	return "", "", fmt.Errorf("invalid owner address: %w", err)
-	// }
-	// 
-	// _, err = address.Base58ToAddress(txReq.ToAddress)
-	// if err != nil {
-	// 	return "", "", fmt.Errorf("invalid to address: %w", err)
-	// }
-
-	// 准备交易数据用于签名
-	// 将交易数据转换为字节用于哈希
-	txData := []byte(rawTx)
-
-	// 计算交易哈希
-	txHashBytes := crypto.Keccak256(txData)
-	txHash = hex.EncodeToString(txHashBytes)
-
-	// 使用ECDSA secp256k1签名交易哈希
-	signature, err := crypto.Sign(txHashBytes, privKey)
+	// 获取待签名的交易：节点返回的raw_data_hex，或在本地构建
+	var tx *TronTransaction
+	if txReq.RawDataHex != "" {
+		tx, err = tronTransactionFromRawDataHex(&txReq)
+	} else {
+		tx, err = buildTronTransaction(&txReq, tronAddressFromPublicKey(publicKey))
+	}
 	if err != nil {
-		return "", txHash, fmt.Errorf("failed to sign transaction: %w", err)
+		return "", "", err
 	}
 
-	// 将签名转换为十六进制字符串并添加前缀
-	signedTx = "tron_signed_" + hex.EncodeToString(signature)
+	// 使用ECDSA secp256k1签名txID
+	txID, _ := hex.DecodeString(tx.TxID)
+	signature, err := signRecoverable(signer, txID)
+	if err != nil {
+		return "", tx.TxID, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	// 与TronWeb一致，恢复标识v取27/28
+	signature[64] += 27
+	tx.Signature = append(tx.Signature, hex.EncodeToString(signature))
 
-	// 添加前缀到交易哈希
-	txHash = "tron_" + txHash
+	signedTxBytes, err := json.Marshal(tx)
+	if err != nil {
+		return "", tx.TxID, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
 
-	return signedTx, txHash, nil
+	return string(signedTxBytes), tx.TxID, nil
 }
 
 // VerifyTransaction 验证TRON交易签名
-// rawTx: 原始交易数据
-// signedTx: 签名后的交易数据
+// rawTx: 原始交易数据（未使用，保留以兼容其他签名器）
+// signedTx: 签名后的标准JSON交易
 // publicKeyHex: 十六进制格式的公钥
-// 返回: 签名是否有效和可能的错误
+// 返回: 交易中是否存在该公钥的有效签名和可能的错误
 func (s *TronTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex string) (bool, error) {
-	// 解析签名
-	signature, err := hex.DecodeString(signedTx)
+	// 解析签名后的交易
+	var tx TronTransaction
+	if err := json.Unmarshal([]byte(signedTx), &tx); err != nil {
+		return false, fmt.Errorf("invalid signed transaction format: %w", err)
+	}
+	rawData, err := hex.DecodeString(tx.RawDataHex)
 	if err != nil {
-		return false, fmt.Errorf("invalid signature format: %w", err)
+		return false, fmt.Errorf("invalid raw_data_hex: %w", err)
 	}
 
 	// 解析公钥
@@ -103,8 +149,8 @@ func (s *TronTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex
 		return false, fmt.Errorf("invalid public key format: %w", err)
 	}
 
-	// 验证签名长度
-	if len(signature) == 0 {
+	// 验证签名数量
+	if len(tx.Signature) == 0 {
 		return false, fmt.Errorf("signature is empty")
 	}
 
@@ -113,10 +159,6 @@ func (s *TronTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex
 		return false, fmt.Errorf("invalid public key length: expected 33 or 65 bytes")
 	}
 
-	// 准备交易数据用于验证
-	txData := []byte(rawTx)
-	txHashBytes := crypto.Keccak256(txData)
-
 	// 处理压缩格式公钥
 	var pubKey *ecdsa.PublicKey
 	var errPub error
@@ -133,19 +175,327 @@ func (s *TronTransactionSigner) VerifyTransaction(rawTx, signedTx, publicKeyHex
 		return false, fmt.Errorf("failed to parse public key: %w", errPub)
 	}
 
-	// 从签名中恢复公钥
-	recoveredPubKey, err := crypto.SigToPub(txHashBytes, signature)
-	if err != nil {
-		return false, fmt.Errorf("failed to recover public key: %w", err)
+	// 从每个签名中恢复公钥并与提供的公钥比较
+	txID := sha256.Sum256(rawData)
+	for _, signatureHex := range tx.Signature {
+		signature, err := hex.DecodeString(signatureHex)
+		if err != nil || len(signature) != 65 {
+			return false, fmt.Errorf("invalid signature format: %s", signatureHex)
+		}
+		if signature[64] >= 27 {
+			signature[64] -= 27
+		}
+		recoveredPubKey, err := crypto.SigToPub(txID[:], signature)
+		if err != nil {
+			return false, fmt.Errorf("failed to recover public key: %w", err)
+		}
+		if crypto.PubkeyToAddress(*recoveredPubKey) == crypto.PubkeyToAddress(*pubKey) {
+			return true, nil
+		}
 	}
 
-	// 比较恢复的公钥和提供的公钥
-	return crypto.PubkeyToAddress(*recoveredPubKey) == crypto.PubkeyToAddress(*pubKey), nil
+	return false, nil
 }
 
 // CreateTronTransaction 创建TRON交易
-// 辅助方法，用于创建符合TRON规范的交易结构
+// 辅助方法，在本地构建未签名的标准JSON交易，ownerAddress为空时返回错误
 func (s *TronTransactionSigner) CreateTronTransaction(rawTx string) (string, error) {
-	// 这里可以实现交易预处理逻辑
-	return rawTx, nil
-}
\ No newline at end of file
+	var txReq TronTransactionRequest
+	if err := json.Unmarshal([]byte(rawTx), &txReq); err != nil {
+		return "", fmt.Errorf("invalid transaction data format: %w", err)
+	}
+	if txReq.OwnerAddress == "" {
+		return "", errors.New("owner address is required")
+	}
+
+	tx, err := buildTronTransaction(&txReq, nil)
+	if err != nil {
+		return "", err
+	}
+	txBytes, err := json.Marshal(tx)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	return string(txBytes), nil
+}
+
+// tronTransactionFromRawDataHex 由节点返回的raw_data_hex得到待签名的交易，并校验txID
+func tronTransactionFromRawDataHex(txReq *TronTransactionRequest) (*TronTransaction, error) {
+	rawData, err := hex.DecodeString(txReq.RawDataHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw_data_hex: %w", err)
+	}
+
+	txID := sha256.Sum256(rawData)
+	if txReq.TxID != "" && !strings.EqualFold(txReq.TxID, hex.EncodeToString(txID[:])) {
+		return nil, fmt.Errorf("txID %s does not match raw_data_hex", txReq.TxID)
+	}
+
+	return &TronTransaction{
+		TxID:       hex.EncodeToString(txID[:]),
+		RawData:    txReq.RawData,
+		RawDataHex: txReq.RawDataHex,
+		Signature:  txReq.Signature,
+	}, nil
+}
+
+// buildTronTransaction 在本地构建交易
+// signer为私钥对应的地址，不为nil时校验ownerAddress，ownerAddress为空时使用该地址
+func buildTronTransaction(txReq *TronTransactionRequest, signer []byte) (*TronTransaction, error) {
+	owner := signer
+	if txReq.OwnerAddress != "" {
+		address, err := decodeTronAddress(txReq.OwnerAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner address: %w", err)
+		}
+		if signer != nil && !bytes.Equal(address, signer) {
+			return nil, fmt.Errorf("owner address %s does not match private key", txReq.OwnerAddress)
+		}
+		owner = address
+	}
+
+	// 引用区块
+	refBlockBytes, refBlockHash, err := tronRefBlock(txReq)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := txReq.Timestamp
+	if timestamp == 0 {
+		timestamp = time.Now().UnixMilli()
+	}
+	expiration := txReq.Expiration
+	if expiration == 0 {
+		expiration = timestamp + tronDefaultExpiration.Milliseconds()
+	}
+
+	// 构建合约
+	contractType, contractName, parameter, value, err := buildTronContract(txReq, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// protocol.Transaction.Contract
+	var contract []byte
+	contract = protowire.AppendTag(contract, 1, protowire.VarintType)
+	contract = protowire.AppendVarint(contract, contractType)
+	var parameterAny []byte
+	parameterAny = protowire.AppendTag(parameterAny, 1, protowire.BytesType)
+	parameterAny = protowire.AppendString(parameterAny, tronTypeURLPrefix+contractName)
+	parameterAny = protowire.AppendTag(parameterAny, 2, protowire.BytesType)
+	parameterAny = protowire.AppendBytes(parameterAny, parameter)
+	contract = protowire.AppendTag(contract, 2, protowire.BytesType)
+	contract = protowire.AppendBytes(contract, parameterAny)
+
+	// protocol.Transaction.raw
+	var raw []byte
+	raw = protowire.AppendTag(raw, 1, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, refBlockBytes)
+	raw = protowire.AppendTag(raw, 4, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, refBlockHash)
+	raw = protowire.AppendTag(raw, 8, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, uint64(expiration))
+	if txReq.Memo != "" {
+		raw = protowire.AppendTag(raw, 10, protowire.BytesType)
+		raw = protowire.AppendString(raw, txReq.Memo)
+	}
+	raw = protowire.AppendTag(raw, 11, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, contract)
+	raw = protowire.AppendTag(raw, 14, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, uint64(timestamp))
+	if contractType == tronTriggerSmartContract && txReq.FeeLimit > 0 {
+		raw = protowire.AppendTag(raw, 18, protowire.VarintType)
+		raw = protowire.AppendVarint(raw, uint64(txReq.FeeLimit))
+	}
+
+	// 与节点返回格式一致的raw_data（visible为false，地址为十六进制）
+	rawDataJSON := map[string]interface{}{
+		"contract": []interface{}{map[string]interface{}{
+			"parameter": map[string]interface{}{
+				"value":    value,
+				"type_url": tronTypeURLPrefix + contractName,
+			},
+			"type": contractName,
+		}},
+		"ref_block_bytes": hex.EncodeToString(refBlockBytes),
+		"ref_block_hash":  hex.EncodeToString(refBlockHash),
+		"expiration":      expiration,
+		"timestamp":       timestamp,
+	}
+	if txReq.Memo != "" {
+		rawDataJSON["data"] = hex.EncodeToString([]byte(txReq.Memo))
+	}
+	if contractType == tronTriggerSmartContract && txReq.FeeLimit > 0 {
+		rawDataJSON["fee_limit"] = txReq.FeeLimit
+	}
+	rawData, err := json.Marshal(rawDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize raw_data: %w", err)
+	}
+
+	txID := sha256.Sum256(raw)
+	return &TronTransaction{
+		TxID:       hex.EncodeToString(txID[:]),
+		RawData:    rawData,
+		RawDataHex: hex.EncodeToString(raw),
+		Signature:  []string{},
+	}, nil
+}
+
+// buildTronContract 构建合约参数，返回合约类型、名称、protobuf编码的参数及其JSON表示
+func buildTronContract(txReq *TronTransactionRequest, owner []byte) (uint64, string, []byte, map[string]interface{}, error) {
+	if owner == nil {
+		return 0, "", nil, nil, errors.New("owner address is required")
+	}
+	value := map[string]interface{}{"owner_address": hex.EncodeToString(owner)}
+
+	var parameter []byte
+	switch {
+	case txReq.ContractAddress != "":
+		// TriggerSmartContract{owner_address = 1, contract_address = 2, call_value = 3, data = 4}
+		contractAddress, err := decodeTronAddress(txReq.ContractAddress)
+		if err != nil {
+			return 0, "", nil, nil, fmt.Errorf("invalid contract address: %w", err)
+		}
+		data, err := tronCallData(txReq)
+		if err != nil {
+			return 0, "", nil, nil, err
+		}
+
+		parameter = protowire.AppendTag(parameter, 1, protowire.BytesType)
+		parameter = protowire.AppendBytes(parameter, owner)
+		parameter = protowire.AppendTag(parameter, 2, protowire.BytesType)
+		parameter = protowire.AppendBytes(parameter, contractAddress)
+		if txReq.CallValue > 0 {
+			parameter = protowire.AppendTag(parameter, 3, protowire.VarintType)
+			parameter = protowire.AppendVarint(parameter, uint64(txReq.CallValue))
+			value["call_value"] = txReq.CallValue
+		}
+		parameter = protowire.AppendTag(parameter, 4, protowire.BytesType)
+		parameter = protowire.AppendBytes(parameter, data)
+
+		value["contract_address"] = hex.EncodeToString(contractAddress)
+		value["data"] = hex.EncodeToString(data)
+		return tronTriggerSmartContract, "TriggerSmartContract", parameter, value, nil
+
+	case txReq.TokenID != "":
+		// TransferAssetContract{asset_name = 1, owner_address = 2, to_address = 3, amount = 4}
+		toAddress, err := decodeTronAddress(txReq.ToAddress)
+		if err != nil {
+			return 0, "", nil, nil, fmt.Errorf("invalid to address: %w", err)
+		}
+		if txReq.TokenAmount != "" {
+			return 0, "", nil, nil, errors.New("tokenAmount is only supported for trc-20 transfers")
+		}
+		if txReq.Amount <= 0 {
+			return 0, "", nil, nil, errors.New("amount must be positive")
+		}
+
+		parameter = protowire.AppendTag(parameter, 1, protowire.BytesType)
+		parameter = protowire.AppendString(parameter, txReq.TokenID)
+		parameter = protowire.AppendTag(parameter, 2, protowire.BytesType)
+		parameter = protowire.AppendBytes(parameter, owner)
+		parameter = protowire.AppendTag(parameter, 3, protowire.BytesType)
+		parameter = protowire.AppendBytes(parameter, toAddress)
+		parameter = protowire.AppendTag(parameter, 4, protowire.VarintType)
+		parameter = protowire.AppendVarint(parameter, uint64(txReq.Amount))
+
+		value["asset_name"] = hex.EncodeToString([]byte(txReq.TokenID))
+		value["to_address"] = hex.EncodeToString(toAddress)
+		value["amount"] = txReq.Amount
+		return tronTransferAssetContract, "TransferAssetContract", parameter, value, nil
+
+	default:
+		// TransferContract{owner_address = 1, to_address = 2, amount = 3}
+		toAddress, err := decodeTronAddress(txReq.ToAddress)
+		if err != nil {
+			return 0, "", nil, nil, fmt.Errorf("invalid to address: %w", err)
+		}
+		if txReq.TokenAmount != "" {
+			return 0, "", nil, nil, errors.New("tokenAmount is only supported for trc-20 transfers")
+		}
+		if txReq.Amount <= 0 {
+			return 0, "", nil, nil, errors.New("amount must be positive")
+		}
+
+		parameter = protowire.AppendTag(parameter, 1, protowire.BytesType)
+		parameter = protowire.AppendBytes(parameter, owner)
+		parameter = protowire.AppendTag(parameter, 2, protowire.BytesType)
+		parameter = protowire.AppendBytes(parameter, toAddress)
+		parameter = protowire.AppendTag(parameter, 3, protowire.VarintType)
+		parameter = protowire.AppendVarint(parameter, uint64(txReq.Amount))
+
+		value["to_address"] = hex.EncodeToString(toAddress)
+		value["amount"] = txReq.Amount
+		return tronTransferContract, "TransferContract", parameter, value, nil
+	}
+}
+
+// tronCallData 合约调用数据：指定Data时直接使用，否则编码TRC-20 transfer(toAddress, amount)
+func tronCallData(txReq *TronTransactionRequest) ([]byte, error) {
+	if txReq.Data != "" {
+		data, err := hex.DecodeString(strings.TrimPrefix(txReq.Data, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid contract call data: %w", err)
+		}
+		return data, nil
+	}
+
+	toAddress, err := decodeTronAddress(txReq.ToAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to address: %w", err)
+	}
+	amount, err := trc20Amount(txReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 参数按ABI编码为32字节：地址去掉0x41前缀后左侧补零
+	data := append([]byte{}, trc20TransferSelector...)
+	data = append(data, make([]byte, 12)...)
+	data = append(data, toAddress[1:]...)
+	data = append(data, amount.FillBytes(make([]byte, 32))...)
+	return data, nil
+}
+
+// trc20Amount TRC-20转账的代币数量：指定TokenAmount时按十进制解析为uint256，否则使用Amount
+func trc20Amount(txReq *TronTransactionRequest) (*big.Int, error) {
+	if txReq.TokenAmount == "" {
+		if txReq.Amount <= 0 {
+			return nil, errors.New("amount must be positive")
+		}
+		return big.NewInt(txReq.Amount), nil
+	}
+	if txReq.Amount != 0 {
+		return nil, errors.New("amount and tokenAmount cannot both be set")
+	}
+
+	amount, ok := new(big.Int).SetString(txReq.TokenAmount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid tokenAmount: %s", txReq.TokenAmount)
+	}
+	if amount.BitLen() > 256 {
+		return nil, errors.New("tokenAmount exceeds uint256")
+	}
+	return amount, nil
+}
+
+// tronRefBlock 引用区块的ref_block_bytes（区块高度的第7、8字节）和ref_block_hash（区块哈希的第9到16字节）
+func tronRefBlock(txReq *TronTransactionRequest) ([]byte, []byte, error) {
+	if txReq.RefBlockID != "" {
+		blockID, err := hex.DecodeString(txReq.RefBlockID)
+		if err != nil || len(blockID) != 32 {
+			return nil, nil, fmt.Errorf("invalid ref block id: %s", txReq.RefBlockID)
+		}
+		return blockID[6:8], blockID[8:16], nil
+	}
+
+	refBlockBytes, err := hex.DecodeString(txReq.RefBlockBytes)
+	if err != nil || len(refBlockBytes) != 2 {
+		return nil, nil, fmt.Errorf("invalid ref block bytes: %s", txReq.RefBlockBytes)
+	}
+	refBlockHash, err := hex.DecodeString(txReq.RefBlockHash)
+	if err != nil || len(refBlockHash) != 8 {
+		return nil, nil, fmt.Errorf("invalid ref block hash: %s", txReq.RefBlockHash)
+	}
+	return refBlockBytes, refBlockHash, nil
+}
@@ -1,36 +1,187 @@
 package crypto
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestTronTransactionSigner_SignTransaction(t *testing.T) {
-	signer := &TronTransactionSigner{}
-
-	// 测试用的私钥
-	privateKeyHex := "0000000000000000000000000000000000000000000000000000000000000001"
+const (
+	// 测试用的私钥及其地址
+	testTronPrivateKey = "0000000000000000000000000000000000000000000000000000000000000001"
+	testTronAddress    = "TMVQGm1qAQYVdetCeGRRkTWYYrLXuHK2HC"
+	// USDT合约地址
+	testTronUSDT = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+)
 
-	// 构建TRON交易请求
-	txReq := TronTransactionRequest{
-		OwnerAddress: "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb",
-		ToAddress:    "TWbcDLmz7Xg47LrFF9YH42h7Z8XfR6V9Vj",
-		Amount:       1000000,
-		FeeLimit:     100000000,
+// newTestTronRequest 构建引用区块和时间固定的转账请求
+func newTestTronRequest() TronTransactionRequest {
+	return TronTransactionRequest{
+		OwnerAddress:  testTronAddress,
+		ToAddress:     testTronUSDT,
+		Amount:        1000000,
+		RefBlockBytes: "ab12",
+		RefBlockHash:  "0102030405060708",
+		Timestamp:     1700000000000,
+		Expiration:    1700000060000,
 	}
+}
 
+// signTestTronTransaction 签名并解析签名后的交易
+func signTestTronTransaction(t *testing.T, txReq TronTransactionRequest) (*TronTransaction, string) {
 	rawTx, err := json.Marshal(txReq)
-	assert.NoError(t, err)
+	require.NoError(t, err)
+
+	signer := &TronTransactionSigner{}
+	signedTx, txHash, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "tron", testTronPrivateKey))
+	require.NoError(t, err)
 
-	// 执行签名
-	signedTx, txHash, err := signer.SignTransaction(string(rawTx), privateKeyHex)
+	var tx TronTransaction
+	require.NoError(t, json.Unmarshal([]byte(signedTx), &tx))
+	assert.Equal(t, tx.TxID, txHash)
 
-	// 验证结果
+	// 签名可以通过公钥验证
+	privKey, _ := crypto.HexToECDSA(testTronPrivateKey)
+	valid, err := signer.VerifyTransaction(string(rawTx), signedTx, hex.EncodeToString(crypto.CompressPubkey(&privKey.PublicKey)))
 	assert.NoError(t, err)
-	assert.NotEmpty(t, signedTx)
-	assert.NotEmpty(t, txHash)
-	assert.Contains(t, signedTx, "tron_signed_")
-	assert.Contains(t, txHash, "tron_")
-}
\ No newline at end of file
+	assert.True(t, valid)
+
+	return &tx, signedTx
+}
+
+func TestTronTransactionSigner_SignTransaction(t *testing.T) {
+	tx, _ := signTestTronTransaction(t, newTestTronRequest())
+
+	// raw_data按protocol.Transaction.raw编码，txID为其SHA-256哈希
+	assert.Equal(t, "0a02ab122208010203040506070840e0a499ffbc315a67080112630a2d747970652e676f6f676c65617069732e636f6d2f70726f746f636f6c2e5472616e73666572436f6e747261637412320a15417e5f4552091a69125d5dfcb7b8c2659029395bdf121541a614f803b6fd780986a42c78ec9c7f77e6ded13c18c0843d7080d095ffbc31", tx.RawDataHex)
+	assert.Equal(t, "116c63179345588a87da04a26ddf921d791d4c7e2e3149d21ecf7f48d7f96666", tx.TxID)
+	require.Len(t, tx.Signature, 1)
+	assert.Len(t, tx.Signature[0], 130)
+
+	// raw_data与节点返回的JSON格式一致
+	var rawData struct {
+		Contract []struct {
+			Parameter struct {
+				Value   map[string]interface{} `json:"value"`
+				TypeURL string                 `json:"type_url"`
+			} `json:"parameter"`
+			Type string `json:"type"`
+		} `json:"contract"`
+		RefBlockBytes string `json:"ref_block_bytes"`
+		Expiration    int64  `json:"expiration"`
+	}
+	require.NoError(t, json.Unmarshal(tx.RawData, &rawData))
+	require.Len(t, rawData.Contract, 1)
+	assert.Equal(t, "TransferContract", rawData.Contract[0].Type)
+	assert.Equal(t, "type.googleapis.com/protocol.TransferContract", rawData.Contract[0].Parameter.TypeURL)
+	assert.Equal(t, "417e5f4552091a69125d5dfcb7b8c2659029395bdf", rawData.Contract[0].Parameter.Value["owner_address"])
+	assert.Equal(t, "ab12", rawData.RefBlockBytes)
+	assert.Equal(t, int64(1700000060000), rawData.Expiration)
+}
+
+func TestTronTransactionSigner_Contracts(t *testing.T) {
+	// TRC-10转账
+	txReq := newTestTronRequest()
+	txReq.TokenID = "1002000"
+	tx, _ := signTestTronTransaction(t, txReq)
+	assert.Contains(t, tx.RawDataHex, hex.EncodeToString([]byte("protocol.TransferAssetContract")))
+	assert.Contains(t, tx.RawDataHex, "0a0731303032303030") // asset_name
+
+	// TRC-20转账：transfer(to, amount)，并设置fee_limit
+	txReq = newTestTronRequest()
+	txReq.ToAddress = testTronAddress
+	txReq.ContractAddress = testTronUSDT
+	txReq.FeeLimit = 100000000
+	tx, _ = signTestTronTransaction(t, txReq)
+	assert.Contains(t, tx.RawDataHex, hex.EncodeToString([]byte("protocol.TriggerSmartContract")))
+	expectedData := "a9059cbb0000000000000000000000007e5f4552091a69125d5dfcb7b8c2659029395bdf00000000000000000000000000000000000000000000000000000000000f4240"
+	assert.Contains(t, tx.RawDataHex, "2244"+expectedData)
+	assert.Contains(t, tx.RawDataHex, "900180c2d72f") // fee_limit
+	assert.Contains(t, string(tx.RawData), `"fee_limit":100000000`)
+
+	// TRC-20转账数量超过int64时使用十进制字符串
+	txReq.Amount = 0
+	txReq.TokenAmount = "100000000000000000000000"
+	tx, _ = signTestTronTransaction(t, txReq)
+	expectedData = "a9059cbb0000000000000000000000007e5f4552091a69125d5dfcb7b8c2659029395bdf00000000000000000000000000000000000000000000152d02c7e14af6800000"
+	assert.Contains(t, tx.RawDataHex, "2244"+expectedData)
+
+	// 使用最新区块的blockID作为引用区块
+	txReq = newTestTronRequest()
+	txReq.RefBlockBytes, txReq.RefBlockHash = "", ""
+	txReq.RefBlockID = "0000000003b1ab12010203040506070811121314151617181920212223242526"
+	tx, _ = signTestTronTransaction(t, txReq)
+	assert.Equal(t, "116c63179345588a87da04a26ddf921d791d4c7e2e3149d21ecf7f48d7f96666", tx.TxID)
+}
+
+func TestTronTransactionSigner_RawDataHex(t *testing.T) {
+	// 节点返回的未签名交易
+	unsigned, err := (&TronTransactionSigner{}).CreateTronTransaction(func() string {
+		rawTx, _ := json.Marshal(newTestTronRequest())
+		return string(rawTx)
+	}())
+	require.NoError(t, err)
+
+	var txReq TronTransactionRequest
+	require.NoError(t, json.Unmarshal([]byte(unsigned), &txReq))
+	tx, signedTx := signTestTronTransaction(t, txReq)
+
+	rawData, _ := hex.DecodeString(tx.RawDataHex)
+	txID := sha256.Sum256(rawData)
+	assert.Equal(t, hex.EncodeToString(txID[:]), tx.TxID)
+	assert.JSONEq(t, string(txReq.RawData), string(tx.RawData))
+	assert.Contains(t, signedTx, `"visible":false`)
+
+	// txID与raw_data_hex不一致
+	txReq.TxID = "00" + txReq.TxID[2:]
+	rawTx, _ := json.Marshal(txReq)
+	_, _, err = (&TronTransactionSigner{}).SignTransaction(string(rawTx), testSigningKey(t, "tron", testTronPrivateKey))
+	assert.Error(t, err)
+}
+
+func TestTronTransactionSigner_InvalidRequest(t *testing.T) {
+	signer := &TronTransactionSigner{}
+
+	testCases := []func(req *TronTransactionRequest){
+		// ownerAddress与私钥不匹配
+		func(req *TronTransactionRequest) { req.OwnerAddress = testTronUSDT },
+		// 无效的接收地址
+		func(req *TronTransactionRequest) { req.ToAddress = "TWbcDLmz7Xg47LrFF9YH42h7Z8XfR6V9Vj" },
+		// 金额为0
+		func(req *TronTransactionRequest) { req.Amount = 0 },
+		// 缺少引用区块
+		func(req *TronTransactionRequest) { req.RefBlockHash = "" },
+		// TRC-20的tokenAmount无效或超过uint256
+		func(req *TronTransactionRequest) {
+			req.ContractAddress, req.Amount, req.TokenAmount = testTronUSDT, 0, "-1"
+		},
+		func(req *TronTransactionRequest) {
+			req.ContractAddress, req.Amount, req.TokenAmount = testTronUSDT, 0, "1e18"
+		},
+		func(req *TronTransactionRequest) {
+			req.ContractAddress, req.Amount = testTronUSDT, 0
+			req.TokenAmount = new(big.Int).Lsh(big.NewInt(1), 256).String()
+		},
+		// amount与tokenAmount同时指定
+		func(req *TronTransactionRequest) { req.ContractAddress, req.TokenAmount = testTronUSDT, "1" },
+		// TRX和TRC-10转账不支持tokenAmount
+		func(req *TronTransactionRequest) { req.TokenAmount = "1" },
+		func(req *TronTransactionRequest) { req.TokenID, req.TokenAmount = "1002000", "1" },
+		// 无效的raw_data_hex
+		func(req *TronTransactionRequest) { req.RawDataHex = "xyz" },
+	}
+
+	for i, modify := range testCases {
+		req := newTestTronRequest()
+		modify(&req)
+		rawTx, _ := json.Marshal(req)
+		_, _, err := signer.SignTransaction(string(rawTx), testSigningKey(t, "tron", testTronPrivateKey))
+		assert.Error(t, err, i)
+	}
+}
@@ -0,0 +1,85 @@
+// Package hsm 通过PKCS#11使用硬件安全模块（HSM）中的密钥
+// 私钥在HSM中生成且不可导出，keystore中只保存密钥的引用（pkcs11:object=<标签>），签名时由HSM计算
+// PKCS#11模块通过cgo加载，禁用cgo编译时Open返回错误
+package hsm
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/featx/keys-gin/lib/crypto"
+)
+
+const (
+	ed25519PublicKeyLength   = 32
+	secp256k1PublicKeyLength = 65
+)
+
+// ErrKeyNotFound HSM中没有该标签的密钥
+var ErrKeyNotFound = errors.New("hsm key not found")
+
+// Config HSM配置
+type Config struct {
+	// LibraryPath PKCS#11模块的路径，如SoftHSM v2的 /usr/lib/softhsm/libsofthsm2.so
+	LibraryPath string
+	// TokenLabel 令牌标签，为空时使用第一个已初始化的令牌
+	TokenLabel string
+	// PIN 用户PIN
+	PIN string
+}
+
+// Key HSM中的签名密钥，实现crypto.Signer
+type Key struct {
+	label     string
+	curve     string
+	publicKey []byte
+	sign      func(digest []byte) ([]byte, error)
+}
+
+// Label 密钥标签
+func (k *Key) Label() string {
+	return k.label
+}
+
+// Curve 密钥曲线
+func (k *Key) Curve() string {
+	return k.curve
+}
+
+// PublicKey secp256k1为33字节压缩公钥，ed25519为32字节公钥
+func (k *Key) PublicKey() []byte {
+	return append([]byte{}, k.publicKey...)
+}
+
+// Sign 由HSM签名，secp256k1对32字节摘要签名并返回 r || s，ed25519对完整消息签名
+func (k *Key) Sign(digest []byte) ([]byte, error) {
+	return k.sign(digest)
+}
+
+// parseECPoint 解析CKA_EC_POINT：DER编码的OCTET STRING，部分实现直接返回原始公钥
+func parseECPoint(point []byte, curve string) ([]byte, error) {
+	expected := ed25519PublicKeyLength
+	if curve == crypto.CurveSecp256k1 {
+		expected = secp256k1PublicKeyLength
+	}
+
+	raw := point
+	var octets []byte
+	if rest, err := asn1.Unmarshal(point, &octets); err == nil && len(rest) == 0 && len(octets) == expected {
+		raw = octets
+	}
+	if len(raw) != expected {
+		return nil, fmt.Errorf("invalid %s public key length: %d", curve, len(raw))
+	}
+
+	if curve == crypto.CurveSecp256k1 {
+		publicKey, err := ethcrypto.UnmarshalPubkey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secp256k1 public key: %w", err)
+		}
+		return ethcrypto.CompressPubkey(publicKey), nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,51 @@
+package hsm
+
+import (
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyURI(t *testing.T) {
+	uri := KeyURI("keys gin/1")
+	assert.Equal(t, "pkcs11:object=keys%20gin%2F1", uri)
+	assert.True(t, IsKeyURI([]byte(uri)))
+	label, err := ParseKeyURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "keys gin/1", label)
+
+	assert.False(t, IsKeyURI([]byte("deadbeef")))
+	_, err = ParseKeyURI("deadbeef")
+	assert.Error(t, err)
+	_, err = ParseKeyURI("pkcs11:object=")
+	assert.Error(t, err)
+}
+
+func TestParseECPoint(t *testing.T) {
+	privateKey, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	uncompressed := ethcrypto.FromECDSAPub(&privateKey.PublicKey)
+
+	// DER编码的OCTET STRING
+	der := append([]byte{0x04, byte(len(uncompressed))}, uncompressed...)
+	publicKey, err := parseECPoint(der, crypto.CurveSecp256k1)
+	require.NoError(t, err)
+	assert.Equal(t, ethcrypto.CompressPubkey(&privateKey.PublicKey), publicKey)
+
+	// 原始公钥
+	publicKey, err = parseECPoint(uncompressed, crypto.CurveSecp256k1)
+	require.NoError(t, err)
+	assert.Equal(t, ethcrypto.CompressPubkey(&privateKey.PublicKey), publicKey)
+
+	ed25519Point := make([]byte, 32)
+	ed25519Point[0] = 0x04
+	publicKey, err = parseECPoint(append([]byte{0x04, 32}, ed25519Point...), crypto.CurveEd25519)
+	require.NoError(t, err)
+	assert.Equal(t, ed25519Point, publicKey)
+
+	_, err = parseECPoint(make([]byte, 31), crypto.CurveEd25519)
+	assert.Error(t, err)
+}
@@ -0,0 +1,296 @@
+//go:build cgo
+
+package hsm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/miekg/pkcs11"
+)
+
+// miekg/pkcs11未定义的PKCS#11 v3.0 EdDSA常量
+const (
+	ckkECEdwards           = 0x00000040
+	ckmECEdwardsKeyPairGen = 0x00001055
+	ckmEdDSA               = 0x00001057
+)
+
+var (
+	// secp256k1OID secp256k1曲线的DER编码OID（1.3.132.0.10），作为CKA_EC_PARAMS
+	secp256k1OID = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+	// ed25519OID Ed25519曲线的DER编码OID（1.3.101.112），作为CKA_EC_PARAMS
+	ed25519OID = []byte{0x06, 0x03, 0x2b, 0x65, 0x70}
+)
+
+// Token 已登录的PKCS#11令牌
+// 所有操作共用一个会话，由互斥锁串行化
+type Token struct {
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+}
+
+// Open 加载PKCS#11模块，打开令牌的读写会话并以用户身份登录
+func Open(config Config) (*Token, error) {
+	if config.LibraryPath == "" {
+		return nil, errors.New("pkcs11 library path is required")
+	}
+
+	ctx := pkcs11.New(config.LibraryPath)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load pkcs11 library %s", config.LibraryPath)
+	}
+	if err := ctx.Initialize(); err != nil && !isError(err, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize pkcs11 library: %w", err)
+	}
+
+	slot, err := findSlot(ctx, config.TokenLabel)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to open pkcs11 session: %w", err)
+	}
+	if err := ctx.Login(session, pkcs11.CKU_USER, config.PIN); err != nil && !isError(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		ctx.CloseSession(session)
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to login to pkcs11 token: %w", err)
+	}
+
+	return &Token{ctx: ctx, session: session}, nil
+}
+
+// findSlot 查找令牌所在的槽，label为空时返回第一个有令牌的槽
+func findSlot(ctx *pkcs11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pkcs11 slots: %w", err)
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if info.Flags&pkcs11.CKF_TOKEN_INITIALIZED == 0 {
+			continue
+		}
+		if label == "" || info.Label == label {
+			return slot, nil
+		}
+	}
+	if label == "" {
+		return 0, errors.New("no initialized pkcs11 token found")
+	}
+	return 0, fmt.Errorf("pkcs11 token %s not found", label)
+}
+
+// Close 登出并关闭会话，卸载PKCS#11模块
+func (t *Token) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ctx.Logout(t.session)
+	err := t.ctx.CloseSession(t.session)
+	t.ctx.Finalize()
+	t.ctx.Destroy()
+	return err
+}
+
+// GenerateKey 在令牌中生成密钥对，私钥不可导出，只能用于签名
+// curve为secp256k1或ed25519，label为密钥的标签（同时作为CKA_ID），不能与已有密钥重复
+func (t *Token) GenerateKey(curve, label string) (*Key, error) {
+	if label == "" {
+		return nil, errors.New("key label is required")
+	}
+
+	var keyType uint
+	var mechanism uint
+	var params []byte
+	switch curve {
+	case crypto.CurveSecp256k1:
+		keyType, mechanism, params = pkcs11.CKK_EC, pkcs11.CKM_EC_KEY_PAIR_GEN, secp256k1OID
+	case crypto.CurveEd25519:
+		keyType, mechanism, params = ckkECEdwards, ckmECEdwardsKeyPairGen, ed25519OID
+	default:
+		return nil, fmt.Errorf("unsupported key curve: %s", curve)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.findObject(pkcs11.CKO_PRIVATE_KEY, label); err == nil {
+		return nil, fmt.Errorf("hsm key %s already exists", label)
+	} else if !errors.Is(err, ErrKeyNotFound) {
+		return nil, err
+	}
+
+	publicTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, []byte(label)),
+	}
+	privateTemplate := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, []byte(label)),
+	}
+	publicHandle, privateHandle, err := t.ctx.GenerateKeyPair(t.session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, publicTemplate, privateTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key pair: %w", curve, err)
+	}
+
+	publicKey, err := t.publicKey(publicHandle, curve)
+	if err != nil {
+		return nil, err
+	}
+	return t.newKey(privateHandle, label, curve, publicKey), nil
+}
+
+// FindKey 按标签查找令牌中的密钥
+func (t *Token) FindKey(label string) (*Key, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	privateHandle, err := t.findObject(pkcs11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		return nil, err
+	}
+	publicHandle, err := t.findObject(pkcs11.CKO_PUBLIC_KEY, label)
+	if err != nil {
+		return nil, fmt.Errorf("public key of %s: %w", label, err)
+	}
+
+	attributes, err := t.ctx.GetAttributeValue(t.session, publicHandle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key attributes: %w", err)
+	}
+	curve, err := keyCurve(attributes[0].Value, attributes[1].Value)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := t.publicKey(publicHandle, curve)
+	if err != nil {
+		return nil, err
+	}
+	return t.newKey(privateHandle, label, curve, publicKey), nil
+}
+
+// findObject 查找指定类型和标签的对象，调用方需持有锁
+func (t *Token) findObject(class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := t.ctx.FindObjectsInit(t.session, template); err != nil {
+		return 0, fmt.Errorf("failed to find hsm key: %w", err)
+	}
+	handles, _, err := t.ctx.FindObjects(t.session, 2)
+	if finalErr := t.ctx.FindObjectsFinal(t.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find hsm key: %w", err)
+	}
+
+	switch len(handles) {
+	case 0:
+		return 0, ErrKeyNotFound
+	case 1:
+		return handles[0], nil
+	default:
+		return 0, fmt.Errorf("multiple hsm keys labeled %s", label)
+	}
+}
+
+// publicKey 读取公钥对象的CKA_EC_POINT，secp256k1返回33字节压缩公钥，ed25519返回32字节公钥
+func (t *Token) publicKey(handle pkcs11.ObjectHandle, curve string) ([]byte, error) {
+	attributes, err := t.ctx.GetAttributeValue(t.session, handle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	return parseECPoint(attributes[0].Value, curve)
+}
+
+// keyCurve 由CKA_KEY_TYPE和CKA_EC_PARAMS确定密钥曲线
+func keyCurve(keyType, params []byte) (string, error) {
+	// CK_ULONG按本机字节序保存
+	var value uint64
+	switch len(keyType) {
+	case 8:
+		value = binary.NativeEndian.Uint64(keyType)
+	case 4:
+		value = uint64(binary.NativeEndian.Uint32(keyType))
+	default:
+		return "", fmt.Errorf("invalid hsm key type length: %d", len(keyType))
+	}
+
+	switch {
+	case value == pkcs11.CKK_EC && bytes.Equal(params, secp256k1OID):
+		return crypto.CurveSecp256k1, nil
+	case value == ckkECEdwards:
+		// 部分实现以PrintableString "edwards25519"作为曲线参数，只支持Ed25519
+		return crypto.CurveEd25519, nil
+	default:
+		return "", fmt.Errorf("unsupported hsm key type 0x%x", value)
+	}
+}
+
+// isError 是否为指定的PKCS#11返回值
+func isError(err error, code uint) bool {
+	var pkcs11Err pkcs11.Error
+	return errors.As(err, &pkcs11Err) && uint(pkcs11Err) == code
+}
+
+// newKey 创建由令牌签名的密钥
+// secp256k1使用CKM_ECDSA对32字节摘要签名，返回 r || s（可能为high-S，由链签名器规范化）；
+// ed25519使用CKM_EDDSA对完整消息签名
+func (t *Token) newKey(handle pkcs11.ObjectHandle, label, curve string, publicKey []byte) *Key {
+	mechanism := uint(pkcs11.CKM_ECDSA)
+	if curve == crypto.CurveEd25519 {
+		mechanism = ckmEdDSA
+	}
+
+	sign := func(digest []byte) ([]byte, error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if err := t.ctx.SignInit(t.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, handle); err != nil {
+			return nil, fmt.Errorf("failed to initialize hsm signing: %w", err)
+		}
+		signature, err := t.ctx.Sign(t.session, digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign with hsm key %s: %w", label, err)
+		}
+		return signature, nil
+	}
+	return &Key{label: label, curve: curve, publicKey: publicKey, sign: sign}
+}
@@ -0,0 +1,31 @@
+//go:build !cgo
+
+package hsm
+
+import "errors"
+
+// errCgoRequired 禁用cgo编译时无法加载PKCS#11模块
+var errCgoRequired = errors.New("pkcs11 support requires cgo")
+
+// Token 禁用cgo编译时的占位实现
+type Token struct{}
+
+// Open 禁用cgo编译时不支持HSM
+func Open(config Config) (*Token, error) {
+	return nil, errCgoRequired
+}
+
+// Close 禁用cgo编译时不支持HSM
+func (t *Token) Close() error {
+	return errCgoRequired
+}
+
+// GenerateKey 禁用cgo编译时不支持HSM
+func (t *Token) GenerateKey(curve, label string) (*Key, error) {
+	return nil, errCgoRequired
+}
+
+// FindKey 禁用cgo编译时不支持HSM
+func (t *Token) FindKey(label string) (*Key, error) {
+	return nil, errCgoRequired
+}
@@ -0,0 +1,173 @@
+//go:build cgo
+
+package hsm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTokenLabel = "keys-gin-test"
+	testUserPIN    = "1234"
+	testSOPIN      = "5678"
+)
+
+// softHSMLibrary SoftHSM v2的PKCS#11模块路径，可以用环境变量SOFTHSM2_LIB指定
+func softHSMLibrary() string {
+	if path := os.Getenv("SOFTHSM2_LIB"); path != "" {
+		return path
+	}
+	for _, path := range []string{
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/lib64/pkcs11/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// openTestToken 在临时目录中初始化SoftHSM令牌并登录，没有安装SoftHSM时跳过测试
+func openTestToken(t *testing.T) *Token {
+	library := softHSMLibrary()
+	if library == "" {
+		t.Skip("softhsm2 is not installed, set SOFTHSM2_LIB to run hsm tests")
+	}
+
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	require.NoError(t, os.Mkdir(tokenDir, 0700))
+	conf := filepath.Join(dir, "softhsm2.conf")
+	require.NoError(t, os.WriteFile(conf, []byte("directories.tokendir = "+tokenDir+"\nobjectstore.backend = file\n"), 0600))
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	// 初始化令牌并设置用户PIN
+	ctx := pkcs11.New(library)
+	require.NotNil(t, ctx)
+	require.NoError(t, ctx.Initialize())
+	slots, err := ctx.GetSlotList(false)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	require.NoError(t, ctx.InitToken(slots[0], testSOPIN, testTokenLabel))
+	slot, err := findSlot(ctx, testTokenLabel)
+	require.NoError(t, err)
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	require.NoError(t, err)
+	require.NoError(t, ctx.Login(session, pkcs11.CKU_SO, testSOPIN))
+	require.NoError(t, ctx.InitPIN(session, testUserPIN))
+	ctx.Logout(session)
+	ctx.CloseSession(session)
+	ctx.Finalize()
+	ctx.Destroy()
+
+	token, err := Open(Config{LibraryPath: library, TokenLabel: testTokenLabel, PIN: testUserPIN})
+	require.NoError(t, err)
+	t.Cleanup(func() { token.Close() })
+	return token
+}
+
+func TestToken_Secp256k1(t *testing.T) {
+	token := openTestToken(t)
+
+	address, publicKey, keyURI, err := token.GenerateKeyPair("tron", &crypto.TronKeyGenerator{})
+	require.NoError(t, err)
+	assert.True(t, IsKeyURI([]byte(keyURI)))
+
+	// 由公钥推导的地址与生成的地址一致
+	generatedAddress, err := (&crypto.TronKeyGenerator{}).PublicKeyToAddress(publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, address, generatedAddress)
+
+	// 私钥不可导出
+	label, _ := ParseKeyURI(keyURI)
+	key, err := token.FindKey(label)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.CurveSecp256k1, key.Curve())
+	token.mu.Lock()
+	handle, err := token.findObject(pkcs11.CKO_PRIVATE_KEY, label)
+	require.NoError(t, err)
+	_, err = token.ctx.GetAttributeValue(token.session, handle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+	})
+	token.mu.Unlock()
+	assert.Error(t, err)
+
+	// 签名交易并用公钥验证
+	rawTx, _ := json.Marshal(crypto.TronTransactionRequest{
+		OwnerAddress:  address,
+		ToAddress:     "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+		Amount:        1000000,
+		RefBlockBytes: "ab12",
+		RefBlockHash:  "0102030405060708",
+		Timestamp:     1700000000000,
+	})
+	for i := 0; i < 4; i++ {
+		// HSM的签名可能为high-S，多次签名覆盖规范化的分支
+		signedTx, _, err := (&crypto.TronTransactionSigner{}).SignTransaction(string(rawTx), key)
+		require.NoError(t, err)
+		valid, err := (&crypto.TronTransactionSigner{}).VerifyTransaction(string(rawTx), signedTx, publicKey)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	// 标签不能重复
+	_, err = token.GenerateKey(crypto.CurveSecp256k1, label)
+	assert.Error(t, err)
+}
+
+func TestToken_Ed25519(t *testing.T) {
+	token := openTestToken(t)
+
+	address, publicKey, keyURI, err := token.GenerateKeyPair("solana", &crypto.SolanaKeyGenerator{})
+	require.NoError(t, err)
+	publicKeyBytes, err := hex.DecodeString(publicKey)
+	require.NoError(t, err)
+	assert.Len(t, publicKeyBytes, 32)
+
+	rawTx, _ := json.Marshal(crypto.SolanaTransactionRequest{
+		RecentBlockhash: "EETubP5AKHgjPAhzPAFcb8BAY1hMHc4py8gRqsAKSKiW",
+		Instructions: []crypto.SolanaInstruction{{
+			ProgramID: "11111111111111111111111111111111",
+			Accounts: []crypto.SolanaAccountMeta{
+				{Pubkey: address, IsSigner: true, IsWritable: true},
+				{Pubkey: "2vJhN51FwR9pLVfFzGkXgW9xNCMdYQyH84ZtMvVwXQ9s", IsWritable: true},
+			},
+			Data: "AgAAAEBCDwAAAAAA",
+		}},
+	})
+	key, err := token.SigningKey(keyURI)
+	require.NoError(t, err)
+	signedTx, _, err := (&crypto.SolanaTransactionSigner{}).SignTransaction(string(rawTx), key)
+	require.NoError(t, err)
+	valid, err := (&crypto.SolanaTransactionSigner{}).VerifyTransaction(string(rawTx), signedTx, publicKey)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestToken_FindKey(t *testing.T) {
+	token := openTestToken(t)
+
+	_, err := token.FindKey("missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = token.SigningKey(KeyURI("missing"))
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = token.SigningKey("invalid")
+	assert.Error(t, err)
+
+	// HSM不支持链的曲线
+	_, _, _, err = token.GenerateKeyPair("polkadot", &crypto.TronKeyGenerator{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package hsm
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/featx/keys-gin/lib/crypto"
+)
+
+// keyURIPrefix keystore中保存的HSM密钥引用的前缀（RFC 7512 PKCS#11 URI）
+const keyURIPrefix = "pkcs11:object="
+
+// KeyURI 密钥标签对应的引用，保存在keystore中代替私钥
+func KeyURI(label string) string {
+	return keyURIPrefix + url.PathEscape(label)
+}
+
+// IsKeyURI keystore中保存的是否为HSM密钥的引用
+func IsKeyURI(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(keyURIPrefix))
+}
+
+// ParseKeyURI 解析密钥引用，返回密钥标签
+func ParseKeyURI(uri string) (string, error) {
+	escaped, ok := strings.CutPrefix(uri, keyURIPrefix)
+	if !ok {
+		return "", fmt.Errorf("invalid pkcs11 key uri: %s", uri)
+	}
+	label, err := url.PathUnescape(escaped)
+	if err != nil || label == "" {
+		return "", fmt.Errorf("invalid pkcs11 key uri: %s", uri)
+	}
+	return label, nil
+}
+
+// newKeyLabel 随机生成密钥标签
+func newKeyLabel() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate key label: %w", err)
+	}
+	return "keys-gin-" + hex.EncodeToString(random), nil
+}
+
+// GenerateKeyPair 在令牌中为指定的链生成密钥对，generator用于由公钥生成链的地址
+// 返回：地址、链格式的公钥、保存在keystore中的密钥引用、错误
+func (t *Token) GenerateKeyPair(chainType string, generator crypto.KeyGenerator) (address, publicKey, keyURI string, err error) {
+	module, ok := crypto.LookupChain(chainType)
+	if !ok {
+		return "", "", "", errors.New("unsupported chain type")
+	}
+	// 链必须有交易签名器，否则生成的密钥无法使用
+	if _, err := crypto.NewTransactionSigner(chainType); err != nil {
+		return "", "", "", err
+	}
+
+	label, err := newKeyLabel()
+	if err != nil {
+		return "", "", "", err
+	}
+	key, err := t.GenerateKey(module.Curve, label)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	address, publicKey, err = crypto.ExternalPublicKey(generator, key)
+	if err != nil {
+		return "", "", "", err
+	}
+	return address, publicKey, KeyURI(label), nil
+}
+
+// SigningKey 查找密钥引用对应的HSM密钥，用作交易签名器的签名密钥
+func (t *Token) SigningKey(keyURI string) (*Key, error) {
+	label, err := ParseKeyURI(keyURI)
+	if err != nil {
+		return nil, err
+	}
+	return t.FindKey(label)
+}
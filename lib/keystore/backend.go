@@ -0,0 +1,186 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrRecordNotFound 存储后端中没有该记录
+var ErrRecordNotFound = errors.New("keystore record not found")
+
+const (
+	// addressRecordPrefix 地址私钥记录名前缀
+	addressRecordPrefix = "address:"
+	// userRecordPrefix 用户私钥记录名前缀
+	userRecordPrefix = "user:"
+	// kekRecordName KEK元数据的记录名
+	kekRecordName = "kek"
+)
+
+// Backend EncryptedStore保存加密记录的存储后端
+// 记录名为 address:<地址>、user:<用户ID> 或 kek，记录内容是已加密的信封或KEK元数据
+type Backend interface {
+	// Get 读取记录，不存在时返回ErrRecordNotFound
+	Get(name string) ([]byte, error)
+	// Put 写入记录，写入中断时不能留下不完整的记录
+	Put(name string, data []byte) error
+	// Delete 删除记录，不存在时返回ErrRecordNotFound
+	Delete(name string) error
+	// List 按名称排序列出所有记录名
+	List() ([]string, error)
+}
+
+// addressRecord 地址私钥的记录名，同时作为信封的附加认证数据
+func addressRecord(address string) string {
+	return addressRecordPrefix + address
+}
+
+// userRecord 用户私钥的记录名，同时作为信封的附加认证数据
+func userRecord(userID string) string {
+	return userRecordPrefix + userID
+}
+
+// isKeyRecord 是否为地址或用户的私钥记录
+func isKeyRecord(name string) bool {
+	return strings.HasPrefix(name, addressRecordPrefix) || strings.HasPrefix(name, userRecordPrefix)
+}
+
+// fileBackend 文件系统存储后端，每条记录是keystore目录中的一个文件
+type fileBackend struct {
+	baseDir string
+}
+
+// NewFileBackend 创建文件系统存储后端
+// 地址私钥保存为 key_<地址>.txt，用户私钥保存为 user_<用户ID>_private_keys.json，KEK元数据保存为 kek.json
+func NewFileBackend(baseDir string) (Backend, error) {
+	// 确保基础目录存在
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keystore directory: %w", err)
+	}
+	return &fileBackend{baseDir: baseDir}, nil
+}
+
+// fileName 记录名对应的文件名
+func (b *fileBackend) fileName(name string) (string, error) {
+	switch {
+	case name == kekRecordName:
+		return "kek.json", nil
+	case strings.HasPrefix(name, addressRecordPrefix) && len(name) > len(addressRecordPrefix):
+		return fmt.Sprintf("key_%s.txt", strings.TrimPrefix(name, addressRecordPrefix)), nil
+	case strings.HasPrefix(name, userRecordPrefix) && len(name) > len(userRecordPrefix):
+		return fmt.Sprintf("user_%s_private_keys.json", strings.TrimPrefix(name, userRecordPrefix)), nil
+	default:
+		return "", fmt.Errorf("invalid keystore record name: %s", name)
+	}
+}
+
+// recordName 文件名对应的记录名，不是keystore文件时返回false
+func (b *fileBackend) recordName(fileName string) (string, bool) {
+	if fileName == "kek.json" {
+		return kekRecordName, true
+	}
+	if address, ok := strings.CutPrefix(fileName, "key_"); ok {
+		if address, ok = strings.CutSuffix(address, ".txt"); ok && address != "" {
+			return addressRecord(address), true
+		}
+	}
+	if userID, ok := strings.CutPrefix(fileName, "user_"); ok {
+		if userID, ok = strings.CutSuffix(userID, "_private_keys.json"); ok && userID != "" {
+			return userRecord(userID), true
+		}
+	}
+	return "", false
+}
+
+// path 记录名对应的文件路径
+func (b *fileBackend) path(name string) (string, error) {
+	fileName, err := b.fileName(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(b.baseDir, fileName), nil
+}
+
+// Get 读取记录文件
+func (b *fileBackend) Get(name string) ([]byte, error) {
+	filePath, err := b.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrRecordNotFound
+	}
+	return data, err
+}
+
+// Put 原子地写入记录文件
+func (b *fileBackend) Put(name string, data []byte) error {
+	filePath, err := b.path(name)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, data)
+}
+
+// Delete 删除记录文件
+func (b *fileBackend) Delete(name string) error {
+	filePath, err := b.path(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+// List 列出目录中的keystore文件，忽略其他文件
+func (b *fileBackend) List() ([]string, error) {
+	entries, err := os.ReadDir(b.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name, ok := b.recordName(entry.Name()); ok && entry.Type().IsRegular() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，避免写入中断时留下不完整的文件
+func writeFileAtomic(filePath string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// EnvelopeVersion 密钥文件信封的当前版本：数据由随机的DEK加密，DEK由KEK包装
+	EnvelopeVersion = 2
+	// passwordEnvelopeVersion 直接用密码派生的密钥加密的信封，EncryptPrivateKey使用该格式，
+	// 也是早期keystore密钥文件的格式，启动时会被迁移为当前版本
+	passwordEnvelopeVersion = 1
+)
+
+const (
+	// KeyDerivationScrypt scrypt密钥派生函数
+	KeyDerivationScrypt = "scrypt"
+	// CipherAESGCM AES-GCM认证加密
+	CipherAESGCM = "aes-gcm"
+
+	// scrypt的块大小和并行度参数
+	scryptR = 8
+	scryptP = 1
+	// maxScryptN、maxScryptRP 解密时接受的最大scrypt参数，防止篡改的信封耗尽内存
+	maxScryptN  = 1 << 20
+	maxScryptRP = 16
+)
+
+// KDFConfig 从主密码派生加密密钥的参数，对应配置文件中的crypto配置
+type KDFConfig struct {
+	KeyDerivation string // 密钥派生函数，目前只支持scrypt
+	Iterations    int    // scrypt的CPU/内存开销参数N，必须是2的幂
+	SaltLength    int    // 盐的字节数
+	KeyLength     int    // AES密钥的字节数：16、24或32
+	NonceLength   int    // AES-GCM的nonce字节数，不少于12
+}
+
+// DefaultKDFConfig 默认的密钥派生参数，未配置的字段使用默认值
+var DefaultKDFConfig = KDFConfig{
+	KeyDerivation: KeyDerivationScrypt,
+	Iterations:    1 << 18,
+	SaltLength:    32,
+	KeyLength:     32,
+	NonceLength:   12,
+}
+
+// withDefaults 未配置的字段使用默认值
+func (c KDFConfig) withDefaults() KDFConfig {
+	if c.KeyDerivation == "" {
+		c.KeyDerivation = DefaultKDFConfig.KeyDerivation
+	}
+	if c.Iterations == 0 {
+		c.Iterations = DefaultKDFConfig.Iterations
+	}
+	if c.SaltLength == 0 {
+		c.SaltLength = DefaultKDFConfig.SaltLength
+	}
+	if c.KeyLength == 0 {
+		c.KeyLength = DefaultKDFConfig.KeyLength
+	}
+	if c.NonceLength == 0 {
+		c.NonceLength = DefaultKDFConfig.NonceLength
+	}
+	return c
+}
+
+// validate 校验密钥派生参数
+func (c KDFConfig) validate() error {
+	if c.KeyDerivation != KeyDerivationScrypt {
+		return fmt.Errorf("unsupported key derivation: %s", c.KeyDerivation)
+	}
+	if c.Iterations < 2 || c.Iterations > maxScryptN || c.Iterations&(c.Iterations-1) != 0 {
+		return fmt.Errorf("iterations must be a power of 2 between 2 and %d", maxScryptN)
+	}
+	if c.SaltLength < 16 {
+		return errors.New("salt length must be at least 16 bytes")
+	}
+	if err := validateKeyLength(c.KeyLength); err != nil {
+		return err
+	}
+	if c.NonceLength < 12 {
+		return errors.New("aes-gcm nonce length must be at least 12 bytes")
+	}
+	return nil
+}
+
+// newKDFParams 按配置生成随机盐的scrypt参数
+func (c KDFConfig) newKDFParams() (KDFParams, error) {
+	salt := make([]byte, c.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return KDFParams{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return KDFParams{
+		N:         c.Iterations,
+		R:         scryptR,
+		P:         scryptP,
+		KeyLength: c.KeyLength,
+		Salt:      hex.EncodeToString(salt),
+	}, nil
+}
+
+// validateKeyLength 校验AES密钥长度
+func validateKeyLength(keyLength int) error {
+	switch keyLength {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("invalid aes key length: %d", keyLength)
+	}
+}
+
+// KDFParams 密钥派生参数
+type KDFParams struct {
+	N         int    `json:"n"`
+	R         int    `json:"r"`
+	P         int    `json:"p"`
+	KeyLength int    `json:"key_length"`
+	Salt      string `json:"salt"`
+}
+
+// deriveScryptKey 用scrypt从密码派生密钥
+func deriveScryptKey(passphrase []byte, params KDFParams) ([]byte, error) {
+	if params.N < 2 || params.N > maxScryptN || params.N&(params.N-1) != 0 ||
+		params.R <= 0 || params.R > maxScryptRP || params.P <= 0 || params.P > maxScryptRP {
+		return nil, errors.New("invalid scrypt parameters")
+	}
+	if err := validateKeyLength(params.KeyLength); err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil || len(salt) == 0 {
+		return nil, errors.New("invalid scrypt salt")
+	}
+
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, params.KeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+// SealedBox AES-GCM加密的数据
+type SealedBox struct {
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// sealBox 用AES-GCM加密数据，nonce随机生成
+func sealBox(key, plaintext []byte, aad string, nonceLength int) (*SealedBox, error) {
+	gcm, err := newGCM(key, nonceLength)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return &SealedBox{
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(gcm.Seal(nil, nonce, plaintext, []byte(aad))),
+	}, nil
+}
+
+// openBox 解密AES-GCM加密的数据，密钥或附加数据不匹配时返回错误
+func openBox(key []byte, box *SealedBox, aad string) ([]byte, error) {
+	if box == nil {
+		return nil, errors.New("sealed data is missing")
+	}
+	nonce, err := hex.DecodeString(box.Nonce)
+	if err != nil || len(nonce) < 12 {
+		return nil, errors.New("invalid nonce")
+	}
+	ciphertext, err := hex.DecodeString(box.Ciphertext)
+	if err != nil {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	gcm, err := newGCM(key, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, []byte(aad))
+}
+
+// newGCM 创建指定nonce长度的AES-GCM
+func newGCM(key []byte, nonceLength int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, nonceLength)
+}
+
+// Envelope 加密后的密钥文件内容
+// 当前版本的明文由每个文件随机生成的DEK以AES-GCM加密，DEK由版本为KEKVersion的KEK包装；
+// 附加数据（AAD）为文件对应的地址或用户，防止密钥文件被互换
+type Envelope struct {
+	Version    int        `json:"version"`
+	KEKVersion int        `json:"kek_version,omitempty"`
+	WrappedDEK *SealedBox `json:"wrapped_dek,omitempty"`
+	KDF        string     `json:"kdf,omitempty"`        // 仅密码信封（版本1）
+	KDFParams  *KDFParams `json:"kdf_params,omitempty"` // 仅密码信封（版本1）
+	Cipher     string     `json:"cipher"`
+	Nonce      string     `json:"nonce"`
+	Ciphertext string     `json:"ciphertext"`
+	AAD        string     `json:"aad"`
+}
+
+// parseEnvelope 解析密钥文件的信封，不是信封格式（如迁移前的明文文件）时返回false
+func parseEnvelope(data []byte) (*Envelope, bool) {
+	envelope := &Envelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, false
+	}
+	if envelope.Version == 0 || envelope.Ciphertext == "" {
+		return nil, false
+	}
+	return envelope, true
+}
+
+// checkEnvelope 校验信封的版本、加密算法和附加数据
+func checkEnvelope(envelope *Envelope, version int, aad string) error {
+	if envelope.Version != version {
+		return fmt.Errorf("unsupported envelope version: %d", envelope.Version)
+	}
+	if envelope.Cipher != CipherAESGCM {
+		return fmt.Errorf("unsupported cipher: %s", envelope.Cipher)
+	}
+	if envelope.AAD != aad {
+		return fmt.Errorf("key file belongs to %s, not %s", envelope.AAD, aad)
+	}
+	return nil
+}
+
+// sealer 用密码加密和解密密码信封（版本1）
+// 同一sealer写入的信封共用一个盐，派生的密钥按KDF参数缓存，避免每次读写都执行scrypt
+type sealer struct {
+	passphrase []byte
+	config     KDFConfig
+	params     KDFParams
+
+	mu   sync.Mutex
+	keys map[KDFParams][]byte
+}
+
+// newSealer 创建密码信封加密器
+func newSealer(passphrase string, config KDFConfig) (*sealer, error) {
+	if passphrase == "" {
+		return nil, errors.New("keystore passphrase is required")
+	}
+	config = config.withDefaults()
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	params, err := config.newKDFParams()
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{
+		passphrase: []byte(passphrase),
+		config:     config,
+		params:     params,
+		keys:       make(map[KDFParams][]byte),
+	}, nil
+}
+
+// seal 加密明文，返回JSON格式的密码信封
+func (s *sealer) seal(plaintext []byte, aad string) ([]byte, error) {
+	key, err := s.deriveKey(s.params)
+	if err != nil {
+		return nil, err
+	}
+	box, err := sealBox(key, plaintext, aad, s.config.NonceLength)
+	if err != nil {
+		return nil, err
+	}
+
+	params := s.params
+	envelope := &Envelope{
+		Version:    passwordEnvelopeVersion,
+		KDF:        KeyDerivationScrypt,
+		KDFParams:  &params,
+		Cipher:     CipherAESGCM,
+		Nonce:      box.Nonce,
+		Ciphertext: box.Ciphertext,
+		AAD:        aad,
+	}
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// open 解密JSON格式的密码信封，aad必须与加密时一致
+func (s *sealer) open(data []byte, aad string) ([]byte, error) {
+	envelope, ok := parseEnvelope(data)
+	if !ok {
+		return nil, errors.New("key file is not an encrypted envelope")
+	}
+	if err := checkEnvelope(envelope, passwordEnvelopeVersion, aad); err != nil {
+		return nil, err
+	}
+	if envelope.KDF != KeyDerivationScrypt || envelope.KDFParams == nil {
+		return nil, fmt.Errorf("unsupported key derivation: %s", envelope.KDF)
+	}
+
+	key, err := s.deriveKey(*envelope.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openBox(key, &SealedBox{Nonce: envelope.Nonce, Ciphertext: envelope.Ciphertext}, aad)
+	if err != nil {
+		return nil, errors.New("failed to decrypt envelope: wrong passphrase or corrupted key file")
+	}
+	return plaintext, nil
+}
+
+// deriveKey 从密码派生密钥，结果按KDF参数缓存
+func (s *sealer) deriveKey(params KDFParams) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[params]; ok {
+		return key, nil
+	}
+	key, err := deriveScryptKey(s.passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	s.keys[params] = key
+	return key, nil
+}
+
+// EncryptPrivateKey 用密码加密私钥，返回JSON格式的密码信封
+// 密钥由scrypt按默认参数从密码派生
+func EncryptPrivateKey(privateKey, password string) (string, error) {
+	s, err := newSealer(password, DefaultKDFConfig)
+	if err != nil {
+		return "", err
+	}
+	envelope, err := s.seal([]byte(privateKey), "")
+	if err != nil {
+		return "", err
+	}
+	return string(envelope), nil
+}
+
+// DecryptPrivateKey 用密码解密EncryptPrivateKey生成的信封
+func DecryptPrivateKey(encryptedData, password string) (string, error) {
+	s, err := newSealer(password, DefaultKDFConfig)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := s.open([]byte(encryptedData), "")
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,319 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// kekCheckPlaintext 用KEK加密的校验数据，用于在启动时验证主密码
+const kekCheckPlaintext = "keys-gin keystore kek"
+
+// KEKRecord 一个版本的密钥加密密钥（KEK）
+// 当前版本的KEK由主密码按KDFParams派生，Check用于校验主密码；
+// 轮换进行中时，旧版本的KEK由当前KEK包装后保存在WrappedKey中，直到所有DEK都重新包装
+type KEKRecord struct {
+	Version    int        `json:"version"`
+	KDF        string     `json:"kdf,omitempty"`
+	KDFParams  *KDFParams `json:"kdf_params,omitempty"`
+	Check      *SealedBox `json:"check,omitempty"`
+	WrappedKey *SealedBox `json:"wrapped_key,omitempty"`
+}
+
+// kekMetadata KEK元数据记录的内容
+type kekMetadata struct {
+	CurrentVersion int         `json:"current_version"`
+	KEKs           []KEKRecord `json:"keks"`
+}
+
+// RotationProgress KEK轮换的进度
+type RotationProgress struct {
+	KEKVersion int  `json:"kek_version"` // 当前KEK版本
+	Total      int  `json:"total"`       // 私钥记录总数
+	Rewrapped  int  `json:"rewrapped"`   // 本次由旧KEK重新包装到当前KEK的记录数
+	Skipped    int  `json:"skipped"`     // 已由当前KEK包装（或已被删除）而跳过的记录数
+	Remaining  int  `json:"remaining"`   // 尚未检查的记录数
+	Done       bool `json:"done"`
+}
+
+// KEKRotator 支持轮换KEK的私钥存储，见EncryptedStore
+type KEKRotator interface {
+	KEKVersion() int
+	RotationPending() bool
+	StartKEKRotation(currentPassphrase, newPassphrase string) (int, error)
+	ResumeKEKRotation(progress func(RotationProgress)) (RotationProgress, error)
+}
+
+// kekAAD KEK校验数据和被包装的旧KEK的附加认证数据
+func kekAAD(version int) string {
+	return "kek:" + strconv.Itoa(version)
+}
+
+// newKEKRecord 生成指定版本的KEK，密钥由主密码派生
+func newKEKRecord(passphrase string, version int, config KDFConfig) (KEKRecord, []byte, error) {
+	params, err := config.newKDFParams()
+	if err != nil {
+		return KEKRecord{}, nil, err
+	}
+	key, err := deriveScryptKey([]byte(passphrase), params)
+	if err != nil {
+		return KEKRecord{}, nil, err
+	}
+	check, err := sealBox(key, []byte(kekCheckPlaintext), kekAAD(version), config.NonceLength)
+	if err != nil {
+		return KEKRecord{}, nil, err
+	}
+
+	return KEKRecord{
+		Version:   version,
+		KDF:       KeyDerivationScrypt,
+		KDFParams: &params,
+		Check:     check,
+	}, key, nil
+}
+
+// deriveKey 从主密码派生KEK并用校验数据验证主密码
+func (r KEKRecord) deriveKey(passphrase string) ([]byte, error) {
+	if r.KDF != KeyDerivationScrypt || r.KDFParams == nil {
+		return nil, fmt.Errorf("unsupported key derivation for kek version %d: %s", r.Version, r.KDF)
+	}
+	key, err := deriveScryptKey([]byte(passphrase), *r.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := openBox(key, r.Check, kekAAD(r.Version)); err != nil {
+		return nil, errors.New("wrong keystore passphrase")
+	}
+	return key, nil
+}
+
+// loadKEKs 读取KEK元数据并解出所有版本的KEK
+// 元数据不存在时生成版本1的KEK，但不写入后端，返回created为true
+func (ks *EncryptedStore) loadKEKs(passphrase string) (bool, error) {
+	data, err := ks.backend.Get(kekRecordName)
+	if errors.Is(err, ErrRecordNotFound) {
+		record, key, err := newKEKRecord(passphrase, 1, ks.config)
+		if err != nil {
+			return false, err
+		}
+		ks.kekMeta = kekMetadata{CurrentVersion: 1, KEKs: []KEKRecord{record}}
+		ks.keks = map[int][]byte{1: key}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read kek metadata: %w", err)
+	}
+
+	meta := kekMetadata{}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return false, fmt.Errorf("failed to parse kek metadata: %w", err)
+	}
+	current, ok := meta.record(meta.CurrentVersion)
+	if !ok {
+		return false, fmt.Errorf("current kek version %d not found", meta.CurrentVersion)
+	}
+	currentKey, err := current.deriveKey(passphrase)
+	if err != nil {
+		return false, err
+	}
+
+	keks := map[int][]byte{current.Version: currentKey}
+	for _, record := range meta.KEKs {
+		if record.Version == current.Version {
+			continue
+		}
+		key, err := openBox(currentKey, record.WrappedKey, kekAAD(record.Version))
+		if err != nil {
+			return false, fmt.Errorf("failed to unwrap kek version %d: %w", record.Version, err)
+		}
+		keks[record.Version] = key
+	}
+
+	ks.kekMeta = meta
+	ks.keks = keks
+	return false, nil
+}
+
+// record 按版本查找KEK
+func (m kekMetadata) record(version int) (KEKRecord, bool) {
+	for _, record := range m.KEKs {
+		if record.Version == version {
+			return record, true
+		}
+	}
+	return KEKRecord{}, false
+}
+
+// saveKEKMetadata 原子地写入KEK元数据
+func (ks *EncryptedStore) saveKEKMetadata(meta kekMetadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal kek metadata: %w", err)
+	}
+	if err := ks.backend.Put(kekRecordName, data); err != nil {
+		return fmt.Errorf("failed to save kek metadata: %w", err)
+	}
+	return nil
+}
+
+// KEKVersion 返回当前KEK版本
+func (ks *EncryptedStore) KEKVersion() int {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.kekMeta.CurrentVersion
+}
+
+// RotationPending 是否有未完成的KEK轮换
+func (ks *EncryptedStore) RotationPending() bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return len(ks.kekMeta.KEKs) > 1
+}
+
+// StartKEKRotation 开始KEK轮换：由新主密码派生新版本的KEK并设为当前版本
+// 旧KEK由新KEK包装后保留在元数据中，已有记录在重新包装前仍可读取，
+// 之后需调用ResumeKEKRotation重新包装所有DEK。元数据一次性原子写入，
+// 写入后重启需要使用新主密码。返回新的KEK版本
+func (ks *EncryptedStore) StartKEKRotation(currentPassphrase, newPassphrase string) (int, error) {
+	if newPassphrase == "" {
+		return 0, errors.New("new keystore passphrase is required")
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if len(ks.kekMeta.KEKs) > 1 {
+		return 0, errors.New("kek rotation already in progress, resume it first")
+	}
+	current, ok := ks.kekMeta.record(ks.kekMeta.CurrentVersion)
+	if !ok {
+		return 0, fmt.Errorf("current kek version %d not found", ks.kekMeta.CurrentVersion)
+	}
+	currentKey, err := current.deriveKey(currentPassphrase)
+	if err != nil {
+		return 0, err
+	}
+
+	version := current.Version + 1
+	record, key, err := newKEKRecord(newPassphrase, version, ks.config)
+	if err != nil {
+		return 0, err
+	}
+	wrappedKey, err := sealBox(key, currentKey, kekAAD(current.Version), ks.config.NonceLength)
+	if err != nil {
+		return 0, err
+	}
+
+	meta := kekMetadata{
+		CurrentVersion: version,
+		KEKs: []KEKRecord{
+			record,
+			{Version: current.Version, WrappedKey: wrappedKey},
+		},
+	}
+	if err := ks.saveKEKMetadata(meta); err != nil {
+		return 0, err
+	}
+
+	ks.kekMeta = meta
+	ks.keks[version] = key
+	return version, nil
+}
+
+// ResumeKEKRotation 用当前KEK重新包装仍由旧KEK包装的DEK，每处理一条记录回调一次进度
+// 每条记录只替换包装的DEK并原子写入，密文不变；中断后再次调用会跳过已处理的记录。
+// 所有记录处理完后从元数据中删除旧KEK。没有进行中的轮换时直接返回完成状态
+func (ks *EncryptedStore) ResumeKEKRotation(progress func(RotationProgress)) (RotationProgress, error) {
+	names, err := ks.keyRecords()
+	if err != nil {
+		return RotationProgress{}, err
+	}
+
+	status := RotationProgress{KEKVersion: ks.KEKVersion(), Total: len(names), Remaining: len(names)}
+	for _, name := range names {
+		rewrapped, err := ks.rewrapRecord(name)
+		if err != nil {
+			return status, fmt.Errorf("failed to rewrap keystore record %s: %w", name, err)
+		}
+		if rewrapped {
+			status.Rewrapped++
+		} else {
+			status.Skipped++
+		}
+		status.Remaining--
+		if progress != nil {
+			progress(status)
+		}
+	}
+
+	if err := ks.retireKEKs(); err != nil {
+		return status, err
+	}
+	status.Done = true
+	if progress != nil {
+		progress(status)
+	}
+	return status, nil
+}
+
+// rewrapRecord 用当前KEK重新包装记录的DEK，返回是否重新包装
+// 记录已被删除或已由当前KEK包装时跳过
+func (ks *EncryptedStore) rewrapRecord(name string) (bool, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	data, err := ks.backend.Get(name)
+	if errors.Is(err, ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	envelope, ok := parseEnvelope(data)
+	if !ok {
+		return false, errors.New("record is not an encrypted envelope")
+	}
+	if err := checkEnvelope(envelope, EnvelopeVersion, name); err != nil {
+		return false, err
+	}
+	if envelope.KEKVersion == ks.kekMeta.CurrentVersion {
+		return false, nil
+	}
+
+	dek, err := ks.unwrapDEK(envelope)
+	if err != nil {
+		return false, err
+	}
+	defer clear(dek)
+	envelope.WrappedDEK, err = sealBox(ks.keks[ks.kekMeta.CurrentVersion], dek, name, ks.config.NonceLength)
+	if err != nil {
+		return false, err
+	}
+	envelope.KEKVersion = ks.kekMeta.CurrentVersion
+
+	data, err = json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return true, ks.backend.Put(name, data)
+}
+
+// retireKEKs 所有DEK都由当前KEK包装后，从元数据中删除旧KEK
+func (ks *EncryptedStore) retireKEKs() error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if len(ks.kekMeta.KEKs) <= 1 {
+		return nil
+	}
+	current, _ := ks.kekMeta.record(ks.kekMeta.CurrentVersion)
+	meta := kekMetadata{CurrentVersion: current.Version, KEKs: []KEKRecord{current}}
+	if err := ks.saveKEKMetadata(meta); err != nil {
+		return err
+	}
+
+	ks.kekMeta = meta
+	ks.keks = map[int][]byte{current.Version: ks.keks[current.Version]}
+	return nil
+}
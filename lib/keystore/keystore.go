@@ -1,258 +1,434 @@
 package keystore
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 )
 
-// Keystore 私钥存储管理器
-type Keystore struct {
-	baseDir string
+// ErrMnemonicNotFound 用户还没有助记词
+var ErrMnemonicNotFound = errors.New("mnemonic not found for user")
+
+// KeyStore 私钥存储
+// 私钥按地址保存，或按用户和链类型保存；用户的BIP-39助记词与用户私钥保存在一起
+type KeyStore interface {
+	// SavePrivateKey 按地址保存私钥
+	SavePrivateKey(address, privateKey string) error
+	// GetPrivateKey 按地址获取私钥，返回的字节由调用方使用后清零
+	GetPrivateKey(address string) ([]byte, error)
+	// DeletePrivateKey 按地址删除私钥
+	DeletePrivateKey(address string) error
+	// ListAddresses 列出保存了私钥的地址
+	ListAddresses() ([]string, error)
+
+	// SaveUserPrivateKey 按用户ID和链类型保存私钥
+	SaveUserPrivateKey(userID, chainType, privateKey string) error
+	// GetUserPrivateKey 按用户ID和链类型获取私钥
+	GetUserPrivateKey(userID, chainType string) (string, error)
+	// DeleteUserPrivateKey 按用户ID和链类型删除私钥
+	DeleteUserPrivateKey(userID, chainType string) error
+	// ListUserPrivateKeys 列出用户保存了私钥的链类型
+	ListUserPrivateKeys(userID string) ([]string, error)
+
+	// SaveUserMnemonic 保存用户的BIP-39助记词，已有助记词时不允许覆盖
+	SaveUserMnemonic(userID, mnemonic string) error
+	// GetUserMnemonic 获取用户的BIP-39助记词，没有时返回ErrMnemonicNotFound
+	GetUserMnemonic(userID string) (string, error)
 }
 
 // UserPrivateKeys 存储用户所有私钥的结构
 type UserPrivateKeys struct {
-	PrivateKeys map[string]string `json:"private_keys"` // 链类型 -> 私钥映射
+	PrivateKeys map[string]string `json:"private_keys"`       // 链类型 -> 私钥映射
+	Mnemonic    string            `json:"mnemonic,omitempty"` // 用户的BIP-39助记词，HD派生的密钥均由其推导
 }
 
-// NewKeystore 创建私钥存储管理器
-func NewKeystore(baseDir string) (*Keystore, error) {
-	// 确保基础目录存在
-	if err := os.MkdirAll(baseDir, 0700); err != nil {
-		return nil, fmt.Errorf("failed to create keystore directory: %w", err)
-	}
-	
-	return &Keystore{baseDir: baseDir},
-		 nil
+// EncryptedStore 在存储后端上实现的加密私钥存储
+// 每条记录都是一个信封（见Envelope）：内容由该记录随机生成的DEK加密，
+// DEK由从主密码派生的KEK包装，记录名（地址或用户ID）作为AAD。KEK的各个版本保存在kek记录中
+type EncryptedStore struct {
+	backend Backend
+	config  KDFConfig
+
+	mu      sync.RWMutex
+	kekMeta kekMetadata
+	keks    map[int][]byte // KEK版本 -> KEK
 }
 
-// getKeyFilePath 根据地址获取私钥文件路径
-func (ks *Keystore) getKeyFilePath(address string) string {
-	// 为了安全，我们可以对地址进行哈希处理作为文件名
-	// 这里简化处理，直接使用地址作为文件名的一部分
-	return filepath.Join(ks.baseDir, fmt.Sprintf("key_%s.txt", address))
+// NewEncryptedStore 在存储后端上创建加密私钥存储
+// 主密码错误时返回错误；后端中已有的明文记录和直接用主密码加密的旧版信封会被就地迁移为当前版本的信封
+func NewEncryptedStore(backend Backend, passphrase string, kdf KDFConfig) (*EncryptedStore, error) {
+	sealer, err := newSealer(passphrase, kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &EncryptedStore{backend: backend, config: sealer.config}
+	created, err := ks.loadKEKs(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.migrateRecords(sealer, created); err != nil {
+		return nil, err
+	}
+	return ks, nil
 }
 
-// getUserKeyFilePath 根据用户ID获取私钥文件路径
-func (ks *Keystore) getUserKeyFilePath(userID string) string {
-	// 使用用户ID作为文件名的一部分
-	return filepath.Join(ks.baseDir, fmt.Sprintf("user_%s_private_keys.json", userID))
+// NewFileKeyStore 创建文件系统加密私钥存储，见NewFileBackend和NewEncryptedStore
+func NewFileKeyStore(baseDir, passphrase string, kdf KDFConfig) (*EncryptedStore, error) {
+	backend, err := NewFileBackend(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptedStore(backend, passphrase, kdf)
 }
 
-// SavePrivateKey 保存私钥到文件
-// 注意：在实际生产环境中，应该对私钥进行加密存储
-func (ks *Keystore) SavePrivateKey(address, privateKey string) error {
-	filePath := ks.getKeyFilePath(address)
-	
-	// 写入文件（简化版本，实际应该加密）
-	if err := os.WriteFile(filePath, []byte(privateKey), 0600); err != nil {
+// SavePrivateKey 加密保存私钥
+func (ks *EncryptedStore) SavePrivateKey(address, privateKey string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err := ks.writeRecord(addressRecord(address), []byte(privateKey)); err != nil {
 		return fmt.Errorf("failed to save private key: %w", err)
 	}
-	
 	return nil
 }
 
-// SaveUserPrivateKey 按用户ID保存私钥
-func (ks *Keystore) SaveUserPrivateKey(userID, chainType, privateKey string) error {
-	filePath := ks.getUserKeyFilePath(userID)
-	
-	// 读取现有私钥
-	userKeys := &UserPrivateKeys{
-		PrivateKeys: make(map[string]string),
-	}
-	
-	// 如果文件已存在，读取现有内容
-	if exists, err := fileExists(filePath); err == nil && exists {
-		fileData, err := os.ReadFile(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to read existing user private keys: %w", err)
-		}
-		
-		// 解析JSON
-		if err := json.Unmarshal(fileData, userKeys); err != nil {
-			return fmt.Errorf("failed to parse user private keys: %w", err)
-		}
-	} else if err != nil {
-		return fmt.Errorf("failed to check user private keys file: %w", err)
+// GetPrivateKey 按地址获取私钥
+func (ks *EncryptedStore) GetPrivateKey(address string) ([]byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	data, err := ks.readRecord(addressRecord(address))
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil, errors.New("private key not found for address")
 	}
-	
-	// 更新或添加私钥
-	userKeys.PrivateKeys[chainType] = privateKey
-	
-	// 序列化并保存
-	jsonData, err := json.MarshalIndent(userKeys, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal user private keys: %w", err)
+		return nil, fmt.Errorf("failed to read private key: %w", err)
 	}
-	
-	if err := os.WriteFile(filePath, jsonData, 0600); err != nil {
-		return fmt.Errorf("failed to save user private keys: %w", err)
+	return data, nil
+}
+
+// DeletePrivateKey 按地址删除私钥
+func (ks *EncryptedStore) DeletePrivateKey(address string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	err := ks.backend.Delete(addressRecord(address))
+	if errors.Is(err, ErrRecordNotFound) {
+		return errors.New("private key not found for address")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete private key: %w", err)
 	}
-	
 	return nil
 }
 
-// GetPrivateKey 从文件中获取私钥
-func (ks *Keystore) GetPrivateKey(address string) (string, error) {
-	filePath := ks.getKeyFilePath(address)
-	
-	// 检查文件是否存在
-	exists, err := fileExists(filePath)
+// ListAddresses 列出保存了私钥的地址
+func (ks *EncryptedStore) ListAddresses() ([]string, error) {
+	names, err := ks.backend.List()
 	if err != nil {
-		return "", fmt.Errorf("failed to check private key file: %w", err)
+		return nil, err
 	}
-	
-	if !exists {
-		return "", errors.New("private key not found for address")
+
+	addresses := make([]string, 0)
+	for _, name := range names {
+		if address, ok := strings.CutPrefix(name, addressRecordPrefix); ok {
+			addresses = append(addresses, address)
+		}
 	}
-	
-	// 读取文件内容
-	data, err := os.ReadFile(filePath)
+	return addresses, nil
+}
+
+// SaveUserPrivateKey 按用户ID保存私钥
+func (ks *EncryptedStore) SaveUserPrivateKey(userID, chainType, privateKey string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	userKeys, err := ks.readUserPrivateKeys(userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to read private key: %w", err)
+		return err
 	}
-	
-	return string(data), nil
+
+	// 更新或添加私钥
+	userKeys.PrivateKeys[chainType] = privateKey
+
+	return ks.writeUserPrivateKeys(userID, userKeys)
 }
 
 // GetUserPrivateKey 按用户ID和链类型获取私钥
-func (ks *Keystore) GetUserPrivateKey(userID, chainType string) (string, error) {
-	filePath := ks.getUserKeyFilePath(userID)
-	
-	// 检查文件是否存在
-	exists, err := fileExists(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to check user private keys file: %w", err)
-	}
-	
-	if !exists {
-		return "", errors.New("private key not found for user")
-	}
-	
-	// 读取文件内容
-	fileData, err := os.ReadFile(filePath)
+func (ks *EncryptedStore) GetUserPrivateKey(userID, chainType string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	userKeys, err := ks.readUserPrivateKeys(userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to read user private keys: %w", err)
-	}
-	
-	// 解析JSON
-	userKeys := &UserPrivateKeys{}
-	if err := json.Unmarshal(fileData, userKeys); err != nil {
-		return "", fmt.Errorf("failed to parse user private keys: %w", err)
+		return "", err
 	}
-	
+
 	// 获取指定链类型的私钥
 	privateKey, exists := userKeys.PrivateKeys[chainType]
 	if !exists {
 		return "", errors.New("private key not found for chain type")
 	}
-	
 	return privateKey, nil
 }
 
-// DeletePrivateKey 删除私钥文件
-func (ks *Keystore) DeletePrivateKey(address string) error {
-	filePath := ks.getKeyFilePath(address)
-	
-	// 检查文件是否存在
-	exists, err := fileExists(filePath)
+// DeleteUserPrivateKey 按用户ID和链类型删除私钥，用户的助记词和其他链的私钥保留
+func (ks *EncryptedStore) DeleteUserPrivateKey(userID, chainType string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	userKeys, err := ks.readUserPrivateKeys(userID)
 	if err != nil {
-		return fmt.Errorf("failed to check private key file: %w", err)
+		return err
 	}
-	
-	if !exists {
-		return errors.New("private key file not found")
+	if _, exists := userKeys.PrivateKeys[chainType]; !exists {
+		return errors.New("private key not found for chain type")
 	}
-	
-	// 删除文件
-	if err := os.Remove(filePath); err != nil {
-		return fmt.Errorf("failed to delete private key: %w", err)
+
+	delete(userKeys.PrivateKeys, chainType)
+
+	return ks.writeUserPrivateKeys(userID, userKeys)
+}
+
+// ListUserPrivateKeys 列出用户保存了私钥的链类型
+func (ks *EncryptedStore) ListUserPrivateKeys(userID string) ([]string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	userKeys, err := ks.readUserPrivateKeys(userID)
+	if err != nil {
+		return nil, err
 	}
-	
-	return nil
+	return sortedChainTypes(userKeys), nil
 }
 
-// fileExists 检查文件是否存在
-func fileExists(path string) (bool, error) {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true, nil
+// SaveUserMnemonic 按用户ID保存BIP-39助记词，已有助记词时不允许覆盖
+func (ks *EncryptedStore) SaveUserMnemonic(userID, mnemonic string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	userKeys, err := ks.readUserPrivateKeys(userID)
+	if err != nil {
+		return err
 	}
-	if os.IsNotExist(err) {
-		return false, nil
+	if userKeys.Mnemonic != "" {
+		return errors.New("mnemonic already exists for user")
 	}
-	return false, err
+
+	userKeys.Mnemonic = mnemonic
+
+	return ks.writeUserPrivateKeys(userID, userKeys)
 }
 
-// EncryptPrivateKey 加密私钥（可选功能）
-// 在实际生产环境中，应该使用这个方法加密私钥后再存储
-func EncryptPrivateKey(privateKey, password string) (string, error) {
-	// 创建AES加密块
-	block, err := aes.NewCipher([]byte(password)[:32])
+// GetUserMnemonic 按用户ID获取BIP-39助记词
+func (ks *EncryptedStore) GetUserMnemonic(userID string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	userKeys, err := ks.readUserPrivateKeys(userID)
 	if err != nil {
 		return "", err
 	}
-	
-	// 创建GCM模式
-	gcm, err := cipher.NewGCM(block)
+	if userKeys.Mnemonic == "" {
+		return "", ErrMnemonicNotFound
+	}
+	return userKeys.Mnemonic, nil
+}
+
+// readUserPrivateKeys 读取用户的私钥记录，记录不存在时返回空的私钥集合
+func (ks *EncryptedStore) readUserPrivateKeys(userID string) (*UserPrivateKeys, error) {
+	userKeys := &UserPrivateKeys{
+		PrivateKeys: make(map[string]string),
+	}
+
+	data, err := ks.readRecord(userRecord(userID))
+	if errors.Is(err, ErrRecordNotFound) {
+		return userKeys, nil
+	}
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to read user private keys: %w", err)
 	}
-	
-	// 创建随机数作为nonce
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	if err := json.Unmarshal(data, userKeys); err != nil {
+		return nil, fmt.Errorf("failed to parse user private keys: %w", err)
 	}
-	
-	// 加密数据
-	ciphertext := gcm.Seal(nonce, nonce, []byte(privateKey), nil)
-	
-	// 转换为十六进制字符串
-	return hex.EncodeToString(ciphertext), nil
+	if userKeys.PrivateKeys == nil {
+		userKeys.PrivateKeys = make(map[string]string)
+	}
+	return userKeys, nil
 }
 
-// DecryptPrivateKey 解密私钥（可选功能）
-func DecryptPrivateKey(encryptedData, password string) (string, error) {
-	// 解码十六进制字符串
-	data, err := hex.DecodeString(encryptedData)
+// writeUserPrivateKeys 保存用户的私钥记录
+func (ks *EncryptedStore) writeUserPrivateKeys(userID string, userKeys *UserPrivateKeys) error {
+	jsonData, err := json.MarshalIndent(userKeys, "", "  ")
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to marshal user private keys: %w", err)
+	}
+
+	if err := ks.writeRecord(userRecord(userID), jsonData); err != nil {
+		return fmt.Errorf("failed to save user private keys: %w", err)
+	}
+
+	return nil
+}
+
+// sortedChainTypes 按名称排序的用户私钥链类型
+func sortedChainTypes(userKeys *UserPrivateKeys) []string {
+	chainTypes := make([]string, 0, len(userKeys.PrivateKeys))
+	for chainType := range userKeys.PrivateKeys {
+		chainTypes = append(chainTypes, chainType)
+	}
+	sort.Strings(chainTypes)
+	return chainTypes
+}
+
+// readRecord 读取并解密记录
+func (ks *EncryptedStore) readRecord(name string) ([]byte, error) {
+	data, err := ks.backend.Get(name)
+	if err != nil {
+		return nil, err
 	}
-	
-	// 创建AES加密块
-	block, err := aes.NewCipher([]byte(password)[:32])
+	return ks.open(data, name)
+}
+
+// writeRecord 加密后写入记录
+func (ks *EncryptedStore) writeRecord(name string, plaintext []byte) error {
+	data, err := ks.seal(plaintext, name)
 	if err != nil {
-		return "", err
+		return err
+	}
+	return ks.backend.Put(name, data)
+}
+
+// seal 用随机生成的DEK加密明文，DEK由当前KEK包装，返回JSON格式的信封
+func (ks *EncryptedStore) seal(plaintext []byte, aad string) ([]byte, error) {
+	dek := make([]byte, ks.config.KeyLength)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
 	}
-	
-	// 创建GCM模式
-	gcm, err := cipher.NewGCM(block)
+	box, err := sealBox(dek, plaintext, aad, ks.config.NonceLength)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	
-	// 提取nonce
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", errors.New("ciphertext too short")
-	}
-	
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	
-	// 解密数据
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+
+	version := ks.kekMeta.CurrentVersion
+	wrappedDEK, err := sealBox(ks.keks[version], dek, aad, ks.config.NonceLength)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	envelope := &Envelope{
+		Version:    EnvelopeVersion,
+		KEKVersion: version,
+		WrappedDEK: wrappedDEK,
+		Cipher:     CipherAESGCM,
+		Nonce:      box.Nonce,
+		Ciphertext: box.Ciphertext,
+		AAD:        aad,
+	}
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// open 解密JSON格式的信封，aad必须与加密时一致
+func (ks *EncryptedStore) open(data []byte, aad string) ([]byte, error) {
+	envelope, ok := parseEnvelope(data)
+	if !ok {
+		return nil, errors.New("record is not an encrypted envelope")
+	}
+	if err := checkEnvelope(envelope, EnvelopeVersion, aad); err != nil {
+		return nil, err
 	}
-	
-	return string(plaintext), nil
-}
\ No newline at end of file
+
+	dek, err := ks.unwrapDEK(envelope)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dek)
+	plaintext, err := openBox(dek, &SealedBox{Nonce: envelope.Nonce, Ciphertext: envelope.Ciphertext}, aad)
+	if err != nil {
+		return nil, errors.New("failed to decrypt envelope: corrupted record")
+	}
+	return plaintext, nil
+}
+
+// unwrapDEK 用信封记录的KEK版本解出DEK
+func (ks *EncryptedStore) unwrapDEK(envelope *Envelope) ([]byte, error) {
+	kek, ok := ks.keks[envelope.KEKVersion]
+	if !ok {
+		return nil, fmt.Errorf("unknown kek version: %d", envelope.KEKVersion)
+	}
+	dek, err := openBox(kek, envelope.WrappedDEK, envelope.AAD)
+	if err != nil {
+		return nil, errors.New("failed to unwrap data key: corrupted record")
+	}
+	return dek, nil
+}
+
+// keyRecords 列出后端中的私钥记录名
+func (ks *EncryptedStore) keyRecords() ([]string, error) {
+	names, err := ks.backend.List()
+	if err != nil {
+		return nil, err
+	}
+
+	keyNames := make([]string, 0, len(names))
+	for _, name := range names {
+		if isKeyRecord(name) {
+			keyNames = append(keyNames, name)
+		}
+	}
+	return keyNames, nil
+}
+
+// migrateRecords 将明文记录和旧版密码信封就地迁移为当前版本的信封
+// 旧版信封先全部用主密码解密验证，主密码错误时拒绝启动；
+// KEK元数据是新生成的（created）时，在写入第一个新信封前保存
+func (ks *EncryptedStore) migrateRecords(sealer *sealer, created bool) error {
+	names, err := ks.keyRecords()
+	if err != nil {
+		return err
+	}
+
+	type pendingRecord struct {
+		name      string
+		plaintext []byte
+	}
+	var pending []pendingRecord
+	for _, name := range names {
+		data, err := ks.backend.Get(name)
+		if err != nil {
+			return fmt.Errorf("failed to read keystore record %s: %w", name, err)
+		}
+
+		envelope, encrypted := parseEnvelope(data)
+		switch {
+		case !encrypted:
+			pending = append(pending, pendingRecord{name: name, plaintext: data})
+		case envelope.Version == passwordEnvelopeVersion:
+			plaintext, err := sealer.open(data, name)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt keystore record %s: %w", name, err)
+			}
+			pending = append(pending, pendingRecord{name: name, plaintext: plaintext})
+		case created:
+			return fmt.Errorf("keystore record %s is encrypted but kek metadata is missing", name)
+		}
+	}
+
+	if created {
+		if err := ks.saveKEKMetadata(ks.kekMeta); err != nil {
+			return err
+		}
+	}
+	for _, record := range pending {
+		if err := ks.writeRecord(record.name, record.plaintext); err != nil {
+			return fmt.Errorf("failed to encrypt keystore record %s: %w", record.name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package keystore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDFConfig 测试使用较小的scrypt参数
+var testKDFConfig = KDFConfig{Iterations: 1 << 10}
+
+func TestKeystore_EncryptedFiles(t *testing.T) {
+	dir := t.TempDir()
+	ks, err := NewFileKeyStore(dir, "passphrase", testKDFConfig)
+	require.NoError(t, err)
+
+	require.NoError(t, ks.SavePrivateKey("0xabc", "deadbeef"))
+	require.NoError(t, ks.SaveUserPrivateKey("user1", "ethereum", "deadbeef"))
+	require.NoError(t, ks.SaveUserMnemonic("user1", "abandon about"))
+
+	// 文件中不包含明文
+	for _, name := range []string{"key_0xabc.txt", "user_user1_private_keys.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "deadbeef")
+		envelope, ok := parseEnvelope(data)
+		require.True(t, ok, name)
+		assert.Equal(t, EnvelopeVersion, envelope.Version)
+		assert.Equal(t, 1, envelope.KEKVersion)
+		assert.NotNil(t, envelope.WrappedDEK)
+		assert.Len(t, envelope.Nonce, 24)
+	}
+
+	privateKey, err := ks.GetPrivateKey("0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("deadbeef"), privateKey)
+	userKey, err := ks.GetUserPrivateKey("user1", "ethereum")
+	require.NoError(t, err)
+	assert.Equal(t, "deadbeef", userKey)
+	mnemonic, err := ks.GetUserMnemonic("user1")
+	require.NoError(t, err)
+	assert.Equal(t, "abandon about", mnemonic)
+
+	addresses, err := ks.ListAddresses()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xabc"}, addresses)
+	require.NoError(t, ks.SaveUserPrivateKey("user1", "bitcoin", "cafebabe"))
+	chainTypes, err := ks.ListUserPrivateKeys("user1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bitcoin", "ethereum"}, chainTypes)
+	require.NoError(t, ks.DeleteUserPrivateKey("user1", "bitcoin"))
+	assert.Error(t, ks.DeleteUserPrivateKey("user1", "bitcoin"))
+	mnemonic, err = ks.GetUserMnemonic("user1")
+	require.NoError(t, err)
+	assert.Equal(t, "abandon about", mnemonic)
+
+	// 互换的密钥文件无法解密
+	data, err := os.ReadFile(filepath.Join(dir, "key_0xabc.txt"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key_0xdef.txt"), data, 0600))
+	_, err = ks.GetPrivateKey("0xdef")
+	assert.Error(t, err)
+	require.NoError(t, ks.DeletePrivateKey("0xdef"))
+
+	// 主密码错误时拒绝打开
+	_, err = NewFileKeyStore(dir, "wrong passphrase", testKDFConfig)
+	assert.Error(t, err)
+
+	// 重新打开后可以读取之前写入的密钥
+	ks, err = NewFileKeyStore(dir, "passphrase", testKDFConfig)
+	require.NoError(t, err)
+	privateKey, err = ks.GetPrivateKey("0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("deadbeef"), privateKey)
+}
+
+func TestKeystore_MigrateLegacyEnvelopes(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newSealer("passphrase", testKDFConfig)
+	require.NoError(t, err)
+	data, err := s.seal([]byte("deadbeef"), addressRecord("0xabc"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key_0xabc.txt"), data, 0600))
+
+	// 主密码错误时不迁移
+	_, err = NewFileKeyStore(dir, "wrong passphrase", testKDFConfig)
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "kek.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	ks, err := NewFileKeyStore(dir, "passphrase", testKDFConfig)
+	require.NoError(t, err)
+	data, err = os.ReadFile(filepath.Join(dir, "key_0xabc.txt"))
+	require.NoError(t, err)
+	envelope, ok := parseEnvelope(data)
+	require.True(t, ok)
+	assert.Equal(t, EnvelopeVersion, envelope.Version)
+	privateKey, err := ks.GetPrivateKey("0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("deadbeef"), privateKey)
+}
+
+func TestKeystore_RotateKEK(t *testing.T) {
+	dir := t.TempDir()
+	ks, err := NewFileKeyStore(dir, "old passphrase", testKDFConfig)
+	require.NoError(t, err)
+	for _, address := range []string{"0x01", "0x02", "0x03"} {
+		require.NoError(t, ks.SavePrivateKey(address, "key"+address))
+	}
+	require.NoError(t, ks.SaveUserMnemonic("user1", "abandon about"))
+
+	_, err = ks.StartKEKRotation("wrong passphrase", "new passphrase")
+	assert.Error(t, err)
+	version, err := ks.StartKEKRotation("old passphrase", "new passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.True(t, ks.RotationPending())
+	_, err = ks.StartKEKRotation("new passphrase", "another passphrase")
+	assert.Error(t, err)
+
+	// 新旧KEK包装的密钥文件并存
+	require.NoError(t, ks.SavePrivateKey("0x04", "key0x04"))
+	assert.Equal(t, 1, kekVersionOf(t, dir, "key_0x01.txt"))
+	assert.Equal(t, 2, kekVersionOf(t, dir, "key_0x04.txt"))
+	privateKey, err := ks.GetPrivateKey("0x01")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key0x01"), privateKey)
+
+	// 模拟中断：只重新包装一个文件
+	rewrapped, err := ks.rewrapRecord(addressRecord("0x01"))
+	require.NoError(t, err)
+	assert.True(t, rewrapped)
+	before, err := os.ReadFile(filepath.Join(dir, "key_0x02.txt"))
+	require.NoError(t, err)
+
+	// 重启后旧主密码失效，新主密码可以读取所有密钥并继续轮换
+	_, err = NewFileKeyStore(dir, "old passphrase", testKDFConfig)
+	assert.Error(t, err)
+	ks, err = NewFileKeyStore(dir, "new passphrase", testKDFConfig)
+	require.NoError(t, err)
+	assert.True(t, ks.RotationPending())
+	privateKey, err = ks.GetPrivateKey("0x02")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("key0x02"), privateKey)
+
+	var updates []RotationProgress
+	status, err := ks.ResumeKEKRotation(func(progress RotationProgress) {
+		updates = append(updates, progress)
+	})
+	require.NoError(t, err)
+	// 中断前已重新包装的0x01和轮换后保存的0x04被跳过
+	assert.Equal(t, RotationProgress{KEKVersion: 2, Total: 5, Rewrapped: 3, Skipped: 2, Remaining: 0, Done: true}, status)
+	require.Len(t, updates, 6)
+	assert.Equal(t, 1, updates[0].Skipped)
+	assert.Equal(t, 4, updates[0].Remaining)
+	assert.False(t, ks.RotationPending())
+
+	// 只替换包装的DEK，密文不变
+	after, err := os.ReadFile(filepath.Join(dir, "key_0x02.txt"))
+	require.NoError(t, err)
+	beforeEnvelope, _ := parseEnvelope(before)
+	afterEnvelope, _ := parseEnvelope(after)
+	assert.Equal(t, beforeEnvelope.Ciphertext, afterEnvelope.Ciphertext)
+	assert.NotEqual(t, beforeEnvelope.WrappedDEK, afterEnvelope.WrappedDEK)
+
+	ks, err = NewFileKeyStore(dir, "new passphrase", testKDFConfig)
+	require.NoError(t, err)
+	for _, name := range []string{"key_0x01.txt", "key_0x02.txt", "key_0x03.txt", "key_0x04.txt", "user_user1_private_keys.json"} {
+		assert.Equal(t, 2, kekVersionOf(t, dir, name), name)
+	}
+	mnemonic, err := ks.GetUserMnemonic("user1")
+	require.NoError(t, err)
+	assert.Equal(t, "abandon about", mnemonic)
+}
+
+// kekVersionOf 读取密钥文件记录的KEK版本
+func kekVersionOf(t *testing.T, dir, name string) int {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	envelope, ok := parseEnvelope(data)
+	require.True(t, ok)
+	return envelope.KEKVersion
+}
+
+func TestKeystore_MigratePlaintextFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key_0xabc.txt"), []byte("deadbeef"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user_user1_private_keys.json"), []byte(`{"private_keys":{"ethereum":"deadbeef"}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a key file"), 0600))
+
+	ks, err := NewFileKeyStore(dir, "passphrase", testKDFConfig)
+	require.NoError(t, err)
+
+	for _, name := range []string{"key_0xabc.txt", "user_user1_private_keys.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		_, ok := parseEnvelope(data)
+		assert.True(t, ok, name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "not a key file", string(data))
+
+	privateKey, err := ks.GetPrivateKey("0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("deadbeef"), privateKey)
+	userKey, err := ks.GetUserPrivateKey("user1", "ethereum")
+	require.NoError(t, err)
+	assert.Equal(t, "deadbeef", userKey)
+}
+
+func TestNewKeystore_InvalidConfig(t *testing.T) {
+	for _, config := range []KDFConfig{
+		{KeyDerivation: "pbkdf2"},
+		{Iterations: 1000},
+		{Iterations: 1 << 10, KeyLength: 20},
+		{Iterations: 1 << 10, NonceLength: 8},
+		{Iterations: 1 << 10, SaltLength: 8},
+	} {
+		_, err := NewFileKeyStore(t.TempDir(), "passphrase", config)
+		assert.Error(t, err, config)
+	}
+
+	_, err := NewFileKeyStore(t.TempDir(), "", testKDFConfig)
+	assert.Error(t, err)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+
+	passphrase, err := ReadPassphrase("", strings.NewReader("from stdin\n"), &strings.Builder{})
+	require.NoError(t, err)
+	assert.Equal(t, "from stdin", passphrase)
+
+	file := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(file, []byte("from file\n"), 0600))
+	passphrase, err = ReadPassphrase(file, strings.NewReader(""), &strings.Builder{})
+	require.NoError(t, err)
+	assert.Equal(t, "from file", passphrase)
+
+	t.Setenv(PassphraseEnv, "from env")
+	passphrase, err = ReadPassphrase(file, strings.NewReader(""), &strings.Builder{})
+	require.NoError(t, err)
+	assert.Equal(t, "from env", passphrase)
+
+	t.Setenv(PassphraseEnv, "")
+	_, err = ReadPassphrase("", strings.NewReader("\n"), &strings.Builder{})
+	assert.Error(t, err)
+}
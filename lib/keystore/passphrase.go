@@ -0,0 +1,46 @@
+package keystore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PassphraseEnv 保存keystore主密码的环境变量
+const PassphraseEnv = "KEYS_GIN_KEYSTORE_PASSPHRASE"
+
+// ReadPassphrase 读取keystore主密码
+// 依次使用环境变量 KEYS_GIN_KEYSTORE_PASSPHRASE、passphraseFile指定的文件（去掉末尾换行），
+// 都没有时在prompt上提示并从stdin读取一行
+func ReadPassphrase(passphraseFile string, stdin io.Reader, prompt io.Writer) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		return nonEmptyPassphrase(string(data))
+	}
+
+	fmt.Fprint(prompt, "Enter keystore passphrase: ")
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read passphrase from stdin: %w", err)
+	}
+	return nonEmptyPassphrase(line)
+}
+
+// nonEmptyPassphrase 去掉末尾的换行符并检查主密码不为空
+func nonEmptyPassphrase(passphrase string) (string, error) {
+	passphrase = strings.TrimRight(passphrase, "\r\n")
+	if passphrase == "" {
+		return "", errors.New("keystore passphrase is empty")
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,421 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultConfig HashiCorp Vault私钥存储配置
+type VaultConfig struct {
+	Address   string // Vault地址，如 http://127.0.0.1:8200
+	Token     string
+	Namespace string // Vault企业版命名空间，可为空
+	// KVMount KV v2引擎的挂载路径，默认secret
+	KVMount string
+	// PathPrefix 私钥在KV引擎中的路径前缀，默认keys-gin
+	PathPrefix string
+	// TransitMount transit引擎的挂载路径，默认transit
+	TransitMount string
+	// TransitKey transit加密密钥名，设置时私钥先由transit加密再写入KV，为空时直接写入KV
+	TransitKey string
+	// HTTPClient 为空时使用超时30秒的默认客户端
+	HTTPClient *http.Client
+}
+
+// VaultKeyStore 保存在HashiCorp Vault中的私钥存储
+// 地址私钥保存在KV v2的 <前缀>/addresses/<地址>，用户私钥保存在 <前缀>/users/<用户ID>；
+// 配置了transit密钥时，写入KV的是transit加密的密文，密钥轮换通过Vault的transit rotate/rewrap完成
+type VaultKeyStore struct {
+	config VaultConfig
+	client *http.Client
+
+	// mu 串行化本进程内对用户记录的读改写
+	mu sync.Mutex
+}
+
+// vaultRecord KV中保存的数据
+type vaultRecord struct {
+	Value string `json:"value"`
+}
+
+// vaultResponse Vault API的响应
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+// NewVaultKeyStore 创建Vault私钥存储
+func NewVaultKeyStore(config VaultConfig) (*VaultKeyStore, error) {
+	if config.Address == "" {
+		return nil, errors.New("vault address is required")
+	}
+	if config.Token == "" {
+		return nil, errors.New("vault token is required")
+	}
+	if config.KVMount == "" {
+		config.KVMount = "secret"
+	}
+	if config.PathPrefix == "" {
+		config.PathPrefix = "keys-gin"
+	}
+	if config.TransitMount == "" {
+		config.TransitMount = "transit"
+	}
+	config.Address = strings.TrimRight(config.Address, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &VaultKeyStore{config: config, client: client}, nil
+}
+
+// SavePrivateKey 按地址保存私钥
+func (vs *VaultKeyStore) SavePrivateKey(address, privateKey string) error {
+	if err := vs.writeRecord(vs.addressPath(address), addressRecord(address), []byte(privateKey)); err != nil {
+		return fmt.Errorf("failed to save private key: %w", err)
+	}
+	return nil
+}
+
+// GetPrivateKey 按地址获取私钥
+func (vs *VaultKeyStore) GetPrivateKey(address string) ([]byte, error) {
+	data, err := vs.readRecord(vs.addressPath(address), addressRecord(address))
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil, errors.New("private key not found for address")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	return data, nil
+}
+
+// DeletePrivateKey 按地址删除私钥的所有版本
+func (vs *VaultKeyStore) DeletePrivateKey(address string) error {
+	path := vs.addressPath(address)
+	if _, err := vs.readKV(path); err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return errors.New("private key not found for address")
+		}
+		return fmt.Errorf("failed to delete private key: %w", err)
+	}
+	if _, err := vs.do(http.MethodDelete, vs.config.KVMount+"/metadata/"+path, nil); err != nil {
+		return fmt.Errorf("failed to delete private key: %w", err)
+	}
+	return nil
+}
+
+// ListAddresses 列出保存了私钥的地址
+func (vs *VaultKeyStore) ListAddresses() ([]string, error) {
+	keys, err := vs.list(vs.config.PathPrefix + "/addresses")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+
+	addresses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		address, err := url.PathUnescape(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vault key %s: %w", key, err)
+		}
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// SaveUserPrivateKey 按用户ID和链类型保存私钥
+func (vs *VaultKeyStore) SaveUserPrivateKey(userID, chainType, privateKey string) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	userKeys, err := vs.readUserPrivateKeys(userID)
+	if err != nil {
+		return err
+	}
+
+	userKeys.PrivateKeys[chainType] = privateKey
+
+	return vs.writeUserPrivateKeys(userID, userKeys)
+}
+
+// GetUserPrivateKey 按用户ID和链类型获取私钥
+func (vs *VaultKeyStore) GetUserPrivateKey(userID, chainType string) (string, error) {
+	userKeys, err := vs.readUserPrivateKeys(userID)
+	if err != nil {
+		return "", err
+	}
+
+	privateKey, exists := userKeys.PrivateKeys[chainType]
+	if !exists {
+		return "", errors.New("private key not found for chain type")
+	}
+	return privateKey, nil
+}
+
+// DeleteUserPrivateKey 按用户ID和链类型删除私钥，用户的助记词和其他链的私钥保留
+func (vs *VaultKeyStore) DeleteUserPrivateKey(userID, chainType string) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	userKeys, err := vs.readUserPrivateKeys(userID)
+	if err != nil {
+		return err
+	}
+	if _, exists := userKeys.PrivateKeys[chainType]; !exists {
+		return errors.New("private key not found for chain type")
+	}
+
+	delete(userKeys.PrivateKeys, chainType)
+
+	return vs.writeUserPrivateKeys(userID, userKeys)
+}
+
+// ListUserPrivateKeys 列出用户保存了私钥的链类型
+func (vs *VaultKeyStore) ListUserPrivateKeys(userID string) ([]string, error) {
+	userKeys, err := vs.readUserPrivateKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	return sortedChainTypes(userKeys), nil
+}
+
+// SaveUserMnemonic 保存用户的BIP-39助记词，已有助记词时不允许覆盖
+func (vs *VaultKeyStore) SaveUserMnemonic(userID, mnemonic string) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	userKeys, err := vs.readUserPrivateKeys(userID)
+	if err != nil {
+		return err
+	}
+	if userKeys.Mnemonic != "" {
+		return errors.New("mnemonic already exists for user")
+	}
+
+	userKeys.Mnemonic = mnemonic
+
+	return vs.writeUserPrivateKeys(userID, userKeys)
+}
+
+// GetUserMnemonic 获取用户的BIP-39助记词
+func (vs *VaultKeyStore) GetUserMnemonic(userID string) (string, error) {
+	userKeys, err := vs.readUserPrivateKeys(userID)
+	if err != nil {
+		return "", err
+	}
+	if userKeys.Mnemonic == "" {
+		return "", ErrMnemonicNotFound
+	}
+	return userKeys.Mnemonic, nil
+}
+
+// readUserPrivateKeys 读取用户的私钥记录，不存在时返回空的私钥集合
+func (vs *VaultKeyStore) readUserPrivateKeys(userID string) (*UserPrivateKeys, error) {
+	userKeys := &UserPrivateKeys{
+		PrivateKeys: make(map[string]string),
+	}
+
+	data, err := vs.readRecord(vs.userPath(userID), userRecord(userID))
+	if errors.Is(err, ErrRecordNotFound) {
+		return userKeys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user private keys: %w", err)
+	}
+	if err := json.Unmarshal(data, userKeys); err != nil {
+		return nil, fmt.Errorf("failed to parse user private keys: %w", err)
+	}
+	if userKeys.PrivateKeys == nil {
+		userKeys.PrivateKeys = make(map[string]string)
+	}
+	return userKeys, nil
+}
+
+// writeUserPrivateKeys 保存用户的私钥记录
+func (vs *VaultKeyStore) writeUserPrivateKeys(userID string, userKeys *UserPrivateKeys) error {
+	jsonData, err := json.Marshal(userKeys)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user private keys: %w", err)
+	}
+
+	if err := vs.writeRecord(vs.userPath(userID), userRecord(userID), jsonData); err != nil {
+		return fmt.Errorf("failed to save user private keys: %w", err)
+	}
+	return nil
+}
+
+// addressPath 地址私钥在KV引擎中的路径
+func (vs *VaultKeyStore) addressPath(address string) string {
+	return vs.config.PathPrefix + "/addresses/" + url.PathEscape(address)
+}
+
+// userPath 用户私钥在KV引擎中的路径
+func (vs *VaultKeyStore) userPath(userID string) string {
+	return vs.config.PathPrefix + "/users/" + url.PathEscape(userID)
+}
+
+// readRecord 读取KV中的记录，配置了transit密钥时解密，记录名作为附加认证数据
+func (vs *VaultKeyStore) readRecord(path, name string) ([]byte, error) {
+	record, err := vs.readKV(path)
+	if err != nil {
+		return nil, err
+	}
+	if vs.config.TransitKey == "" {
+		return []byte(record.Value), nil
+	}
+	return vs.decrypt(record.Value, name)
+}
+
+// writeRecord 写入KV中的记录，配置了transit密钥时先加密，记录名作为附加认证数据
+func (vs *VaultKeyStore) writeRecord(path, name string, plaintext []byte) error {
+	value := string(plaintext)
+	if vs.config.TransitKey != "" {
+		ciphertext, err := vs.encrypt(plaintext, name)
+		if err != nil {
+			return err
+		}
+		value = ciphertext
+	}
+
+	_, err := vs.do(http.MethodPost, vs.config.KVMount+"/data/"+path, map[string]interface{}{
+		"data": vaultRecord{Value: value},
+	})
+	return err
+}
+
+// readKV 读取KV v2中最新版本的记录
+func (vs *VaultKeyStore) readKV(path string) (*vaultRecord, error) {
+	data, err := vs.do(http.MethodGet, vs.config.KVMount+"/data/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret struct {
+		Data *vaultRecord `json:"data"`
+	}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return nil, fmt.Errorf("failed to parse vault secret: %w", err)
+	}
+	// 最新版本已被软删除时data为null
+	if secret.Data == nil {
+		return nil, ErrRecordNotFound
+	}
+	return secret.Data, nil
+}
+
+// list 列出KV v2路径下的键，路径不存在时返回空列表
+func (vs *VaultKeyStore) list(path string) ([]string, error) {
+	data, err := vs.do(http.MethodGet, vs.config.KVMount+"/metadata/"+path+"?list=true", nil)
+	if errors.Is(err, ErrRecordNotFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys struct {
+		Keys []string `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse vault key list: %w", err)
+	}
+	return keys.Keys, nil
+}
+
+// encrypt 用transit密钥加密，返回 vault:v<版本>: 格式的密文
+func (vs *VaultKeyStore) encrypt(plaintext []byte, name string) (string, error) {
+	data, err := vs.do(http.MethodPost, vs.config.TransitMount+"/encrypt/"+url.PathEscape(vs.config.TransitKey), map[string]string{
+		"plaintext":       base64.StdEncoding.EncodeToString(plaintext),
+		"associated_data": base64.StdEncoding.EncodeToString([]byte(name)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt with vault transit: %w", err)
+	}
+
+	var result struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil || result.Ciphertext == "" {
+		return "", errors.New("invalid vault transit encrypt response")
+	}
+	return result.Ciphertext, nil
+}
+
+// decrypt 用transit密钥解密
+func (vs *VaultKeyStore) decrypt(ciphertext, name string) ([]byte, error) {
+	data, err := vs.do(http.MethodPost, vs.config.TransitMount+"/decrypt/"+url.PathEscape(vs.config.TransitKey), map[string]string{
+		"ciphertext":      ciphertext,
+		"associated_data": base64.StdEncoding.EncodeToString([]byte(name)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with vault transit: %w", err)
+	}
+
+	var result struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, errors.New("invalid vault transit decrypt response")
+	}
+	return base64.StdEncoding.DecodeString(result.Plaintext)
+}
+
+// do 调用Vault API，返回响应的data字段；404返回ErrRecordNotFound
+func (vs *VaultKeyStore) do(method, path string, body interface{}) (json.RawMessage, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, vs.config.Address+"/v1/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", vs.config.Token)
+	if vs.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vs.config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := vs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrRecordNotFound
+	}
+
+	result := vaultResponse{}
+	if len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse vault response: %w", err)
+		}
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+	}
+	return result.Data, nil
+}
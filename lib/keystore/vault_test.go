@@ -0,0 +1,167 @@
+package keystore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault 进程内模拟的Vault，实现KV v2读写、删除、列表和transit加解密
+type fakeVault struct {
+	mu      sync.Mutex
+	token   string
+	secrets map[string]map[string]interface{} // KV路径 -> 最新版本的数据
+}
+
+func newFakeVault(token string) *fakeVault {
+	return &fakeVault{token: token, secrets: make(map[string]map[string]interface{})}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		writeVault(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case strings.HasPrefix(path, "secret/data/"):
+		key := strings.TrimPrefix(path, "secret/data/")
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := f.secrets[key]
+			if !ok {
+				writeVault(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+				return
+			}
+			writeVault(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"data": data}})
+		case http.MethodPost, http.MethodPut:
+			var body struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeVault(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{err.Error()}})
+				return
+			}
+			f.secrets[key] = body.Data
+			writeVault(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"version": 1}})
+		}
+	case strings.HasPrefix(path, "secret/metadata/"):
+		key := strings.TrimPrefix(path, "secret/metadata/")
+		if r.Method == http.MethodDelete {
+			delete(f.secrets, key)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var keys []string
+		for secret := range f.secrets {
+			if child, ok := strings.CutPrefix(secret, key+"/"); ok {
+				keys = append(keys, child)
+			}
+		}
+		if len(keys) == 0 {
+			writeVault(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+			return
+		}
+		sort.Strings(keys)
+		writeVault(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case strings.HasPrefix(path, "transit/encrypt/"):
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		sealed, _ := json.Marshal(body)
+		writeVault(w, http.StatusOK, map[string]interface{}{"data": map[string]string{
+			"ciphertext": "vault:v1:" + base64.StdEncoding.EncodeToString(sealed),
+		}})
+	case strings.HasPrefix(path, "transit/decrypt/"):
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(body["ciphertext"], "vault:v1:"))
+		var encrypted map[string]string
+		json.Unmarshal(sealed, &encrypted)
+		if encrypted["associated_data"] != body["associated_data"] {
+			writeVault(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"cipher: message authentication failed"}})
+			return
+		}
+		writeVault(w, http.StatusOK, map[string]interface{}{"data": map[string]string{"plaintext": encrypted["plaintext"]}})
+	default:
+		writeVault(w, http.StatusNotFound, map[string]interface{}{"errors": []string{"no handler for route"}})
+	}
+}
+
+func writeVault(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestVaultKeyStore(t *testing.T) {
+	vault := newFakeVault("root")
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	var store KeyStore
+	store, err := NewVaultKeyStore(VaultConfig{Address: server.URL, Token: "root", TransitKey: "keys-gin"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.SavePrivateKey("0xabc", "deadbeef"))
+	require.NoError(t, store.SavePrivateKey("0xdef", "cafebabe"))
+	privateKey, err := store.GetPrivateKey("0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("deadbeef"), privateKey)
+	addresses, err := store.ListAddresses()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xabc", "0xdef"}, addresses)
+
+	// KV中保存的是transit密文
+	value := vault.secrets["keys-gin/addresses/0xabc"]["value"].(string)
+	assert.True(t, strings.HasPrefix(value, "vault:v1:"))
+	assert.NotContains(t, value, "deadbeef")
+
+	// 互换的记录无法解密
+	vault.secrets["keys-gin/addresses/0x123"] = vault.secrets["keys-gin/addresses/0xabc"]
+	_, err = store.GetPrivateKey("0x123")
+	assert.Error(t, err)
+
+	require.NoError(t, store.DeletePrivateKey("0xdef"))
+	_, err = store.GetPrivateKey("0xdef")
+	assert.Error(t, err)
+	assert.Error(t, store.DeletePrivateKey("0xdef"))
+
+	require.NoError(t, store.SaveUserPrivateKey("user1", "ethereum", "deadbeef"))
+	require.NoError(t, store.SaveUserPrivateKey("user1", "bitcoin", "cafebabe"))
+	require.NoError(t, store.SaveUserMnemonic("user1", "abandon about"))
+	assert.Error(t, store.SaveUserMnemonic("user1", "zoo wrong"))
+	chainTypes, err := store.ListUserPrivateKeys("user1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bitcoin", "ethereum"}, chainTypes)
+
+	require.NoError(t, store.DeleteUserPrivateKey("user1", "bitcoin"))
+	_, err = store.GetUserPrivateKey("user1", "bitcoin")
+	assert.Error(t, err)
+	userKey, err := store.GetUserPrivateKey("user1", "ethereum")
+	require.NoError(t, err)
+	assert.Equal(t, "deadbeef", userKey)
+	mnemonic, err := store.GetUserMnemonic("user1")
+	require.NoError(t, err)
+	assert.Equal(t, "abandon about", mnemonic)
+
+	_, err = store.GetUserMnemonic("user2")
+	assert.ErrorIs(t, err, ErrMnemonicNotFound)
+
+	// token错误时返回Vault的错误
+	store, err = NewVaultKeyStore(VaultConfig{Address: server.URL, Token: "wrong"})
+	require.NoError(t, err)
+	_, err = store.GetPrivateKey("0xabc")
+	assert.ErrorContains(t, err, "permission denied")
+}
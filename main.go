@@ -11,6 +11,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/featx/keys-gin/lib/crypto"
 	"github.com/featx/keys-gin/web/config"
 	"github.com/featx/keys-gin/web/db"
 )
@@ -22,6 +23,18 @@ func main() {
 		log.Fatalf("Failed to initialize config: %v", err)
 	}
 
+	// 注册配置中的EVM链
+	for _, chain := range config.Config.EvmChains {
+		if err := crypto.RegisterEvmChain(crypto.EvmChain{
+			Name:    chain.Name,
+			ChainID: chain.ChainID,
+			Symbol:  chain.Symbol,
+			EIP1559: chain.EIP1559,
+		}); err != nil {
+			log.Fatalf("Failed to register evm chain: %v", err)
+		}
+	}
+
 	// 初始化数据库
 	if err := db.Init(db.DatabaseConfig{
 		Driver:          config.Config.Database.Driver,
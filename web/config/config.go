@@ -12,7 +12,11 @@ type Configuration struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Crypto   CryptoConfig   `mapstructure:"crypto"`
+	Keystore KeystoreConfig `mapstructure:"keystore"`
+	HSM      HSMConfig      `mapstructure:"hsm"`
 	Logging  LoggingConfig  `mapstructure:"logging"`
+	// EvmChains 追加或覆盖内置的EVM链
+	EvmChains []EvmChainConfig `mapstructure:"evm_chains"`
 }
 
 // ServerConfig 服务器配置
@@ -40,6 +44,44 @@ type CryptoConfig struct {
 	AESGCMNonceLength int   `mapstructure:"aes_gcm_nonce_length"`
 }
 
+// KeystoreConfig 私钥存储配置
+type KeystoreConfig struct {
+	// Backend 存储后端：file（默认，保存在Dir目录）、sql（保存在数据库的keystore_record表）或vault
+	Backend string `mapstructure:"backend"`
+	Dir     string `mapstructure:"dir"`
+	// PassphraseFile 主密码文件，未设置环境变量 KEYS_GIN_KEYSTORE_PASSPHRASE 时使用，都没有时启动时从标准输入读取
+	PassphraseFile string      `mapstructure:"passphrase_file"`
+	Vault          VaultConfig `mapstructure:"vault"`
+}
+
+// VaultConfig HashiCorp Vault存储后端配置
+type VaultConfig struct {
+	Address   string `mapstructure:"address"`
+	Namespace string `mapstructure:"namespace"`
+	// TokenFile Vault令牌文件，未设置环境变量 VAULT_TOKEN 时使用
+	TokenFile    string `mapstructure:"token_file"`
+	KVMount      string `mapstructure:"kv_mount"`
+	PathPrefix   string `mapstructure:"path_prefix"`
+	TransitMount string `mapstructure:"transit_mount"`
+	TransitKey   string `mapstructure:"transit_key"`
+}
+
+// HSMConfig PKCS#11硬件安全模块配置，未设置LibraryPath时不启用
+type HSMConfig struct {
+	LibraryPath string `mapstructure:"library_path"`
+	TokenLabel  string `mapstructure:"token_label"`
+	// PINFile 用户PIN文件，未设置环境变量 KEYS_GIN_HSM_PIN 时使用
+	PINFile string `mapstructure:"pin_file"`
+}
+
+// EvmChainConfig EVM链配置
+type EvmChainConfig struct {
+	Name    string `mapstructure:"name"`
+	ChainID uint64 `mapstructure:"chain_id"`
+	Symbol  string `mapstructure:"symbol"`
+	EIP1559 bool   `mapstructure:"eip1559"`
+}
+
 // LoggingConfig 日志配置
 type LoggingConfig struct {
 	Level  string `mapstructure:"level"`
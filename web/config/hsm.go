@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/featx/keys-gin/lib/hsm"
+)
+
+// hsmPINEnv 保存HSM用户PIN的环境变量
+const hsmPINEnv = "KEYS_GIN_HSM_PIN"
+
+// ProvideHSM 按hsm配置打开PKCS#11令牌，未配置library_path时返回nil，不启用HSM密钥
+// 用户PIN依次从环境变量 KEYS_GIN_HSM_PIN 和 pin_file 指定的文件读取
+func ProvideHSM() (*hsm.Token, error) {
+	if Config.HSM.LibraryPath == "" {
+		return nil, nil
+	}
+
+	pin := os.Getenv(hsmPINEnv)
+	if pin == "" && Config.HSM.PINFile != "" {
+		data, err := os.ReadFile(Config.HSM.PINFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hsm pin file: %w", err)
+		}
+		pin = strings.TrimSpace(string(data))
+	}
+
+	return hsm.Open(hsm.Config{
+		LibraryPath: Config.HSM.LibraryPath,
+		TokenLabel:  Config.HSM.TokenLabel,
+		PIN:         pin,
+	})
+}
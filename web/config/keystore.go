@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/featx/keys-gin/lib/keystore"
+	"github.com/featx/keys-gin/web/db"
+	"xorm.io/xorm"
+)
+
+// defaultKeystoreDir 默认的私钥存储目录
+const defaultKeystoreDir = "./data/keystore"
+
+// vaultTokenEnv 保存Vault令牌的环境变量
+const vaultTokenEnv = "VAULT_TOKEN"
+
+// ProvideKeystore 按keystore.backend配置创建私钥存储
+// file和sql后端启动时读取主密码，按crypto配置的参数加密记录，并将已有的明文记录迁移为加密信封；
+// vault后端由Vault的transit引擎加密
+func ProvideKeystore(engine *xorm.Engine) (keystore.KeyStore, error) {
+	switch Config.Keystore.Backend {
+	case "", "file":
+		dir := Config.Keystore.Dir
+		if dir == "" {
+			dir = defaultKeystoreDir
+		}
+		backend, err := keystore.NewFileBackend(dir)
+		if err != nil {
+			return nil, err
+		}
+		return newEncryptedStore(backend)
+	case "sql":
+		return newEncryptedStore(db.NewKeystoreBackend(engine))
+	case "vault":
+		return newVaultKeyStore()
+	default:
+		return nil, fmt.Errorf("unsupported keystore backend: %s", Config.Keystore.Backend)
+	}
+}
+
+// newEncryptedStore 读取主密码并在存储后端上创建加密私钥存储
+func newEncryptedStore(backend keystore.Backend) (keystore.KeyStore, error) {
+	passphrase, err := keystore.ReadPassphrase(Config.Keystore.PassphraseFile, os.Stdin, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	return keystore.NewEncryptedStore(backend, passphrase, keystore.KDFConfig{
+		KeyDerivation: Config.Crypto.KeyDerivation,
+		Iterations:    Config.Crypto.Iterations,
+		SaltLength:    Config.Crypto.SaltLength,
+		KeyLength:     Config.Crypto.KeyLength,
+		NonceLength:   Config.Crypto.AESGCMNonceLength,
+	})
+}
+
+// newVaultKeyStore 创建Vault私钥存储，令牌依次从环境变量 VAULT_TOKEN 和 token_file 指定的文件读取
+func newVaultKeyStore() (keystore.KeyStore, error) {
+	vault := Config.Keystore.Vault
+
+	token := os.Getenv(vaultTokenEnv)
+	if token == "" && vault.TokenFile != "" {
+		data, err := os.ReadFile(vault.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return nil, errors.New("vault token is required: set VAULT_TOKEN or keystore.vault.token_file")
+	}
+
+	return keystore.NewVaultKeyStore(keystore.VaultConfig{
+		Address:      vault.Address,
+		Token:        token,
+		Namespace:    vault.Namespace,
+		KVMount:      vault.KVMount,
+		PathPrefix:   vault.PathPrefix,
+		TransitMount: vault.TransitMount,
+		TransitKey:   vault.TransitKey,
+	})
+}
@@ -14,10 +14,16 @@ import (
 func InitializeApp() (*gin.Engine, error) {
 	wire.Build(
 		db.GetEngine,
+		ProvideKeystore,
+		ProvideHSM,
 		service.NewKeyService,
 		service.NewTransactionService,
+		service.NewChainService,
+		service.NewKeystoreService,
 		handler.NewKeyHandler,
 		handler.NewTransactionHandler,
+		handler.NewChainHandler,
+		handler.NewKeystoreHandler,
 		ProvideRouter,
 	)
 	return nil, nil
@@ -27,12 +33,16 @@ func InitializeApp() (*gin.Engine, error) {
 func ProvideRouter(
 	keyHandler *handler.KeyHandler,
 	transactionHandler *handler.TransactionHandler,
+	chainHandler *handler.ChainHandler,
+	keystoreHandler *handler.KeystoreHandler,
 ) *gin.Engine {
 	router := gin.Default()
 	
 	// 注册路由
 	keyHandler.RegisterRoutes(router)
 	transactionHandler.RegisterRoutes(router)
+	chainHandler.RegisterRoutes(router)
+	keystoreHandler.RegisterRoutes(router)
 	
 	// 添加健康检查端点
 	router.GET("/health", func(c *gin.Context) {
@@ -21,7 +21,15 @@ func InitializeApp() (*gin.Engine, error) {
 	if err != nil {
 		return nil, err
 	}
-	keyService, err := service.NewKeyService(xormEngine)
+	keyStore, err := ProvideKeystore(xormEngine)
+	if err != nil {
+		return nil, err
+	}
+	token, err := ProvideHSM()
+	if err != nil {
+		return nil, err
+	}
+	keyService, err := service.NewKeyService(xormEngine, keyStore, token)
 	if err != nil {
 		return nil, err
 	}
@@ -37,7 +45,23 @@ func InitializeApp() (*gin.Engine, error) {
 	if err != nil {
 		return nil, err
 	}
-	ginEngine := ProvideRouter(keyHandler, transactionHandler)
+	chainService, err := service.NewChainService()
+	if err != nil {
+		return nil, err
+	}
+	chainHandler, err := handler.NewChainHandler(chainService)
+	if err != nil {
+		return nil, err
+	}
+	keystoreService, err := service.NewKeystoreService(keyStore)
+	if err != nil {
+		return nil, err
+	}
+	keystoreHandler, err := handler.NewKeystoreHandler(keystoreService)
+	if err != nil {
+		return nil, err
+	}
+	ginEngine := ProvideRouter(keyHandler, transactionHandler, chainHandler, keystoreHandler)
 	return ginEngine, nil
 }
 
@@ -45,12 +69,16 @@ func InitializeApp() (*gin.Engine, error) {
 func ProvideRouter(
 	keyHandler *handler.KeyHandler,
 	transactionHandler *handler.TransactionHandler,
+	chainHandler *handler.ChainHandler,
+	keystoreHandler *handler.KeystoreHandler,
 ) *gin.Engine {
 	router := gin.Default()
 	
 	// 注册路由
 	keyHandler.RegisterRoutes(router)
 	transactionHandler.RegisterRoutes(router)
+	chainHandler.RegisterRoutes(router)
+	keystoreHandler.RegisterRoutes(router)
 	
 	// 添加健康检查端点
 	router.GET("/health", func(c *gin.Context) {
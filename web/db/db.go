@@ -9,6 +9,8 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 	_ "github.com/lib/pq" // PostgreSQL驱动
 	"xorm.io/xorm"
+	"xorm.io/xorm/names"
+	"xorm.io/xorm/schemas"
 	"github.com/featx/keys-gin/web/model"
 )
 
@@ -39,6 +41,9 @@ func Init(dbConfig DatabaseConfig) error {
 		return fmt.Errorf("failed to create database engine: %w", err)
 	}
 
+	// 字段名映射与查询条件保持一致：UserID -> user_id，而非默认的user_i_d
+	engine.SetMapper(names.GonicMapper{})
+
 	// 设置数据库参数
 	engine.ShowSQL(dbConfig.ShowSQL)
 	engine.SetMaxOpenConns(dbConfig.MaxOpenConns)
@@ -88,6 +93,7 @@ func syncTables(engine *xorm.Engine) error {
 		&model.PublicKey{},
 		&model.Address{},
 		&model.Transaction{},
+		&model.KeystoreRecord{},
 	}
 
 	for _, table := range tables {
@@ -96,5 +102,34 @@ func syncTables(engine *xorm.Engine) error {
 		}
 	}
 
+	return widenAddressColumn(engine)
+}
+
+// widenAddressColumn 加宽已有数据库中较短的地址列，以容纳Cardano基本地址
+// xorm同步表结构时只在MySQL上加宽varchar列，PostgreSQL需要手动修改，SQLite不限制长度
+func widenAddressColumn(engine *xorm.Engine) error {
+	if engine.Dialect().URI().DBType != schemas.POSTGRES {
+		return nil
+	}
+	table, err := engine.TableInfo(&model.Address{})
+	if err != nil {
+		return fmt.Errorf("failed to get address table info: %w", err)
+	}
+	column := table.GetColumn("address")
+
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return fmt.Errorf("failed to get database tables: %w", err)
+	}
+	for _, dbTable := range tables {
+		if dbTable.Name != table.Name {
+			continue
+		}
+		if dbColumn := dbTable.GetColumn(column.Name); dbColumn != nil && dbColumn.Length < column.Length {
+			if _, err := engine.Exec(engine.Dialect().ModifyColumnSQL(table.Name, column)); err != nil {
+				return fmt.Errorf("failed to widen address column: %w", err)
+			}
+		}
+	}
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/featx/keys-gin/lib/keystore"
+	"github.com/featx/keys-gin/web/model"
+	"xorm.io/xorm"
+)
+
+// KeystoreBackend 在keystore_record表中保存加密私钥记录的keystore存储后端
+// 记录的加密和KEK轮换由keystore.EncryptedStore完成，表中只保存密文
+type KeystoreBackend struct {
+	engine *xorm.Engine
+}
+
+// NewKeystoreBackend 创建数据库keystore存储后端
+func NewKeystoreBackend(engine *xorm.Engine) *KeystoreBackend {
+	return &KeystoreBackend{engine: engine}
+}
+
+// Get 读取记录
+func (b *KeystoreBackend) Get(name string) ([]byte, error) {
+	record := &model.KeystoreRecord{}
+	has, err := b.engine.Where("name = ?", name).Get(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keystore record: %w", err)
+	}
+	if !has {
+		return nil, keystore.ErrRecordNotFound
+	}
+	return []byte(record.Data), nil
+}
+
+// Put 在事务中插入或更新记录
+func (b *KeystoreBackend) Put(name string, data []byte) error {
+	session := b.engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	existing := &model.KeystoreRecord{}
+	has, err := session.Where("name = ?", name).Get(existing)
+	if err != nil {
+		session.Rollback()
+		return fmt.Errorf("failed to get keystore record: %w", err)
+	}
+	if has {
+		_, err = session.ID(existing.ID).Cols("data").Update(&model.KeystoreRecord{Data: string(data)})
+	} else {
+		_, err = session.Insert(&model.KeystoreRecord{Name: name, Data: string(data)})
+	}
+	if err != nil {
+		session.Rollback()
+		return fmt.Errorf("failed to save keystore record: %w", err)
+	}
+
+	return session.Commit()
+}
+
+// Delete 删除记录
+func (b *KeystoreBackend) Delete(name string) error {
+	affected, err := b.engine.Where("name = ?", name).Delete(&model.KeystoreRecord{})
+	if err != nil {
+		return fmt.Errorf("failed to delete keystore record: %w", err)
+	}
+	if affected == 0 {
+		return keystore.ErrRecordNotFound
+	}
+	return nil
+}
+
+// List 按名称排序列出所有记录名
+func (b *KeystoreBackend) List() ([]string, error) {
+	var records []model.KeystoreRecord
+	if err := b.engine.Cols("name").Asc("name").Find(&records); err != nil {
+		return nil, fmt.Errorf("failed to list keystore records: %w", err)
+	}
+
+	names := make([]string, 0, len(records))
+	for _, record := range records {
+		names = append(names, record.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/featx/keys-gin/web/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ChainHandler 链信息处理器
+type ChainHandler struct {
+	chainService *service.ChainService
+}
+
+// NewChainHandler 创建链信息处理器
+func NewChainHandler(chainService *service.ChainService) (*ChainHandler, error) {
+	return &ChainHandler{
+			chainService: chainService,
+		},
+		nil
+}
+
+// RegisterRoutes 注册路由
+func (h *ChainHandler) RegisterRoutes(router *gin.Engine) {
+	chains := router.Group("/api/v1/chains")
+	{
+		chains.GET("", h.ListChains)
+		chains.GET("/:chainType", h.GetChain)
+		chains.GET("/:chainType/addresses/:address", h.ValidateAddress)
+	}
+}
+
+// ListChains 处理获取支持的链列表请求
+func (h *ChainHandler) ListChains(c *gin.Context) {
+	c.JSON(http.StatusOK, h.chainService.ListChains())
+}
+
+// GetChain 处理获取指定链能力的请求
+func (h *ChainHandler) GetChain(c *gin.Context) {
+	chain := h.chainService.GetChain(c.Param("chainType"))
+	if chain == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unsupported chain type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, chain)
+}
+
+// ValidateAddress 处理地址校验请求
+func (h *ChainHandler) ValidateAddress(c *gin.Context) {
+	if err := h.chainService.ValidateAddress(c.Param("chainType"), c.Param("address")); err != nil {
+		c.JSON(http.StatusOK, gin.H{"valid": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
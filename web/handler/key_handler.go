@@ -26,9 +26,19 @@ func (h *KeyHandler) RegisterRoutes(router *gin.Engine) {
 	keys := router.Group("/api/v1/keys")
 	{
 		keys.POST("", h.GenerateKeyPair)
+		keys.POST("/next", h.NextAddress)
 		keys.GET("/user/:userID", h.GetUserKeyPairs)
 		keys.GET("/:id", h.GetKeyPairByID)
 		keys.GET("/address/:address", h.GetKeyPairByAddress)
+		keys.GET("/user/:userID/xpub", h.ExportExtendedPublicKey)
+	}
+
+	xpubs := router.Group("/api/v1/xpubs")
+	{
+		xpubs.POST("", h.RegisterExtendedPublicKey)
+		xpubs.GET("/:id", h.GetExtendedPublicKey)
+		xpubs.POST("/:id/addresses", h.DeriveWatchOnlyAddress)
+		xpubs.GET("/:id/addresses", h.ScanWatchOnlyAddresses)
 	}
 }
 
@@ -37,6 +47,30 @@ func (h *KeyHandler) RegisterRoutes(router *gin.Engine) {
 type GenerateKeyPairRequest struct {
 	UserID    string `json:"user_id" binding:"required"`
 	ChainType string `json:"chain_type" binding:"required"`
+	// AddressType 比特币地址类型：p2pkh（默认）、p2sh-p2wpkh、p2wpkh、p2tr
+	AddressType string `json:"address_type"`
+	// Bech32Prefix Cosmos SDK链的bech32地址前缀，如juno，链类型为cosmos_sdk时必填
+	Bech32Prefix string `json:"bech32_prefix"`
+	// SS58Prefix Substrate链的SS58地址前缀，仅对链类型substrate有效，默认42
+	SS58Prefix *uint16 `json:"ss58_prefix"`
+	// Account HD派生的账户索引，默认0
+	Account uint32 `json:"account"`
+	// Index HD派生的地址索引，默认0
+	Index uint32 `json:"index"`
+	// HSM 在HSM中生成不可导出的密钥，需要配置hsm，不支持HD派生
+	HSM bool `json:"hsm"`
+}
+
+// NextAddressRequest 分配新地址请求参数，地址索引由服务端分配
+
+type NextAddressRequest struct {
+	UserID       string  `json:"user_id" binding:"required"`
+	ChainType    string  `json:"chain_type" binding:"required"`
+	AddressType  string  `json:"address_type"`
+	Bech32Prefix string  `json:"bech32_prefix"`
+	SS58Prefix   *uint16 `json:"ss58_prefix"`
+	// Account HD派生的账户索引，默认0
+	Account uint32 `json:"account"`
 }
 
 // GenerateKeyPair 处理生成密钥对请求
@@ -47,7 +81,36 @@ func (h *KeyHandler) GenerateKeyPair(c *gin.Context) {
 		return
 	}
 
-	keyPair, err := h.keyService.GenerateKeyPair(req.UserID, req.ChainType)
+	keyPair, err := h.keyService.GenerateKeyPair(req.UserID, req.ChainType, service.GenerateKeyPairOptions{
+		AddressType:  req.AddressType,
+		Bech32Prefix: req.Bech32Prefix,
+		SS58Prefix:   req.SS58Prefix,
+		Account:      req.Account,
+		Index:        req.Index,
+		HSM:          req.HSM,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keyPair)
+}
+
+// NextAddress 处理分配新地址请求，在用户的账户下派生下一个地址索引的地址
+func (h *KeyHandler) NextAddress(c *gin.Context) {
+	var req NextAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	keyPair, err := h.keyService.NextAddress(req.UserID, req.ChainType, service.GenerateKeyPairOptions{
+		AddressType:  req.AddressType,
+		Bech32Prefix: req.Bech32Prefix,
+		SS58Prefix:   req.SS58Prefix,
+		Account:      req.Account,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/featx/keys-gin/web/service"
+	"github.com/gin-gonic/gin"
+)
+
+// KeystoreHandler keystore管理处理器
+type KeystoreHandler struct {
+	keystoreService *service.KeystoreService
+}
+
+// RotateKEKRequest KEK轮换请求参数
+type RotateKEKRequest struct {
+	CurrentPassphrase string `json:"current_passphrase" binding:"required"`
+	NewPassphrase     string `json:"new_passphrase" binding:"required"`
+}
+
+// NewKeystoreHandler 创建keystore管理处理器
+func NewKeystoreHandler(keystoreService *service.KeystoreService) (*KeystoreHandler, error) {
+	return &KeystoreHandler{
+		keystoreService: keystoreService,
+	}, nil
+}
+
+// RegisterRoutes 注册路由
+// 管理接口只接受本机发起的请求：KEK轮换请求中带有主密码，不能暴露给能访问服务端口的其他主机
+func (h *KeystoreHandler) RegisterRoutes(router *gin.Engine) {
+	admin := router.Group("/api/v1/admin/keystore", loopbackOnly)
+	{
+		admin.GET("/kek", h.GetKEKStatus)
+		admin.POST("/kek/rotate", h.RotateKEK)
+		admin.POST("/kek/resume", h.ResumeKEKRotation)
+	}
+}
+
+// loopbackOnly 拒绝非本机发起的请求
+// 按TCP连接的对端地址判断，不信任X-Forwarded-For等可以伪造的请求头
+func loopbackOnly(c *gin.Context) {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil || !ip.IsLoopback() {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin api is only available from localhost"})
+		return
+	}
+	c.Next()
+}
+
+// GetKEKStatus 处理获取KEK版本和轮换进度请求
+func (h *KeystoreHandler) GetKEKStatus(c *gin.Context) {
+	status, err := h.keystoreService.KEKRotationStatus()
+	if err != nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// RotateKEK 处理KEK轮换请求，DEK在后台重新包装，通过GetKEKStatus查询进度
+func (h *KeystoreHandler) RotateKEK(c *gin.Context) {
+	var req RotateKEKRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.keystoreService.RotateKEK(req.CurrentPassphrase, req.NewPassphrase)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": status})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// ResumeKEKRotation 处理继续未完成的KEK轮换请求
+func (h *KeystoreHandler) ResumeKEKRotation(c *gin.Context) {
+	status, err := h.keystoreService.ResumeKEKRotation()
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": status})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/featx/keys-gin/web/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterExtendedPublicKeyRequest 注册仅观察扩展公钥请求参数
+
+type RegisterExtendedPublicKeyRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	ChainType string `json:"chain_type" binding:"required"`
+	// ExtendedPublicKey 账户层级的扩展公钥：xpub、ypub、zpub（测试网络为tpub、upub、vpub）
+	ExtendedPublicKey string `json:"extended_public_key" binding:"required"`
+	// AddressType 比特币地址类型，ypub/zpub隐含地址类型，xpub默认p2pkh
+	AddressType  string `json:"address_type"`
+	Bech32Prefix string `json:"bech32_prefix"`
+}
+
+// DeriveWatchOnlyAddressRequest 分配仅观察地址请求参数
+
+type DeriveWatchOnlyAddressRequest struct {
+	// Change 0为收款地址，1为找零地址
+	Change uint32 `json:"change"`
+	// Index 地址索引，为空时分配下一个地址索引
+	Index *uint32 `json:"index"`
+	// GapLimit 地址间隔上限，默认20
+	GapLimit uint32 `json:"gap_limit"`
+}
+
+// ScanWatchOnlyAddressesRequest 扫描仅观察地址请求参数
+
+type ScanWatchOnlyAddressesRequest struct {
+	Change uint32 `form:"change"`
+	Start  uint32 `form:"start"`
+	// Count 派生的地址数量，为0时按地址间隔上限扫描
+	Count    uint32 `form:"count"`
+	GapLimit uint32 `form:"gap_limit"`
+}
+
+// ExportExtendedPublicKeyRequest 导出账户扩展公钥请求参数
+
+type ExportExtendedPublicKeyRequest struct {
+	ChainType    string `form:"chain_type" binding:"required"`
+	AddressType  string `form:"address_type"`
+	Bech32Prefix string `form:"bech32_prefix"`
+	Account      uint32 `form:"account"`
+}
+
+// RegisterExtendedPublicKey 处理注册仅观察扩展公钥请求
+func (h *KeyHandler) RegisterExtendedPublicKey(c *gin.Context) {
+	var req RegisterExtendedPublicKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	publicKey, err := h.keyService.RegisterExtendedPublicKey(req.UserID, req.ChainType, req.ExtendedPublicKey, service.GenerateKeyPairOptions{
+		AddressType:  req.AddressType,
+		Bech32Prefix: req.Bech32Prefix,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, publicKey)
+}
+
+// GetExtendedPublicKey 处理根据ID获取仅观察扩展公钥请求
+func (h *KeyHandler) GetExtendedPublicKey(c *gin.Context) {
+	id, ok := extendedKeyID(c)
+	if !ok {
+		return
+	}
+
+	publicKey, err := h.keyService.GetExtendedPublicKey(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, publicKey)
+}
+
+// DeriveWatchOnlyAddress 处理分配仅观察地址请求
+func (h *KeyHandler) DeriveWatchOnlyAddress(c *gin.Context) {
+	id, ok := extendedKeyID(c)
+	if !ok {
+		return
+	}
+	// 请求参数均为可选，空请求体按默认值分配下一个地址
+	var req DeriveWatchOnlyAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	keyPair, err := h.keyService.DeriveWatchOnlyAddress(id, req.Change, req.Index, req.GapLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keyPair)
+}
+
+// ScanWatchOnlyAddresses 处理扫描仅观察地址请求
+func (h *KeyHandler) ScanWatchOnlyAddresses(c *gin.Context) {
+	id, ok := extendedKeyID(c)
+	if !ok {
+		return
+	}
+	var req ScanWatchOnlyAddressesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	addresses, err := h.keyService.ScanWatchOnlyAddresses(id, req.Change, req.Start, req.Count, req.GapLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// ExportExtendedPublicKey 处理导出用户账户扩展公钥请求
+func (h *KeyHandler) ExportExtendedPublicKey(c *gin.Context) {
+	userID := c.Param("userID")
+	var req ExportExtendedPublicKeyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	extendedKey, path, err := h.keyService.ExportExtendedPublicKey(userID, req.ChainType, service.GenerateKeyPairOptions{
+		AddressType:  req.AddressType,
+		Bech32Prefix: req.Bech32Prefix,
+		Account:      req.Account,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"extended_public_key": extendedKey, "derivation_path": path})
+}
+
+// extendedKeyID 解析路径中的扩展公钥ID，无效时返回400
+func extendedKeyID(c *gin.Context) (int64, bool) {
+	var id int64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid extended public key ID"})
+		return 0, false
+	}
+	return id, true
+}
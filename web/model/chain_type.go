@@ -6,6 +6,12 @@ const (
 	ChainTypeETH = "ethereum"
 	// ChainTypeBTC 比特币
 	ChainTypeBTC = "bitcoin"
+	// ChainTypeBTCTestnet 比特币测试网（testnet3）
+	ChainTypeBTCTestnet = "bitcoin_testnet"
+	// ChainTypeBTCSignet 比特币Signet测试网
+	ChainTypeBTCSignet = "bitcoin_signet"
+	// ChainTypeBTCRegtest 比特币回归测试网络（本地regtest节点）
+	ChainTypeBTCRegtest = "bitcoin_regtest"
 	// ChainTypeSolana Solana
 	ChainTypeSolana = "solana"
 	// ChainTypeTRON TRON
@@ -14,10 +20,14 @@ const (
 	ChainTypeSUI = "sui"
 	// ChainTypeADA Cardano (ADA)
 	ChainTypeADA = "cardano"
+	// ChainTypeADATestnet Cardano测试网（preprod、preview）
+	ChainTypeADATestnet = "cardano_testnet"
 	// ChainTypePolkadot Polkadot
 	ChainTypePolkadot = "polkadot"
 	// ChainTypeKusama Kusama
 	ChainTypeKusama = "kusama"
+	// ChainTypeSubstrate 其他Substrate链（平行链、独立链），可指定SS58地址前缀，默认42
+	ChainTypeSubstrate = "substrate"
 	// ChainTypeTON TON (The Open Network)
 	ChainTypeTON = "ton"
 	// ChainTypeAvalanche Avalanche
@@ -26,6 +36,38 @@ const (
 	ChainTypeBSC = "binance_smart_chain"
 	// ChainTypePolygon Polygon
 	ChainTypePolygon = "polygon"
+	// ChainTypeArbitrum Arbitrum One
+	ChainTypeArbitrum = "arbitrum"
+	// ChainTypeOptimism OP Mainnet
+	ChainTypeOptimism = "optimism"
+	// ChainTypeBase Base
+	ChainTypeBase = "base"
+	// ChainTypeZkSync zkSync Era
+	ChainTypeZkSync = "zksync"
+	// ChainTypeLinea Linea
+	ChainTypeLinea = "linea"
 	// ChainTypeAPTOS Aptos
 	ChainTypeAPTOS = "aptos"
-)
\ No newline at end of file
+	// ChainTypeCosmos Cosmos Hub
+	ChainTypeCosmos = "cosmos"
+	// ChainTypeOsmosis Osmosis
+	ChainTypeOsmosis = "osmosis"
+	// ChainTypeCelestia Celestia
+	ChainTypeCelestia = "celestia"
+	// ChainTypeInjective Injective（eth_secp256k1密钥）
+	ChainTypeInjective = "injective"
+	// ChainTypeCosmosSDK 其他Cosmos SDK链，需指定bech32地址前缀
+	ChainTypeCosmosSDK = "cosmos_sdk"
+)
+
+// 比特币地址类型常量定义
+const (
+	// BtcAddressTypeP2PKH 传统地址（Base58，以1开头）
+	BtcAddressTypeP2PKH = "p2pkh"
+	// BtcAddressTypeP2SHP2WPKH 嵌套隔离见证地址（Base58，以3开头）
+	BtcAddressTypeP2SHP2WPKH = "p2sh-p2wpkh"
+	// BtcAddressTypeP2WPKH 原生隔离见证地址（bech32，以bc1q开头）
+	BtcAddressTypeP2WPKH = "p2wpkh"
+	// BtcAddressTypeP2TR Taproot地址（bech32m，以bc1p开头）
+	BtcAddressTypeP2TR = "p2tr"
+)
@@ -9,10 +9,11 @@ import (
 
 type PublicKey struct {
 	ID        int64     `xorm:"pk autoincr" json:"id"`
-	UserID    string    `xorm:"varchar(50) notnull index" json:"user_id"`
+	UserID    string    `xorm:"varchar(50) notnull index unique(user_public_key)" json:"user_id"`
 	ChainType string    `xorm:"varchar(30) notnull index" json:"chain_type"`
-	PublicKey string    `xorm:"text notnull unique" json:"public_key"`
-	Curve     string    `xorm:"varchar(50) notnull" json:"curve"` // 推导椭圆曲线方式
+	PublicKey string    `xorm:"text notnull unique(user_public_key)" json:"public_key"` // 同一公钥在用户内唯一，不同用户各自保存
+	Curve     string    `xorm:"varchar(50) notnull" json:"curve"`                       // 推导椭圆曲线方式
+	Encoding  string    `xorm:"varchar(50)" json:"encoding,omitempty"`                  // 仅观察的扩展公钥派生地址的编码方式，普通公钥为空
 	CreatedAt time.Time `xorm:"created" json:"created_at"`
 	UpdatedAt time.Time `xorm:"updated" json:"updated_at"`
 }
@@ -21,14 +22,20 @@ type PublicKey struct {
 // 存储地址信息及相关元数据
 
 type Address struct {
-	ID        int64     `xorm:"pk autoincr" json:"id"`
-	PublicKey string    `xorm:"text notnull index" json:"public_key"` // 直接使用公钥作为关联字段
-	UserID    string    `xorm:"varchar(50) notnull index" json:"user_id"`
-	ChainType string    `xorm:"varchar(30) notnull index" json:"chain_type"`
-	Address   string    `xorm:"varchar(100) notnull unique" json:"address"`
-	Encoding  string    `xorm:"varchar(50) notnull" json:"encoding"` // 从公钥转换的编码方式
-	CreatedAt time.Time `xorm:"created" json:"created_at"`
-	UpdatedAt time.Time `xorm:"updated" json:"updated_at"`
+	ID             int64     `xorm:"pk autoincr" json:"id"`
+	PublicKey      string    `xorm:"text notnull index" json:"public_key"` // 直接使用公钥作为关联字段
+	UserID         string    `xorm:"varchar(50) notnull index unique(address_slot)" json:"user_id"`
+	ChainType      string    `xorm:"varchar(30) notnull index unique(address_slot) unique(chain_address)" json:"chain_type"`
+	Address        string    `xorm:"varchar(128) notnull unique(chain_address)" json:"address"`               // 同一地址可以属于多个链（如EVM链共用的密钥），在链内唯一；Cardano基本地址长达108个字符
+	Encoding       string    `xorm:"varchar(50) notnull unique(address_slot)" json:"encoding"`                // 从公钥转换的编码方式
+	Account        uint32    `xorm:"notnull default 0 unique(address_slot)" json:"account"`                   // HD派生的账户索引
+	Change         uint32    `xorm:"'change_index' notnull default 0 unique(address_slot)" json:"change"`     // HD派生的找零层级，0为收款地址，1为找零地址
+	AddressIndex   uint32    `xorm:"notnull default 0 unique(address_slot)" json:"address_index"`             // HD派生的地址索引，同一用户、链、编码、账户和找零层级下唯一
+	ExtendedKeyID  int64     `xorm:"notnull default 0 unique(address_slot)" json:"extended_key_id,omitempty"` // 派生该地址的仅观察扩展公钥ID，本节点持有私钥的地址为0
+	DerivationPath string    `xorm:"varchar(100)" json:"derivation_path,omitempty"`                           // 从用户助记词派生的HD路径，扩展公钥派生的地址为相对路径 change/index，非HD密钥为空
+	Stale          bool      `xorm:"notnull default false" json:"stale,omitempty"`                            // 按旧方式推导且无法迁移的地址，不再用于签名
+	CreatedAt      time.Time `xorm:"created" json:"created_at"`
+	UpdatedAt      time.Time `xorm:"updated" json:"updated_at"`
 }
 
 // KeyPair 密钥对模型
@@ -39,6 +46,18 @@ type KeyPair struct {
 	Address   *Address   `xorm:"-" json:"address"`
 }
 
+// DerivedAddress 扩展公钥派生的地址
+// 注意：这是一个组合结构，不会被同步为数据库表
+
+type DerivedAddress struct {
+	Change         uint32 `json:"change"`
+	AddressIndex   uint32 `json:"address_index"`
+	DerivationPath string `json:"derivation_path"`
+	PublicKey      string `json:"public_key"`
+	Address        string `json:"address"`
+	Issued         bool   `json:"issued"` // 地址是否已分配
+}
+
 // Transaction 交易模型
 type Transaction struct {
 	ID        int64     `xorm:"pk autoincr" json:"id"`
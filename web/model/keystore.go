@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+// KeystoreRecord 加密私钥记录
+// keystore使用数据库存储后端时，每个地址私钥、用户私钥和KEK元数据各保存为一行，Data为已加密的信封
+
+type KeystoreRecord struct {
+	ID        int64     `xorm:"pk autoincr"`
+	Name      string    `xorm:"varchar(255) notnull unique"` // 记录名：address:<地址>、user:<用户ID> 或 kek
+	Data      string    `xorm:"text notnull"`
+	CreatedAt time.Time `xorm:"created"`
+	UpdatedAt time.Time `xorm:"updated"`
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/featx/keys-gin/lib/hsm"
+	"github.com/featx/keys-gin/web/model"
+	"github.com/featx/keys-gin/web/util"
+)
+
+// legacyAddressChains 地址推导方式修正过的链
+// Aptos地址由 SHA3-256(公钥) 改为认证密钥 SHA3-256(公钥 || 0x00)；
+// Polkadot/Kusama由Blake2b模拟的公钥和地址改为sr25519公钥的SS58地址
+var legacyAddressChains = []string{model.ChainTypeAPTOS, model.ChainTypePolkadot, model.ChainTypeKusama}
+
+// migrateLegacyAddresses 重新推导按旧方式生成的地址，更新数据库中的地址、公钥和keystore中私钥的地址
+// 由已保存的公钥推导出的地址与记录一致时不需要迁移；私钥无法重新推导时将地址标记为过期，不再用于签名
+func (s *KeyService) migrateLegacyAddresses() error {
+	var addresses []model.Address
+	err := s.db.In("chain_type", legacyAddressChains).
+		And("extended_key_id = 0 AND stale = ?", false).
+		Asc("id").Find(&addresses)
+	if err != nil {
+		return fmt.Errorf("failed to get addresses to migrate: %w", err)
+	}
+
+	for i := range addresses {
+		if err := s.migrateLegacyAddress(&addresses[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLegacyAddress 迁移单个地址
+func (s *KeyService) migrateLegacyAddress(address *model.Address) error {
+	generator, err := crypto.NewKeyGenerator(address.ChainType)
+	if err != nil {
+		return fmt.Errorf("failed to create key generator: %w", err)
+	}
+	if current, err := generator.PublicKeyToAddress(address.PublicKey); err == nil && current == address.Address {
+		return nil
+	}
+
+	privateKey, err := s.keyStore.GetPrivateKey(address.Address)
+	if err != nil {
+		return s.markAddressStale(address)
+	}
+	defer crypto.Zeroize(privateKey)
+
+	// HSM中的密钥生成时使用的是真实公钥，只需重新计算地址；其他私钥重新推导公钥和地址
+	var addressValue, publicKeyValue string
+	privateKeyValue := string(privateKey)
+	if hsm.IsKeyURI(privateKey) {
+		publicKeyValue = address.PublicKey
+		addressValue, err = generator.PublicKeyToAddress(publicKeyValue)
+	} else {
+		addressValue, publicKeyValue, privateKeyValue, err = rederiveLegacyKeyPair(generator, address.ChainType, privateKeyValue)
+	}
+	if err != nil {
+		return s.markAddressStale(address)
+	}
+
+	// 新地址已被其他记录使用时无法迁移
+	taken, err := s.db.Where("chain_type = ? AND address = ?", address.ChainType, addressValue).Exist(&model.Address{})
+	if err != nil {
+		return fmt.Errorf("failed to check migrated address: %w", err)
+	}
+	if taken {
+		return s.markAddressStale(address)
+	}
+
+	if err := s.keyStore.SavePrivateKey(addressValue, privateKeyValue); err != nil {
+		return fmt.Errorf("failed to save private key of migrated address: %w", err)
+	}
+	if err := s.updateMigratedAddress(address, addressValue, publicKeyValue); err != nil {
+		// 数据库更新失败时删除新保存的私钥，保留旧地址的私钥
+		if deleteErr := s.keyStore.DeletePrivateKey(addressValue); deleteErr != nil {
+			return fmt.Errorf("%w; failed to delete private key of migrated address: %v", err, deleteErr)
+		}
+		return err
+	}
+
+	// 删除旧地址的私钥，其他链的相同地址仍在使用时保留
+	return s.deleteAddressPrivateKey(address)
+}
+
+// rederiveLegacyKeyPair 从私钥重新推导公钥和地址，返回迁移后保存的私钥
+// 旧实现生成的Polkadot/Kusama私钥是64字节随机数，通常不是有效的sr25519私钥（旧地址也不是有效的SS58地址），
+// 此时取前32字节作为mini secret key
+func rederiveLegacyKeyPair(generator crypto.KeyGenerator, chainType, privateKey string) (address, publicKey, migratedPrivateKey string, err error) {
+	address, publicKey, err = generator.DeriveKeyPairFromPrivateKey(privateKey)
+	if err == nil || chainType == model.ChainTypeAPTOS || len(privateKey) != 128 {
+		return address, publicKey, privateKey, err
+	}
+	migratedPrivateKey = privateKey[:64]
+	address, publicKey, err = generator.DeriveKeyPairFromPrivateKey(migratedPrivateKey)
+	return address, publicKey, migratedPrivateKey, err
+}
+
+// updateMigratedAddress 在同一事务中更新地址记录，新公钥没有记录时创建，旧公钥不再使用时删除
+func (s *KeyService) updateMigratedAddress(address *model.Address, addressValue, publicKeyValue string) error {
+	session := s.db.NewSession()
+	defer session.Close()
+	if err := session.Begin(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if publicKeyValue != address.PublicKey {
+		has, err := session.Where("user_id = ? AND public_key = ?", address.UserID, publicKeyValue).Exist(&model.PublicKey{})
+		if err != nil {
+			return fmt.Errorf("failed to check existing public key: %w", err)
+		}
+		if !has {
+			curve, _ := util.GetCurveAndEncoding(address.ChainType)
+			publicKey := &model.PublicKey{
+				PublicKey: publicKeyValue,
+				UserID:    address.UserID,
+				ChainType: address.ChainType,
+				Curve:     curve,
+			}
+			if _, err := session.Insert(publicKey); err != nil {
+				return fmt.Errorf("failed to save public key: %w", err)
+			}
+		}
+	}
+
+	_, err := session.ID(address.ID).Cols("address", "public_key").
+		Update(&model.Address{Address: addressValue, PublicKey: publicKeyValue})
+	if err != nil {
+		return fmt.Errorf("failed to update address: %w", err)
+	}
+
+	if publicKeyValue != address.PublicKey {
+		inUse, err := session.Where("user_id = ? AND public_key = ?", address.UserID, address.PublicKey).Exist(&model.Address{})
+		if err != nil {
+			return fmt.Errorf("failed to check addresses of public key: %w", err)
+		}
+		if !inUse {
+			_, err = session.Where("user_id = ? AND public_key = ?", address.UserID, address.PublicKey).Delete(&model.PublicKey{})
+			if err != nil {
+				return fmt.Errorf("failed to delete public key: %w", err)
+			}
+		}
+	}
+
+	if err := session.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// markAddressStale 将无法迁移的地址标记为过期
+func (s *KeyService) markAddressStale(address *model.Address) error {
+	address.Stale = true
+	if _, err := s.db.ID(address.ID).Cols("stale").Update(address); err != nil {
+		return fmt.Errorf("failed to mark address %s as stale: %w", address.Address, err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+	"xorm.io/xorm"
+
+	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/featx/keys-gin/lib/keystore"
+	"github.com/featx/keys-gin/web/model"
+)
+
+// insertLegacyKeyPair 按旧方式保存的公钥、地址和私钥
+func insertLegacyKeyPair(t *testing.T, engine *xorm.Engine, ks keystore.KeyStore, userID, chainType, curve, publicKey, address, privateKey string) {
+	t.Helper()
+	has, err := engine.Where("user_id = ? AND public_key = ?", userID, publicKey).Exist(&model.PublicKey{})
+	require.NoError(t, err)
+	if !has {
+		_, err = engine.Insert(&model.PublicKey{UserID: userID, ChainType: chainType, PublicKey: publicKey, Curve: curve})
+		require.NoError(t, err)
+	}
+	_, err = engine.Insert(&model.Address{UserID: userID, ChainType: chainType, PublicKey: publicKey, Address: address, Encoding: "legacy"})
+	require.NoError(t, err)
+	if privateKey != "" {
+		require.NoError(t, ks.SavePrivateKey(address, privateKey))
+	}
+}
+
+// legacyPolkadotKeyPair 旧实现生成的Polkadot密钥对：64字节随机私钥，Blake2b模拟的公钥和地址
+func legacyPolkadotKeyPair(t *testing.T) (address, publicKey, privateKey string) {
+	t.Helper()
+	privateKeyBytes := make([]byte, 64)
+	_, err := rand.Read(privateKeyBytes)
+	require.NoError(t, err)
+	publicKeyBytes := blake2b.Sum256(privateKeyBytes)
+	addressHash := blake2b.Sum256(publicKeyBytes[:])
+	return "1" + hex.EncodeToString(addressHash[:20]), hex.EncodeToString(publicKeyBytes[:]), hex.EncodeToString(privateKeyBytes)
+}
+
+func TestMigrateLegacyAddresses_Aptos(t *testing.T) {
+	engine, ks := newTestStores(t)
+
+	publicKeyBytes, privateKeyBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicKey := hex.EncodeToString(publicKeyBytes)
+	legacyHash := sha3.Sum256(publicKeyBytes)
+	legacyAddress := "0x" + hex.EncodeToString(legacyHash[:])
+	insertLegacyKeyPair(t, engine, ks, "u1", model.ChainTypeAPTOS, "ed25519", publicKey, legacyAddress, hex.EncodeToString(privateKeyBytes))
+
+	service, err := NewKeyService(engine, ks, nil)
+	require.NoError(t, err)
+
+	expected, err := (&crypto.AptosKeyGenerator{}).PublicKeyToAddress(publicKey)
+	require.NoError(t, err)
+	keyPair, err := service.GetKeyPairByAddress(expected)
+	require.NoError(t, err)
+	require.NotNil(t, keyPair)
+	assert.Equal(t, publicKey, keyPair.PublicKey.PublicKey)
+	assert.False(t, keyPair.Address.Stale)
+
+	// 私钥随地址迁移，旧地址的私钥已删除
+	_, err = service.SigningKey(model.ChainTypeAPTOS, expected)
+	require.NoError(t, err)
+	_, err = ks.GetPrivateKey(legacyAddress)
+	assert.Error(t, err)
+
+	// 再次启动时不重复迁移
+	_, err = NewKeyService(engine, ks, nil)
+	require.NoError(t, err)
+	count, err := engine.Count(&model.Address{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMigrateLegacyAddresses_Polkadot(t *testing.T) {
+	engine, ks := newTestStores(t)
+
+	// Polkadot和Kusama共用同一私钥，旧地址由同一个模拟公钥得到
+	address, publicKey, privateKey := legacyPolkadotKeyPair(t)
+	insertLegacyKeyPair(t, engine, ks, "u1", model.ChainTypePolkadot, "sr25519", publicKey, address, privateKey)
+	insertLegacyKeyPair(t, engine, ks, "u1", model.ChainTypeKusama, "sr25519", publicKey, address, privateKey)
+
+	// 私钥丢失的地址无法迁移
+	lostAddress, lostPublicKey, _ := legacyPolkadotKeyPair(t)
+	insertLegacyKeyPair(t, engine, ks, "u2", model.ChainTypePolkadot, "sr25519", lostPublicKey, lostAddress, "")
+
+	service, err := NewKeyService(engine, ks, nil)
+	require.NoError(t, err)
+
+	keyPairs, err := service.GetUserKeyPairs("u1")
+	require.NoError(t, err)
+	require.Len(t, keyPairs, 2)
+	for _, keyPair := range keyPairs {
+		generator, err := crypto.NewKeyGenerator(keyPair.Address.ChainType)
+		require.NoError(t, err)
+		expectedAddress, expectedPublicKey, _, err := rederiveLegacyKeyPair(generator, keyPair.Address.ChainType, privateKey)
+		require.NoError(t, err)
+		assert.Equal(t, expectedAddress, keyPair.Address.Address)
+		assert.Equal(t, expectedPublicKey, keyPair.Address.PublicKey)
+		assert.Equal(t, expectedPublicKey, keyPair.PublicKey.PublicKey)
+		assert.False(t, keyPair.Address.Stale)
+
+		_, err = service.SigningKey(keyPair.Address.ChainType, keyPair.Address.Address)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, keyPairs[0].PublicKey.ID, keyPairs[1].PublicKey.ID)
+
+	// 模拟公钥的记录已删除
+	has, err := engine.Where("public_key = ?", publicKey).Exist(&model.PublicKey{})
+	require.NoError(t, err)
+	assert.False(t, has)
+	_, err = ks.GetPrivateKey(address)
+	assert.Error(t, err)
+
+	// 无法迁移的地址标记为过期，不能用于签名
+	keyPair, err := service.GetKeyPairByAddress(lostAddress)
+	require.NoError(t, err)
+	require.NotNil(t, keyPair)
+	assert.True(t, keyPair.Address.Stale)
+	_, err = service.GetPrivateKey(lostAddress)
+	assert.Error(t, err)
+}
+
+func TestMigrateLegacyAddresses_SkipsCurrentAddresses(t *testing.T) {
+	engine, ks := newTestStores(t)
+	service, err := NewKeyService(engine, ks, nil)
+	require.NoError(t, err)
+
+	var generated []*model.KeyPair
+	for _, chainType := range legacyAddressChains {
+		keyPair, err := service.GenerateKeyPair("u1", chainType, GenerateKeyPairOptions{})
+		require.NoError(t, err)
+		generated = append(generated, keyPair)
+	}
+
+	service, err = NewKeyService(engine, ks, nil)
+	require.NoError(t, err)
+	for _, keyPair := range generated {
+		current, err := service.GetKeyPairByID(keyPair.Address.ID)
+		require.NoError(t, err)
+		assert.Equal(t, keyPair.Address.Address, current.Address.Address)
+		assert.False(t, current.Address.Stale)
+	}
+}
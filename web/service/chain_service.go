@@ -0,0 +1,31 @@
+package service
+
+import "github.com/featx/keys-gin/lib/crypto"
+
+// ChainService 链信息服务
+type ChainService struct{}
+
+// NewChainService 创建链信息服务
+func NewChainService() (*ChainService, error) {
+	return &ChainService{}, nil
+}
+
+// ListChains 获取所有支持的链及其能力
+func (s *ChainService) ListChains() []crypto.ChainInfo {
+	return crypto.Chains()
+}
+
+// GetChain 获取指定链类型的能力，不支持的链类型返回nil
+func (s *ChainService) GetChain(chainType string) *crypto.ChainInfo {
+	for _, info := range crypto.Chains() {
+		if info.ChainType == chainType {
+			return &info
+		}
+	}
+	return nil
+}
+
+// ValidateAddress 校验地址是否为指定链的有效地址
+func (s *ChainService) ValidateAddress(chainType, address string) error {
+	return crypto.ValidateAddress(chainType, address)
+}
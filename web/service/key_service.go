@@ -3,8 +3,10 @@ package service
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/featx/keys-gin/lib/hsm"
 	"github.com/featx/keys-gin/lib/keystore"
 	"github.com/featx/keys-gin/web/model"
 	"github.com/featx/keys-gin/web/util"
@@ -14,66 +16,254 @@ import (
 // KeyService 密钥对服务
 type KeyService struct {
 	db       *xorm.Engine
-	keyStore *keystore.Keystore
+	keyStore keystore.KeyStore
+	// hsm 未配置HSM时为nil
+	hsm *hsm.Token
+	// mu 串行化地址分配，避免并发请求分配到相同的地址索引
+	mu sync.Mutex
 }
 
-// NewKeyService 创建密钥服务
-func NewKeyService(dbEngine *xorm.Engine) (*KeyService, error) {
-	// 创建私钥存储管理器
-	keyStore, err := keystore.NewKeystore("./data/keystore")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create keystore: %w", err)
+// NewKeyService 创建密钥服务，hsmToken为nil时不支持在HSM中生成密钥
+func NewKeyService(dbEngine *xorm.Engine, keyStore keystore.KeyStore, hsmToken *hsm.Token) (*KeyService, error) {
+	service := &KeyService{
+		db:       dbEngine,
+		keyStore: keyStore,
+		hsm:      hsmToken,
+	}
+	// 启动时迁移按旧方式推导的地址
+	if err := service.migrateLegacyAddresses(); err != nil {
+		return nil, err
 	}
+	return service, nil
+}
 
-	return &KeyService{
-			db:       dbEngine,
-			keyStore: keyStore,
-		},
-		nil
+// GenerateKeyPairOptions 生成密钥对的可选参数
+type GenerateKeyPairOptions struct {
+	// AddressType 比特币地址类型（p2pkh、p2sh-p2wpkh、p2wpkh、p2tr），仅对比特币（含测试网络）有效，默认p2pkh
+	AddressType string
+	// Bech32Prefix Cosmos SDK链的bech32地址前缀，仅对Cosmos SDK链有效，链类型为cosmos_sdk时必须指定
+	Bech32Prefix string
+	// SS58Prefix Substrate链的SS58地址前缀，仅对链类型substrate有效，默认42
+	SS58Prefix *uint16
+	// Account HD派生的账户索引，仅对支持HD派生的链有效，默认0
+	Account uint32
+	// Index HD派生的地址索引，仅对支持HD派生的链有效，默认0
+	Index uint32
+	// HSM 在HSM中生成不可导出的密钥，keystore中只保存密钥引用，不支持HD派生
+	HSM bool
 }
 
-// GenerateKeyPair 为用户生成指定链的密钥对
+// GenerateKeyPair 为用户生成指定链（及账户、地址索引）的密钥对
 // 实现逻辑：
-// 1. 检查用户是否已有该链类型的地址，如有则直接返回
-// 2. 如果没有，检查用户是否有使用相同曲线的其他链类型的密钥对
-// 3. 如果有，从已有私钥推导出新链类型的公钥和地址
-// 4. 如果都没有，生成新的密钥对
-func (s *KeyService) GenerateKeyPair(userID, chainType string) (*model.KeyPair, error) {
-	// 验证参数
-	if userID == "" || chainType == "" {
-		return nil, errors.New("userID and chainType are required")
+// 1. 检查用户是否已有该链类型（及地址编码、账户和地址索引）的地址，如有则直接返回
+// 2. 如果链支持HD派生，从用户的BIP-39助记词（首次使用时生成）按链的派生路径推导密钥对
+// 3. 否则检查用户是否有使用相同曲线的其他链类型的密钥对
+// 4. 如果有，从已有私钥推导出新链类型的公钥和地址
+// 5. 如果都没有，生成新的密钥对
+func (s *KeyService) GenerateKeyPair(userID, chainType string, opts GenerateKeyPairOptions) (*model.KeyPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getOrCreateKeyPair(userID, chainType, opts)
+}
+
+// NextAddress 在用户指定账户下分配一个新地址
+// 地址索引为该用户、链类型、地址编码和账户下已分配的最大索引加1，没有地址时为0
+func (s *KeyService) NextAddress(userID, chainType string, opts GenerateKeyPairOptions) (*model.KeyPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, encoding, err := keyPairEncoding(userID, chainType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var last model.Address
+	has, err := s.db.Where("user_id = ? AND chain_type = ? AND encoding = ? AND account = ? AND change_index = 0 AND extended_key_id = 0",
+		userID, chainType, encoding, opts.Account).Desc("address_index").Get(&last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last address index: %w", err)
+	}
+
+	opts.Index = 0
+	if has {
+		opts.Index = last.AddressIndex + 1
+	}
+	return s.getOrCreateKeyPair(userID, chainType, opts)
+}
+
+// getOrCreateKeyPair 获取或生成密钥对，调用方需持有s.mu
+func (s *KeyService) getOrCreateKeyPair(userID, chainType string, opts GenerateKeyPairOptions) (*model.KeyPair, error) {
+	// 获取曲线类型和编码方式
+	curve, encoding, err := keyPairEncoding(userID, chainType, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	// 步骤1: 检查用户是否已有该链类型的地址
-	if existingKeyPair, err := s.checkExistingAddress(userID, chainType); err != nil {
+	if existingKeyPair, err := s.checkExistingAddress(userID, chainType, encoding, opts.Account, opts.Index); err != nil {
 		return nil, err
 	} else if existingKeyPair != nil {
 		return existingKeyPair, nil
 	}
 
-	// 获取曲线类型和编码方式
-	curve, encoding := util.GetCurveAndEncoding(chainType)
+	// 步骤2: 支持HD派生的链从用户的助记词推导
+	generator, err := newKeyGenerator(chainType, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key generator: %w", err)
+	}
+	if opts.HSM {
+		if opts.Account != 0 || opts.Index != 0 {
+			return nil, errors.New("account and index are not supported for hsm keys")
+		}
+		return s.generateHSMKeyPair(userID, chainType, curve, encoding, generator)
+	}
+	if hdGenerator, ok := generator.(crypto.HDKeyGenerator); ok {
+		return s.deriveHDKeyPair(userID, chainType, curve, encoding, hdGenerator, opts.Account, opts.Index)
+	}
+	if opts.Account != 0 || opts.Index != 0 {
+		return nil, fmt.Errorf("account and index are not supported for chain type %s", chainType)
+	}
 
-	// 步骤2: 检查用户是否有使用相同曲线的其他链类型的密钥对
+	// 步骤3: 检查用户是否有使用相同曲线的其他链类型的密钥对
+	// 只共用本节点持有私钥的非HD密钥，HD派生的地址和仅观察的扩展公钥（及其派生的地址）不参与
 	var existingPublicKeys []model.PublicKey
-	err := s.db.Where("user_id = ? AND curve = ?", userID, curve).Find(&existingPublicKeys)
+	err = s.db.Where("user_id = ? AND curve = ?", userID, curve).
+		And("public_key IN (SELECT public_key FROM address WHERE user_id = ? AND extended_key_id = 0 AND (derivation_path = '' OR derivation_path IS NULL))", userID).
+		Asc("id").Find(&existingPublicKeys)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing public keys with same curve: %w", err)
 	}
 
-	// 步骤3: 如果有相同曲线的密钥对，尝试从已有密钥推导
+	// 步骤4: 如果有相同曲线的密钥对，尝试从已有密钥推导
 	if len(existingPublicKeys) > 0 {
-		return s.deriveKeyPairFromExisting(existingPublicKeys, userID, chainType, curve, encoding)
+		return s.deriveKeyPairFromExisting(existingPublicKeys, userID, chainType, curve, encoding, opts)
+	}
+
+	// 步骤5: 生成新的密钥对
+	return s.generateNewKeyPair(userID, chainType, curve, encoding, opts)
+}
+
+// keyPairEncoding 校验生成参数，返回链的曲线类型和地址编码方式
+func keyPairEncoding(userID, chainType string, opts GenerateKeyPairOptions) (curve, encoding string, err error) {
+	// 验证参数
+	if userID == "" || chainType == "" {
+		return "", "", errors.New("userID and chainType are required")
+	}
+	if opts.Account >= crypto.HardenedKeyStart || opts.Index >= crypto.HardenedKeyStart {
+		return "", "", errors.New("account and index must be less than 2^31")
+	}
+
+	curve, encoding = util.GetCurveAndEncoding(chainType)
+	if opts.AddressType != "" {
+		if !util.IsBitcoinChain(chainType) {
+			return "", "", errors.New("address_type is only supported for bitcoin")
+		}
+		if encoding = util.GetBtcAddressEncoding(opts.AddressType); encoding == "" {
+			return "", "", fmt.Errorf("unsupported bitcoin address type: %s", opts.AddressType)
+		}
+	}
+	if opts.Bech32Prefix != "" && !util.IsCosmosChain(chainType) {
+		return "", "", errors.New("bech32_prefix is only supported for cosmos sdk chains")
+	}
+	if util.IsCosmosChain(chainType) {
+		generator, err := crypto.NewCosmosKeyGenerator(chainType, opts.Bech32Prefix)
+		if err != nil {
+			return "", "", err
+		}
+		encoding = util.GetCosmosAddressEncoding(generator.HRP)
+	}
+	if opts.SS58Prefix != nil && chainType != model.ChainTypeSubstrate {
+		return "", "", errors.New("ss58_prefix is only supported for substrate chains")
+	}
+	if chainType == model.ChainTypeSubstrate {
+		generator, err := crypto.NewSubstrateKeyGenerator(chainType, opts.SS58Prefix)
+		if err != nil {
+			return "", "", err
+		}
+		encoding = util.GetSubstrateAddressEncoding(generator.SS58Prefix)
 	}
+	return curve, encoding, nil
+}
+
+// newKeyGenerator 根据链类型和可选参数创建密钥生成器
+func newKeyGenerator(chainType string, opts GenerateKeyPairOptions) (crypto.KeyGenerator, error) {
+	if util.IsBitcoinChain(chainType) {
+		return crypto.NewBtcKeyGenerator(chainType, opts.AddressType)
+	}
+	if util.IsCosmosChain(chainType) {
+		return crypto.NewCosmosKeyGenerator(chainType, opts.Bech32Prefix)
+	}
+	if chainType == model.ChainTypeSubstrate {
+		return crypto.NewSubstrateKeyGenerator(chainType, opts.SS58Prefix)
+	}
+	return crypto.NewKeyGenerator(chainType)
+}
+
+// deriveHDKeyPair 从用户的助记词按链的派生路径推导指定账户和地址索引的密钥对并保存
+func (s *KeyService) deriveHDKeyPair(userID, chainType, curve, encoding string, generator crypto.HDKeyGenerator, account, index uint32) (*model.KeyPair, error) {
+	mnemonic, err := s.getOrCreateUserMnemonic(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	path := generator.DerivationPath(account, index)
+	addressValue, publicKeyValue, privateKey, err := generator.DeriveKeyPairFromMnemonic(mnemonic, "", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key pair: %w", err)
+	}
+
+	slot := addressSlot{account: account, index: index, derivationPath: path}
+	keyPair, err := s.saveDerivedKeyPair(userID, chainType, curve, encoding, publicKeyValue, addressValue, privateKey, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cardano同时保存权益凭证对应的奖励地址，同一账户的所有地址共用一个奖励地址
+	// 奖励地址保存失败时删除已保存的基本地址，下次生成时重新保存两者
+	if util.IsCardanoChain(chainType) {
+		stakeSlot := addressSlot{account: account, derivationPath: crypto.CardanoDerivationPath(account, crypto.CardanoRoleStaking, 0)}
+		if err := s.saveCardanoRewardAddress(userID, chainType, curve, publicKeyValue, privateKey, stakeSlot); err != nil {
+			return nil, errors.Join(err, s.deleteAddressPrivateKey(keyPair.Address), s.deleteKeyPairRecords(keyPair))
+		}
+	}
+
+	return keyPair, nil
+}
+
+// addressSlot 地址在用户HD钱包（或仅观察的扩展公钥）中的位置，非HD密钥为零值
+type addressSlot struct {
+	account        uint32
+	change         uint32
+	index          uint32
+	extendedKeyID  int64
+	derivationPath string
+}
 
-	// 步骤4: 生成新的密钥对
-	return s.generateNewKeyPair(userID, chainType, curve, encoding)
+// getOrCreateUserMnemonic 获取用户的BIP-39助记词，没有时生成24个单词的助记词并保存
+func (s *KeyService) getOrCreateUserMnemonic(userID string) (string, error) {
+	mnemonic, err := s.keyStore.GetUserMnemonic(userID)
+	if err == nil {
+		return mnemonic, nil
+	}
+	if !errors.Is(err, keystore.ErrMnemonicNotFound) {
+		return "", fmt.Errorf("failed to get user mnemonic: %w", err)
+	}
+
+	if mnemonic, err = crypto.NewMnemonic(256); err != nil {
+		return "", fmt.Errorf("failed to generate mnemonic: %w", err)
+	}
+	if err := s.keyStore.SaveUserMnemonic(userID, mnemonic); err != nil {
+		return "", fmt.Errorf("failed to save user mnemonic: %w", err)
+	}
+	return mnemonic, nil
 }
 
-// checkExistingAddress 检查用户是否已有该链类型的地址，有则返回对应的密钥对
-func (s *KeyService) checkExistingAddress(userID, chainType string) (*model.KeyPair, error) {
+// checkExistingAddress 检查用户是否已有该链类型、编码方式、账户和地址索引的地址，有则返回对应的密钥对
+func (s *KeyService) checkExistingAddress(userID, chainType, encoding string, account, index uint32) (*model.KeyPair, error) {
 	var existingAddress model.Address
-	has, err := s.db.Where("user_id = ? AND chain_type = ?", userID, chainType).Get(&existingAddress)
+	has, err := s.db.Where("user_id = ? AND chain_type = ? AND encoding = ? AND account = ? AND change_index = 0 AND address_index = ? AND extended_key_id = 0",
+		userID, chainType, encoding, account, index).Get(&existingAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing address: %w", err)
 	}
@@ -81,7 +271,7 @@ func (s *KeyService) checkExistingAddress(userID, chainType string) (*model.KeyP
 	// 如果地址已存在，返回对应的密钥对
 	if has {
 		var existingPublicKey model.PublicKey
-		has, err := s.db.Where("public_key = ?", existingAddress.PublicKey).Get(&existingPublicKey)
+		has, err := s.db.Where("user_id = ? AND public_key = ?", existingAddress.UserID, existingAddress.PublicKey).Get(&existingPublicKey)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get existing public key: %w", err)
 		}
@@ -98,47 +288,58 @@ func (s *KeyService) checkExistingAddress(userID, chainType string) (*model.KeyP
 }
 
 // deriveKeyPairFromExisting 从已有密钥对推导新链类型的密钥对
-func (s *KeyService) deriveKeyPairFromExisting(existingPublicKeys []model.PublicKey, userID, chainType, curve, encoding string) (*model.KeyPair, error) {
+func (s *KeyService) deriveKeyPairFromExisting(existingPublicKeys []model.PublicKey, userID, chainType, curve, encoding string, opts GenerateKeyPairOptions) (*model.KeyPair, error) {
 	// 创建密钥生成器
-	generator, err := crypto.NewKeyGenerator(chainType)
+	generator, err := newKeyGenerator(chainType, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create key generator: %w", err)
 	}
 
-	// 选择第一个使用相同曲线的公钥
+	// 选择第一个使用相同曲线的公钥，私钥按该公钥的地址获取，与公钥一定匹配
 	publicKey := existingPublicKeys[0].PublicKey
-	benchmarkChainType := existingPublicKeys[0].ChainType
+	privateKey, err := s.getPublicKeyPrivateKey(&existingPublicKeys[0])
+	if err != nil {
+		// 如果获取私钥失败，回退到生成新密钥对
+		return s.generateNewKeyPair(userID, chainType, curve, encoding, opts)
+	}
 
 	// 优先尝试直接从公钥生成新链类型的地址
-	addressValue, err := generator.PublicKeyToAddress(publicKey)
-	if err == nil {
-		// 获取基准链类型的私钥（用于保存）
-		var privateKey string
-		if privateKey, err = s.keyStore.GetUserPrivateKey(userID, benchmarkChainType); err != nil {
-			// 如果获取私钥失败，回退到生成新密钥对
-			return s.generateNewKeyPair(userID, chainType, curve, encoding)
-		}
-
+	if addressValue, err := generator.PublicKeyToAddress(publicKey); err == nil {
 		// 保存新的公钥和地址到数据库
-		return s.saveDerivedKeyPair(userID, chainType, curve, encoding, publicKey, addressValue, privateKey)
+		return s.saveDerivedKeyPair(userID, chainType, curve, encoding, publicKey, addressValue, privateKey, addressSlot{})
 	}
 
 	// 如果从公钥生成地址失败，回退到从私钥推导
-	privateKey, err := s.keyStore.GetUserPrivateKey(userID, benchmarkChainType)
-	if err != nil {
-		// 如果获取私钥失败，回退到生成新密钥对
-		return s.generateNewKeyPair(userID, chainType, curve, encoding)
-	}
-
-	// 从现有私钥推导公钥和地址
 	addressValue, publicKeyValue, err := generator.DeriveKeyPairFromPrivateKey(privateKey)
 	if err != nil {
 		// 如果推导失败，回退到生成新密钥对
-		return s.generateNewKeyPair(userID, chainType, curve, encoding)
+		return s.generateNewKeyPair(userID, chainType, curve, encoding, opts)
 	}
 
 	// 保存新的公钥和地址到数据库
-	return s.saveDerivedKeyPair(userID, chainType, curve, encoding, publicKeyValue, addressValue, privateKey)
+	return s.saveDerivedKeyPair(userID, chainType, curve, encoding, publicKeyValue, addressValue, privateKey, addressSlot{})
+}
+
+// getPublicKeyPrivateKey 按公钥的地址从keystore获取对应的私钥，HSM中的密钥不能用于推导其他链的密钥对
+func (s *KeyService) getPublicKeyPrivateKey(publicKey *model.PublicKey) (string, error) {
+	var address model.Address
+	has, err := s.db.Where("user_id = ? AND public_key = ? AND extended_key_id = 0", publicKey.UserID, publicKey.PublicKey).Asc("id").Get(&address)
+	if err != nil {
+		return "", fmt.Errorf("failed to get address of public key: %w", err)
+	}
+	if !has {
+		return "", errors.New("address of public key not found")
+	}
+
+	privateKey, err := s.keyStore.GetPrivateKey(address.Address)
+	if err != nil {
+		return "", fmt.Errorf("failed to get private key: %w", err)
+	}
+	defer crypto.Zeroize(privateKey)
+	if hsm.IsKeyURI(privateKey) {
+		return "", errors.New("hsm keys cannot be shared with other chains")
+	}
+	return string(privateKey), nil
 }
 
 // GetUserKeyPairs 获取用户的所有密钥对
@@ -147,27 +348,33 @@ func (s *KeyService) GetUserKeyPairs(userID string) ([]*model.KeyPair, error) {
 		return nil, errors.New("userID is required")
 	}
 
-	// 查询公钥
-	var publicKeys []*model.PublicKey
-	err := s.db.Where("user_id = ?", userID).Find(&publicKeys)
+	// 查询地址，同一公钥可以对应多个地址（如EVM链共用的密钥、Cardano同一账户的多个地址）
+	var addresses []*model.Address
+	err := s.db.Where("user_id = ?", userID).Asc("chain_type", "extended_key_id", "account", "change_index", "address_index", "id").Find(&addresses)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get public keys: %w", err)
-	}
-
-	// 查询对应的地址
-	keyPairs := make([]*model.KeyPair, 0, len(publicKeys))
-	for _, pk := range publicKeys {
-		address := &model.Address{}
-		has, err := s.db.Where("public_key = ?", pk.PublicKey).Get(address)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get address for public key: %w", err)
-		}
-		if has {
-			keyPairs = append(keyPairs, &model.KeyPair{
-				PublicKey: pk,
-				Address:   address,
-			})
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	// 查询对应的公钥
+	publicKeys := make(map[string]*model.PublicKey)
+	keyPairs := make([]*model.KeyPair, 0, len(addresses))
+	for _, address := range addresses {
+		pk, ok := publicKeys[address.PublicKey]
+		if !ok {
+			pk = &model.PublicKey{}
+			has, err := s.db.Where("user_id = ? AND public_key = ?", address.UserID, address.PublicKey).Get(pk)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get public key for address: %w", err)
+			}
+			if !has {
+				continue
+			}
+			publicKeys[address.PublicKey] = pk
 		}
+		keyPairs = append(keyPairs, &model.KeyPair{
+			PublicKey: pk,
+			Address:   address,
+		})
 	}
 
 	return keyPairs, nil
@@ -188,7 +395,7 @@ func (s *KeyService) GetKeyPairByID(id int64) (*model.KeyPair, error) {
 
 	// 然后查找对应的公钥
 	publicKey := &model.PublicKey{}
-	has, err = s.db.Where("public_key = ?", address.PublicKey).Get(publicKey)
+	has, err = s.db.Where("user_id = ? AND public_key = ?", address.UserID, address.PublicKey).Get(publicKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get public key: %w", err)
 	}
@@ -204,7 +411,7 @@ func (s *KeyService) GetKeyPairByID(id int64) (*model.KeyPair, error) {
 	return keyPair, nil
 }
 
-// GetKeyPairByAddress 获取指定地址的密钥对
+// GetKeyPairByAddress 获取指定地址的密钥对，地址属于多个链时返回最先保存的
 // 注意：此方法不返回私钥，私钥需要通过GetPrivateKey方法单独获取
 func (s *KeyService) GetKeyPairByAddress(addressValue string) (*model.KeyPair, error) {
 	if addressValue == "" {
@@ -213,7 +420,7 @@ func (s *KeyService) GetKeyPairByAddress(addressValue string) (*model.KeyPair, e
 
 	// 先查找地址
 	address := &model.Address{}
-	has, err := s.db.Where("address = ?", addressValue).Get(address)
+	has, err := s.db.Where("address = ?", addressValue).Asc("id").Get(address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get address: %w", err)
 	}
@@ -223,7 +430,7 @@ func (s *KeyService) GetKeyPairByAddress(addressValue string) (*model.KeyPair, e
 
 	// 然后查找对应的公钥
 	publicKey := &model.PublicKey{}
-	has, err = s.db.Where("public_key = ?", address.PublicKey).Get(publicKey)
+	has, err = s.db.Where("user_id = ? AND public_key = ?", address.UserID, address.PublicKey).Get(publicKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get public key: %w", err)
 	}
@@ -239,31 +446,65 @@ func (s *KeyService) GetKeyPairByAddress(addressValue string) (*model.KeyPair, e
 	return keyPair, nil
 }
 
-// GetPrivateKey 获取指定地址的私钥
-func (s *KeyService) GetPrivateKey(addressValue string) (string, error) {
+// GetPrivateKey 获取指定地址的私钥，返回的字节使用后应调用crypto.Zeroize清零
+func (s *KeyService) GetPrivateKey(addressValue string) ([]byte, error) {
 	if addressValue == "" {
-		return "", errors.New("address is required")
+		return nil, errors.New("address is required")
 	}
 
-	// 验证该地址是否存在
+	// 验证该地址是否存在，地址属于多个链时优先使用持有私钥的记录
 	address := &model.Address{}
-	has, err := s.db.Where("address = ?", addressValue).Get(address)
+	has, err := s.db.Where("address = ?", addressValue).Asc("extended_key_id").Get(address)
 	if err != nil {
-		return "", fmt.Errorf("failed to verify address: %w", err)
+		return nil, fmt.Errorf("failed to verify address: %w", err)
 	}
 	if !has {
-		return "", errors.New("address not found")
+		return nil, errors.New("address not found")
+	}
+	if address.ExtendedKeyID != 0 {
+		return nil, fmt.Errorf("address %s is watch-only", addressValue)
+	}
+	if address.Stale {
+		return nil, fmt.Errorf("address %s is stale and cannot be used for signing", addressValue)
 	}
 
 	// 从文件系统获取私钥
 	privateKey, err := s.keyStore.GetPrivateKey(addressValue)
 	if err != nil {
-		return "", fmt.Errorf("failed to get private key: %w", err)
+		return nil, fmt.Errorf("failed to get private key: %w", err)
 	}
 
 	return privateKey, nil
 }
 
+// SigningKey 获取指定地址的签名密钥，keystore中保存的是HSM密钥引用时返回HSM中的密钥
+// 内存中的签名密钥使用后应调用crypto.ZeroizeKey清除
+func (s *KeyService) SigningKey(chainType, addressValue string) (crypto.Signer, error) {
+	privateKey, err := s.GetPrivateKey(addressValue)
+	if err != nil {
+		return nil, err
+	}
+	// 解析后清零keystore返回的私钥
+	defer crypto.Zeroize(privateKey)
+
+	if hsm.IsKeyURI(privateKey) {
+		if s.hsm == nil {
+			return nil, errors.New("hsm is not configured")
+		}
+		key, err := s.hsm.SigningKey(string(privateKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hsm key: %w", err)
+		}
+		return key, nil
+	}
+
+	key, err := crypto.ParsePrivateKey(chainType, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return key, nil
+}
+
 // DeleteKeyPair 删除指定ID的密钥对
 func (s *KeyService) DeleteKeyPair(id int64) error {
 	// 获取密钥对
@@ -275,21 +516,49 @@ func (s *KeyService) DeleteKeyPair(id int64) error {
 		return nil
 	}
 
-	// 删除私钥文件
-	if err = s.keyStore.DeletePrivateKey(keyPair.Address.Address); err != nil {
+	// 删除私钥文件，仅观察的地址没有私钥
+	if keyPair.Address.ExtendedKeyID == 0 {
+		if err = s.deleteAddressPrivateKey(keyPair.Address); err != nil {
+			return err
+		}
+	}
+
+	return s.deleteKeyPairRecords(keyPair)
+}
+
+// deleteAddressPrivateKey 删除按地址保存的私钥，其他链的相同地址仍在使用时保留
+func (s *KeyService) deleteAddressPrivateKey(address *model.Address) error {
+	shared, err := s.db.Where("address = ? AND extended_key_id = 0 AND id <> ?", address.Address, address.ID).Exist(&model.Address{})
+	if err != nil {
+		return fmt.Errorf("failed to check addresses of private key: %w", err)
+	}
+	if shared {
+		return nil
+	}
+	if err := s.keyStore.DeletePrivateKey(address.Address); err != nil {
 		return fmt.Errorf("failed to delete private key: %w", err)
 	}
+	return nil
+}
 
+// deleteKeyPairRecords 从数据库删除地址，公钥没有其他地址使用时一并删除
+func (s *KeyService) deleteKeyPairRecords(keyPair *model.KeyPair) error {
 	// 从数据库删除地址
-	_, err = s.db.ID(keyPair.Address.ID).Delete(&model.Address{})
+	_, err := s.db.ID(keyPair.Address.ID).Delete(&model.Address{})
 	if err != nil {
 		return fmt.Errorf("failed to delete address from database: %w", err)
 	}
 
-	// 从数据库删除公钥
-	_, err = s.db.ID(keyPair.PublicKey.ID).Delete(&model.PublicKey{})
+	// 公钥没有其他地址使用时，从数据库删除公钥
+	inUse, err := s.db.Exist(&model.Address{UserID: keyPair.PublicKey.UserID, PublicKey: keyPair.PublicKey.PublicKey})
 	if err != nil {
-		return fmt.Errorf("failed to delete public key from database: %w", err)
+		return fmt.Errorf("failed to check addresses of public key: %w", err)
+	}
+	if !inUse {
+		_, err = s.db.ID(keyPair.PublicKey.ID).Delete(&model.PublicKey{})
+		if err != nil {
+			return fmt.Errorf("failed to delete public key from database: %w", err)
+		}
 	}
 
 	return nil
@@ -301,9 +570,9 @@ func (s *KeyService) GetUserPrivateKey(userID, chainType string) (string, error)
 }
 
 // generateNewKeyPair 生成新的密钥对并保存
-func (s *KeyService) generateNewKeyPair(userID, chainType, curve, encoding string) (*model.KeyPair, error) {
+func (s *KeyService) generateNewKeyPair(userID, chainType, curve, encoding string, opts GenerateKeyPairOptions) (*model.KeyPair, error) {
 	// 创建密钥生成器
-	generator, err := crypto.NewKeyGenerator(chainType)
+	generator, err := newKeyGenerator(chainType, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create key generator: %w", err)
 	}
@@ -326,40 +595,144 @@ func (s *KeyService) generateNewKeyPair(userID, chainType, curve, encoding strin
 	}
 
 	// 保存公钥和地址到数据库
-	return s.saveKeyPairToDatabase(userID, chainType, curve, encoding, publicKeyValue, addressValue)
+	keyPair, err := s.saveKeyPairToDatabase(userID, chainType, curve, encoding, publicKeyValue, addressValue, addressSlot{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cardano同时保存权益凭证对应的奖励地址，保存失败时删除已保存的基本地址
+	if util.IsCardanoChain(chainType) {
+		if err := s.saveCardanoRewardAddress(userID, chainType, curve, publicKeyValue, privateKey, addressSlot{}); err != nil {
+			return nil, errors.Join(err, s.deleteAddressPrivateKey(keyPair.Address), s.deleteKeyPairRecords(keyPair))
+		}
+	}
+
+	return keyPair, nil
 }
 
-// saveDerivedKeyPair 保存从现有私钥推导的公钥和地址
-func (s *KeyService) saveDerivedKeyPair(userID, chainType, curve, encoding, publicKeyValue, addressValue, privateKey string) (*model.KeyPair, error) {
-	// 保存私钥按用户ID索引（如果还没有保存的话）
-	if err := s.keyStore.SaveUserPrivateKey(userID, chainType, privateKey); err != nil {
-		return nil, fmt.Errorf("failed to save private key by user ID: %w", err)
+// generateHSMKeyPair 在HSM中生成密钥对，按地址保存密钥引用
+// 私钥不离开HSM，不按用户ID保存，因此不会被相同曲线的其他链复用
+func (s *KeyService) generateHSMKeyPair(userID, chainType, curve, encoding string, generator crypto.KeyGenerator) (*model.KeyPair, error) {
+	if s.hsm == nil {
+		return nil, errors.New("hsm is not configured")
 	}
 
+	addressValue, publicKeyValue, keyURI, err := s.hsm.GenerateKeyPair(chainType, generator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate hsm key pair: %w", err)
+	}
+	if err := s.keyStore.SavePrivateKey(addressValue, keyURI); err != nil {
+		return nil, fmt.Errorf("failed to save hsm key reference by address: %w", err)
+	}
+
+	keyPair, err := s.saveKeyPairToDatabase(userID, chainType, curve, encoding, publicKeyValue, addressValue, addressSlot{})
+	if err != nil {
+		s.keyStore.DeletePrivateKey(addressValue)
+		return nil, err
+	}
+	return keyPair, nil
+}
+
+// saveCardanoRewardAddress 保存Cardano账户扩展公钥（acct_xvk）派生的奖励地址
+// 基本地址由支付密钥（role 0）和权益密钥（role 2）组成，奖励地址与基本地址共用账户密钥，签名时通过 signing_paths 选择权益密钥
+// 奖励地址已存在时（同一账户的其他地址索引已保存）直接跳过
+func (s *KeyService) saveCardanoRewardAddress(userID, chainType, curve, publicKeyValue, privateKey string, slot addressSlot) error {
+	generator, err := crypto.NewAdaKeyGenerator(chainType)
+	if err != nil {
+		return err
+	}
+	rewardAddress, err := generator.RewardAddress(publicKeyValue)
+	if err != nil {
+		return fmt.Errorf("failed to derive cardano reward address: %w", err)
+	}
+	if exists, err := s.db.Exist(&model.Address{ChainType: chainType, Address: rewardAddress}); err != nil {
+		return fmt.Errorf("failed to check existing address: %w", err)
+	} else if exists {
+		return nil
+	}
+
+	if err := s.keyStore.SavePrivateKey(rewardAddress, privateKey); err != nil {
+		return fmt.Errorf("failed to save private key by address: %w", err)
+	}
+	if _, err := s.saveKeyPairToDatabase(userID, chainType, curve, util.CardanoRewardAddressEncoding, publicKeyValue, rewardAddress, slot); err != nil {
+		if deleteErr := s.keyStore.DeletePrivateKey(rewardAddress); deleteErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to delete private key: %w", deleteErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// saveDerivedKeyPair 保存从现有私钥或助记词推导的公钥和地址
+// 先保存数据库记录，私钥保存失败时删除已保存的记录和私钥，keystore中不会留下没有地址记录的私钥
+func (s *KeyService) saveDerivedKeyPair(userID, chainType, curve, encoding, publicKeyValue, addressValue, privateKey string, slot addressSlot) (*model.KeyPair, error) {
 	// 保存公钥和地址到数据库
-	return s.saveKeyPairToDatabase(userID, chainType, curve, encoding, publicKeyValue, addressValue)
+	keyPair, err := s.saveKeyPairToDatabase(userID, chainType, curve, encoding, publicKeyValue, addressValue, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按地址索引保存私钥，签名时通过地址查找私钥
+	if err := s.keyStore.SavePrivateKey(addressValue, privateKey); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to save private key by address: %w", err), s.deleteKeyPairRecords(keyPair))
+	}
+
+	// 保存私钥按用户ID索引，HD派生的私钥只按地址保存，避免同一链的其他账户和地址索引相互覆盖
+	if slot.derivationPath != "" {
+		return keyPair, nil
+	}
+	if err := s.keyStore.SaveUserPrivateKey(userID, chainType, privateKey); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to save private key by user ID: %w", err),
+			s.deleteAddressPrivateKey(keyPair.Address), s.deleteKeyPairRecords(keyPair))
+	}
+
+	return keyPair, nil
 }
 
 // saveKeyPairToDatabase 将公钥和地址保存到数据库
-func (s *KeyService) saveKeyPairToDatabase(userID, chainType, curve, encoding, publicKeyValue, addressValue string) (*model.KeyPair, error) {
-	// 创建公钥记录
-	publicKey := &model.PublicKey{
-		PublicKey: publicKeyValue,
-		UserID:    userID,
-		Curve:     curve,
+func (s *KeyService) saveKeyPairToDatabase(userID, chainType, curve, encoding, publicKeyValue, addressValue string, slot addressSlot) (*model.KeyPair, error) {
+	// 同一公钥可以对应用户的多个地址（如比特币的不同地址类型），已存在时直接复用
+	// 不同用户导入同一私钥时各自保存公钥记录，不共用
+	existingPublicKey := &model.PublicKey{}
+	has, err := s.db.Where("user_id = ? AND public_key = ?", userID, publicKeyValue).Get(existingPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing public key: %w", err)
 	}
 
 	// 创建地址记录
 	address := &model.Address{
+		PublicKey:      publicKeyValue,
+		UserID:         userID,
+		ChainType:      chainType,
+		Address:        addressValue,
+		Encoding:       encoding,
+		Account:        slot.account,
+		Change:         slot.change,
+		AddressIndex:   slot.index,
+		ExtendedKeyID:  slot.extendedKeyID,
+		DerivationPath: slot.derivationPath,
+	}
+
+	if has {
+		if _, err := s.db.Insert(address); err != nil {
+			return nil, fmt.Errorf("failed to save address: %w", err)
+		}
+		return &model.KeyPair{
+			PublicKey: existingPublicKey,
+			Address:   address,
+		}, nil
+	}
+
+	// 创建公钥记录
+	publicKey := &model.PublicKey{
 		PublicKey: publicKeyValue,
 		UserID:    userID,
 		ChainType: chainType,
-		Address:   addressValue,
-		Encoding:  encoding,
+		Curve:     curve,
 	}
 
 	// 保存公钥到数据库
-	_, err := s.db.Insert(publicKey)
+	_, err = s.db.Insert(publicKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save public key: %w", err)
 	}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/featx/keys-gin/lib/keystore"
+)
+
+// KEKRotationStatus KEK轮换状态
+type KEKRotationStatus struct {
+	keystore.RotationProgress
+	Running bool   `json:"running"` // 是否正在重新包装DEK
+	Pending bool   `json:"pending"` // 是否有未完成的轮换，未运行时需调用恢复接口继续
+	Error   string `json:"error,omitempty"`
+}
+
+// errKEKRotationUnsupported 存储后端不支持KEK轮换
+var errKEKRotationUnsupported = errors.New("keystore backend does not support kek rotation")
+
+// KeystoreService keystore管理服务
+// KEK轮换仅支持file和sql后端（keystore.KEKRotator），vault后端的密钥由Vault的transit引擎轮换
+type KeystoreService struct {
+	keyStore keystore.KEKRotator
+
+	mu     sync.Mutex
+	status KEKRotationStatus
+}
+
+// NewKeystoreService 创建keystore管理服务
+func NewKeystoreService(keyStore keystore.KeyStore) (*KeystoreService, error) {
+	rotator, _ := keyStore.(keystore.KEKRotator)
+	return &KeystoreService{keyStore: rotator}, nil
+}
+
+// RotateKEK 用新主密码生成新版本的KEK，并在后台把所有DEK重新包装到新KEK下
+// 新KEK在返回前已生效，之后重启服务需要使用新主密码
+func (s *KeystoreService) RotateKEK(currentPassphrase, newPassphrase string) (KEKRotationStatus, error) {
+	if s.keyStore == nil {
+		return KEKRotationStatus{}, errKEKRotationUnsupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.Running {
+		return s.status, errors.New("kek rotation is already running")
+	}
+	if _, err := s.keyStore.StartKEKRotation(currentPassphrase, newPassphrase); err != nil {
+		return s.statusLocked(), err
+	}
+	s.startRewrap()
+	return s.status, nil
+}
+
+// ResumeKEKRotation 在后台继续未完成的KEK轮换，例如服务在重新包装过程中重启或出错后
+func (s *KeystoreService) ResumeKEKRotation() (KEKRotationStatus, error) {
+	if s.keyStore == nil {
+		return KEKRotationStatus{}, errKEKRotationUnsupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.Running {
+		return s.status, errors.New("kek rotation is already running")
+	}
+	if !s.keyStore.RotationPending() {
+		return s.statusLocked(), errors.New("no kek rotation in progress")
+	}
+	s.startRewrap()
+	return s.status, nil
+}
+
+// KEKRotationStatus 获取KEK版本和轮换进度，存储后端不支持KEK轮换时返回错误
+func (s *KeystoreService) KEKRotationStatus() (KEKRotationStatus, error) {
+	if s.keyStore == nil {
+		return KEKRotationStatus{}, errKEKRotationUnsupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statusLocked(), nil
+}
+
+// statusLocked 返回当前状态，未运行时刷新KEK版本和是否有未完成的轮换，调用方需持有锁
+func (s *KeystoreService) statusLocked() KEKRotationStatus {
+	if !s.status.Running {
+		s.status.KEKVersion = s.keyStore.KEKVersion()
+		s.status.Pending = s.keyStore.RotationPending()
+	}
+	return s.status
+}
+
+// startRewrap 启动后台重新包装，调用方需持有锁
+func (s *KeystoreService) startRewrap() {
+	s.status = KEKRotationStatus{
+		RotationProgress: keystore.RotationProgress{KEKVersion: s.keyStore.KEKVersion()},
+		Running:          true,
+		Pending:          true,
+	}
+
+	go func() {
+		progress, err := s.keyStore.ResumeKEKRotation(func(progress keystore.RotationProgress) {
+			s.mu.Lock()
+			s.status.RotationProgress = progress
+			s.mu.Unlock()
+		})
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.status.RotationProgress = progress
+		s.status.Running = false
+		s.status.Pending = s.keyStore.RotationPending()
+		if err != nil {
+			s.status.Error = err.Error()
+		}
+	}()
+}
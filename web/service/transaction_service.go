@@ -41,11 +41,13 @@ func (s *TransactionService) SignTransaction(keyPairID int64, rawTx string) (*mo
 		return nil, errors.New("key pair not found")
 	}
 
-	// 获取私钥（从文件系统）
-	privateKey, err := s.keyService.GetPrivateKey(keyPair.Address.Address)
+	// 获取签名密钥，keystore中保存的是HSM密钥引用时由HSM签名
+	key, err := s.keyService.SigningKey(keyPair.Address.ChainType, keyPair.Address.Address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get private key: %w", err)
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
 	}
+	// 签名后清除内存中的私钥
+	defer crypto.ZeroizeKey(key)
 
 	// 创建交易签名器
 	signer, err := crypto.NewTransactionSigner(keyPair.Address.ChainType)
@@ -54,7 +56,7 @@ func (s *TransactionService) SignTransaction(keyPairID int64, rawTx string) (*mo
 	}
 
 	// 签名交易
-	signedTx, txHash, err := signer.SignTransaction(rawTx, privateKey)
+	signedTx, txHash, err := signer.SignTransaction(rawTx, key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign transaction: %w", err)
 	}
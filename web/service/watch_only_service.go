@@ -0,0 +1,315 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/featx/keys-gin/web/model"
+	"github.com/featx/keys-gin/web/util"
+)
+
+const (
+	// DefaultGapLimit 默认的地址间隔上限（BIP-44），连续这么多个未分配的地址之后不再有已分配的地址
+	DefaultGapLimit uint32 = 20
+	// maxGapLimit 地址间隔上限的最大值
+	maxGapLimit uint32 = 1000
+	// maxScanAddresses 一次扫描最多派生的地址数量
+	maxScanAddresses uint32 = 1000
+)
+
+// RegisterExtendedPublicKey 为用户注册仅观察的账户扩展公钥（xpub/ypub/zpub等）
+// 地址由扩展公钥按 change/index 非硬化派生，本节点不保存私钥，签名在持有助记词的节点上完成
+// 比特币的ypub/zpub（upub/vpub）隐含地址类型，xpub（tpub）按 address_type 选择，默认p2pkh
+func (s *KeyService) RegisterExtendedPublicKey(userID, chainType, extendedKey string, opts GenerateKeyPairOptions) (*model.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if extendedKey == "" {
+		return nil, errors.New("extended public key is required")
+	}
+	xpub, err := crypto.ParseExtendedPublicKey(extendedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if util.IsBitcoinChain(chainType) {
+		if xpub.Testnet() != (chainType != model.ChainTypeBTC) {
+			return nil, fmt.Errorf("extended public key network does not match chain type %s", chainType)
+		}
+		if xpub.AddressType() != "" {
+			if opts.AddressType != "" && opts.AddressType != xpub.AddressType() {
+				return nil, fmt.Errorf("address type %s does not match extended public key of %s", opts.AddressType, xpub.AddressType())
+			}
+			opts.AddressType = xpub.AddressType()
+		}
+	} else if xpub.Testnet() || xpub.AddressType() != "" {
+		return nil, fmt.Errorf("only xpub extended public keys are supported for chain type %s", chainType)
+	}
+
+	curve, encoding, err := keyPairEncoding(userID, chainType, opts)
+	if err != nil {
+		return nil, err
+	}
+	if curve != "secp256k1" {
+		return nil, fmt.Errorf("chain type %s does not support extended public keys", chainType)
+	}
+
+	// 校验扩展公钥可以派生该链的地址
+	generator, err := newKeyGenerator(chainType, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key generator: %w", err)
+	}
+	if _, err := deriveWatchOnlyAddress(xpub, generator, 0, 0); err != nil {
+		return nil, err
+	}
+
+	// 同一用户的扩展公钥只能注册一次，重复注册时返回已有的记录
+	existing := &model.PublicKey{}
+	has, err := s.db.Where("user_id = ? AND public_key = ?", userID, xpub.String()).Get(existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing public key: %w", err)
+	}
+	if has {
+		if existing.ChainType != chainType || existing.Encoding != encoding {
+			return nil, errors.New("extended public key is already registered")
+		}
+		return existing, nil
+	}
+
+	publicKey := &model.PublicKey{
+		PublicKey: xpub.String(),
+		UserID:    userID,
+		ChainType: chainType,
+		Curve:     curve,
+		Encoding:  encoding,
+	}
+	if _, err := s.db.Insert(publicKey); err != nil {
+		return nil, fmt.Errorf("failed to save extended public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+// GetExtendedPublicKey 获取指定ID的仅观察扩展公钥，不存在时返回nil
+func (s *KeyService) GetExtendedPublicKey(id int64) (*model.PublicKey, error) {
+	publicKey := &model.PublicKey{}
+	has, err := s.db.ID(id).Get(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extended public key: %w", err)
+	}
+	if !has || publicKey.Encoding == "" {
+		return nil, nil
+	}
+	return publicKey, nil
+}
+
+// DeriveWatchOnlyAddress 从仅观察的扩展公钥分配 change/index 的地址并保存
+// index为nil时分配该找零层级下已分配的最大索引加1；指定的索引与已分配的最大索引之间未分配的地址不能达到gapLimit个，
+// 保证钱包按相同的地址间隔上限扫描时能找到所有已分配的地址。地址已分配时直接返回
+func (s *KeyService) DeriveWatchOnlyAddress(id int64, change uint32, index *uint32, gapLimit uint32) (*model.KeyPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	publicKey, xpub, generator, err := s.watchOnlyAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if gapLimit, err = normalizeGapLimit(gapLimit); err != nil {
+		return nil, err
+	}
+	if change > 1 {
+		return nil, errors.New("change must be 0 or 1")
+	}
+
+	var last model.Address
+	has, err := s.db.Where("extended_key_id = ? AND change_index = ?", id, change).Desc("address_index").Get(&last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last address index: %w", err)
+	}
+	var next uint32
+	if has {
+		next = last.AddressIndex + 1
+	}
+
+	if index == nil {
+		index = &next
+	} else if *index >= next+gapLimit {
+		return nil, fmt.Errorf("address index %d exceeds gap limit %d after the last issued address", *index, gapLimit)
+	}
+
+	// 地址已分配时直接返回
+	var existing model.Address
+	has, err = s.db.Where("extended_key_id = ? AND change_index = ? AND address_index = ?", id, change, *index).Get(&existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing address: %w", err)
+	}
+	if has {
+		return s.GetKeyPairByID(existing.ID)
+	}
+
+	derived, err := deriveWatchOnlyAddress(xpub, generator, change, *index)
+	if err != nil {
+		return nil, err
+	}
+	slot := addressSlot{change: change, index: *index, extendedKeyID: id, derivationPath: derived.DerivationPath}
+	return s.saveKeyPairToDatabase(publicKey.UserID, publicKey.ChainType, publicKey.Curve, publicKey.Encoding, derived.PublicKey, derived.Address, slot)
+}
+
+// ScanWatchOnlyAddresses 从仅观察的扩展公钥派生 change 层级从start开始的地址，不保存
+// count大于0时派生固定数量的地址；否则一直派生到连续gapLimit个未分配的地址为止
+func (s *KeyService) ScanWatchOnlyAddresses(id int64, change, start, count, gapLimit uint32) ([]*model.DerivedAddress, error) {
+	_, xpub, generator, err := s.watchOnlyAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if gapLimit, err = normalizeGapLimit(gapLimit); err != nil {
+		return nil, err
+	}
+	if change > 1 {
+		return nil, errors.New("change must be 0 or 1")
+	}
+	if count > maxScanAddresses {
+		return nil, fmt.Errorf("count must not exceed %d", maxScanAddresses)
+	}
+
+	// 已分配的地址索引
+	var addresses []model.Address
+	if err := s.db.Where("extended_key_id = ? AND change_index = ?", id, change).Cols("address_index").Find(&addresses); err != nil {
+		return nil, fmt.Errorf("failed to get issued addresses: %w", err)
+	}
+	issued := make(map[uint32]bool, len(addresses))
+	for _, address := range addresses {
+		issued[address.AddressIndex] = true
+	}
+
+	derivedAddresses := make([]*model.DerivedAddress, 0)
+	var unused uint32
+	for index := start; index < crypto.HardenedKeyStart && uint32(len(derivedAddresses)) < maxScanAddresses; index++ {
+		if count > 0 && index-start >= count {
+			break
+		}
+		if count == 0 && unused >= gapLimit {
+			break
+		}
+
+		derived, err := deriveWatchOnlyAddress(xpub, generator, change, index)
+		if err != nil {
+			return nil, err
+		}
+		derived.Issued = issued[index]
+		derivedAddresses = append(derivedAddresses, derived)
+
+		if derived.Issued {
+			unused = 0
+		} else {
+			unused++
+		}
+	}
+
+	return derivedAddresses, nil
+}
+
+// ExportExtendedPublicKey 导出用户助记词在指定账户下的扩展公钥，用于在不持有私钥的节点上注册仅观察账户
+// 返回：扩展公钥、账户的派生路径、错误
+func (s *KeyService) ExportExtendedPublicKey(userID, chainType string, opts GenerateKeyPairOptions) (extendedKey, path string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	curve, _, err := keyPairEncoding(userID, chainType, opts)
+	if err != nil {
+		return "", "", err
+	}
+	if curve != "secp256k1" {
+		return "", "", fmt.Errorf("chain type %s does not support extended public keys", chainType)
+	}
+
+	generator, err := newKeyGenerator(chainType, opts)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create key generator: %w", err)
+	}
+	hdGenerator, ok := generator.(crypto.HDKeyGenerator)
+	if !ok {
+		return "", "", fmt.Errorf("chain type %s does not support extended public keys", chainType)
+	}
+	if path, err = crypto.AccountDerivationPath(hdGenerator, opts.Account); err != nil {
+		return "", "", err
+	}
+
+	mnemonic, err := s.getOrCreateUserMnemonic(userID)
+	if err != nil {
+		return "", "", err
+	}
+	testnet := util.IsBitcoinChain(chainType) && chainType != model.ChainTypeBTC
+	if extendedKey, err = crypto.DeriveExtendedPublicKey(mnemonic, "", path, opts.AddressType, testnet); err != nil {
+		return "", "", fmt.Errorf("failed to derive extended public key: %w", err)
+	}
+	return extendedKey, path, nil
+}
+
+// watchOnlyAccount 获取仅观察的扩展公钥及其地址的密钥生成器
+func (s *KeyService) watchOnlyAccount(id int64) (*model.PublicKey, *crypto.ExtendedPublicKey, crypto.KeyGenerator, error) {
+	publicKey, err := s.GetExtendedPublicKey(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if publicKey == nil {
+		return nil, nil, nil, fmt.Errorf("extended public key %d not found", id)
+	}
+
+	xpub, err := crypto.ParseExtendedPublicKey(publicKey.PublicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	generator, err := newKeyGenerator(publicKey.ChainType, watchOnlyKeyOptions(publicKey.ChainType, publicKey.Encoding))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create key generator: %w", err)
+	}
+	return publicKey, xpub, generator, nil
+}
+
+// watchOnlyKeyOptions 根据注册时保存的地址编码还原密钥生成器的参数
+func watchOnlyKeyOptions(chainType, encoding string) GenerateKeyPairOptions {
+	var opts GenerateKeyPairOptions
+	if util.IsBitcoinChain(chainType) {
+		for _, addressType := range []string{model.BtcAddressTypeP2SHP2WPKH, model.BtcAddressTypeP2WPKH, model.BtcAddressTypeP2TR} {
+			if util.GetBtcAddressEncoding(addressType) == encoding {
+				opts.AddressType = addressType
+			}
+		}
+	}
+	if util.IsCosmosChain(chainType) {
+		opts.Bech32Prefix = strings.TrimPrefix(encoding, util.GetCosmosAddressEncoding(""))
+	}
+	return opts
+}
+
+// deriveWatchOnlyAddress 从扩展公钥派生 change/index 的公钥和地址
+func deriveWatchOnlyAddress(xpub *crypto.ExtendedPublicKey, generator crypto.KeyGenerator, change, index uint32) (*model.DerivedAddress, error) {
+	publicKey, err := xpub.DerivePublicKey(change, index)
+	if err != nil {
+		return nil, err
+	}
+	address, err := generator.PublicKeyToAddress(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive address: %w", err)
+	}
+	return &model.DerivedAddress{
+		Change:         change,
+		AddressIndex:   index,
+		DerivationPath: crypto.ExtendedPublicKeyPath(change, index),
+		PublicKey:      publicKey,
+		Address:        address,
+	}, nil
+}
+
+// normalizeGapLimit 校验地址间隔上限，为0时使用默认值
+func normalizeGapLimit(gapLimit uint32) (uint32, error) {
+	if gapLimit == 0 {
+		return DefaultGapLimit, nil
+	}
+	if gapLimit > maxGapLimit {
+		return 0, fmt.Errorf("gap limit must not exceed %d", maxGapLimit)
+	}
+	return gapLimit, nil
+}
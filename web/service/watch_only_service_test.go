@@ -0,0 +1,145 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+	"xorm.io/xorm/names"
+
+	"github.com/featx/keys-gin/lib/keystore"
+	"github.com/featx/keys-gin/web/model"
+)
+
+// newTestStores 创建临时SQLite数据库和文件keystore
+func newTestStores(t *testing.T) (*xorm.Engine, keystore.KeyStore) {
+	t.Helper()
+	dir := t.TempDir()
+
+	engine, err := xorm.NewEngine("sqlite3", filepath.Join(dir, "keys.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { engine.Close() })
+	engine.SetMapper(names.GonicMapper{})
+	require.NoError(t, engine.Sync(&model.PublicKey{}))
+	require.NoError(t, engine.Sync(&model.Address{}))
+
+	ks, err := keystore.NewFileKeyStore(dir, "test-password", keystore.KDFConfig{Iterations: 1 << 10})
+	require.NoError(t, err)
+	return engine, ks
+}
+
+// newTestKeyService 创建使用临时SQLite数据库和文件keystore的KeyService
+func newTestKeyService(t *testing.T) *KeyService {
+	t.Helper()
+	engine, ks := newTestStores(t)
+	service, err := NewKeyService(engine, ks, nil)
+	require.NoError(t, err)
+	return service
+}
+
+// registerTestWatchOnlyAccount 导出用户的以太坊扩展公钥并注册为另一个用户的仅观察账户
+func registerTestWatchOnlyAccount(t *testing.T, service *KeyService) int64 {
+	t.Helper()
+	xpub, _, err := service.ExportExtendedPublicKey("signer", model.ChainTypeETH, GenerateKeyPairOptions{})
+	require.NoError(t, err)
+	publicKey, err := service.RegisterExtendedPublicKey("watcher", model.ChainTypeETH, xpub, GenerateKeyPairOptions{})
+	require.NoError(t, err)
+	return publicKey.ID
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func TestDeriveWatchOnlyAddress_GapLimit(t *testing.T) {
+	service := newTestKeyService(t)
+	id := registerTestWatchOnlyAccount(t, service)
+	const gapLimit = 5
+
+	// 尚未分配地址时，下一个索引为0
+	_, err := service.DeriveWatchOnlyAddress(id, 0, uint32Ptr(gapLimit), gapLimit)
+	assert.Error(t, err)
+	keyPair, err := service.DeriveWatchOnlyAddress(id, 0, uint32Ptr(gapLimit-1), gapLimit)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(gapLimit-1), keyPair.Address.AddressIndex)
+
+	// 已分配索引4，下一个索引为5
+	next := uint32(gapLimit)
+	_, err = service.DeriveWatchOnlyAddress(id, 0, uint32Ptr(next+gapLimit), gapLimit)
+	assert.Error(t, err)
+	keyPair, err = service.DeriveWatchOnlyAddress(id, 0, uint32Ptr(next+gapLimit-1), gapLimit)
+	require.NoError(t, err)
+	assert.Equal(t, next+gapLimit-1, keyPair.Address.AddressIndex)
+
+	// 找零层级的地址索引独立计算
+	keyPair, err = service.DeriveWatchOnlyAddress(id, 1, nil, gapLimit)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), keyPair.Address.AddressIndex)
+}
+
+func TestDeriveWatchOnlyAddress_NextAndExisting(t *testing.T) {
+	service := newTestKeyService(t)
+	id := registerTestWatchOnlyAccount(t, service)
+
+	first, err := service.DeriveWatchOnlyAddress(id, 0, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), first.Address.AddressIndex)
+	second, err := service.DeriveWatchOnlyAddress(id, 0, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), second.Address.AddressIndex)
+	assert.NotEqual(t, first.Address.Address, second.Address.Address)
+
+	// 已分配的地址直接返回
+	again, err := service.DeriveWatchOnlyAddress(id, 0, uint32Ptr(0), 0)
+	require.NoError(t, err)
+	assert.Equal(t, first.Address.ID, again.Address.ID)
+	assert.Equal(t, first.Address.Address, again.Address.Address)
+}
+
+func TestScanWatchOnlyAddresses(t *testing.T) {
+	service := newTestKeyService(t)
+	id := registerTestWatchOnlyAccount(t, service)
+	const gapLimit = 3
+
+	// 没有已分配的地址时，扫描到连续gapLimit个未分配的地址为止
+	addresses, err := service.ScanWatchOnlyAddresses(id, 0, 0, 0, gapLimit)
+	require.NoError(t, err)
+	require.Len(t, addresses, gapLimit)
+	for i, address := range addresses {
+		assert.Equal(t, uint32(i), address.AddressIndex)
+		assert.False(t, address.Issued)
+	}
+
+	issued, err := service.DeriveWatchOnlyAddress(id, 0, uint32Ptr(2), gapLimit)
+	require.NoError(t, err)
+
+	// 已分配的地址重置未分配计数
+	addresses, err = service.ScanWatchOnlyAddresses(id, 0, 0, 0, gapLimit)
+	require.NoError(t, err)
+	require.Len(t, addresses, 2+1+gapLimit)
+	for i, address := range addresses {
+		assert.Equal(t, i == 2, address.Issued, "index %d", i)
+	}
+	assert.Equal(t, issued.Address.Address, addresses[2].Address)
+
+	// count大于0时派生固定数量的地址
+	addresses, err = service.ScanWatchOnlyAddresses(id, 0, 1, 10, gapLimit)
+	require.NoError(t, err)
+	require.Len(t, addresses, 10)
+	assert.Equal(t, uint32(1), addresses[0].AddressIndex)
+	assert.True(t, addresses[1].Issued)
+
+	// 找零层级没有已分配的地址
+	addresses, err = service.ScanWatchOnlyAddresses(id, 1, 0, 0, gapLimit)
+	require.NoError(t, err)
+	require.Len(t, addresses, gapLimit)
+	assert.False(t, addresses[0].Issued)
+
+	_, err = service.ScanWatchOnlyAddresses(id, 2, 0, 0, gapLimit)
+	assert.Error(t, err)
+	_, err = service.ScanWatchOnlyAddresses(id, 0, 0, maxScanAddresses+1, gapLimit)
+	assert.Error(t, err)
+}
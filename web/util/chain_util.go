@@ -1,27 +1,77 @@
 package util
 
-import "github.com/featx/keys-gin/web/model"
+import (
+	"github.com/featx/keys-gin/lib/crypto"
+	"github.com/featx/keys-gin/web/model"
+)
 
-// GetCurveAndEncoding 根据链类型获取对应的曲线类型和编码方式
+// CardanoRewardAddressEncoding Cardano奖励地址（权益凭证）的编码方式
+const CardanoRewardAddressEncoding = "cardano_reward_address"
+
+// GetCurveAndEncoding 根据链类型获取对应的曲线类型和编码方式，取自链注册表
 func GetCurveAndEncoding(chainType string) (string, string) {
+	module, ok := crypto.LookupChain(chainType)
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return module.Curve, module.Encoding
+}
+
+// IsBitcoinChain 判断链类型是否为比特币（主网或任一测试网络）
+func IsBitcoinChain(chainType string) bool {
 	switch chainType {
-	case model.ChainTypeETH, model.ChainTypeAvalanche:
-		return "secp256k1", "ethereum_address"
-	case model.ChainTypeBTC:
-		return "secp256k1", "bitcoin_public_key"
-	case model.ChainTypeSolana:
-		return "ed25519", "solana_address"
-	case model.ChainTypeTRON:
-		return "secp256k1", "tron_address"
-	case model.ChainTypeSUI:
-		return "ed25519", "sui_address"
-	case model.ChainTypeADA:
-		return "ed25519", "cardano_address"
-	case model.ChainTypePolkadot, model.ChainTypeKusama:
-		return "sr25519", "ss58_address"
-	case model.ChainTypeTON:
-		return "ed25519", "ton_address"
+	case model.ChainTypeBTC, model.ChainTypeBTCTestnet, model.ChainTypeBTCSignet, model.ChainTypeBTCRegtest:
+		return true
 	default:
-		return "unknown", "unknown"
+		return false
+	}
+}
+
+// IsCardanoChain 判断链类型是否为Cardano（含测试网）
+func IsCardanoChain(chainType string) bool {
+	switch chainType {
+	case model.ChainTypeADA, model.ChainTypeADATestnet:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCosmosChain 判断链类型是否为Cosmos SDK链
+func IsCosmosChain(chainType string) bool {
+	switch chainType {
+	case model.ChainTypeCosmos, model.ChainTypeOsmosis, model.ChainTypeCelestia, model.ChainTypeInjective, model.ChainTypeCosmosSDK:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetCosmosAddressEncoding 根据bech32地址前缀获取Cosmos SDK链的地址编码方式
+// 不同前缀的地址分别保存，同一用户可以在多条cosmos_sdk链上各有一个地址
+func GetCosmosAddressEncoding(bech32Prefix string) string {
+	return crypto.CosmosAddressEncoding(bech32Prefix)
+}
+
+// GetSubstrateAddressEncoding 根据SS58前缀获取substrate链的地址编码方式
+// 不同前缀的地址分别保存，同一用户可以在多条平行链上各有一个地址
+func GetSubstrateAddressEncoding(ss58Prefix uint16) string {
+	return crypto.SS58AddressEncoding(ss58Prefix)
+}
+
+// GetBtcAddressEncoding 根据比特币地址类型获取对应的地址编码方式
+// 未知的地址类型返回空字符串
+func GetBtcAddressEncoding(addressType string) string {
+	switch addressType {
+	case model.BtcAddressTypeP2PKH:
+		return crypto.BtcP2PKHEncoding
+	case model.BtcAddressTypeP2SHP2WPKH:
+		return "bitcoin_p2sh_p2wpkh"
+	case model.BtcAddressTypeP2WPKH:
+		return "bitcoin_p2wpkh"
+	case model.BtcAddressTypeP2TR:
+		return "bitcoin_p2tr"
+	default:
+		return ""
 	}
-}
\ No newline at end of file
+}